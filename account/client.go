@@ -4,8 +4,9 @@ import (
 	"context"
 
 	pb "github.com/sunil8777/E-commerce-microservices/account/pb"
+	"github.com/sunil8777/E-commerce-microservices/grpcopts"
+	"github.com/sunil8777/E-commerce-microservices/healthcheck"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 type Client struct {
@@ -13,8 +14,15 @@ type Client struct {
 	service pb.AccountServiceClient
 }
 
-func NewClient(url string) (*Client, error) {
-	conn, err := grpc.NewClient(url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// NewClient dials url with the shared grpcopts defaults. opts are applied
+// after them, for example to guard the connection with a retry.Breaker.
+func NewClient(url string, opts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.NewClient(
+		grpcopts.Target(url),
+		grpcopts.New(pb.AccountService_ServiceDesc.ServiceName).
+			Idempotent("GetAccount", "GetAccounts").
+			DialOptions(opts...)...,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -27,18 +35,24 @@ func (c *Client) Close() {
 	c.conn.Close()
 }
 
-func (c *Client) PostAccount(ctx context.Context, name string) (*Account, error) {
+// HealthCheck reports whether the account service is SERVING.
+func (c *Client) HealthCheck(ctx context.Context) error {
+	return healthcheck.Check(ctx, c.conn, "")
+}
+
+func (c *Client) PostAccount(ctx context.Context, name string, email string) (*Account, error) {
 	r, err := c.service.PostAccount(
 		ctx,
-		&pb.PostAccountRequest{Name: name},
+		&pb.PostAccountRequest{Name: name, Email: email},
 	)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Account{
-		ID:   r.Account.Id,
-		Name: r.Account.Name,
+		ID:    r.Account.Id,
+		Name:  r.Account.Name,
+		Email: r.Account.Email,
 	}, nil
 }
 
@@ -52,8 +66,9 @@ func (c *Client) GetAccount(ctx context.Context, id string) (*Account, error) {
 	}
 
 	return &Account{
-		ID:   r.Account.Id,
-		Name: r.Account.Name,
+		ID:    r.Account.Id,
+		Name:  r.Account.Name,
+		Email: r.Account.Email,
 	}, nil
 }
 
@@ -72,9 +87,37 @@ func (c *Client) GetAccounts(ctx context.Context, take uint64, skip uint64) ([]A
 
 	for _, a := range r.Accounts {
 		accounts = append(accounts, Account{
-			ID:   a.Id,
-			Name: a.Name,
+			ID:    a.Id,
+			Name:  a.Name,
+			Email: a.Email,
 		})
 	}
 	return accounts, nil
 }
+
+func (c *Client) GetAccountsPage(ctx context.Context, after string, take uint64) (*AccountPage, error) {
+	r, err := c.service.GetAccounts(
+		ctx,
+		&pb.GetAccountsRequest{
+			After: &after,
+			Take:  take,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &AccountPage{
+		Accounts:    []Account{},
+		Cursors:     r.Cursors,
+		HasNextPage: r.HasNextPage,
+	}
+	for _, a := range r.Accounts {
+		page.Accounts = append(page.Accounts, Account{
+			ID:    a.Id,
+			Name:  a.Name,
+			Email: a.Email,
+		})
+	}
+	return page, nil
+}
@@ -1,37 +1,155 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"os"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
 	"github.com/sunil8777/E-commerce-microservices/account"
+	"github.com/sunil8777/E-commerce-microservices/events"
+	"github.com/sunil8777/E-commerce-microservices/grpcopts"
+	"github.com/sunil8777/E-commerce-microservices/metrics"
+	"github.com/sunil8777/E-commerce-microservices/migrate"
 	"github.com/sunil8777/E-commerce-microservices/retry"
+	"github.com/sunil8777/E-commerce-microservices/shutdown"
+	"github.com/sunil8777/E-commerce-microservices/tlsconfig"
+	"github.com/sunil8777/E-commerce-microservices/tracing"
 )
 
 type Config struct {
-	DatabaseURL string `envconfig:"DATABASE_URL"`
+	DatabaseURL     string `envconfig:"DATABASE_URL"`
+	NotificationURL string `envconfig:"NOTIFICATION_SERVICE_URL"`
+	TracingExporter string `envconfig:"TRACING_EXPORTER" default:"none"`
+	MetricsPort     int    `envconfig:"METRICS_PORT" default:"9090"`
+	RESTPort        int    `envconfig:"REST_PORT" default:"8090"`
+
+	TLS tlsconfig.Config
 }
 
 func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves until SIGINT or SIGTERM and then unwinds in reverse order of
+// startup: drain the gRPC server, close the repository, flush traces.
+func run() error {
 	var cfg Config
 	err := envconfig.Process("", &cfg)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	ctx, stop := shutdown.Context()
+	defer stop()
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		return migrateDatabase(ctx, cfg.DatabaseURL, os.Args[2:])
+	}
+
+	certs, err := grpcopts.ConfigureTLS(ctx, cfg.TLS)
+	if err != nil {
+		return err
+	}
+
+	shutdownTracing, err := tracing.Init(ctx, "account", cfg.TracingExporter)
+	if err != nil {
+		return err
 	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), shutdown.Timeout)
+		defer cancel()
+		shutdownTracing(flushCtx)
+	}()
 
 	var r account.Repository
 
-	retry.ForeverSleep(func() error {
+	err = retry.Do(ctx, retry.Connect("account database"), func(ctx context.Context) error {
 		r, err = account.NewPostgresRepository(cfg.DatabaseURL)
-		if err != nil {
-			log.Println(err)
+		if errors.Is(err, migrate.ErrSchemaMismatch) {
+			return retry.Permanent(err)
 		}
 		return err
 	})
+	if err != nil {
+		return err
+	}
 
 	defer r.Close()
+
+	if cfg.NotificationURL != "" {
+		stopRelay, err := relayEvents(ctx, cfg.DatabaseURL, cfg.NotificationURL)
+		if err != nil {
+			return err
+		}
+		defer stopRelay()
+	} else {
+		log.Println("NOTIFICATION_SERVICE_URL is not set; events stay in the outbox")
+	}
+
+	go func() {
+		if err := metrics.ListenHTTP(ctx, cfg.MetricsPort); err != nil {
+			log.Println(err)
+		}
+	}()
+	go func() {
+		if err := account.ListenREST(ctx, 8080, cfg.RESTPort, certs.HTTPServerConfig()); err != nil {
+			log.Println(err)
+		}
+	}()
+
 	log.Println("listening on port 8080")
 	s := account.NewService(r)
-	log.Fatal(account.ListenGRPC(s, 8080))
+	return account.ListenGRPC(ctx, s, 8080)
+
+}
+
+// relayEvents publishes the events of the account outbox to the
+// notification service until ctx is done or the returned func is called,
+// which also waits for the relay to stop and closes its connections.
+func relayEvents(ctx context.Context, databaseURL, notificationURL string) (func(), error) {
+	store, err := events.OpenPostgresStore(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	sink, err := events.NewClient(notificationURL, retry.NewBreaker("notification").DialOption())
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		events.NewRelay(store, sink).Run(ctx, time.Second)
+	}()
+	return func() {
+		cancel()
+		<-done
+		sink.Close()
+		store.Close()
+	}, nil
+}
+
+// migrateDatabase runs "account migrate [args]": it moves the database schema to
+// the version this binary expects, or to the one given in args, and exits.
+// See migrate.Command for the arguments.
+func migrateDatabase(ctx context.Context, url string, args []string) error {
+	var m *migrate.Migrator
+	err := retry.Do(ctx, retry.Connect("account database"), func(ctx context.Context) error {
+		var err error
+		m, err = migrate.Open(url, account.Migrations)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	defer m.Close()
 
+	return migrate.Command(ctx, m, args)
 }
@@ -0,0 +1,11 @@
+package account
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var accountsCreated = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "accounts_created_total",
+	Help: "Accounts successfully created.",
+})
@@ -0,0 +1,14 @@
+package account
+
+import (
+	"embed"
+
+	"github.com/sunil8777/E-commerce-microservices/migrate"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrations are the versions of the account database schema, oldest first.
+// NewPostgresRepository refuses a database that is not at the latest one.
+var Migrations = migrate.MustLoad(migrationFiles, "migrations")
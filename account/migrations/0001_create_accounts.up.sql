@@ -1,4 +1,4 @@
 CREATE TABLE IF NOT EXISTS accounts (
   id CHAR(27) PRIMARY KEY,
   name VARCHAR(24) NOT NULL
-);
\ No newline at end of file
+);
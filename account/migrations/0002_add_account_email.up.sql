@@ -0,0 +1 @@
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS email VARCHAR(254) NOT NULL DEFAULT '';
@@ -3,14 +3,26 @@ package account
 import (
 	"context"
 	"database/sql"
+
 	_ "github.com/lib/pq"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+	"github.com/sunil8777/E-commerce-microservices/events"
+	"github.com/sunil8777/E-commerce-microservices/metrics"
+	"github.com/sunil8777/E-commerce-microservices/migrate"
+	"github.com/sunil8777/E-commerce-microservices/tracing"
+)
+
+var (
+	ErrNotFound = errs.New(errs.NotFound, "ACCOUNT_NOT_FOUND", "account not found")
 )
 
 type Repository interface{
 	Close() error
-	PutAccount(ctx context.Context, a Account) error
+	Ping(ctx context.Context) error
+	PutAccount(ctx context.Context, a Account, e events.Event) error
 	GetAccountById(ctx context.Context, id string) (*Account, error)
 	ListAccounts(ctx context.Context, skip uint64, take uint64) ([]Account, error)
+	ListAccountsAfter(ctx context.Context, afterID string, take uint64) ([]Account, error)
 }
 
 type PostgresRepository struct {
@@ -27,6 +39,11 @@ func NewPostgresRepository(url string) (Repository, error) {
 		return nil,err
 	}
 
+	if err = migrate.New(db, Migrations).Check(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	return &PostgresRepository{db},nil
 }
 
@@ -34,25 +51,61 @@ func (r *PostgresRepository) Close() error {
 	return r.db.Close()
 }
 
-func (r *PostgresRepository) PutAccount(ctx context.Context, a Account) error {
-	_,err := r.db.ExecContext(ctx, "INSERT INTO accounts(id, name) VALUES($1, $2)",a.ID, a.Name)
-	return err
+func (r *PostgresRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// PutAccount inserts a and records e in the outbox in one transaction.
+func (r *PostgresRepository) PutAccount(ctx context.Context, a Account, e events.Event) (err error) {
+	ctx, span := tracing.Start(ctx, "account.repository.PutAccount")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "account.PutAccount")(&err)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO accounts(id, name, email) VALUES($1, $2, $3)", a.ID, a.Name, a.Email)
+	if err != nil {
+		return err
+	}
+	return events.Insert(ctx, tx, e)
 }
 
-func (r *PostgresRepository) GetAccountById(ctx context.Context, id string) (*Account, error) {
-	row := r.db.QueryRowContext(ctx, "SELECT id, name FROM account WHERE id = $1", id)
+func (r *PostgresRepository) GetAccountById(ctx context.Context, id string) (_ *Account, err error) {
+	ctx, span := tracing.Start(ctx, "account.repository.GetAccountById")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "account.GetAccountById")(&err)
+
+	row := r.db.QueryRowContext(ctx, "SELECT id, name, email FROM accounts WHERE id = $1", id)
 	a := Account{}
-	if err := row.Scan(&a.ID, &a.Name); err != nil {
+	err = row.Scan(&a.ID, &a.Name, &a.Email)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound.With("id", id)
+	}
+	if err != nil {
 		return nil, err
 	}
 
 	return &a, nil
 }
 
-func (r *PostgresRepository) ListAccounts(ctx context.Context, skip uint64, take uint64) ([]Account, error) {
+func (r *PostgresRepository) ListAccounts(ctx context.Context, skip uint64, take uint64) (_ []Account, err error) {
+	ctx, span := tracing.Start(ctx, "account.repository.ListAccounts")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "account.ListAccounts")(&err)
+
 	rows, err := r.db.QueryContext(
 		ctx,
-		"SELECT id, name FROM accounts ORDER BY id DESC OFFSET $1 LIMIT $2",
+		"SELECT id, name, email FROM accounts ORDER BY id DESC OFFSET $1 LIMIT $2",
 		skip,
 		take,
 	)
@@ -66,7 +119,7 @@ func (r *PostgresRepository) ListAccounts(ctx context.Context, skip uint64, take
 
 	for rows.Next(){
 		a := &Account{}
-		if err = rows.Scan(&a.ID, &a.Name); err == nil {
+		if err = rows.Scan(&a.ID, &a.Name, &a.Email); err == nil {
 			accounts = append(accounts, *a)
 		}
 	}
@@ -75,4 +128,46 @@ func (r *PostgresRepository) ListAccounts(ctx context.Context, skip uint64, take
 	}
 
 	return accounts, nil
-}
\ No newline at end of file
+}
+
+func (r *PostgresRepository) ListAccountsAfter(ctx context.Context, afterID string, take uint64) (_ []Account, err error) {
+	ctx, span := tracing.Start(ctx, "account.repository.ListAccountsAfter")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "account.ListAccountsAfter")(&err)
+
+	var rows *sql.Rows
+	if afterID == "" {
+		rows, err = r.db.QueryContext(
+			ctx,
+			"SELECT id, name, email FROM accounts ORDER BY id DESC LIMIT $1",
+			take,
+		)
+	} else {
+		rows, err = r.db.QueryContext(
+			ctx,
+			"SELECT id, name, email FROM accounts WHERE id < $1 ORDER BY id DESC LIMIT $2",
+			afterID,
+			take,
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	accounts := []Account{}
+
+	for rows.Next() {
+		a := Account{}
+		if err = rows.Scan(&a.ID, &a.Name, &a.Email); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
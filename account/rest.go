@@ -0,0 +1,26 @@
+package account
+
+import (
+	"context"
+	"crypto/tls"
+	_ "embed"
+	"strconv"
+
+	pb "github.com/sunil8777/E-commerce-microservices/account/pb"
+	"github.com/sunil8777/E-commerce-microservices/rest"
+)
+
+//go:embed account.swagger.json
+var openAPI []byte
+
+// ListenREST serves the REST/JSON mapping of AccountService declared in
+// account.proto on port, and its OpenAPI document at /openapi.json. Requests
+// go to the gRPC server on grpcPort of this host.
+func ListenREST(ctx context.Context, grpcPort int, port int, tlsConfig *tls.Config) error {
+	gw := rest.Gateway{
+		Service:  pb.AccountService_ServiceDesc.ServiceName,
+		Register: pb.RegisterAccountServiceHandler,
+		OpenAPI:  openAPI,
+	}
+	return gw.ListenHTTP(ctx, "localhost:"+strconv.Itoa(grpcPort), port, tlsConfig)
+}
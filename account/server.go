@@ -4,6 +4,13 @@ import (
 	"context"
 	"net"
 	"strconv"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+	"github.com/sunil8777/E-commerce-microservices/grpcopts"
+	"github.com/sunil8777/E-commerce-microservices/healthcheck"
+	"github.com/sunil8777/E-commerce-microservices/metrics"
+	"github.com/sunil8777/E-commerce-microservices/shutdown"
+	"github.com/sunil8777/E-commerce-microservices/tracing"
+	"github.com/sunil8777/E-commerce-microservices/validation"
 	pb "github.com/sunil8777/E-commerce-microservices/account/pb"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
@@ -14,19 +21,33 @@ type grpcServer struct {
 	pb.UnimplementedAccountServiceServer
 }
 
-func ListenGRPC(s Service, port int) error {
+func ListenGRPC(ctx context.Context, s Service, port int) error {
 	lis, err := net.Listen("tcp",":" + strconv.Itoa(port))
 	if err != nil {
 		return err
 	}
-	server := grpc.NewServer()
+	return Serve(ctx, s, lis)
+}
+
+// Serve serves s on lis until ctx is done.
+func Serve(ctx context.Context, s Service, lis net.Listener) error {
+	server := grpc.NewServer(append(
+		grpcopts.ServerOptions(),
+		grpc.ChainUnaryInterceptor(
+			metrics.UnaryServerInterceptor(),
+			errs.UnaryServerInterceptor(),
+			validation.UnaryServerInterceptor(),
+		),
+		tracing.ServerOption(),
+	)...)
 	pb.RegisterAccountServiceServer(server, &grpcServer{service: s})
+	healthcheck.Register(ctx, server, s.Ping, pb.AccountService_ServiceDesc.ServiceName)
 	reflection.Register(server)
-	return server.Serve(lis)
+	return shutdown.ServeGRPC(ctx, server, lis)
 }
 
 func (s *grpcServer) PostAccount(ctx context.Context, r *pb.PostAccountRequest) (*pb.PostAccountResponse, error){
-	a, err := s.service.PostAccount(ctx,r.Name)
+	a, err := s.service.PostAccount(ctx,r.Name, r.Email)
 	if err != nil {
 		return nil, err
 	}
@@ -35,6 +56,7 @@ func (s *grpcServer) PostAccount(ctx context.Context, r *pb.PostAccountRequest)
 		Account: &pb.Account{
 			Id: a.ID,
 			Name: a.Name,
+			Email: a.Email,
 		},
 	},nil
 }
@@ -49,11 +71,16 @@ func (s *grpcServer) GetAccount(ctx context.Context, r *pb.GetAccountRequest) (*
 		Account: &pb.Account{
 			Id: a.ID,
 			Name: a.Name,
+			Email: a.Email,
 		},
 	},nil
 }
 
 func (s *grpcServer) GetAccounts (ctx context.Context, r *pb.GetAccountsRequest) (*pb.GetAccountsResponse, error){
+	if r.After != nil {
+		return s.getAccountsPage(ctx, r)
+	}
+
 	res, err := s.service.GetAccounts(ctx, r.Skip, r.Take)
 	if err != nil {
 		return nil, err
@@ -65,10 +92,34 @@ func (s *grpcServer) GetAccounts (ctx context.Context, r *pb.GetAccountsRequest)
 			&pb.Account{
 				Id: p.ID,
 				Name: p.Name,
+				Email: p.Email,
 			}, 
 		)
 	}
 	return &pb.GetAccountsResponse{
 		Accounts: accounts,
 	},nil
-}
\ No newline at end of file
+}
+
+func (s *grpcServer) getAccountsPage(ctx context.Context, r *pb.GetAccountsRequest) (*pb.GetAccountsResponse, error) {
+	page, err := s.service.GetAccountsPage(ctx, r.GetAfter(), r.Take)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := []*pb.Account{}
+	for _, a := range page.Accounts {
+		accounts = append(accounts,
+			&pb.Account{
+				Id:    a.ID,
+				Name:  a.Name,
+				Email: a.Email,
+			},
+		)
+	}
+	return &pb.GetAccountsResponse{
+		Accounts:    accounts,
+		Cursors:     page.Cursors,
+		HasNextPage: page.HasNextPage,
+	}, nil
+}
@@ -4,17 +4,30 @@ import (
 	"context"
 
 	"github.com/segmentio/ksuid"
+	"github.com/sunil8777/E-commerce-microservices/cursor"
+	"github.com/sunil8777/E-commerce-microservices/events"
 )
 
 type Service interface {
-	PostAccount(ctx context.Context, name string) (*Account, error)
+	PostAccount(ctx context.Context, name string, email string) (*Account, error)
 	GetAccount(ctx context.Context, id string) (*Account, error)
 	GetAccounts(ctx context.Context, skip uint64, take uint64) ([]Account, error)
+	GetAccountsPage(ctx context.Context, after string, take uint64) (*AccountPage, error)
+	Ping(ctx context.Context) error
 }
 
 type Account struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// AccountPage is one page of a cursor-paginated account listing. Cursors[i]
+// is the opaque cursor pointing just past Accounts[i].
+type AccountPage struct {
+	Accounts    []Account
+	Cursors     []string
+	HasNextPage bool
 }
 
 type AccountService struct {
@@ -25,14 +38,22 @@ func NewService(r Repository) Service {
 	return &AccountService{r}
 }
 
-func (s *AccountService) PostAccount(ctx context.Context, name string) (*Account, error) {
+// PostAccount creates an account and records an events.AccountCreated
+// event for it.
+func (s *AccountService) PostAccount(ctx context.Context, name string, email string) (*Account, error) {
 	a := Account{
-		ID:   ksuid.New().String(),
-		Name: name,
+		ID:    ksuid.New().String(),
+		Name:  name,
+		Email: email,
 	}
-	if err := s.repository.PutAccount(ctx, a); err != nil {
+	e, err := events.New(events.AccountCreated, a.ID, events.AccountCreatedPayload{Name: a.Name})
+	if err != nil {
 		return nil, err
 	}
+	if err := s.repository.PutAccount(ctx, a, e); err != nil {
+		return nil, err
+	}
+	accountsCreated.Inc()
 	return &a, nil
 }
 
@@ -47,3 +68,36 @@ func (s *AccountService) GetAccounts(ctx context.Context, skip uint64, take uint
 
 	return s.repository.ListAccounts(ctx, skip, take)
 }
+
+func (s *AccountService) GetAccountsPage(ctx context.Context, after string, take uint64) (*AccountPage, error) {
+	if take == 0 || take > 100 {
+		take = 100
+	}
+
+	afterID, err := cursor.DecodeString(after)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.repository.ListAccountsAfter(ctx, afterID, take+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &AccountPage{Cursors: []string{}}
+	if uint64(len(accounts)) > take {
+		accounts = accounts[:take]
+		page.HasNextPage = true
+	}
+	for _, a := range accounts {
+		page.Cursors = append(page.Cursors, cursor.Encode(a.ID))
+	}
+	page.Accounts = accounts
+
+	return page, nil
+}
+
+// Ping fails while the accounts database in Postgres is unreachable.
+func (s *AccountService) Ping(ctx context.Context) error {
+	return s.repository.Ping(ctx)
+}
@@ -2,10 +2,12 @@ package catalog
 
 import (
 	"context"
+	"time"
 
 	pb "github.com/sunil8777/E-commerce-microservices/catalog/pb"
+	"github.com/sunil8777/E-commerce-microservices/grpcopts"
+	"github.com/sunil8777/E-commerce-microservices/healthcheck"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 type Client struct {
@@ -13,8 +15,15 @@ type Client struct {
 	service pb.CatalogServiceClient
 }
 
-func NewClient(url string) (*Client, error) {
-	conn, err := grpc.NewClient(url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// NewClient dials url with the shared grpcopts defaults. opts are applied
+// after them, for example to guard the connection with a retry.Breaker.
+func NewClient(url string, opts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.NewClient(
+		grpcopts.Target(url),
+		grpcopts.New(pb.CatalogService_ServiceDesc.ServiceName).
+			Idempotent("GetProduct", "GetProducts", "GetPriceHistory", "UpdateProductRating", "ReserveStock", "ReleaseStock").
+			DialOptions(opts...)...,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -27,25 +36,28 @@ func (c *Client) Close() {
 	c.conn.Close()
 }
 
-func (c *Client) PostProduct(ctx context.Context, name, description string, price float64) (*Product, error) {
+// HealthCheck asks the catalog server for its gRPC health status.
+func (c *Client) HealthCheck(ctx context.Context) error {
+	return healthcheck.Check(ctx, c.conn, "")
+}
+
+func (c *Client) PostProduct(ctx context.Context, name, description string, price float64, options []ProductOption, variants []Variant) (*Product, error) {
 	res, err := c.service.PostProduct(
 		ctx,
 		&pb.PostProductRequest{
 			Name:        name,
 			Description: description,
 			Price:       price,
+			Options:     optionsToProto(options),
+			Variants:    variantsToProto(variants),
 		},
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Product{
-		ID:          res.Product.Id,
-		Name:        res.Product.Name,
-		Description: res.Product.Description,
-		Price:       res.Product.Price,
-	}, nil
+	p := productFromProto(res.Product)
+	return &p, nil
 }
 
 func (c *Client) GetProduct(ctx context.Context, id string) (*Product, error) {
@@ -59,12 +71,8 @@ func (c *Client) GetProduct(ctx context.Context, id string) (*Product, error) {
 		return nil, err
 	}
 
-	return &Product{
-		ID:          res.Product.Id,
-		Name:        res.Product.Name,
-		Description: res.Product.Description,
-		Price:       res.Product.Price,
-	}, nil
+	p := productFromProto(res.Product)
+	return &p, nil
 }
 
 func (c *Client) GetProducts(ctx context.Context, ids []string, query string, skip uint64, take uint64) ([]Product, error) {
@@ -83,13 +91,244 @@ func (c *Client) GetProducts(ctx context.Context, ids []string, query string, sk
 
 	products := []Product{}
 	for _, val := range res.Products {
-		products = append(products, Product{
-			ID:          val.Id,
-			Name:        val.Name,
-			Description: val.Description,
-			Price:       val.Price,
-		})
+		products = append(products, productFromProto(val))
 	}
 
 	return products, nil
 }
+
+func (c *Client) GetProductsPage(ctx context.Context, query string, after string, take uint64) (*ProductPage, error) {
+	res, err := c.service.GetProducts(
+		ctx,
+		&pb.GetProductsRequest{
+			Take:  take,
+			Query: query,
+			After: &after,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ProductPage{
+		Products:    []Product{},
+		Cursors:     res.Cursors,
+		HasNextPage: res.HasNextPage,
+	}
+	for _, val := range res.Products {
+		page.Products = append(page.Products, productFromProto(val))
+	}
+
+	return page, nil
+}
+
+func (c *Client) UpdateProductRating(ctx context.Context, id string, rating float64, reviewCount uint32) error {
+	_, err := c.service.UpdateProductRating(
+		ctx,
+		&pb.UpdateProductRatingRequest{
+			Id:          id,
+			Rating:      rating,
+			ReviewCount: reviewCount,
+		},
+	)
+	return err
+}
+
+// ReserveStock takes items out of stock under reservationID. It is safe to
+// retry with the same ID.
+func (c *Client) ReserveStock(ctx context.Context, reservationID string, items []StockItem) error {
+	req := &pb.ReserveStockRequest{ReservationId: reservationID}
+	for _, i := range items {
+		req.Items = append(req.Items, &pb.StockItem{
+			ProductId: i.ProductID,
+			VariantId: i.VariantID,
+			Quantity:  i.Quantity,
+		})
+	}
+	_, err := c.service.ReserveStock(ctx, req)
+	return err
+}
+
+// ReleaseStock gives back the stock held under reservationID. It is safe to
+// retry, and to call for a reservation that never succeeded.
+func (c *Client) ReleaseStock(ctx context.Context, reservationID string) error {
+	_, err := c.service.ReleaseStock(ctx, &pb.ReleaseStockRequest{ReservationId: reservationID})
+	return err
+}
+
+// ReturnStock puts items returned by customers back into stock under
+// returnID. It is safe to retry with the same ID.
+func (c *Client) ReturnStock(ctx context.Context, returnID string, items []StockItem) error {
+	req := &pb.ReturnStockRequest{ReturnId: returnID}
+	for _, i := range items {
+		req.Items = append(req.Items, &pb.StockItem{
+			ProductId: i.ProductID,
+			VariantId: i.VariantID,
+			Quantity:  i.Quantity,
+		})
+	}
+	_, err := c.service.ReturnStock(ctx, req)
+	return err
+}
+
+// AddProductMedia uploads data, the file of m, and attaches it to the
+// product. The catalog sets the ID and URL of m.
+func (c *Client) AddProductMedia(ctx context.Context, productID string, m Media, data []byte, position *uint32) (*Product, error) {
+	res, err := c.service.AddProductMedia(
+		ctx,
+		&pb.AddProductMediaRequest{
+			ProductId: productID,
+			Media:     mediaToProto(m),
+			Position:  position,
+			Data:      data,
+		},
+		grpc.MaxCallSendMsgSize(maxMessageSize),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	p := productFromProto(res.Product)
+	return &p, nil
+}
+
+func (c *Client) UpdateProductPrice(ctx context.Context, id string, price float64, actor string) (*Product, error) {
+	res, err := c.service.UpdateProductPrice(
+		ctx,
+		&pb.UpdateProductPriceRequest{
+			Id:    id,
+			Price: price,
+			Actor: actor,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	p := productFromProto(res.Product)
+	return &p, nil
+}
+
+func (c *Client) GetPriceHistory(ctx context.Context, productID string, take uint64) ([]PriceChange, error) {
+	res, err := c.service.GetPriceHistory(
+		ctx,
+		&pb.GetPriceHistoryRequest{
+			ProductId: productID,
+			Take:      take,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []PriceChange{}
+	for _, c := range res.Changes {
+		changedAt := time.Time{}
+		changedAt.UnmarshalBinary(c.ChangedAt)
+		changes = append(changes, PriceChange{
+			ID:         c.Id,
+			ProductID:  c.ProductId,
+			OldPrice:   c.OldPrice,
+			NewPrice:   c.NewPrice,
+			Actor:      c.Actor,
+			ScheduleID: c.ScheduleId,
+			ChangedAt:  changedAt,
+		})
+	}
+	return changes, nil
+}
+
+func (c *Client) SchedulePriceChange(ctx context.Context, productID string, price float64, startsAt, endsAt time.Time, actor string) (*PriceSchedule, error) {
+	req := &pb.SchedulePriceChangeRequest{
+		ProductId: productID,
+		Price:     price,
+		Actor:     actor,
+	}
+	req.StartsAt, _ = startsAt.MarshalBinary()
+	if !endsAt.IsZero() {
+		req.EndsAt, _ = endsAt.MarshalBinary()
+	}
+
+	res, err := c.service.SchedulePriceChange(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	ps := &PriceSchedule{
+		ID:        res.Schedule.Id,
+		ProductID: res.Schedule.ProductId,
+		Price:     res.Schedule.Price,
+		Actor:     res.Schedule.Actor,
+		Status:    res.Schedule.Status,
+	}
+	ps.StartsAt.UnmarshalBinary(res.Schedule.StartsAt)
+	ps.EndsAt.UnmarshalBinary(res.Schedule.EndsAt)
+	return ps, nil
+}
+
+func productFromProto(p *pb.Product) Product {
+	return Product{
+		ID:          p.Id,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       p.Price,
+		Options:     optionsFromProto(p.Options),
+		Variants:    variantsFromProto(p.Variants),
+		Rating:      p.Rating,
+		ReviewCount: p.ReviewCount,
+		Media:       mediaListFromProto(p.Media),
+	}
+}
+
+func optionsToProto(options []ProductOption) []*pb.ProductOption {
+	res := []*pb.ProductOption{}
+	for _, o := range options {
+		res = append(res, &pb.ProductOption{
+			Name:   o.Name,
+			Values: o.Values,
+		})
+	}
+	return res
+}
+
+func variantsToProto(variants []Variant) []*pb.Variant {
+	res := []*pb.Variant{}
+	for _, v := range variants {
+		res = append(res, &pb.Variant{
+			Id:      v.ID,
+			Sku:     v.SKU,
+			Options: v.Options,
+			Price:   v.Price,
+			Stock:   v.Stock,
+		})
+	}
+	return res
+}
+
+func mediaToProto(m Media) *pb.ProductMedia {
+	return &pb.ProductMedia{
+		Id:          m.ID,
+		Url:         m.URL,
+		AltText:     m.AltText,
+		Position:    m.Position,
+		Width:       m.Width,
+		Height:      m.Height,
+		ContentType: m.ContentType,
+	}
+}
+
+func mediaListToProto(media []Media) []*pb.ProductMedia {
+	res := []*pb.ProductMedia{}
+	for _, m := range media {
+		res = append(res, mediaToProto(m))
+	}
+	return res
+}
+
+func mediaListFromProto(media []*pb.ProductMedia) []Media {
+	res := []Media{}
+	for _, m := range media {
+		res = append(res, mediaFromProto(m))
+	}
+	return res
+}
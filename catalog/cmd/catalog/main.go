@@ -1,36 +1,97 @@
 package main
 
 import (
+	"context"
 	"log"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
 	"github.com/sunil8777/E-commerce-microservices/catalog"
+	"github.com/sunil8777/E-commerce-microservices/grpcopts"
+	"github.com/sunil8777/E-commerce-microservices/metrics"
 	"github.com/sunil8777/E-commerce-microservices/retry"
+	"github.com/sunil8777/E-commerce-microservices/shutdown"
+	"github.com/sunil8777/E-commerce-microservices/tlsconfig"
+	"github.com/sunil8777/E-commerce-microservices/tracing"
 )
 
 type Config struct {
-	DatabaseURL string `envconfig:"DATABASE_URL"`
+	DatabaseURL            string        `envconfig:"DATABASE_URL"`
+	PriceSchedulerInterval time.Duration `envconfig:"PRICE_SCHEDULER_INTERVAL" default:"30s"`
+	TracingExporter        string        `envconfig:"TRACING_EXPORTER" default:"none"`
+	MetricsPort            int           `envconfig:"METRICS_PORT" default:"9090"`
+	RESTPort               int           `envconfig:"REST_PORT" default:"8090"`
+	// MediaDir holds the files of product media, served under /media/ on
+	// RESTPort. MediaURL is the public URL of that path.
+	MediaDir string `envconfig:"MEDIA_DIR" default:"./media"`
+	MediaURL string `envconfig:"MEDIA_BASE_URL" default:"/media"`
+
+	TLS tlsconfig.Config
 }
 
 func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves until SIGINT or SIGTERM and then unwinds in reverse order of
+// startup: drain the gRPC server, close the repository, flush traces.
+func run() error {
 	var cfg Config
 	err := envconfig.Process("", &cfg)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	ctx, stop := shutdown.Context()
+	defer stop()
+
+	certs, err := grpcopts.ConfigureTLS(ctx, cfg.TLS)
+	if err != nil {
+		return err
+	}
+
+	shutdownTracing, err := tracing.Init(ctx, "catalog", cfg.TracingExporter)
+	if err != nil {
+		return err
 	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), shutdown.Timeout)
+		defer cancel()
+		shutdownTracing(flushCtx)
+	}()
 
 	var r catalog.Repository
 
-	retry.ForeverSleep(func() error {
+	err = retry.Do(ctx, retry.Connect("elasticsearch"), func(ctx context.Context) error {
 		r, err = catalog.NewElasticRepository(cfg.DatabaseURL)
-		if err != nil {
-			log.Println(err)
-		}
 		return err
 	})
+	if err != nil {
+		return err
+	}
 
 	defer r.Close()
+
+	media, err := catalog.NewFileBlobStore(cfg.MediaDir, cfg.MediaURL)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := metrics.ListenHTTP(ctx, cfg.MetricsPort); err != nil {
+			log.Println(err)
+		}
+	}()
+	go func() {
+		if err := catalog.ListenREST(ctx, 8080, cfg.RESTPort, certs.HTTPServerConfig(), cfg.MediaDir); err != nil {
+			log.Println(err)
+		}
+	}()
+
 	log.Println("listening on port 8080")
-	s := catalog.NewService(r)
-	log.Fatal(catalog.ListenGRPC(s, 8080))
+	s := catalog.NewService(r, media)
+	go catalog.RunPriceScheduler(ctx, s, cfg.PriceSchedulerInterval)
+	return catalog.ListenGRPC(ctx, s, 8080)
 }
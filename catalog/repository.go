@@ -5,22 +5,56 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/elastic/go-elasticsearch/v9"
 	"github.com/elastic/go-elasticsearch/v9/esapi"
+	"github.com/sunil8777/E-commerce-microservices/cursor"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+	"github.com/sunil8777/E-commerce-microservices/metrics"
+	"github.com/sunil8777/E-commerce-microservices/tracing"
+	"go.opentelemetry.io/otel"
 )
 
 var (
-	ErrNotFound = errors.New("entity not found")
+	ErrNotFound            = errs.New(errs.NotFound, "PRODUCT_NOT_FOUND", "product not found")
+	ErrReservationNotFound = errs.New(errs.NotFound, "STOCK_RESERVATION_NOT_FOUND", "stock reservation not found")
+	ErrReservationExists   = errs.New(errs.AlreadyExists, "STOCK_RESERVATION_EXISTS", "stock reservation already exists")
+	ErrScheduleNotFound    = errs.New(errs.NotFound, "PRICE_SCHEDULE_NOT_FOUND", "price schedule not found")
 )
 
+// errVersionConflict is returned by the conditional writes when the
+// document was changed, or created, by someone else since it was read.
+var errVersionConflict = errors.New("version conflict")
+
 type Repository interface {
 	Close()
+	Ping(ctx context.Context) error
 	PutProduct(ctx context.Context, p Product) error
 	GetProductByID(ctx context.Context, id string) (*Product, error)
 	ListProducts(ctx context.Context, skip uint64, take uint64) ([]Product, error)
 	ListProductsWithIDs(ctx context.Context, ids []string) ([]Product, error)
 	SearchProducts(ctx context.Context, query string, skip uint64, take uint64) ([]Product, error)
+	ListProductsAfter(ctx context.Context, after string, take uint64) ([]Product, []string, error)
+	SearchProductsAfter(ctx context.Context, query string, after string, take uint64) ([]Product, []string, error)
+	UpdateProductRating(ctx context.Context, id string, rating float64, reviewCount uint32) error
+	// UpdateProduct applies update to the stored product and writes it back
+	// only if nobody changed it in between, reading it again and retrying
+	// update when someone did.
+	UpdateProduct(ctx context.Context, id string, update func(p *Product) error) error
+	PutPriceChange(ctx context.Context, c PriceChange) error
+	ListPriceChanges(ctx context.Context, productID string, take uint64) ([]PriceChange, error)
+	PutPriceSchedule(ctx context.Context, ps PriceSchedule) error
+	// UpdatePriceSchedule is UpdateProduct for price schedules.
+	UpdatePriceSchedule(ctx context.Context, id string, update func(ps *PriceSchedule) error) error
+	ListDuePriceSchedules(ctx context.Context, now time.Time) ([]PriceSchedule, error)
+	AdjustStock(ctx context.Context, productID string, variantID string, delta int64) error
+	GetStockReservation(ctx context.Context, id string) (*StockReservation, error)
+	CreateStockReservation(ctx context.Context, r StockReservation) error
+	UpdateStockReservation(ctx context.Context, id string, update func(r *StockReservation) error) error
+	PutStockReservation(ctx context.Context, r StockReservation) error
 }
 
 type elasticSearchRepository struct {
@@ -28,9 +62,50 @@ type elasticSearchRepository struct {
 }
 
 type productDocument struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Price       float64 `json:"price"`
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Price       float64         `json:"price"`
+	Options     []ProductOption `json:"options,omitempty"`
+	Variants    []Variant       `json:"variants,omitempty"`
+	Rating      float64         `json:"rating"`
+	ReviewCount uint32          `json:"reviewCount"`
+	Media       []Media         `json:"media,omitempty"`
+
+	PriceSchedule *AppliedPriceSchedule `json:"priceSchedule,omitempty"`
+	PriceChangeID string                `json:"priceChangeId,omitempty"`
+}
+
+func productToDocument(p Product) productDocument {
+	return productDocument{
+		ID:            p.ID,
+		Name:          p.Name,
+		Description:   p.Description,
+		Price:         p.Price,
+		Options:       p.Options,
+		Variants:      p.Variants,
+		Rating:        p.Rating,
+		ReviewCount:   p.ReviewCount,
+		Media:         p.Media,
+		PriceSchedule: p.PriceSchedule,
+		PriceChangeID: p.PriceChangeID,
+	}
+}
+
+func productFromDocument(id string, doc productDocument) *Product {
+	return &Product{
+		ID:            id,
+		Name:          doc.Name,
+		Description:   doc.Description,
+		Price:         doc.Price,
+		Options:       doc.Options,
+		Variants:      doc.Variants,
+		Rating:        doc.Rating,
+		ReviewCount:   doc.ReviewCount,
+		Media:         doc.Media,
+		PriceSchedule: doc.PriceSchedule,
+		PriceChangeID: doc.PriceChangeID,
+	}
 }
 
 func NewElasticRepository(url string) (Repository, error) {
@@ -38,29 +113,225 @@ func NewElasticRepository(url string) (Repository, error) {
 		Addresses: []string{
 			url,
 		},
+		Instrumentation: elasticsearch.NewOpenTelemetryInstrumentation(otel.GetTracerProvider(), false),
 	}
 	client, err := elasticsearch.NewClient(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	return &elasticSearchRepository{client}, nil
+	r := &elasticSearchRepository{client}
+	if err := r.ensureIndices(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return r, nil
 }
 
-func (r *elasticSearchRepository) Close() {
+// catalogMapping keeps a keyword copy of the product ID so that listings can
+// sort on it and page with search_after; _id itself is not sortable. Variant
+// SKUs and option values are indexed so a search for "XL" or a SKU finds the
+// product they belong to.
+const catalogMapping = `{
+	"mappings": {
+		"properties": {
+			"id":          {"type": "keyword"},
+			"name":        {"type": "text"},
+			"description": {"type": "text"},
+			"price":       {"type": "double"},
+			"rating":      {"type": "double"},
+			"reviewCount": {"type": "integer"},
+			"priceSchedule": {"type": "object", "enabled": false},
+			"media": {
+				"properties": {
+					"id":          {"type": "keyword"},
+					"url":         {"type": "keyword", "index": false},
+					"altText":     {"type": "text"},
+					"position":    {"type": "integer"},
+					"width":       {"type": "integer"},
+					"height":      {"type": "integer"},
+					"contentType": {"type": "keyword"}
+				}
+			},
+			"options": {
+				"properties": {
+					"name":   {"type": "keyword"},
+					"values": {"type": "keyword"}
+				}
+			},
+			"variants": {
+				"properties": {
+					"id":      {"type": "keyword"},
+					"sku":     {"type": "keyword"},
+					"options": {"type": "object"},
+					"price":   {"type": "double"},
+					"stock":   {"type": "integer"}
+				}
+			}
+		}
+	}
+}`
+
+// priceHistoryMapping and priceScheduleMapping back the audit trail of price
+// edits and the queue of scheduled price changes, kept apart from the
+// product documents so that searches never see them.
+const priceHistoryMapping = `{
+	"mappings": {
+		"properties": {
+			"id":         {"type": "keyword"},
+			"productId":  {"type": "keyword"},
+			"oldPrice":   {"type": "double"},
+			"newPrice":   {"type": "double"},
+			"actor":      {"type": "keyword"},
+			"scheduleId": {"type": "keyword"},
+			"changedAt":  {"type": "date"}
+		}
+	}
+}`
+
+const priceScheduleMapping = `{
+	"mappings": {
+		"properties": {
+			"id":            {"type": "keyword"},
+			"productId":     {"type": "keyword"},
+			"price":         {"type": "double"},
+			"previousPrice": {"type": "double"},
+			"startsAt":      {"type": "date"},
+			"endsAt":        {"type": "date"},
+			"actor":         {"type": "keyword"},
+			"status":        {"type": "keyword"},
+			"claimedUntil":  {"type": "date"}
+		}
+	}
+}`
+
+// stockReservationMapping backs the stock held for orders being placed.
+const stockReservationMapping = `{
+	"mappings": {
+		"properties": {
+			"id":        {"type": "keyword"},
+			"items":     {"type": "object", "enabled": false},
+			"status":    {"type": "keyword"},
+			"createdAt": {"type": "date"}
+		}
+	}
+}`
+
+func (r *elasticSearchRepository) ensureIndices(ctx context.Context) error {
+	indices := []struct {
+		name    string
+		mapping string
+	}{
+		{"catalog", catalogMapping},
+		{"catalog_price_history", priceHistoryMapping},
+		{"catalog_price_schedules", priceScheduleMapping},
+		{"catalog_stock_reservations", stockReservationMapping},
+	}
+	for _, i := range indices {
+		if err := r.ensureIndex(ctx, i.name, i.mapping); err != nil {
+			return err
+		}
+	}
+	return r.backfillProductIDs(ctx)
 }
 
-func (r *elasticSearchRepository) PutProduct(ctx context.Context, p Product) error {
+// backfillProductIDs brings a catalog index created before products carried
+// their ID in the document up to catalogMapping: it adds the id mapping and
+// copies _id into every product that has no id yet, so that the cursor
+// listings see them. It is a no-op once every product has one.
+func (r *elasticSearchRepository) backfillProductIDs(ctx context.Context) error {
+	mapping := esapi.IndicesPutMappingRequest{
+		Index: []string{"catalog"},
+		Body:  strings.NewReader(`{"properties": {"id": {"type": "keyword"}}}`),
+	}
+	res, err := mapping.Do(ctx, r.client)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("mapping catalog id: %s", res.Status())
+	}
 
-	body, err := json.Marshal(productDocument{
-		Name:        p.Name,
-		Description: p.Description,
-		Price:       p.Price,
+	body, err := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must_not": map[string]interface{}{
+					"exists": map[string]interface{}{"field": "id"},
+				},
+			},
+		},
+		"script": map[string]interface{}{
+			"source": "ctx._source.id = ctx._id",
+		},
 	})
 	if err != nil {
 		return err
 	}
 
+	refresh := true
+	backfill := esapi.UpdateByQueryRequest{
+		Index:     []string{"catalog"},
+		Body:      bytes.NewReader(body),
+		Conflicts: "proceed",
+		Refresh:   &refresh,
+	}
+	res, err = backfill.Do(ctx, r.client)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("backfilling catalog ids: %s", res.Status())
+	}
+	return nil
+}
+
+func (r *elasticSearchRepository) ensureIndex(ctx context.Context, index string, mapping string) error {
+	res, err := r.client.Indices.Exists(
+		[]string{index},
+		r.client.Indices.Exists.WithContext(ctx),
+	)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode != 404 {
+		return nil
+	}
+
+	res, err = r.client.Indices.Create(
+		index,
+		r.client.Indices.Create.WithContext(ctx),
+		r.client.Indices.Create.WithBody(strings.NewReader(mapping)),
+	)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("creating %s index: %s", index, res.Status())
+	}
+	return nil
+}
+
+func (r *elasticSearchRepository) Close() {
+}
+
+func (r *elasticSearchRepository) PutProduct(ctx context.Context, p Product) (err error) {
+	ctx, span := tracing.Start(ctx, "catalog.repository.PutProduct")
+	defer tracing.End(span, &err)
+	defer metrics.Query("elasticsearch", "catalog.PutProduct")(&err)
+
+	body, err := json.Marshal(productToDocument(p))
+	if err != nil {
+		return err
+	}
+
 	req := esapi.IndexRequest{
 		Index:      "catalog",
 		DocumentID: p.ID,
@@ -77,7 +348,38 @@ func (r *elasticSearchRepository) PutProduct(ctx context.Context, p Product) err
 	return err
 }
 
-func (r *elasticSearchRepository) GetProductByID(ctx context.Context, id string) (*Product, error) {
+func (r *elasticSearchRepository) Ping(ctx context.Context) error {
+	res, err := r.client.Ping(r.client.Ping.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("pinging elasticsearch: %s", res.Status())
+	}
+	return nil
+}
+
+// UpdateProductRating writes only the review aggregate fields, with
+// UpdateProduct, so that concurrent edits to the rest of the product
+// document are not overwritten.
+func (r *elasticSearchRepository) UpdateProductRating(ctx context.Context, id string, rating float64, reviewCount uint32) (err error) {
+	ctx, span := tracing.Start(ctx, "catalog.repository.UpdateProductRating")
+	defer tracing.End(span, &err)
+	defer metrics.Query("elasticsearch", "catalog.UpdateProductRating")(&err)
+
+	return r.UpdateProduct(ctx, id, func(p *Product) error {
+		p.Rating, p.ReviewCount = rating, reviewCount
+		return nil
+	})
+}
+
+func (r *elasticSearchRepository) GetProductByID(ctx context.Context, id string) (_ *Product, err error) {
+	ctx, span := tracing.Start(ctx, "catalog.repository.GetProductByID")
+	defer tracing.End(span, &err)
+	defer metrics.Query("elasticsearch", "catalog.GetProductByID")(&err)
+
 	res, err := r.client.Get(
 		"catalog",
 		id,
@@ -89,7 +391,7 @@ func (r *elasticSearchRepository) GetProductByID(ctx context.Context, id string)
 	defer res.Body.Close()
 
 	if res.StatusCode == 404 {
-		return nil, ErrNotFound
+		return nil, ErrNotFound.With("id", id)
 	}
 
 	var doc struct {
@@ -100,15 +402,40 @@ func (r *elasticSearchRepository) GetProductByID(ctx context.Context, id string)
 		return nil, err
 	}
 
-	return &Product{
-		ID:          id,
-		Name:        doc.Source.Name,
-		Description: doc.Source.Description,
-		Price:       doc.Source.Price,
-	}, nil
+	return productFromDocument(id, doc.Source), nil
 }
 
-func (r *elasticSearchRepository) ListProducts(ctx context.Context, skip uint64, take uint64) ([]Product, error) {
+func (r *elasticSearchRepository) UpdateProduct(ctx context.Context, id string, update func(p *Product) error) (err error) {
+	ctx, span := tracing.Start(ctx, "catalog.repository.UpdateProduct")
+	defer tracing.End(span, &err)
+	defer metrics.Query("elasticsearch", "catalog.UpdateProduct")(&err)
+
+	for {
+		var doc productDocument
+		v, err := r.getDocument(ctx, "catalog", id, &doc)
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			return ErrNotFound.With("id", id)
+		}
+		p := productFromDocument(id, doc)
+		if err := update(p); err != nil {
+			return err
+		}
+
+		err = r.putDocumentIf(ctx, "catalog", id, productToDocument(*p), v)
+		if !errors.Is(err, errVersionConflict) {
+			return err
+		}
+	}
+}
+
+func (r *elasticSearchRepository) ListProducts(ctx context.Context, skip uint64, take uint64) (_ []Product, err error) {
+	ctx, span := tracing.Start(ctx, "catalog.repository.ListProducts")
+	defer tracing.End(span, &err)
+	defer metrics.Query("elasticsearch", "catalog.ListProducts")(&err)
+
 	query := map[string]interface{}{
 		"from": skip,
 		"size": take,
@@ -156,7 +483,11 @@ func (r *elasticSearchRepository) ListProducts(ctx context.Context, skip uint64,
 
 }
 
-func (r *elasticSearchRepository) ListProductsWithIDs(ctx context.Context, ids []string) ([]Product, error) {
+func (r *elasticSearchRepository) ListProductsWithIDs(ctx context.Context, ids []string) (_ []Product, err error) {
+	ctx, span := tracing.Start(ctx, "catalog.repository.ListProductsWithIDs")
+	defer tracing.End(span, &err)
+	defer metrics.Query("elasticsearch", "catalog.ListProductsWithIDs")(&err)
+
 	if len(ids) == 0 {
 		return []Product{}, nil
 	}
@@ -211,7 +542,13 @@ func (r *elasticSearchRepository) ListProductsWithIDs(ctx context.Context, ids [
 	return products, nil
 }
 
-func (r *elasticSearchRepository) SearchProducts(ctx context.Context, query string, skip uint64, take uint64) ([]Product, error) {
+var productSearchFields = []string{"name", "description", "variants.sku", "variants.options.*"}
+
+func (r *elasticSearchRepository) SearchProducts(ctx context.Context, query string, skip uint64, take uint64) (_ []Product, err error) {
+	ctx, span := tracing.Start(ctx, "catalog.repository.SearchProducts")
+	defer tracing.End(span, &err)
+	defer metrics.Query("elasticsearch", "catalog.SearchProducts")(&err)
+
 	if query == "" {
 		return []Product{}, nil
 	}
@@ -222,7 +559,7 @@ func (r *elasticSearchRepository) SearchProducts(ctx context.Context, query stri
 		"query": map[string]interface{}{
 			"multi_match": map[string]interface{}{
 				"query":  query,
-				"fields": []string{"name", "description"},
+				"fields": productSearchFields,
 			},
 		},
 	}
@@ -268,3 +605,567 @@ func (r *elasticSearchRepository) SearchProducts(ctx context.Context, query stri
 
 	return products, nil
 }
+
+func (r *elasticSearchRepository) ListProductsAfter(ctx context.Context, after string, take uint64) (_ []Product, _ []string, err error) {
+	ctx, span := tracing.Start(ctx, "catalog.repository.ListProductsAfter")
+	defer tracing.End(span, &err)
+	defer metrics.Query("elasticsearch", "catalog.ListProductsAfter")(&err)
+
+	query := map[string]interface{}{
+		"match_all": map[string]interface{}{},
+	}
+	sort := []interface{}{
+		map[string]interface{}{"id": "desc"},
+	}
+
+	return r.searchAfter(ctx, query, sort, after, take)
+}
+
+func (r *elasticSearchRepository) SearchProductsAfter(ctx context.Context, query string, after string, take uint64) (_ []Product, _ []string, err error) {
+	ctx, span := tracing.Start(ctx, "catalog.repository.SearchProductsAfter")
+	defer tracing.End(span, &err)
+	defer metrics.Query("elasticsearch", "catalog.SearchProductsAfter")(&err)
+
+	if query == "" {
+		return []Product{}, []string{}, nil
+	}
+
+	esQuery := map[string]interface{}{
+		"multi_match": map[string]interface{}{
+			"query":  query,
+			"fields": productSearchFields,
+		},
+	}
+	sort := []interface{}{
+		map[string]interface{}{"_score": "desc"},
+		map[string]interface{}{"id": "asc"},
+	}
+
+	return r.searchAfter(ctx, esQuery, sort, after, take)
+}
+
+// searchAfter runs query sorted by sort and resumes after the hit encoded in
+// the after cursor. Each returned product comes with the cursor of its own
+// sort values so any of them can be used to continue the listing.
+func (r *elasticSearchRepository) searchAfter(ctx context.Context, query map[string]interface{}, sort []interface{}, after string, take uint64) ([]Product, []string, error) {
+	searchAfter, err := cursor.Decode(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	esQuery := map[string]interface{}{
+		"size":  take,
+		"query": query,
+		"sort":  sort,
+	}
+	if searchAfter != nil {
+		esQuery["search_after"] = searchAfter
+	}
+
+	body, err := json.Marshal(esQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res, err := r.client.Search(
+		r.client.Search.WithContext(ctx),
+		r.client.Search.WithIndex("catalog"),
+		r.client.Search.WithBody(bytes.NewReader(body)),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return nil, nil, ErrNotFound
+	}
+	if res.IsError() {
+		return nil, nil, fmt.Errorf("searching products: %s", res.Status())
+	}
+
+	var sr struct {
+		Hits struct {
+			Hits []struct {
+				ID     string        `json:"_id"`
+				Source Product       `json:"_source"`
+				Sort   []interface{} `json:"sort"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&sr); err != nil {
+		return nil, nil, err
+	}
+
+	products := []Product{}
+	cursors := []string{}
+	for _, hit := range sr.Hits.Hits {
+		p := hit.Source
+		p.ID = hit.ID
+		products = append(products, p)
+		cursors = append(cursors, cursor.Encode(hit.Sort...))
+	}
+
+	return products, cursors, nil
+}
+
+type priceScheduleDocument struct {
+	ID            string     `json:"id"`
+	ProductID     string     `json:"productId"`
+	Price         float64    `json:"price"`
+	PreviousPrice float64    `json:"previousPrice"`
+	StartsAt      time.Time  `json:"startsAt"`
+	EndsAt        *time.Time `json:"endsAt,omitempty"`
+	Actor         string     `json:"actor"`
+	Status        string     `json:"status"`
+	ClaimedUntil  *time.Time `json:"claimedUntil,omitempty"`
+}
+
+func priceScheduleToDocument(ps PriceSchedule) priceScheduleDocument {
+	doc := priceScheduleDocument{
+		ID:            ps.ID,
+		ProductID:     ps.ProductID,
+		Price:         ps.Price,
+		PreviousPrice: ps.PreviousPrice,
+		StartsAt:      ps.StartsAt,
+		Actor:         ps.Actor,
+		Status:        ps.Status,
+	}
+	if !ps.EndsAt.IsZero() {
+		doc.EndsAt = &ps.EndsAt
+	}
+	if !ps.ClaimedUntil.IsZero() {
+		doc.ClaimedUntil = &ps.ClaimedUntil
+	}
+	return doc
+}
+
+func priceScheduleFromDocument(doc priceScheduleDocument) PriceSchedule {
+	ps := PriceSchedule{
+		ID:            doc.ID,
+		ProductID:     doc.ProductID,
+		Price:         doc.Price,
+		PreviousPrice: doc.PreviousPrice,
+		StartsAt:      doc.StartsAt,
+		Actor:         doc.Actor,
+		Status:        doc.Status,
+	}
+	if doc.EndsAt != nil {
+		ps.EndsAt = *doc.EndsAt
+	}
+	if doc.ClaimedUntil != nil {
+		ps.ClaimedUntil = *doc.ClaimedUntil
+	}
+	return ps
+}
+
+func (r *elasticSearchRepository) PutPriceChange(ctx context.Context, c PriceChange) (err error) {
+	ctx, span := tracing.Start(ctx, "catalog.repository.PutPriceChange")
+	defer tracing.End(span, &err)
+	defer metrics.Query("elasticsearch", "catalog.PutPriceChange")(&err)
+
+	return r.putDocument(ctx, "catalog_price_history", c.ID, c)
+}
+
+func (r *elasticSearchRepository) ListPriceChanges(ctx context.Context, productID string, take uint64) (_ []PriceChange, err error) {
+	ctx, span := tracing.Start(ctx, "catalog.repository.ListPriceChanges")
+	defer tracing.End(span, &err)
+	defer metrics.Query("elasticsearch", "catalog.ListPriceChanges")(&err)
+
+	query := map[string]interface{}{
+		"size": take,
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{
+				"productId": productID,
+			},
+		},
+		"sort": []interface{}{
+			map[string]interface{}{"changedAt": "desc"},
+		},
+	}
+
+	var sr struct {
+		Hits struct {
+			Hits []struct {
+				Source PriceChange `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := r.search(ctx, "catalog_price_history", query, &sr); err != nil {
+		return nil, err
+	}
+
+	changes := []PriceChange{}
+	for _, hit := range sr.Hits.Hits {
+		changes = append(changes, hit.Source)
+	}
+	return changes, nil
+}
+
+func (r *elasticSearchRepository) PutPriceSchedule(ctx context.Context, ps PriceSchedule) (err error) {
+	ctx, span := tracing.Start(ctx, "catalog.repository.PutPriceSchedule")
+	defer tracing.End(span, &err)
+	defer metrics.Query("elasticsearch", "catalog.PutPriceSchedule")(&err)
+
+	return r.putDocument(ctx, "catalog_price_schedules", ps.ID, priceScheduleToDocument(ps))
+}
+
+func (r *elasticSearchRepository) UpdatePriceSchedule(ctx context.Context, id string, update func(ps *PriceSchedule) error) (err error) {
+	ctx, span := tracing.Start(ctx, "catalog.repository.UpdatePriceSchedule")
+	defer tracing.End(span, &err)
+	defer metrics.Query("elasticsearch", "catalog.UpdatePriceSchedule")(&err)
+
+	for {
+		var doc priceScheduleDocument
+		v, err := r.getDocument(ctx, "catalog_price_schedules", id, &doc)
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			return ErrScheduleNotFound.With("id", id)
+		}
+		ps := priceScheduleFromDocument(doc)
+		if err := update(&ps); err != nil {
+			return err
+		}
+
+		err = r.putDocumentIf(ctx, "catalog_price_schedules", id, priceScheduleToDocument(ps), v)
+		if !errors.Is(err, errVersionConflict) {
+			return err
+		}
+	}
+}
+
+// ListDuePriceSchedules finds pending schedules whose start has passed and
+// active ones whose end has passed, oldest first, leaving out those another
+// scheduler has claimed until after now.
+func (r *elasticSearchRepository) ListDuePriceSchedules(ctx context.Context, now time.Time) (_ []PriceSchedule, err error) {
+	ctx, span := tracing.Start(ctx, "catalog.repository.ListDuePriceSchedules")
+	defer tracing.End(span, &err)
+	defer metrics.Query("elasticsearch", "catalog.ListDuePriceSchedules")(&err)
+
+	due := func(status, field string) map[string]interface{} {
+		return map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []interface{}{
+					map[string]interface{}{"term": map[string]interface{}{"status": status}},
+					map[string]interface{}{"range": map[string]interface{}{field: map[string]interface{}{"lte": now}}},
+				},
+			},
+		}
+	}
+
+	query := map[string]interface{}{
+		"size": 100,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should": []interface{}{
+					due(PriceSchedulePending, "startsAt"),
+					due(PriceScheduleActive, "endsAt"),
+				},
+				"minimum_should_match": 1,
+				"must_not": []interface{}{
+					map[string]interface{}{"range": map[string]interface{}{"claimedUntil": map[string]interface{}{"gt": now}}},
+				},
+			},
+		},
+		"sort": []interface{}{
+			map[string]interface{}{"startsAt": "asc"},
+		},
+	}
+
+	var sr struct {
+		Hits struct {
+			Hits []struct {
+				Source priceScheduleDocument `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := r.search(ctx, "catalog_price_schedules", query, &sr); err != nil {
+		return nil, err
+	}
+
+	schedules := []PriceSchedule{}
+	for _, hit := range sr.Hits.Hits {
+		schedules = append(schedules, priceScheduleFromDocument(hit.Source))
+	}
+	return schedules, nil
+}
+
+// adjustStockScript changes the stock of one variant in place, so that
+// concurrent orders for the same product cannot both take its last item.
+// It leaves the document untouched when the variant is missing or would go
+// below zero.
+const adjustStockScript = `
+for (v in ctx._source.variants) {
+	if (v.id == params.variantId) {
+		if (v.stock + params.delta < 0) {
+			ctx.op = 'noop';
+		} else {
+			v.stock += params.delta;
+		}
+		return;
+	}
+}
+ctx.op = 'noop';
+`
+
+// AdjustStock adds delta, which may be negative, to the stock of a variant.
+// It fails with ErrOutOfStock rather than take the stock below zero.
+func (r *elasticSearchRepository) AdjustStock(ctx context.Context, productID string, variantID string, delta int64) (err error) {
+	ctx, span := tracing.Start(ctx, "catalog.repository.AdjustStock")
+	defer tracing.End(span, &err)
+	defer metrics.Query("elasticsearch", "catalog.AdjustStock")(&err)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"script": map[string]interface{}{
+			"source": adjustStockScript,
+			"params": map[string]interface{}{
+				"variantId": variantID,
+				"delta":     delta,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	retries := 3
+	req := esapi.UpdateRequest{
+		Index:           "catalog",
+		DocumentID:      productID,
+		Body:            bytes.NewReader(body),
+		Refresh:         "true",
+		RetryOnConflict: &retries,
+	}
+
+	res, err := req.Do(ctx, r.client)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return ErrNotFound.With("id", productID)
+	}
+	if res.IsError() {
+		return fmt.Errorf("adjusting stock: %s", res.Status())
+	}
+
+	var result struct {
+		Result string `json:"result"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return err
+	}
+	if result.Result == "noop" {
+		return ErrOutOfStock.With("productId", productID).With("variantId", variantID)
+	}
+	return nil
+}
+
+func (r *elasticSearchRepository) GetStockReservation(ctx context.Context, id string) (_ *StockReservation, err error) {
+	ctx, span := tracing.Start(ctx, "catalog.repository.GetStockReservation")
+	defer tracing.End(span, &err)
+	defer metrics.Query("elasticsearch", "catalog.GetStockReservation")(&err)
+
+	res, err := r.client.Get(
+		"catalog_stock_reservations",
+		id,
+		r.client.Get.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return nil, ErrReservationNotFound.With("id", id)
+	}
+	if res.IsError() {
+		return nil, fmt.Errorf("getting stock reservation: %s", res.Status())
+	}
+
+	var doc struct {
+		Source StockReservation `json:"_source"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc.Source, nil
+}
+
+func (r *elasticSearchRepository) PutStockReservation(ctx context.Context, sr StockReservation) (err error) {
+	ctx, span := tracing.Start(ctx, "catalog.repository.PutStockReservation")
+	defer tracing.End(span, &err)
+	defer metrics.Query("elasticsearch", "catalog.PutStockReservation")(&err)
+
+	return r.putDocument(ctx, "catalog_stock_reservations", sr.ID, sr)
+}
+
+// CreateStockReservation stores a new reservation, failing with
+// ErrReservationExists if one with its ID was stored before.
+func (r *elasticSearchRepository) CreateStockReservation(ctx context.Context, sr StockReservation) (err error) {
+	ctx, span := tracing.Start(ctx, "catalog.repository.CreateStockReservation")
+	defer tracing.End(span, &err)
+	defer metrics.Query("elasticsearch", "catalog.CreateStockReservation")(&err)
+
+	err = r.putDocumentIf(ctx, "catalog_stock_reservations", sr.ID, sr, nil)
+	if errors.Is(err, errVersionConflict) {
+		return ErrReservationExists.With("id", sr.ID)
+	}
+	return err
+}
+
+// UpdateStockReservation applies update to the stored reservation and writes
+// it back only if nobody changed it in between, reading it again and
+// retrying update when someone did.
+func (r *elasticSearchRepository) UpdateStockReservation(ctx context.Context, id string, update func(sr *StockReservation) error) (err error) {
+	ctx, span := tracing.Start(ctx, "catalog.repository.UpdateStockReservation")
+	defer tracing.End(span, &err)
+	defer metrics.Query("elasticsearch", "catalog.UpdateStockReservation")(&err)
+
+	for {
+		var sr StockReservation
+		v, err := r.getDocument(ctx, "catalog_stock_reservations", id, &sr)
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			return ErrReservationNotFound.With("id", id)
+		}
+		if err := update(&sr); err != nil {
+			return err
+		}
+
+		err = r.putDocumentIf(ctx, "catalog_stock_reservations", id, sr, v)
+		if !errors.Is(err, errVersionConflict) {
+			return err
+		}
+	}
+}
+
+// docVersion is the revision of a document as read, which a write can be
+// made conditional on.
+type docVersion struct {
+	SeqNo       int `json:"_seq_no"`
+	PrimaryTerm int `json:"_primary_term"`
+}
+
+// getDocument decodes the source of a document into dst and returns its
+// revision, or a nil revision if there is no such document.
+func (r *elasticSearchRepository) getDocument(ctx context.Context, index string, id string, dst interface{}) (*docVersion, error) {
+	res, err := r.client.Get(
+		index,
+		id,
+		r.client.Get.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return nil, nil
+	}
+	if res.IsError() {
+		return nil, fmt.Errorf("getting from %s: %s", index, res.Status())
+	}
+
+	var doc struct {
+		docVersion
+		Source json.RawMessage `json:"_source"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&doc); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(doc.Source, dst); err != nil {
+		return nil, err
+	}
+	return &doc.docVersion, nil
+}
+
+// putDocumentIf indexes doc only if the stored document is still at
+// revision v or, when v is nil, only if there is no document with that ID
+// yet. It fails with errVersionConflict otherwise.
+func (r *elasticSearchRepository) putDocumentIf(ctx context.Context, index string, id string, doc interface{}, v *docVersion) error {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	req := esapi.IndexRequest{
+		Index:      index,
+		DocumentID: id,
+		Body:       bytes.NewReader(body),
+		Refresh:    "true",
+	}
+	if v == nil {
+		req.OpType = "create"
+	} else {
+		req.IfSeqNo, req.IfPrimaryTerm = &v.SeqNo, &v.PrimaryTerm
+	}
+
+	res, err := req.Do(ctx, r.client)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 409 {
+		return errVersionConflict
+	}
+	if res.IsError() {
+		return fmt.Errorf("indexing into %s: %s", index, res.Status())
+	}
+	return nil
+}
+
+func (r *elasticSearchRepository) putDocument(ctx context.Context, index string, id string, doc interface{}) error {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	req := esapi.IndexRequest{
+		Index:      index,
+		DocumentID: id,
+		Body:       bytes.NewReader(body),
+		Refresh:    "true",
+	}
+
+	res, err := req.Do(ctx, r.client)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("indexing into %s: %s", index, res.Status())
+	}
+	return nil
+}
+
+func (r *elasticSearchRepository) search(ctx context.Context, index string, query map[string]interface{}, dst interface{}) error {
+	body, err := json.Marshal(query)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.client.Search(
+		r.client.Search.WithContext(ctx),
+		r.client.Search.WithIndex(index),
+		r.client.Search.WithBody(bytes.NewReader(body)),
+	)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("searching %s: %s", index, res.Status())
+	}
+
+	return json.NewDecoder(res.Body).Decode(dst)
+}
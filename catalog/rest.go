@@ -0,0 +1,31 @@
+package catalog
+
+import (
+	"context"
+	"crypto/tls"
+	_ "embed"
+	"net/http"
+	"strconv"
+
+	pb "github.com/sunil8777/E-commerce-microservices/catalog/pb"
+	"github.com/sunil8777/E-commerce-microservices/rest"
+)
+
+//go:embed catalog.swagger.json
+var openAPI []byte
+
+// ListenREST serves the REST/JSON mapping of CatalogService declared in
+// catalog.proto on port, its OpenAPI document at /openapi.json and the
+// files of product media stored in mediaDir under /media/. Requests go to
+// the gRPC server on grpcPort of this host.
+func ListenREST(ctx context.Context, grpcPort int, port int, tlsConfig *tls.Config, mediaDir string) error {
+	gw := rest.Gateway{
+		Service:  pb.CatalogService_ServiceDesc.ServiceName,
+		Register: pb.RegisterCatalogServiceHandler,
+		OpenAPI:  openAPI,
+		Handlers: map[string]http.Handler{
+			"/media/": http.StripPrefix("/media/", http.FileServer(http.Dir(mediaDir))),
+		},
+	}
+	return gw.ListenHTTP(ctx, "localhost:"+strconv.Itoa(grpcPort), port, tlsConfig)
+}
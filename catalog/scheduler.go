@@ -0,0 +1,30 @@
+package catalog
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// priceScheduleLease is how long a scheduler holds a price schedule it
+// claimed before the schedulers of other replicas may take it up again.
+const priceScheduleLease = time.Minute
+
+// RunPriceScheduler applies due price schedules every interval until ctx is
+// cancelled. It is meant to run in its own goroutine next to ListenGRPC.
+func RunPriceScheduler(ctx context.Context, s Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.ApplyDuePriceSchedules(ctx, time.Now().UTC()); err != nil {
+			log.Println("Error applying price schedules:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
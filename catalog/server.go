@@ -5,41 +5,87 @@ import (
 	"log"
 	"net"
 	"strconv"
+	"time"
 
 	pb "github.com/sunil8777/E-commerce-microservices/catalog/pb"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+	"github.com/sunil8777/E-commerce-microservices/grpcopts"
+	"github.com/sunil8777/E-commerce-microservices/healthcheck"
+	"github.com/sunil8777/E-commerce-microservices/metrics"
+	"github.com/sunil8777/E-commerce-microservices/shutdown"
+	"github.com/sunil8777/E-commerce-microservices/tlsconfig"
+	"github.com/sunil8777/E-commerce-microservices/tracing"
+	"github.com/sunil8777/E-commerce-microservices/validation"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 )
 
+// peerRules limits RPCs that only one other service has any business
+// making to that service's client certificate. Adding media is left to the
+// gateway, which only lets admins do it. They are enforced when the server
+// runs with mutual TLS.
+var peerRules = map[string][]string{
+	pb.CatalogService_UpdateProductRating_FullMethodName: {"review"},
+	pb.CatalogService_AddProductMedia_FullMethodName:     {"graphql"},
+	pb.CatalogService_ReserveStock_FullMethodName:        {"order"},
+	pb.CatalogService_ReleaseStock_FullMethodName:        {"order"},
+	pb.CatalogService_ReturnStock_FullMethodName:         {"order"},
+}
+
+// MaxMediaSize is the largest media file AddProductMedia accepts.
+const MaxMediaSize = 10 << 20
+
+// maxMessageSize lets an AddProductMedia request carry a file of
+// MaxMediaSize.
+const maxMessageSize = MaxMediaSize + 1<<20
+
 type grpcServer struct {
 	pb.UnimplementedCatalogServiceServer
 	service Service
 }
 
-func ListenGRPC(s Service, port int) error {
+func ListenGRPC(ctx context.Context, s Service, port int) error {
 	lis, err := net.Listen("tcp", ":"+strconv.Itoa(port))
 	if err != nil {
 		return err
 	}
-	server := grpc.NewServer()
+	return Serve(ctx, s, lis)
+}
+
+// Serve serves s on lis until ctx is done.
+func Serve(ctx context.Context, s Service, lis net.Listener) error {
+	server := grpc.NewServer(append(
+		grpcopts.ServerOptions(),
+		grpc.ChainUnaryInterceptor(
+			metrics.UnaryServerInterceptor(),
+			errs.UnaryServerInterceptor(),
+			tlsconfig.AuthorizePeers(peerRules),
+			validation.UnaryServerInterceptor(),
+		),
+		tracing.ServerOption(),
+		grpc.MaxRecvMsgSize(maxMessageSize),
+	)...)
 	pb.RegisterCatalogServiceServer(server, &grpcServer{service: s})
+	healthcheck.Register(ctx, server, s.Ping, pb.CatalogService_ServiceDesc.ServiceName)
 	reflection.Register(server)
-	return server.Serve(lis)
+	return shutdown.ServeGRPC(ctx, server, lis)
 }
 
 func (s *grpcServer) PostProduct(ctx context.Context, r *pb.PostProductRequest) (*pb.PostProductResponse, error) {
-	p, err := s.service.PostProduct(ctx, r.Name, r.Description, r.Price)
+	p, err := s.service.PostProduct(
+		ctx,
+		r.Name,
+		r.Description,
+		r.Price,
+		optionsFromProto(r.Options),
+		variantsFromProto(r.Variants),
+	)
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
 
-	return &pb.PostProductResponse{Product: &pb.Product{
-		Id:          p.ID,
-		Name:        p.Name,
-		Description: p.Description,
-		Price:       p.Price,
-	}}, nil
+	return &pb.PostProductResponse{Product: productToProto(*p)}, nil
 }
 
 func (s *grpcServer) GetProduct(ctx context.Context, r *pb.GetProductRequest) (*pb.GetProductResponse, error) {
@@ -49,15 +95,14 @@ func (s *grpcServer) GetProduct(ctx context.Context, r *pb.GetProductRequest) (*
 		return nil, err
 	}
 
-	return &pb.GetProductResponse{Product: &pb.Product{
-		Id:          p.ID,
-		Name:        p.Name,
-		Description: p.Description,
-		Price:       p.Price,
-	}}, nil
+	return &pb.GetProductResponse{Product: productToProto(*p)}, nil
 }
 
 func (s *grpcServer) GetProducts(ctx context.Context, r *pb.GetProductsRequest) (*pb.GetProductsResponse, error) {
+	if r.After != nil {
+		return s.getProductsPage(ctx, r)
+	}
+
 	var res []Product
 	var err error
 
@@ -75,17 +120,210 @@ func (s *grpcServer) GetProducts(ctx context.Context, r *pb.GetProductsRequest)
 
 	products := []*pb.Product{}
 	for _, p := range res {
-
-		products = append(
-			products,
-			&pb.Product{
-				Id:          p.ID,
-				Name:        p.Name,
-				Description: p.Description,
-				Price:       p.Price,
-			},
-		)
+		products = append(products, productToProto(p))
 	}
 
 	return &pb.GetProductsResponse{Products: products}, nil
 }
+
+func (s *grpcServer) getProductsPage(ctx context.Context, r *pb.GetProductsRequest) (*pb.GetProductsResponse, error) {
+	var page *ProductPage
+	var err error
+
+	if r.Query != "" {
+		page, err = s.service.SearchProductsPage(ctx, r.Query, r.GetAfter(), r.Take)
+	} else {
+		page, err = s.service.GetProductsPage(ctx, r.GetAfter(), r.Take)
+	}
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	products := []*pb.Product{}
+	for _, p := range page.Products {
+		products = append(products, productToProto(p))
+	}
+
+	return &pb.GetProductsResponse{
+		Products:    products,
+		Cursors:     page.Cursors,
+		HasNextPage: page.HasNextPage,
+	}, nil
+}
+
+func (s *grpcServer) UpdateProductRating(ctx context.Context, r *pb.UpdateProductRatingRequest) (*pb.UpdateProductRatingResponse, error) {
+	if err := s.service.UpdateProductRating(ctx, r.Id, r.Rating, r.ReviewCount); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return &pb.UpdateProductRatingResponse{}, nil
+}
+
+func (s *grpcServer) AddProductMedia(ctx context.Context, r *pb.AddProductMediaRequest) (*pb.AddProductMediaResponse, error) {
+	if r.Media == nil || len(r.Data) > MaxMediaSize {
+		return nil, ErrInvalidMedia
+	}
+
+	p, err := s.service.AddProductMedia(ctx, r.ProductId, mediaFromProto(r.Media), r.Data, r.Position)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return &pb.AddProductMediaResponse{Product: productToProto(*p)}, nil
+}
+
+func (s *grpcServer) UpdateProductPrice(ctx context.Context, r *pb.UpdateProductPriceRequest) (*pb.UpdateProductPriceResponse, error) {
+	p, err := s.service.UpdateProductPrice(ctx, r.Id, r.Price, r.Actor)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return &pb.UpdateProductPriceResponse{Product: productToProto(*p)}, nil
+}
+
+func (s *grpcServer) GetPriceHistory(ctx context.Context, r *pb.GetPriceHistoryRequest) (*pb.GetPriceHistoryResponse, error) {
+	res, err := s.service.GetPriceHistory(ctx, r.ProductId, r.Take)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	changes := []*pb.PriceChange{}
+	for _, c := range res {
+		change := &pb.PriceChange{
+			Id:         c.ID,
+			ProductId:  c.ProductID,
+			OldPrice:   c.OldPrice,
+			NewPrice:   c.NewPrice,
+			Actor:      c.Actor,
+			ScheduleId: c.ScheduleID,
+		}
+		change.ChangedAt, _ = c.ChangedAt.MarshalBinary()
+		changes = append(changes, change)
+	}
+
+	return &pb.GetPriceHistoryResponse{Changes: changes}, nil
+}
+
+func (s *grpcServer) SchedulePriceChange(ctx context.Context, r *pb.SchedulePriceChangeRequest) (*pb.SchedulePriceChangeResponse, error) {
+	startsAt, endsAt := time.Time{}, time.Time{}
+	startsAt.UnmarshalBinary(r.StartsAt)
+	endsAt.UnmarshalBinary(r.EndsAt)
+
+	ps, err := s.service.SchedulePriceChange(ctx, r.ProductId, r.Price, startsAt, endsAt, r.Actor)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	schedule := &pb.PriceSchedule{
+		Id:        ps.ID,
+		ProductId: ps.ProductID,
+		Price:     ps.Price,
+		Actor:     ps.Actor,
+		Status:    ps.Status,
+	}
+	schedule.StartsAt, _ = ps.StartsAt.MarshalBinary()
+	if !ps.EndsAt.IsZero() {
+		schedule.EndsAt, _ = ps.EndsAt.MarshalBinary()
+	}
+
+	return &pb.SchedulePriceChangeResponse{Schedule: schedule}, nil
+}
+
+func (s *grpcServer) ReserveStock(ctx context.Context, r *pb.ReserveStockRequest) (*pb.ReserveStockResponse, error) {
+	items := []StockItem{}
+	for _, i := range r.Items {
+		items = append(items, StockItem{
+			ProductID: i.ProductId,
+			VariantID: i.VariantId,
+			Quantity:  i.Quantity,
+		})
+	}
+
+	if err := s.service.ReserveStock(ctx, r.ReservationId, items); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return &pb.ReserveStockResponse{}, nil
+}
+
+func (s *grpcServer) ReleaseStock(ctx context.Context, r *pb.ReleaseStockRequest) (*pb.ReleaseStockResponse, error) {
+	if err := s.service.ReleaseStock(ctx, r.ReservationId); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return &pb.ReleaseStockResponse{}, nil
+}
+
+func (s *grpcServer) ReturnStock(ctx context.Context, r *pb.ReturnStockRequest) (*pb.ReturnStockResponse, error) {
+	items := []StockItem{}
+	for _, i := range r.Items {
+		items = append(items, StockItem{
+			ProductID: i.ProductId,
+			VariantID: i.VariantId,
+			Quantity:  i.Quantity,
+		})
+	}
+
+	if err := s.service.ReturnStock(ctx, r.ReturnId, items); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return &pb.ReturnStockResponse{}, nil
+}
+
+func productToProto(p Product) *pb.Product {
+	return &pb.Product{
+		Id:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       p.Price,
+		Options:     optionsToProto(p.Options),
+		Variants:    variantsToProto(p.Variants),
+		Rating:      p.Rating,
+		ReviewCount: p.ReviewCount,
+		Media:       mediaListToProto(p.Media),
+	}
+}
+
+func optionsFromProto(options []*pb.ProductOption) []ProductOption {
+	res := []ProductOption{}
+	for _, o := range options {
+		res = append(res, ProductOption{
+			Name:   o.Name,
+			Values: o.Values,
+		})
+	}
+	return res
+}
+
+func variantsFromProto(variants []*pb.Variant) []Variant {
+	res := []Variant{}
+	for _, v := range variants {
+		res = append(res, Variant{
+			ID:      v.Id,
+			SKU:     v.Sku,
+			Options: v.Options,
+			Price:   v.Price,
+			Stock:   v.Stock,
+		})
+	}
+	return res
+}
+
+func mediaFromProto(m *pb.ProductMedia) Media {
+	return Media{
+		ID:          m.Id,
+		URL:         m.Url,
+		AltText:     m.AltText,
+		Position:    m.Position,
+		Width:       m.Width,
+		Height:      m.Height,
+		ContentType: m.ContentType,
+	}
+}
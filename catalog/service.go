@@ -1,40 +1,224 @@
 package catalog
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/segmentio/ksuid"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+)
+
+var (
+	ErrInvalidVariant  = errs.New(errs.InvalidArgument, "INVALID_VARIANT", "variant does not match product options")
+	ErrInvalidMedia    = errs.New(errs.InvalidArgument, "INVALID_MEDIA", "media is missing or too large")
+	ErrInvalidPrice    = errs.New(errs.InvalidArgument, "INVALID_PRICE", "price must not be negative")
+	ErrInvalidSchedule = errs.New(errs.InvalidArgument, "INVALID_PRICE_SCHEDULE", "price schedule must start before it ends")
+	ErrOutOfStock      = errs.New(errs.FailedPrecondition, "OUT_OF_STOCK", "not enough stock")
+	ErrReleased        = errs.New(errs.FailedPrecondition, "STOCK_RESERVATION_RELEASED", "stock reservation was already released")
+	ErrPending         = errs.New(errs.Unavailable, "STOCK_RESERVATION_PENDING", "stock reservation is still being taken")
+)
+
+const (
+	PriceSchedulePending = "pending"
+	PriceScheduleActive  = "active"
+	PriceScheduleDone    = "done"
+
+	// A reservation is pending while its stock is being taken, and held
+	// once all of it is.
+	ReservationPending  = "pending"
+	ReservationHeld     = "held"
+	ReservationReleased = "released"
+	// Returns are recorded alongside reservations: returning while items
+	// are being put back, returned once they all are.
+	ReservationReturning = "returning"
+	ReservationReturned  = "returned"
 )
 
 type Service interface {
-	PostProduct(ctx context.Context, name, description string, price float64) (*Product, error)
+	PostProduct(ctx context.Context, name, description string, price float64, options []ProductOption, variants []Variant) (*Product, error)
 	GetProduct(ctx context.Context, id string) (*Product, error)
 	GetProducts(ctx context.Context, skip uint64, take uint64) ([]Product, error)
 	GetProductByIDs(ctx context.Context, ids []string) ([]Product, error)
 	SearchProducts(ctx context.Context, query string, skip uint64, take uint64) ([]Product, error)
+	GetProductsPage(ctx context.Context, after string, take uint64) (*ProductPage, error)
+	SearchProductsPage(ctx context.Context, query string, after string, take uint64) (*ProductPage, error)
+	UpdateProductRating(ctx context.Context, id string, rating float64, reviewCount uint32) error
+	AddProductMedia(ctx context.Context, productID string, m Media, data []byte, position *uint32) (*Product, error)
+	UpdateProductPrice(ctx context.Context, id string, price float64, actor string) (*Product, error)
+	GetPriceHistory(ctx context.Context, productID string, take uint64) ([]PriceChange, error)
+	SchedulePriceChange(ctx context.Context, productID string, price float64, startsAt, endsAt time.Time, actor string) (*PriceSchedule, error)
+	ApplyDuePriceSchedules(ctx context.Context, now time.Time) error
+	ReserveStock(ctx context.Context, reservationID string, items []StockItem) error
+	ReleaseStock(ctx context.Context, reservationID string) error
+	ReturnStock(ctx context.Context, returnID string, items []StockItem) error
+	Ping(ctx context.Context) error
 }
 
 type Product struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Price       float64         `json:"price"`
+	Options     []ProductOption `json:"options,omitempty"`
+	Variants    []Variant       `json:"variants,omitempty"`
+	Rating      float64         `json:"rating"`
+	ReviewCount uint32          `json:"reviewCount"`
+	Media       []Media         `json:"media,omitempty"`
+	// PriceSchedule is the last price schedule step applied to the product.
+	PriceSchedule *AppliedPriceSchedule `json:"priceSchedule,omitempty"`
+	// PriceChangeID is the price change that set Price.
+	PriceChangeID string `json:"priceChangeId,omitempty"`
+}
+
+// AppliedPriceSchedule records, on the product it priced, that a price
+// schedule moved to Status, and the price in force before it started. It
+// is written together with the price, so a scheduler that stops before
+// recording the step on the schedule can tell that the step was applied.
+type AppliedPriceSchedule struct {
+	ID            string  `json:"id"`
+	Status        string  `json:"status"`
+	PreviousPrice float64 `json:"previousPrice"`
+}
+
+// Media describes an image attached to a product. The file itself lives in a
+// BlobStore; URL is where it is served from. Media are shown in Position
+// order.
+type Media struct {
+	ID          string `json:"id"`
+	URL         string `json:"url"`
+	AltText     string `json:"altText"`
+	Position    uint32 `json:"position"`
+	Width       uint32 `json:"width"`
+	Height      uint32 `json:"height"`
+	ContentType string `json:"contentType"`
+}
+
+// ProductOption is one axis a product varies along, such as size or colour,
+// with the values a variant may pick for it.
+type ProductOption struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// Variant is a sellable SKU of a product. Options holds one value for every
+// product option axis. A nil Price means the variant sells at the product
+// price; a variant can be given away with a Price of zero.
+type Variant struct {
+	ID      string            `json:"id"`
+	SKU     string            `json:"sku"`
+	Options map[string]string `json:"options"`
+	Price   *float64          `json:"price,omitempty"`
+	Stock   uint32            `json:"stock"`
+}
+
+// Variant looks up one of the product's variants by ID.
+func (p Product) Variant(id string) (*Variant, bool) {
+	for i := range p.Variants {
+		if p.Variants[i].ID == id {
+			return &p.Variants[i], true
+		}
+	}
+	return nil, false
+}
+
+// VariantPrice returns what v sells for, falling back to the product price
+// when the variant does not override it.
+func (p Product) VariantPrice(v Variant) float64 {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return p.Price
+}
+
+// PriceChange is an audit record of one edit to a product's price. ScheduleID
+// is set when the edit was made by the price scheduler rather than by hand.
+type PriceChange struct {
+	ID         string    `json:"id"`
+	ProductID  string    `json:"productId"`
+	OldPrice   float64   `json:"oldPrice"`
+	NewPrice   float64   `json:"newPrice"`
+	Actor      string    `json:"actor"`
+	ScheduleID string    `json:"scheduleId,omitempty"`
+	ChangedAt  time.Time `json:"changedAt"`
+}
+
+// PriceSchedule sets a product to Price from StartsAt. When EndsAt is set the
+// change is temporary, as for a sale, and the price in force before the
+// schedule started is restored at EndsAt, unless the price was changed
+// during the sale. ClaimedUntil is when the scheduler applying the schedule
+// lets other schedulers take it up.
+type PriceSchedule struct {
+	ID            string
+	ProductID     string
+	Price         float64
+	PreviousPrice float64
+	StartsAt      time.Time
+	EndsAt        time.Time
+	Actor         string
+	Status        string
+	ClaimedUntil  time.Time
+}
+
+// StockItem is a quantity of one variant. Only variants track stock, so
+// items of products without variants are never short.
+type StockItem struct {
+	ProductID string `json:"productId"`
+	VariantID string `json:"variantId"`
+	Quantity  uint32 `json:"quantity"`
+}
+
+// StockReservation records the stock taken out for an order, so that it is
+// given back exactly once however often the order service retries.
+type StockReservation struct {
+	ID        string      `json:"id"`
+	Items     []StockItem `json:"items"`
+	Status    string      `json:"status"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+// ProductPage is one page of a cursor-paginated product listing. Cursors[i]
+// is the opaque cursor pointing just past Products[i].
+type ProductPage struct {
+	Products    []Product
+	Cursors     []string
+	HasNextPage bool
 }
 
 type catalogService struct {
 	respository Repository
+	media       BlobStore
 }
 
-func NewService(r Repository) Service {
-	return &catalogService{r}
+// NewService serves the catalog stored in r. The files of product media
+// are stored in media.
+func NewService(r Repository, media BlobStore) Service {
+	return &catalogService{r, media}
 }
 
-func (s *catalogService) PostProduct(ctx context.Context, name, description string, price float64) (*Product, error) {
+func (s *catalogService) PostProduct(ctx context.Context, name, description string, price float64, options []ProductOption, variants []Variant) (*Product, error) {
 	p := Product{
 		ID:          ksuid.New().String(),
 		Name:        name,
 		Description: description,
 		Price:       price,
+		Options:     options,
+	}
+
+	skus := map[string]bool{}
+	for _, v := range variants {
+		if !matchesOptions(v, options) || skus[v.SKU] {
+			return nil, ErrInvalidVariant
+		}
+		skus[v.SKU] = true
+
+		v.ID = ksuid.New().String()
+		p.Variants = append(p.Variants, v)
 	}
 
 	if err := s.respository.PutProduct(ctx, p); err != nil {
@@ -66,3 +250,510 @@ func (s *catalogService) SearchProducts(ctx context.Context, query string, skip
 
 	return s.respository.SearchProducts(ctx, query, skip, take)
 }
+
+func (s *catalogService) GetProductsPage(ctx context.Context, after string, take uint64) (*ProductPage, error) {
+	if take == 0 || take > 100 {
+		take = 100
+	}
+
+	products, cursors, err := s.respository.ListProductsAfter(ctx, after, take+1)
+	if err != nil {
+		return nil, err
+	}
+
+	return newProductPage(products, cursors, take), nil
+}
+
+func (s *catalogService) SearchProductsPage(ctx context.Context, query string, after string, take uint64) (*ProductPage, error) {
+	if take == 0 || take > 100 {
+		take = 100
+	}
+
+	products, cursors, err := s.respository.SearchProductsAfter(ctx, query, after, take+1)
+	if err != nil {
+		return nil, err
+	}
+
+	return newProductPage(products, cursors, take), nil
+}
+
+func (s *catalogService) UpdateProductRating(ctx context.Context, id string, rating float64, reviewCount uint32) error {
+	return s.respository.UpdateProductRating(ctx, id, rating, reviewCount)
+}
+
+// AddProductMedia stores data in the media store and attaches it to the
+// product as m. With a nil position the media goes last; otherwise it is
+// inserted at that index and later media shift down one place. The file is
+// removed again when the product cannot be updated.
+func (s *catalogService) AddProductMedia(ctx context.Context, productID string, m Media, data []byte, position *uint32) (*Product, error) {
+	if len(data) == 0 {
+		return nil, ErrInvalidMedia
+	}
+	if _, err := s.respository.GetProductByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	m.ID = ksuid.New().String()
+	key := productID + "/" + m.ID
+	if ext, ok := strings.CutPrefix(m.ContentType, "image/"); ok {
+		key += "." + ext
+	}
+	url, err := s.media.Put(ctx, key, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	m.URL = url
+
+	var updated Product
+	err = s.respository.UpdateProduct(ctx, productID, func(p *Product) error {
+		at := len(p.Media)
+		if position != nil && int(*position) < at {
+			at = int(*position)
+		}
+
+		media := append([]Media{}, p.Media[:at]...)
+		media = append(media, m)
+		media = append(media, p.Media[at:]...)
+		for i := range media {
+			media[i].Position = uint32(i)
+		}
+		p.Media = media
+		updated = *p
+		return nil
+	})
+	if err != nil {
+		if err := s.media.Delete(ctx, key); err != nil {
+			log.Println(err)
+		}
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// UpdateProductPrice sets the price of the product by hand and records the
+// edit in the price history, computed from the product as it is written.
+func (s *catalogService) UpdateProductPrice(ctx context.Context, id string, price float64, actor string) (*Product, error) {
+	if price < 0 {
+		return nil, ErrInvalidPrice
+	}
+
+	var updated Product
+	err := s.respository.UpdateProduct(ctx, id, func(p *Product) error {
+		if p.Price != price {
+			if err := s.recordPriceChange(ctx, p, price, actor, ""); err != nil {
+				return err
+			}
+		}
+		updated = *p
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (s *catalogService) GetPriceHistory(ctx context.Context, productID string, take uint64) ([]PriceChange, error) {
+	if take == 0 || take > 100 {
+		take = 100
+	}
+
+	return s.respository.ListPriceChanges(ctx, productID, take)
+}
+
+func (s *catalogService) SchedulePriceChange(ctx context.Context, productID string, price float64, startsAt, endsAt time.Time, actor string) (*PriceSchedule, error) {
+	if price < 0 {
+		return nil, ErrInvalidPrice
+	}
+	if startsAt.IsZero() || (!endsAt.IsZero() && !endsAt.After(startsAt)) {
+		return nil, ErrInvalidSchedule
+	}
+
+	if _, err := s.respository.GetProductByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	ps := PriceSchedule{
+		ID:        ksuid.New().String(),
+		ProductID: productID,
+		Price:     price,
+		StartsAt:  startsAt.UTC(),
+		Actor:     actor,
+		Status:    PriceSchedulePending,
+	}
+	if !endsAt.IsZero() {
+		ps.EndsAt = endsAt.UTC()
+	}
+
+	if err := s.respository.PutPriceSchedule(ctx, ps); err != nil {
+		return nil, err
+	}
+	return &ps, nil
+}
+
+// ApplyDuePriceSchedules starts every pending schedule whose start time has
+// passed and ends every active one whose end time has passed. Each schedule
+// is claimed first, so that the schedulers of other replicas leave it
+// alone. A schedule that fails is taken up again once its claim runs out.
+func (s *catalogService) ApplyDuePriceSchedules(ctx context.Context, now time.Time) error {
+	schedules, err := s.respository.ListDuePriceSchedules(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, ps := range schedules {
+		claimed, err := s.claimPriceSchedule(ctx, ps.ID, now)
+		if errors.Is(err, errScheduleTaken) {
+			continue
+		}
+		if err == nil {
+			err = s.applyPriceSchedule(ctx, *claimed, now)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// errScheduleTaken is returned by claimPriceSchedule for a schedule that
+// another scheduler claimed or applied since it was listed.
+var errScheduleTaken = errors.New("price schedule was taken by another scheduler")
+
+// claimPriceSchedule claims the schedule for priceScheduleLease if it is
+// still due and unclaimed at now.
+func (s *catalogService) claimPriceSchedule(ctx context.Context, id string, now time.Time) (*PriceSchedule, error) {
+	var claimed PriceSchedule
+	err := s.respository.UpdatePriceSchedule(ctx, id, func(ps *PriceSchedule) error {
+		due := (ps.Status == PriceSchedulePending && !ps.StartsAt.After(now)) ||
+			(ps.Status == PriceScheduleActive && !ps.EndsAt.After(now))
+		if !due || ps.ClaimedUntil.After(now) {
+			return errScheduleTaken
+		}
+		ps.ClaimedUntil = now.Add(priceScheduleLease)
+		claimed = *ps
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &claimed, nil
+}
+
+// applyPriceSchedule moves a claimed schedule on to its next status. The
+// price and the step are written to the product in one conditional update,
+// so the step is applied once even when the scheduler stops before
+// recording it on the schedule, and a price edited by hand meanwhile is
+// seen. Ending a sale restores the previous price only if the product still
+// sells at the sale price.
+func (s *catalogService) applyPriceSchedule(ctx context.Context, ps PriceSchedule, now time.Time) error {
+	next := PriceScheduleDone
+	if ps.Status == PriceSchedulePending && !ps.EndsAt.IsZero() {
+		next = PriceScheduleActive
+	}
+
+	err := s.respository.UpdateProduct(ctx, ps.ProductID, func(p *Product) error {
+		if a := p.PriceSchedule; a != nil && a.ID == ps.ID && a.Status == next {
+			ps.PreviousPrice = a.PreviousPrice
+			return nil
+		}
+
+		price := p.Price
+		switch ps.Status {
+		case PriceSchedulePending:
+			ps.PreviousPrice = p.Price
+			price = ps.Price
+		case PriceScheduleActive:
+			if p.Price == ps.Price {
+				price = ps.PreviousPrice
+			}
+		}
+		if price != p.Price {
+			if err := s.recordPriceChange(ctx, p, price, ps.Actor, ps.ID); err != nil {
+				return err
+			}
+		}
+		p.PriceSchedule = &AppliedPriceSchedule{ID: ps.ID, Status: next, PreviousPrice: ps.PreviousPrice}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.respository.UpdatePriceSchedule(ctx, ps.ID, func(stored *PriceSchedule) error {
+		stored.Status, stored.PreviousPrice = next, ps.PreviousPrice
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// A schedule picked up late may already be past its end as well.
+	if next == PriceScheduleActive && !ps.EndsAt.After(now) {
+		ps.Status = next
+		return s.applyPriceSchedule(ctx, ps, now)
+	}
+	return nil
+}
+
+// recordPriceChange records in the price history that p goes to price, and
+// sets it on p for the caller to write. The change is recorded before the
+// product is written, under an ID derived from the change that set the
+// current price, so that writing the product again after a failure or a
+// conflict replaces the record rather than adding another, and a price is
+// never written without its history.
+func (s *catalogService) recordPriceChange(ctx context.Context, p *Product, price float64, actor string, scheduleID string) error {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%v\x00%s", p.ID, p.PriceChangeID, price, scheduleID)))
+	id, _ := ksuid.FromBytes(sum[:len(ksuid.Nil)])
+
+	c := PriceChange{
+		ID:         id.String(),
+		ProductID:  p.ID,
+		OldPrice:   p.Price,
+		NewPrice:   price,
+		Actor:      actor,
+		ScheduleID: scheduleID,
+		ChangedAt:  time.Now().UTC(),
+	}
+	if err := s.respository.PutPriceChange(ctx, c); err != nil {
+		return err
+	}
+	p.Price, p.PriceChangeID = price, c.ID
+	return nil
+}
+
+// ReserveStock takes items out of stock under reservationID, all or none.
+// The reservation is created pending before any stock is taken and records
+// each item as it is, so that a release, or a reservation that stops part
+// way through, puts back exactly what was taken. Reserving an ID that
+// already exists does nothing once it is held, fails with ErrPending while
+// it is still being taken and with ErrReleased once it was released, so a
+// retried reservation never takes stock twice or after the order gave up
+// on it.
+func (s *catalogService) ReserveStock(ctx context.Context, reservationID string, items []StockItem) error {
+	stocked := []StockItem{}
+	for _, item := range items {
+		p, err := s.respository.GetProductByID(ctx, item.ProductID)
+		if err != nil {
+			return err
+		}
+		if len(p.Variants) == 0 {
+			continue
+		}
+		if _, ok := p.Variant(item.VariantID); !ok || item.VariantID == "" {
+			return ErrInvalidVariant.With("productId", p.ID)
+		}
+		stocked = append(stocked, item)
+	}
+
+	err := s.respository.CreateStockReservation(ctx, StockReservation{
+		ID:        reservationID,
+		Items:     []StockItem{},
+		Status:    ReservationPending,
+		CreatedAt: time.Now().UTC(),
+	})
+	if errors.Is(err, ErrReservationExists) {
+		held, err := s.respository.GetStockReservation(ctx, reservationID)
+		switch {
+		case err != nil:
+			return err
+		case held.Status == ReservationReleased:
+			return ErrReleased.With("reservationId", reservationID)
+		case held.Status == ReservationPending:
+			return ErrPending.With("reservationId", reservationID)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, item := range stocked {
+		if err := s.respository.AdjustStock(ctx, item.ProductID, item.VariantID, -int64(item.Quantity)); err != nil {
+			s.abandon(ctx, reservationID, err)
+			return err
+		}
+		err := s.respository.UpdateStockReservation(ctx, reservationID, func(r *StockReservation) error {
+			if r.Status != ReservationPending {
+				return ErrReleased.With("reservationId", reservationID)
+			}
+			r.Items = append(r.Items, item)
+			return nil
+		})
+		if err != nil {
+			s.restock(ctx, []StockItem{item})
+			s.abandon(ctx, reservationID, err)
+			return err
+		}
+	}
+
+	err = s.respository.UpdateStockReservation(ctx, reservationID, func(r *StockReservation) error {
+		if r.Status != ReservationPending {
+			return ErrReleased.With("reservationId", reservationID)
+		}
+		r.Status = ReservationHeld
+		return nil
+	})
+	if err != nil {
+		s.abandon(ctx, reservationID, err)
+		return err
+	}
+	return nil
+}
+
+// ReleaseStock puts the stock held under reservationID back. The
+// reservation is marked released first, which stops a reservation still
+// being taken from recording more items. Releasing an unknown ID records it
+// as released, so that a reservation still in flight when the order gave up
+// fails instead of holding stock forever.
+func (s *catalogService) ReleaseStock(ctx context.Context, reservationID string) error {
+	var r StockReservation
+	err := s.respository.UpdateStockReservation(ctx, reservationID, func(sr *StockReservation) error {
+		sr.Status = ReservationReleased
+		r = *sr
+		return nil
+	})
+	if errors.Is(err, ErrReservationNotFound) {
+		err = s.respository.CreateStockReservation(ctx, StockReservation{
+			ID:        reservationID,
+			Items:     []StockItem{},
+			Status:    ReservationReleased,
+			CreatedAt: time.Now().UTC(),
+		})
+		if errors.Is(err, ErrReservationExists) {
+			return s.ReleaseStock(ctx, reservationID)
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	// Items are dropped from the reservation as they go back, so a release
+	// retried after a failure part way through does not restock them twice.
+	for len(r.Items) > 0 {
+		item := r.Items[0]
+		if err := s.respository.AdjustStock(ctx, item.ProductID, item.VariantID, int64(item.Quantity)); err != nil {
+			return err
+		}
+		r.Items = r.Items[1:]
+		err := s.respository.UpdateStockReservation(ctx, reservationID, func(sr *StockReservation) error {
+			sr.Items = r.Items
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReturnStock puts items that customers sent back into stock under
+// returnID. Like a release, it records the items still to go back and drops
+// them as they do, so a retried return restocks each item once. Items
+// without a variant are skipped, since they track no stock.
+func (s *catalogService) ReturnStock(ctx context.Context, returnID string, items []StockItem) error {
+	r, err := s.respository.GetStockReservation(ctx, returnID)
+	switch {
+	case errors.Is(err, ErrReservationNotFound):
+		r = &StockReservation{ID: returnID, Items: []StockItem{}, Status: ReservationReturning, CreatedAt: time.Now().UTC()}
+		for _, item := range items {
+			if item.VariantID != "" {
+				r.Items = append(r.Items, item)
+			}
+		}
+		if err := s.respository.PutStockReservation(ctx, *r); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case r.Status == ReservationReturned:
+		return nil
+	}
+
+	for len(r.Items) > 0 {
+		item := r.Items[0]
+		if err := s.respository.AdjustStock(ctx, item.ProductID, item.VariantID, int64(item.Quantity)); err != nil {
+			return err
+		}
+		r.Items = r.Items[1:]
+		if err := s.respository.PutStockReservation(ctx, *r); err != nil {
+			return err
+		}
+	}
+	r.Status = ReservationReturned
+	return s.respository.PutStockReservation(ctx, *r)
+}
+
+// abandon releases a reservation that failed part way through with cause,
+// unless it failed because it was released already. Errors are logged
+// rather than returned so that they do not hide cause; the order releases
+// the reservation again when it gives up.
+func (s *catalogService) abandon(ctx context.Context, reservationID string, cause error) {
+	if errors.Is(cause, ErrReleased) {
+		return
+	}
+	if err := s.ReleaseStock(ctx, reservationID); err != nil {
+		log.Printf("releasing stock reservation %s: %v", reservationID, err)
+	}
+}
+
+// restock puts back items that were taken but could not be recorded on
+// their reservation. Errors are logged rather than returned so that they do
+// not hide the reason the reservation failed.
+func (s *catalogService) restock(ctx context.Context, items []StockItem) {
+	for _, item := range items {
+		if err := s.respository.AdjustStock(ctx, item.ProductID, item.VariantID, int64(item.Quantity)); err != nil {
+			log.Printf("restocking %s of %s: %v", item.VariantID, item.ProductID, err)
+		}
+	}
+}
+
+// newProductPage trims a result fetched with one extra item down to take,
+// using the presence of the extra item to report whether more pages exist.
+func newProductPage(products []Product, cursors []string, take uint64) *ProductPage {
+	page := &ProductPage{
+		Products: products,
+		Cursors:  cursors,
+	}
+	if uint64(len(products)) > take {
+		page.Products = products[:take]
+		page.Cursors = cursors[:take]
+		page.HasNextPage = true
+	}
+	return page
+}
+
+// matchesOptions reports whether v picks exactly one allowed value for each
+// of the product's option axes and nothing else.
+func matchesOptions(v Variant, options []ProductOption) bool {
+	if v.SKU == "" || len(v.Options) != len(options) {
+		return false
+	}
+
+	for _, o := range options {
+		value, ok := v.Options[o.Name]
+		if !ok {
+			return false
+		}
+
+		allowed := false
+		for _, a := range o.Values {
+			if a == value {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// Ping fails while the Elasticsearch cluster holding the catalog is
+// unreachable.
+func (s *catalogService) Ping(ctx context.Context) error {
+	return s.respository.Ping(ctx)
+}
@@ -0,0 +1,23 @@
+#!/bin/sh
+# Generates a development CA and one certificate per service for running the
+# stack with mutual TLS (see docker-compose.tls.yaml). Each certificate's
+# common name is the service name, which is what peer rules match on, and it
+# is valid both as a server and as a client certificate.
+set -eu
+
+cd "$(dirname "$0")"
+
+openssl req -x509 -newkey rsa:2048 -nodes -days 365 \
+	-keyout ca-key.pem -out ca.pem -subj "/CN=e-commerce-dev-ca"
+
+for service in account catalog order review notification graphql; do
+	openssl req -newkey rsa:2048 -nodes \
+		-keyout "$service-key.pem" -out "$service.csr" -subj "/CN=$service"
+	printf "subjectAltName=DNS:%s,DNS:localhost\nextendedKeyUsage=serverAuth,clientAuth\n" "$service" > "$service.ext"
+	openssl x509 -req -in "$service.csr" -CA ca.pem -CAkey ca-key.pem -CAcreateserial \
+		-days 90 -out "$service.pem" -extfile "$service.ext"
+	rm "$service.csr" "$service.ext"
+done
+
+rm -f ca.srl
+chmod 644 *.pem
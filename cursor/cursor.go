@@ -0,0 +1,55 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/sunil8777/E-commerce-microservices/errs"
+)
+
+var (
+	ErrInvalidCursor = errs.New(errs.InvalidArgument, "INVALID_CURSOR", "invalid cursor")
+)
+
+// Encode packs the sort key of the last item on a page into an opaque
+// string that clients hand back to fetch the next page.
+func Encode(values ...interface{}) string {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode unpacks a cursor produced by Encode. An empty cursor decodes to
+// no values, meaning "start from the first page".
+func Decode(c string) ([]interface{}, error) {
+	if c == "" {
+		return nil, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(c)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var values []interface{}
+	if err := json.Unmarshal(b, &values); err != nil || len(values) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return values, nil
+}
+
+// DecodeString unpacks a cursor holding a single string key, such as a ksuid.
+func DecodeString(c string) (string, error) {
+	values, err := Decode(c)
+	if err != nil || values == nil {
+		return "", err
+	}
+
+	s, ok := values[0].(string)
+	if !ok || len(values) != 1 {
+		return "", ErrInvalidCursor
+	}
+	return s, nil
+}
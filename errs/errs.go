@@ -0,0 +1,214 @@
+package errs
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// Domain identifies this system in the ErrorInfo details attached to gRPC
+// statuses.
+const Domain = "e-commerce-microservices"
+
+// Code is the stable, transport independent category of an error. Callers
+// branch on the Code; the Reason narrows it down to a specific condition.
+type Code string
+
+const (
+	InvalidArgument    Code = "INVALID_ARGUMENT"
+	NotFound           Code = "NOT_FOUND"
+	AlreadyExists      Code = "ALREADY_EXISTS"
+	FailedPrecondition Code = "FAILED_PRECONDITION"
+	PermissionDenied   Code = "PERMISSION_DENIED"
+	Unauthenticated    Code = "UNAUTHENTICATED"
+	DeadlineExceeded   Code = "DEADLINE_EXCEEDED"
+	Canceled           Code = "CANCELED"
+	Unavailable        Code = "UNAVAILABLE"
+	Internal           Code = "INTERNAL"
+)
+
+var grpcCodes = map[Code]codes.Code{
+	InvalidArgument:    codes.InvalidArgument,
+	NotFound:           codes.NotFound,
+	AlreadyExists:      codes.AlreadyExists,
+	FailedPrecondition: codes.FailedPrecondition,
+	PermissionDenied:   codes.PermissionDenied,
+	Unauthenticated:    codes.Unauthenticated,
+	DeadlineExceeded:   codes.DeadlineExceeded,
+	Canceled:           codes.Canceled,
+	Unavailable:        codes.Unavailable,
+	Internal:           codes.Internal,
+}
+
+// Error is a domain error with a Code and a Reason such as
+// "ACCOUNT_NOT_FOUND". Two Errors match under errors.Is when their Code and
+// Reason agree, so a sentinel declared in a service package still matches
+// the copy a Client rebuilds from the gRPC status.
+type Error struct {
+	Code       Code
+	Reason     string
+	Message    string
+	Metadata   map[string]string
+	Violations []FieldViolation
+}
+
+// FieldViolation describes why one field of a request is invalid. Field is
+// the path to it, such as "products[1].quantity".
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+func New(code Code, reason string, message string) *Error {
+	return &Error{Code: code, Reason: reason, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code && t.Reason == e.Reason
+}
+
+// With returns a copy of e carrying an extra metadata entry, for example the
+// ID that was not found.
+func (e *Error) With(key, value string) *Error {
+	c := *e
+	c.Metadata = map[string]string{}
+	for k, v := range e.Metadata {
+		c.Metadata[k] = v
+	}
+	c.Metadata[key] = value
+	return &c
+}
+
+// WithViolations returns a copy of e listing the fields that made a request
+// invalid.
+func (e *Error) WithViolations(v ...FieldViolation) *Error {
+	c := *e
+	c.Violations = append(append([]FieldViolation{}, e.Violations...), v...)
+	return &c
+}
+
+// From classifies any error. Errors that are not *Error become Internal,
+// except context cancellation and deadlines which keep their meaning.
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return New(DeadlineExceeded, string(DeadlineExceeded), err.Error())
+	case errors.Is(err, context.Canceled):
+		return New(Canceled, string(Canceled), err.Error())
+	}
+	return New(Internal, string(Internal), "internal error")
+}
+
+// CodeOf returns the Code of err, Internal for unclassified errors.
+func CodeOf(err error) Code {
+	if err == nil {
+		return ""
+	}
+	return From(err).Code
+}
+
+// ToStatus converts err into a gRPC status error carrying an ErrorInfo
+// detail with its Reason and Metadata, and a BadRequest detail with its
+// Violations if it has any.
+func ToStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	e := From(err)
+	st := status.New(grpcCodes[e.Code], e.Message)
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason:   e.Reason,
+		Domain:   Domain,
+		Metadata: e.Metadata,
+	}}
+	if len(e.Violations) > 0 {
+		req := &errdetails.BadRequest{}
+		for _, v := range e.Violations {
+			req.FieldViolations = append(req.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       v.Field,
+				Description: v.Description,
+			})
+		}
+		details = append(details, req)
+	}
+	if d, derr := st.WithDetails(details...); derr == nil {
+		st = d
+	}
+	return st.Err()
+}
+
+// FromStatus rebuilds the *Error sent by ToStatus. Statuses that did not
+// come from ToStatus, such as transport failures, are classified by their
+// gRPC code alone.
+func FromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() == codes.OK {
+		return err
+	}
+
+	e := &Error{Code: codeFromGRPC(st.Code()), Message: st.Message()}
+	e.Reason = string(e.Code)
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			if d.Domain == Domain {
+				e.Reason = d.Reason
+				e.Metadata = d.Metadata
+			}
+		case *errdetails.BadRequest:
+			for _, v := range d.FieldViolations {
+				e.Violations = append(e.Violations, FieldViolation{Field: v.Field, Description: v.Description})
+			}
+		}
+	}
+	return e
+}
+
+func codeFromGRPC(c codes.Code) Code {
+	for code, gc := range grpcCodes {
+		if gc == c {
+			return code
+		}
+	}
+	if c == codes.ResourceExhausted || c == codes.Aborted {
+		return Unavailable
+	}
+	return Internal
+}
+
+// UnaryServerInterceptor converts every error returned by a handler into a
+// gRPC status with ToStatus.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		res, err := handler(ctx, req)
+		return res, ToStatus(err)
+	}
+}
+
+// UnaryClientInterceptor converts every gRPC status returned to a client back
+// into an *Error with FromStatus.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return FromStatus(invoker(ctx, method, req, reply, cc, opts...))
+	}
+}
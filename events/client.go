@@ -0,0 +1,65 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/sunil8777/E-commerce-microservices/events/pb"
+	"github.com/sunil8777/E-commerce-microservices/grpcopts"
+	"google.golang.org/grpc"
+)
+
+// Client publishes events to an EventSink.
+type Client struct {
+	conn    *grpc.ClientConn
+	service pb.EventSinkClient
+}
+
+// NewClient dials url with the shared grpcopts defaults. opts are applied
+// after them, for example to guard the connection with a retry.Breaker.
+func NewClient(url string, opts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.NewClient(
+		grpcopts.Target(url),
+		grpcopts.New(pb.EventSink_ServiceDesc.ServiceName).
+			Idempotent("Publish").
+			DialOptions(opts...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	c := pb.NewEventSinkClient(conn)
+	return &Client{conn, c}, nil
+}
+
+func (c *Client) Close() {
+	c.conn.Close()
+}
+
+func (c *Client) Publish(ctx context.Context, e Event) error {
+	_, err := c.service.Publish(ctx, &pb.PublishRequest{Event: ToProto(e)})
+	return err
+}
+
+func ToProto(e Event) *pb.Event {
+	event := &pb.Event{
+		Id:        e.ID,
+		Type:      e.Type,
+		AccountId: e.AccountID,
+		Payload:   e.Payload,
+	}
+	event.OccurredAt, _ = e.OccurredAt.MarshalBinary()
+	return event
+}
+
+func FromProto(e *pb.Event) Event {
+	occurredAt := time.Time{}
+	occurredAt.UnmarshalBinary(e.OccurredAt)
+	return Event{
+		ID:         e.Id,
+		Type:       e.Type,
+		AccountID:  e.AccountId,
+		Payload:    e.Payload,
+		OccurredAt: occurredAt,
+	}
+}
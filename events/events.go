@@ -0,0 +1,81 @@
+// Package events carries domain events from the service that records them
+// to the services that react to them. A producer inserts an event into its
+// outbox table in the same transaction as the change the event describes,
+// and a Relay then publishes it to an event sink. Delivery is at least
+// once: sinks must ignore events whose ID they have already seen.
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/segmentio/ksuid"
+)
+
+// Event types.
+const (
+	AccountCreated = "account.created"
+	OrderPlaced    = "order.placed"
+	OrderShipped   = "order.shipped"
+)
+
+type Event struct {
+	ID         string
+	Type       string
+	AccountID  string
+	Payload    json.RawMessage
+	OccurredAt time.Time
+}
+
+// New returns an event of type typ about accountID, with payload encoded as
+// its payload.
+func New(typ string, accountID string, payload interface{}) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		ID:         ksuid.New().String(),
+		Type:       typ,
+		AccountID:  accountID,
+		Payload:    data,
+		OccurredAt: time.Now().UTC(),
+	}, nil
+}
+
+// Decode decodes the payload of e into v.
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// AccountCreatedPayload is the payload of AccountCreated events.
+type AccountCreatedPayload struct {
+	Name string `json:"name"`
+}
+
+// OrderPlacedPayload is the payload of OrderPlaced events. It snapshots the
+// order as it was placed.
+type OrderPlacedPayload struct {
+	OrderID    string      `json:"orderId"`
+	TotalPrice float64     `json:"totalPrice"`
+	Lines      []OrderLine `json:"lines"`
+	PlacedAt   time.Time   `json:"placedAt"`
+}
+
+type OrderLine struct {
+	ProductID string            `json:"productId"`
+	Name      string            `json:"name"`
+	SKU       string            `json:"sku,omitempty"`
+	Options   map[string]string `json:"options,omitempty"`
+	Price     float64           `json:"price"`
+	Quantity  uint64            `json:"quantity"`
+}
+
+// OrderShippedPayload is the payload of OrderShipped events.
+type OrderShippedPayload struct {
+	OrderID        string    `json:"orderId"`
+	Carrier        string    `json:"carrier"`
+	TrackingNumber string    `json:"trackingNumber"`
+	TrackingURL    string    `json:"trackingUrl,omitempty"`
+	ShippedAt      time.Time `json:"shippedAt"`
+}
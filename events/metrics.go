@@ -0,0 +1,13 @@
+package events
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	eventsRelayed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "events_relayed_total",
+		Help: "Outbox events published to the event sink, by type.",
+	}, []string{"type"})
+)
@@ -0,0 +1,173 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sunil8777/E-commerce-microservices/metrics"
+	"github.com/sunil8777/E-commerce-microservices/tracing"
+)
+
+// relayBatch is how many events a Relay reads from its Store at a time.
+const relayBatch = 100
+
+// Insert records e in the outbox table of the database tx runs against.
+// Call it in the transaction that makes the change e describes, so that the
+// event is recorded if and only if the change is.
+func Insert(ctx context.Context, tx *sql.Tx, e Event) error {
+	_, err := tx.ExecContext(
+		ctx,
+		"INSERT INTO outbox(id, type, account_id, payload, occurred_at) VALUES($1, $2, $3, $4, $5)",
+		e.ID,
+		e.Type,
+		e.AccountID,
+		string(e.Payload),
+		e.OccurredAt,
+	)
+	return err
+}
+
+// Store is an outbox a Relay reads from.
+type Store interface {
+	// Pending returns up to limit unpublished events, oldest first.
+	Pending(ctx context.Context, limit int) ([]Event, error)
+	MarkPublished(ctx context.Context, ids []string) error
+}
+
+// PostgresStore reads the outbox table written by Insert.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db}
+}
+
+// OpenPostgresStore opens a connection pool of its own to the database at
+// url, so that relaying events does not compete with requests for
+// connections.
+func OpenPostgresStore(url string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(2)
+	return &PostgresStore{db}, nil
+}
+
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *PostgresStore) Pending(ctx context.Context, limit int) (_ []Event, err error) {
+	ctx, span := tracing.Start(ctx, "events.outbox.Pending")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "outbox.Pending")(&err)
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT id, type, account_id, payload, occurred_at FROM outbox
+		WHERE published_at IS NULL ORDER BY occurred_at, id LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pending := []Event{}
+	for rows.Next() {
+		e := Event{}
+		var payload []byte
+		if err = rows.Scan(&e.ID, &e.Type, &e.AccountID, &payload, &e.OccurredAt); err != nil {
+			return nil, err
+		}
+		e.Payload = payload
+		pending = append(pending, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
+
+func (s *PostgresStore) MarkPublished(ctx context.Context, ids []string) (err error) {
+	ctx, span := tracing.Start(ctx, "events.outbox.MarkPublished")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "outbox.MarkPublished")(&err)
+
+	_, err = s.db.ExecContext(
+		ctx,
+		"UPDATE outbox SET published_at = $2 WHERE id = ANY($1)",
+		pq.Array(ids),
+		time.Now().UTC(),
+	)
+	return err
+}
+
+// Publisher delivers events to a sink. *Client is one.
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// Relay publishes the events of an outbox in the order they occurred.
+type Relay struct {
+	store Store
+	sink  Publisher
+}
+
+func NewRelay(store Store, sink Publisher) *Relay {
+	return &Relay{store: store, sink: sink}
+}
+
+// Run flushes the outbox right away and then every interval until ctx is
+// done. Several replicas may run a Relay on the same outbox; an event is
+// then occasionally published twice, which sinks tolerate.
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	for {
+		if err := r.Flush(ctx); err != nil && ctx.Err() == nil {
+			log.Println("Error relaying events:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Flush publishes pending events until none are left. It stops at the
+// first event that fails to publish, so that later events are not
+// delivered ahead of it, and returns that error.
+func (r *Relay) Flush(ctx context.Context) error {
+	for {
+		pending, err := r.store.Pending(ctx, relayBatch)
+		if err != nil || len(pending) == 0 {
+			return err
+		}
+
+		published := []string{}
+		for _, e := range pending {
+			if err = r.sink.Publish(ctx, e); err != nil {
+				break
+			}
+			eventsRelayed.WithLabelValues(e.Type).Inc()
+			published = append(published, e.ID)
+		}
+		if len(published) > 0 {
+			if err := r.store.MarkPublished(ctx, published); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if len(pending) < relayBatch {
+			return nil
+		}
+	}
+}
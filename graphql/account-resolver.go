@@ -6,42 +6,79 @@ import (
 	"time"
 
 	"github.com/sunil8777/E-commerce-microservices/graphql/model"
-	"github.com/sunil8777/E-commerce-microservices/order"
 )
 
 type accountResolver struct {
 	server *Server
 }
 
-func (r *accountResolver) Orders(ctx context.Context, obj *model.Account) ([]*order.Order, error) {
+func (r *accountResolver) Orders(ctx context.Context, obj *model.Account, first *int, filter *model.OrderFilterInput, sort *model.SortDirection) ([]*model.Order, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	orderList, err := r.server.orderClient.GetOrderForAccount(ctx, obj.ID)
+	take, _, err := connectionBounds(first, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := r.server.orderClient.GetOrdersPage(ctx, obj.ID, orderQuery(filter, sort), "", take)
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
 
-	var orders []*order.Order
-	for _, o := range orderList {
-		var products []order.OrderedProduct
-		for _, p := range o.Products {
-			products = append(products, order.OrderedProduct{
-				ID:          p.ID,
-				Name:        p.Name,
-				Description: p.Description,
-				Price:       p.Price,
-				Quantity:    uint64(p.Quantity),
-			})
-		}
-		orders = append(orders, &order.Order{
-			ID:         o.ID,
-			CreatedAt:  o.CreatedAt,
-			TotalPrice: o.TotalPrice,
-			Products:   products,
-		})
+	orders := []*model.Order{}
+	for _, o := range page.Orders {
+		orders = append(orders, orderModel(o))
 	}
 
 	return orders, nil
 }
+
+func (r *accountResolver) OrdersConnection(ctx context.Context, obj *model.Account, first *int, after *string, filter *model.OrderFilterInput, sort *model.SortDirection) (*model.OrderConnection, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	take, cursor, err := connectionBounds(first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := r.server.orderClient.GetOrdersPage(ctx, obj.ID, orderQuery(filter, sort), cursor, take)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return orderConnectionModel(page), nil
+}
+
+func (r *accountResolver) NotificationPreferences(ctx context.Context, obj *model.Account) (*model.NotificationPreferences, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	p, err := r.server.notificationClient.GetPreferences(ctx, obj.ID)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return preferencesModel(*p), nil
+}
+
+func (r *accountResolver) Subscriptions(ctx context.Context, obj *model.Account) ([]*model.OrderSubscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	subList, err := r.server.orderClient.ListSubscriptions(ctx, obj.ID)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	subs := []*model.OrderSubscription{}
+	for _, sub := range subList {
+		subs = append(subs, subscriptionModel(sub))
+	}
+	return subs, nil
+}
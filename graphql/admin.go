@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+)
+
+var (
+	ErrAdminRequired = errs.New(errs.PermissionDenied, "ADMIN_REQUIRED", "only admins can see this field")
+)
+
+type adminKey struct{}
+
+// withAdmin marks requests that carry "Authorization: Bearer <token>" as
+// made by an admin. When token is empty no request is.
+func withAdmin(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok && token != "" && subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1 {
+			r = r.WithContext(context.WithValue(r.Context(), adminKey{}, true))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isAdmin reports whether withAdmin marked the request of ctx.
+func isAdmin(ctx context.Context) bool {
+	ok, _ := ctx.Value(adminKey{}).(bool)
+	return ok
+}
+
+// admin implements the @admin directive: it resolves the field only for
+// requests withAdmin marked.
+func admin(ctx context.Context, obj any, next graphql.Resolver) (any, error) {
+	if !isAdmin(ctx) {
+		return nil, ErrAdminRequired
+	}
+	return next(ctx)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+	"github.com/sunil8777/E-commerce-microservices/validation"
+)
+
+// constraintPatterns caches compiled @constraint patterns by source.
+var constraintPatterns sync.Map
+
+// constraint implements the @constraint directive. It reports violations
+// the way the validation interceptor does, as validation.ErrInvalidRequest
+// naming the input field, so clients see the same error whether the gateway
+// or a service rejects the input. Null values are not checked.
+func constraint(ctx context.Context, obj any, next graphql.Resolver, minLength *int, maxLength *int, pattern *string, min *float64, max *float64, minItems *int, maxItems *int, uniqueBy []string) (any, error) {
+	value, err := next(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return value, nil
+		}
+		v = v.Elem()
+	}
+
+	var descriptions []string
+	add := func(format string, args ...any) {
+		descriptions = append(descriptions, fmt.Sprintf(format, args...))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		s := v.String()
+		n := utf8.RuneCountInString(s)
+		if minLength != nil && n < *minLength {
+			if *minLength == 1 {
+				add("must not be empty")
+			} else {
+				add("must be at least %d characters", *minLength)
+			}
+		}
+		if maxLength != nil && n > *maxLength {
+			add("must be at most %d characters", *maxLength)
+		}
+		if pattern != nil && s != "" && !constraintPattern(*pattern).MatchString(s) {
+			add("must match %s", *pattern)
+		}
+
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64:
+		x := v.Convert(reflect.TypeOf(float64(0))).Float()
+		if min != nil && !(x >= *min) {
+			add("must be at least %v", *min)
+		}
+		if max != nil && !(x <= *max) {
+			add("must be at most %v", *max)
+		}
+
+	case reflect.Slice:
+		if minItems != nil && v.Len() < *minItems {
+			add("must have at least %d items", *minItems)
+		}
+		if maxItems != nil && v.Len() > *maxItems {
+			add("must have at most %d items", *maxItems)
+		}
+		if len(uniqueBy) > 0 {
+			seen := map[string]int{}
+			for i := 0; i < v.Len(); i++ {
+				key := uniqueKey(v.Index(i), uniqueBy)
+				if first, dup := seen[key]; dup {
+					add("item %d duplicates item %d", i, first)
+				} else {
+					seen[key] = i
+				}
+			}
+		}
+	}
+
+	if len(descriptions) == 0 {
+		return value, nil
+	}
+
+	field := inputPath(ctx)
+	violations := make([]errs.FieldViolation, len(descriptions))
+	for i, d := range descriptions {
+		violations[i] = errs.FieldViolation{Field: field, Description: d}
+	}
+	e := validation.ErrInvalidRequest.WithViolations(violations...)
+	e.Message = validation.Message(violations)
+	return nil, e
+}
+
+// uniqueKey joins the fields of an input object named by their GraphQL
+// names, which gqlgen keeps in the json tags of the model.
+func uniqueKey(item reflect.Value, fields []string) string {
+	for item.Kind() == reflect.Pointer && !item.IsNil() {
+		item = item.Elem()
+	}
+	if item.Kind() != reflect.Struct {
+		return fmt.Sprint(item.Interface())
+	}
+
+	parts := make([]string, len(fields))
+	for i, name := range fields {
+		for j := 0; j < item.NumField(); j++ {
+			tag, _, _ := strings.Cut(item.Type().Field(j).Tag.Get("json"), ",")
+			if tag != name {
+				continue
+			}
+			f := item.Field(j)
+			if f.Kind() == reflect.Pointer {
+				if f.IsNil() {
+					break
+				}
+				f = f.Elem()
+			}
+			parts[i] = fmt.Sprintf("%q", fmt.Sprint(f.Interface()))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// inputPath names the argument or input field being checked, such as
+// "order.products".
+func inputPath(ctx context.Context) string {
+	var names []string
+	index := ""
+	for p := graphql.GetPathContext(ctx); p != nil; p = p.Parent {
+		switch {
+		case p.Field != nil:
+			names = append([]string{*p.Field + index}, names...)
+			index = ""
+		case p.Index != nil:
+			index = fmt.Sprintf("[%d]", *p.Index) + index
+		}
+	}
+	return strings.Join(names, ".")
+}
+
+func constraintPattern(expr string) *regexp.Regexp {
+	if re, ok := constraintPatterns.Load(expr); ok {
+		return re.(*regexp.Regexp)
+	}
+	re := regexp.MustCompile(expr)
+	constraintPatterns.Store(expr, re)
+	return re
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/sunil8777/E-commerce-microservices/account"
+	"github.com/sunil8777/E-commerce-microservices/catalog"
+	"github.com/sunil8777/E-commerce-microservices/graphql/model"
+	"github.com/sunil8777/E-commerce-microservices/notification"
+	"github.com/sunil8777/E-commerce-microservices/order"
+	"github.com/sunil8777/E-commerce-microservices/review"
+)
+
+func accountModel(a account.Account) *model.Account {
+	return &model.Account{
+		ID:    a.ID,
+		Name:  a.Name,
+		Email: a.Email,
+	}
+}
+
+func preferencesModel(p notification.Preferences) *model.NotificationPreferences {
+	return &model.NotificationPreferences{
+		OrderConfirmations: p.OrderConfirmations,
+		ShippingUpdates:    p.ShippingUpdates,
+	}
+}
+
+func productModel(p catalog.Product) *model.Product {
+	product := &model.Product{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       p.Price,
+		Rating:      p.Rating,
+		ReviewCount: int(p.ReviewCount),
+		Options:     []*model.ProductOption{},
+		Variants:    []*model.ProductVariant{},
+		Media:       []*model.ProductMedia{},
+	}
+	for _, o := range p.Options {
+		product.Options = append(product.Options, &model.ProductOption{
+			Name:   o.Name,
+			Values: o.Values,
+		})
+	}
+	for _, v := range p.Variants {
+		product.Variants = append(product.Variants, &model.ProductVariant{
+			ID:      v.ID,
+			Sku:     v.SKU,
+			Options: variantOptionsModel(v.Options),
+			Price:   p.VariantPrice(v),
+			Stock:   int(v.Stock),
+		})
+	}
+	for _, m := range p.Media {
+		product.Media = append(product.Media, &model.ProductMedia{
+			ID:          m.ID,
+			URL:         m.URL,
+			AltText:     m.AltText,
+			Position:    int(m.Position),
+			Width:       int(m.Width),
+			Height:      int(m.Height),
+			ContentType: m.ContentType,
+		})
+	}
+	return product
+}
+
+// variantOptionsModel flattens a variant's option map into a list ordered by
+// option name so responses are stable between requests.
+func variantOptionsModel(options map[string]string) []*model.VariantOption {
+	names := []string{}
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	res := []*model.VariantOption{}
+	for _, name := range names {
+		res = append(res, &model.VariantOption{
+			Name:  name,
+			Value: options[name],
+		})
+	}
+	return res
+}
+
+func reviewModel(r review.Review) *model.Review {
+	return &model.Review{
+		ID:        r.ID,
+		ProductID: r.ProductID,
+		AccountID: r.AccountID,
+		Rating:    int(r.Rating),
+		Body:      r.Body,
+		Status:    model.ReviewStatus(strings.ToUpper(r.Status)),
+		CreatedAt: r.CreatedAt,
+	}
+}
+
+// orderQuery converts the filter and sort arguments of an order history
+// field. Nil arguments do not filter and sort oldest first.
+func orderQuery(filter *model.OrderFilterInput, sort *model.SortDirection) order.OrderQuery {
+	q := order.OrderQuery{NewestFirst: sort != nil && *sort == model.SortDirectionNewestFirst}
+	if filter == nil {
+		return q
+	}
+	if filter.PlacedFrom != nil {
+		q.PlacedFrom = *filter.PlacedFrom
+	}
+	if filter.PlacedTo != nil {
+		q.PlacedTo = *filter.PlacedTo
+	}
+	if filter.Status != nil {
+		q.Status = strings.ToLower(string(*filter.Status))
+	}
+	if filter.MinTotal != nil {
+		q.MinTotal = *filter.MinTotal
+	}
+	return q
+}
+
+// orderSearchQuery converts the search and sort arguments of the orders
+// query.
+func orderSearchQuery(search *model.OrderSearchInput, sort *model.SortDirection) order.OrderQuery {
+	q := order.OrderQuery{NewestFirst: sort != nil && *sort == model.SortDirectionNewestFirst}
+	if search == nil {
+		return q
+	}
+	if search.AccountID != nil {
+		q.AccountID = *search.AccountID
+	}
+	if search.ProductID != nil {
+		q.ProductID = *search.ProductID
+	}
+	if search.Status != nil {
+		q.Status = strings.ToLower(string(*search.Status))
+	}
+	if search.PlacedFrom != nil {
+		q.PlacedFrom = *search.PlacedFrom
+	}
+	if search.PlacedTo != nil {
+		q.PlacedTo = *search.PlacedTo
+	}
+	if search.MinTotal != nil {
+		q.MinTotal = *search.MinTotal
+	}
+	if search.MaxTotal != nil {
+		q.MaxTotal = *search.MaxTotal
+	}
+	return q
+}
+
+// shippingAddress converts the shipping address of an order input. Without
+// one the order is placed with the zero Address.
+func shippingAddress(in *model.AddressInput) order.Address {
+	if in == nil {
+		return order.Address{}
+	}
+	a := order.Address{
+		Name:    in.Name,
+		Line1:   in.Line1,
+		City:    in.City,
+		Country: in.Country,
+	}
+	if in.Line2 != nil {
+		a.Line2 = *in.Line2
+	}
+	if in.Region != nil {
+		a.Region = *in.Region
+	}
+	if in.PostalCode != nil {
+		a.PostalCode = *in.PostalCode
+	}
+	return a
+}
+
+// addressModel returns nil for the zero address.
+func addressModel(a order.Address) *model.Address {
+	if a.IsZero() {
+		return nil
+	}
+	return &model.Address{
+		Name:       a.Name,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+	}
+}
+
+func shipmentModel(sh order.Shipment) *model.Shipment {
+	res := &model.Shipment{
+		ID:             sh.ID,
+		OrderID:        sh.OrderID,
+		Carrier:        sh.Carrier,
+		TrackingNumber: sh.TrackingNumber,
+		Status:         model.ShipmentStatus(strings.ToUpper(sh.Status)),
+		Lines:          []*model.ShipmentLine{},
+		Events:         []*model.ShipmentEvent{},
+		CreatedAt:      sh.CreatedAt,
+		UpdatedAt:      sh.UpdatedAt,
+	}
+	if url := sh.TrackingURL(); url != "" {
+		res.TrackingURL = &url
+	}
+	for _, l := range sh.Lines {
+		line := &model.ShipmentLine{ProductID: l.ProductID, Quantity: int(l.Quantity)}
+		if l.VariantID != "" {
+			variantID := l.VariantID
+			line.VariantID = &variantID
+		}
+		res.Lines = append(res.Lines, line)
+	}
+	for _, e := range sh.Events {
+		res.Events = append(res.Events, &model.ShipmentEvent{
+			Status:      model.ShipmentStatus(strings.ToUpper(e.Status)),
+			Description: e.Description,
+			Location:    e.Location,
+			OccurredAt:  e.OccurredAt,
+		})
+	}
+	return res
+}
+
+func returnModel(rt order.Return) *model.Return {
+	res := &model.Return{
+		ID:           rt.ID,
+		OrderID:      rt.OrderID,
+		Status:       model.ReturnStatus(strings.ToUpper(rt.Status)),
+		Reason:       rt.Reason,
+		Note:         rt.Note,
+		Lines:        []*model.ReturnLine{},
+		RefundAmount: rt.RefundAmount,
+		CreatedAt:    rt.CreatedAt,
+		UpdatedAt:    rt.UpdatedAt,
+	}
+	for _, l := range rt.Lines {
+		line := &model.ReturnLine{ProductID: l.ProductID, Quantity: int(l.Quantity)}
+		if l.VariantID != "" {
+			variantID := l.VariantID
+			line.VariantID = &variantID
+		}
+		res.Lines = append(res.Lines, line)
+	}
+	return res
+}
+
+func subscriptionModel(sub order.Subscription) *model.OrderSubscription {
+	res := &model.OrderSubscription{
+		ID:              sub.ID,
+		AccountID:       sub.AccountID,
+		Status:          model.SubscriptionStatus(strings.ToUpper(sub.Status)),
+		Lines:           []*model.SubscriptionLine{},
+		ShippingAddress: addressModel(sub.ShippingAddress),
+		IntervalUnit:    model.IntervalUnit(strings.ToUpper(sub.Interval.Unit)),
+		IntervalCount:   int(sub.Interval.Count),
+		StartsAt:        sub.StartsAt,
+		NextRunAt:       sub.NextRunAt,
+		Failures:        int(sub.Failures),
+		CreatedAt:       sub.CreatedAt,
+		UpdatedAt:       sub.UpdatedAt,
+	}
+	if sub.LastFailure != "" {
+		lastFailure := sub.LastFailure
+		res.LastFailure = &lastFailure
+	}
+	if !sub.DueAt.IsZero() {
+		retryAt := sub.DueAt
+		res.RetryAt = &retryAt
+	}
+	if sub.LastOrderID != "" {
+		lastOrderID := sub.LastOrderID
+		res.LastOrderID = &lastOrderID
+	}
+	for _, l := range sub.Lines {
+		line := &model.SubscriptionLine{ProductID: l.ProductID, Quantity: int(l.Quantity)}
+		if l.VariantID != "" {
+			variantID := l.VariantID
+			line.VariantID = &variantID
+		}
+		res.Lines = append(res.Lines, line)
+	}
+	return res
+}
+
+func orderConnectionModel(page *order.OrderPage) *model.OrderConnection {
+	conn := &model.OrderConnection{
+		Edges:    []*model.OrderEdge{},
+		PageInfo: &model.PageInfo{HasNextPage: page.HasNextPage},
+	}
+	for i, o := range page.Orders {
+		conn.Edges = append(conn.Edges, &model.OrderEdge{
+			Cursor: page.Cursors[i],
+			Node:   orderModel(o),
+		})
+	}
+	if n := len(page.Cursors); n > 0 {
+		conn.PageInfo.EndCursor = &page.Cursors[n-1]
+	}
+	return conn
+}
+
+func orderModel(o order.Order) *model.Order {
+	res := &model.Order{
+		ID:         o.ID,
+		AccountID:  o.AccountID,
+		CreatedAt:  o.CreatedAt,
+		TotalPrice: o.TotalPrice,
+		Status:     model.OrderStatus(strings.ToUpper(o.Status)),
+		Products:   []*model.OrderedProduct{},
+	}
+	res.ShippingAddress = addressModel(o.ShippingAddress)
+	for _, p := range o.Products {
+		product := &model.OrderedProduct{
+			ID:          p.ID,
+			Name:        p.Name,
+			Description: p.Description,
+			Price:       p.Price,
+			Quantity:    int(p.Quantity),
+			Options:     variantOptionsModel(p.Options),
+		}
+		if p.VariantID != "" {
+			variantID, sku := p.VariantID, p.SKU
+			product.VariantID = &variantID
+			product.Sku = &sku
+		}
+		res.Products = append(res.Products, product)
+	}
+	return res
+}
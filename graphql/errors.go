@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// presentError exposes the errs code and reason of resolver errors as
+// extensions, so clients can branch on "code" instead of parsing messages.
+// Invalid requests also list their field violations.
+// Errors that were never classified are reported as INTERNAL with their
+// message hidden.
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	presented := graphql.DefaultErrorPresenter(ctx, err)
+
+	var gqlErr *gqlerror.Error
+	if errors.As(err, &gqlErr) && gqlErr.Err == nil {
+		// Parse and validation errors from gqlgen itself.
+		return presented
+	}
+
+	e := errs.From(err)
+	presented.Message = e.Message
+	if presented.Extensions == nil {
+		presented.Extensions = map[string]interface{}{}
+	}
+	presented.Extensions["code"] = string(e.Code)
+	presented.Extensions["reason"] = e.Reason
+	if len(e.Metadata) > 0 {
+		presented.Extensions["metadata"] = e.Metadata
+	}
+	if len(e.Violations) > 0 {
+		presented.Extensions["violations"] = e.Violations
+	}
+	return presented
+}
@@ -0,0 +1,977 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sunil8777/E-commerce-microservices/account"
+	"github.com/sunil8777/E-commerce-microservices/catalog"
+	"github.com/sunil8777/E-commerce-microservices/events"
+	"github.com/sunil8777/E-commerce-microservices/notification"
+	"github.com/sunil8777/E-commerce-microservices/order"
+	"github.com/sunil8777/E-commerce-microservices/retry"
+	"github.com/sunil8777/E-commerce-microservices/review"
+)
+
+// The in-memory repositories below stand in for Postgres and Elasticsearch.
+// Each embeds its Repository interface so that a method the tests do not
+// expect to be called panics instead of silently returning zero values.
+
+// memoryOutbox is the outbox table of a repository. It is an events.Store,
+// so the tests relay from it just as production relays from Postgres.
+type memoryOutbox struct {
+	outboxMu  sync.Mutex
+	pending   []events.Event
+	published map[string]bool
+}
+
+func (o *memoryOutbox) insert(e events.Event) {
+	o.outboxMu.Lock()
+	defer o.outboxMu.Unlock()
+	o.pending = append(o.pending, e)
+}
+
+func (o *memoryOutbox) Pending(ctx context.Context, limit int) ([]events.Event, error) {
+	o.outboxMu.Lock()
+	defer o.outboxMu.Unlock()
+
+	pending := []events.Event{}
+	for _, e := range o.pending {
+		if !o.published[e.ID] && len(pending) < limit {
+			pending = append(pending, e)
+		}
+	}
+	return pending, nil
+}
+
+func (o *memoryOutbox) MarkPublished(ctx context.Context, ids []string) error {
+	o.outboxMu.Lock()
+	defer o.outboxMu.Unlock()
+	if o.published == nil {
+		o.published = map[string]bool{}
+	}
+	for _, id := range ids {
+		o.published[id] = true
+	}
+	return nil
+}
+
+type memoryAccountRepository struct {
+	account.Repository
+	memoryOutbox
+
+	mu       sync.Mutex
+	accounts map[string]account.Account
+}
+
+func newMemoryAccountRepository() *memoryAccountRepository {
+	return &memoryAccountRepository{accounts: map[string]account.Account{}}
+}
+
+func (r *memoryAccountRepository) Close() error                   { return nil }
+func (r *memoryAccountRepository) Ping(ctx context.Context) error { return nil }
+
+func (r *memoryAccountRepository) PutAccount(ctx context.Context, a account.Account, e events.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.accounts[a.ID] = a
+	r.insert(e)
+	return nil
+}
+
+func (r *memoryAccountRepository) GetAccountById(ctx context.Context, id string) (*account.Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a, ok := r.accounts[id]
+	if !ok {
+		return nil, account.ErrNotFound.With("id", id)
+	}
+	return &a, nil
+}
+
+func (r *memoryAccountRepository) ListAccounts(ctx context.Context, skip uint64, take uint64) ([]account.Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	accounts := []account.Account{}
+	for _, a := range r.accounts {
+		accounts = append(accounts, a)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].ID > accounts[j].ID })
+	return page(accounts, skip, take), nil
+}
+
+type memoryCatalogRepository struct {
+	catalog.Repository
+
+	mu           sync.Mutex
+	products     map[string]catalog.Product
+	changes      []catalog.PriceChange
+	schedules    map[string]catalog.PriceSchedule
+	reservations map[string]catalog.StockReservation
+	// ratingsDown makes UpdateProductRating fail, and historyDown
+	// PutPriceChange, as an unreachable search index would.
+	ratingsDown bool
+	historyDown bool
+}
+
+func newMemoryCatalogRepository() *memoryCatalogRepository {
+	return &memoryCatalogRepository{
+		products:     map[string]catalog.Product{},
+		schedules:    map[string]catalog.PriceSchedule{},
+		reservations: map[string]catalog.StockReservation{},
+	}
+}
+
+func (r *memoryCatalogRepository) Close()                         {}
+func (r *memoryCatalogRepository) Ping(ctx context.Context) error { return nil }
+
+func (r *memoryCatalogRepository) PutProduct(ctx context.Context, p catalog.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.products[p.ID] = p
+	return nil
+}
+
+func (r *memoryCatalogRepository) GetProductByID(ctx context.Context, id string) (*catalog.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.products[id]
+	if !ok {
+		return nil, catalog.ErrNotFound.With("id", id)
+	}
+	return &p, nil
+}
+
+func (r *memoryCatalogRepository) ListProducts(ctx context.Context, skip uint64, take uint64) ([]catalog.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	products := []catalog.Product{}
+	for _, p := range r.products {
+		products = append(products, p)
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+	return page(products, skip, take), nil
+}
+
+func (r *memoryCatalogRepository) ListProductsWithIDs(ctx context.Context, ids []string) ([]catalog.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	products := []catalog.Product{}
+	for _, id := range ids {
+		if p, ok := r.products[id]; ok {
+			products = append(products, p)
+		}
+	}
+	return products, nil
+}
+
+func (r *memoryCatalogRepository) UpdateProductRating(ctx context.Context, id string, rating float64, reviewCount uint32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ratingsDown {
+		return errors.New("search index unavailable")
+	}
+	p, ok := r.products[id]
+	if !ok {
+		return catalog.ErrNotFound.With("id", id)
+	}
+	p.Rating, p.ReviewCount = rating, reviewCount
+	r.products[id] = p
+	return nil
+}
+
+// UpdateProduct runs update without holding the lock, as update may call
+// the repository, and writes the product back only if it is unchanged.
+func (r *memoryCatalogRepository) UpdateProduct(ctx context.Context, id string, update func(p *catalog.Product) error) error {
+	for {
+		r.mu.Lock()
+		read, ok := r.products[id]
+		r.mu.Unlock()
+		if !ok {
+			return catalog.ErrNotFound.With("id", id)
+		}
+
+		p := read
+		p.Media = append([]catalog.Media{}, p.Media...)
+		if err := update(&p); err != nil {
+			return err
+		}
+
+		r.mu.Lock()
+		if reflect.DeepEqual(r.products[id], read) {
+			r.products[id] = p
+			r.mu.Unlock()
+			return nil
+		}
+		r.mu.Unlock()
+	}
+}
+
+func (r *memoryCatalogRepository) PutPriceSchedule(ctx context.Context, ps catalog.PriceSchedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schedules[ps.ID] = ps
+	return nil
+}
+
+func (r *memoryCatalogRepository) UpdatePriceSchedule(ctx context.Context, id string, update func(ps *catalog.PriceSchedule) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ps, ok := r.schedules[id]
+	if !ok {
+		return catalog.ErrScheduleNotFound.With("id", id)
+	}
+	if err := update(&ps); err != nil {
+		return err
+	}
+	r.schedules[id] = ps
+	return nil
+}
+
+func (r *memoryCatalogRepository) ListDuePriceSchedules(ctx context.Context, now time.Time) ([]catalog.PriceSchedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	schedules := []catalog.PriceSchedule{}
+	for _, ps := range r.schedules {
+		due := (ps.Status == catalog.PriceSchedulePending && !ps.StartsAt.After(now)) ||
+			(ps.Status == catalog.PriceScheduleActive && !ps.EndsAt.After(now))
+		if due && !ps.ClaimedUntil.After(now) {
+			schedules = append(schedules, ps)
+		}
+	}
+	sort.Slice(schedules, func(i, j int) bool { return schedules[i].StartsAt.Before(schedules[j].StartsAt) })
+	return schedules, nil
+}
+
+func (r *memoryCatalogRepository) AdjustStock(ctx context.Context, productID string, variantID string, delta int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.products[productID]
+	if !ok {
+		return catalog.ErrNotFound.With("id", productID)
+	}
+
+	variants := append([]catalog.Variant{}, p.Variants...)
+	for i, v := range variants {
+		if v.ID != variantID {
+			continue
+		}
+		if int64(v.Stock)+delta < 0 {
+			return catalog.ErrOutOfStock.With("variantId", variantID)
+		}
+		variants[i].Stock = uint32(int64(v.Stock) + delta)
+		p.Variants = variants
+		r.products[productID] = p
+		return nil
+	}
+	return catalog.ErrOutOfStock.With("variantId", variantID)
+}
+
+func (r *memoryCatalogRepository) GetStockReservation(ctx context.Context, id string) (*catalog.StockReservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sr, ok := r.reservations[id]
+	if !ok {
+		return nil, catalog.ErrReservationNotFound.With("id", id)
+	}
+	return &sr, nil
+}
+
+func (r *memoryCatalogRepository) CreateStockReservation(ctx context.Context, sr catalog.StockReservation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.reservations[sr.ID]; ok {
+		return catalog.ErrReservationExists.With("id", sr.ID)
+	}
+	r.reservations[sr.ID] = sr
+	return nil
+}
+
+func (r *memoryCatalogRepository) UpdateStockReservation(ctx context.Context, id string, update func(sr *catalog.StockReservation) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sr, ok := r.reservations[id]
+	if !ok {
+		return catalog.ErrReservationNotFound.With("id", id)
+	}
+	sr.Items = append([]catalog.StockItem{}, sr.Items...)
+	if err := update(&sr); err != nil {
+		return err
+	}
+	r.reservations[id] = sr
+	return nil
+}
+
+func (r *memoryCatalogRepository) PutStockReservation(ctx context.Context, sr catalog.StockReservation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reservations[sr.ID] = sr
+	return nil
+}
+
+func (r *memoryCatalogRepository) PutPriceChange(ctx context.Context, c catalog.PriceChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.historyDown {
+		return errors.New("search index unavailable")
+	}
+	for i := range r.changes {
+		if r.changes[i].ID == c.ID {
+			r.changes[i] = c
+			return nil
+		}
+	}
+	r.changes = append(r.changes, c)
+	return nil
+}
+
+// memoryOrderRepository keeps only the columns the Postgres repository
+// stores, so product names and descriptions in order history have to come
+// from the catalog just as they do in production.
+type memoryOrderRepository struct {
+	order.Repository
+	memoryOutbox
+
+	mu        sync.Mutex
+	orders    []order.Order
+	sagas     map[string]order.Saga
+	invoices  []order.Invoice
+	shipments []order.Shipment
+	returns   []order.Return
+	refunds   []order.Refund
+
+	subscriptions []order.Subscription
+}
+
+func newMemoryOrderRepository() *memoryOrderRepository {
+	return &memoryOrderRepository{sagas: map[string]order.Saga{}}
+}
+
+func (r *memoryOrderRepository) Close()                         {}
+func (r *memoryOrderRepository) Ping(ctx context.Context) error { return nil }
+
+func (r *memoryOrderRepository) CreateSaga(ctx context.Context, sg order.Saga) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.sagas[sg.Order.ID]; ok {
+		return order.ErrOrderExists.With("id", sg.Order.ID)
+	}
+	r.sagas[sg.Order.ID] = sg
+	return nil
+}
+
+func (r *memoryOrderRepository) GetSaga(ctx context.Context, orderID string) (*order.Saga, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sg, ok := r.sagas[orderID]
+	if !ok {
+		return nil, order.ErrOrderNotFound.With("id", orderID)
+	}
+	return &sg, nil
+}
+
+func (r *memoryOrderRepository) UpdateSaga(ctx context.Context, sg order.Saga) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sagas[sg.Order.ID] = sg
+	return nil
+}
+
+func (r *memoryOrderRepository) ClaimStaleSagas(ctx context.Context, before time.Time, now time.Time) ([]order.Saga, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sagas := []order.Saga{}
+	for id, sg := range r.sagas {
+		if (sg.Status == order.SagaRunning || sg.Status == order.SagaCompensating) && sg.UpdatedAt.Before(before) {
+			sg.UpdatedAt = now
+			r.sagas[id] = sg
+			sagas = append(sagas, sg)
+		}
+	}
+	return sagas, nil
+}
+
+func (r *memoryOrderRepository) saga(id string) order.Saga {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sagas[id]
+}
+
+func (r *memoryOrderRepository) CompleteSaga(ctx context.Context, sg order.Saga, e events.Event, render func(number uint64) ([]byte, []byte, error)) (*order.Invoice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sagas[sg.Order.ID].Status == order.SagaCompleted {
+		return nil, nil
+	}
+
+	number := uint64(len(r.invoices)) + 1
+	html, pdf, err := render(number)
+	if err != nil {
+		return nil, err
+	}
+	inv := order.Invoice{Number: number, OrderID: sg.Order.ID, IssuedAt: sg.UpdatedAt, HTML: html, PDF: pdf}
+
+	r.sagas[sg.Order.ID] = sg
+	r.orders = append(r.orders, sg.Order)
+	r.insert(e)
+	r.invoices = append(r.invoices, inv)
+	return &inv, nil
+}
+
+func (r *memoryOrderRepository) SearchOrders(ctx context.Context, q order.OrderQuery, after string, limit uint64) ([]order.Order, []string, error) {
+	afterCreatedAt, afterID, err := order.DecodeOrderCursor(after)
+	if err != nil {
+		return nil, nil, err
+	}
+	less := func(a, b order.Order) bool {
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt) != q.NewestFirst
+		}
+		return a.ID != b.ID && (a.ID < b.ID) != q.NewestFirst
+	}
+	start := order.Order{CreatedAt: afterCreatedAt, ID: afterID}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	orders := []order.Order{}
+	for _, o := range r.orders {
+		switch {
+		case q.AccountID != "" && o.AccountID != q.AccountID,
+			q.ProductID != "" && !hasProduct(o, q.ProductID),
+			!q.PlacedFrom.IsZero() && o.CreatedAt.Before(q.PlacedFrom),
+			!q.PlacedTo.IsZero() && !o.CreatedAt.Before(q.PlacedTo),
+			q.Status != "" && o.Status != q.Status,
+			o.TotalPrice < q.MinTotal,
+			q.MaxTotal > 0 && o.TotalPrice > q.MaxTotal,
+			afterID != "" && !less(start, o):
+			continue
+		}
+		orders = append(orders, o)
+	}
+	sort.Slice(orders, func(i, j int) bool { return less(orders[i], orders[j]) })
+	if uint64(len(orders)) > limit {
+		orders = orders[:limit]
+	}
+
+	cursors := []string{}
+	for _, o := range orders {
+		cursors = append(cursors, order.EncodeOrderCursor(o))
+	}
+	return orders, cursors, nil
+}
+
+func (r *memoryOrderRepository) GetOrder(ctx context.Context, id string) (*order.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.orders {
+		if o.ID == id {
+			return &o, nil
+		}
+	}
+	return nil, order.ErrOrderNotFound
+}
+
+func (r *memoryOrderRepository) GetInvoice(ctx context.Context, orderID string) (*order.Invoice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.invoice(orderID)
+}
+
+func (r *memoryOrderRepository) invoice(orderID string) (*order.Invoice, error) {
+	for _, inv := range r.invoices {
+		if inv.OrderID == orderID {
+			return &inv, nil
+		}
+	}
+	return nil, order.ErrInvoiceNotFound
+}
+
+func (r *memoryOrderRepository) ListShipments(ctx context.Context, orderID string) ([]order.Shipment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.shipmentsOf(orderID), nil
+}
+
+func (r *memoryOrderRepository) GetShipment(ctx context.Context, id string) (*order.Shipment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, sh := range r.shipments {
+		if sh.ID == id {
+			return &sh, nil
+		}
+	}
+	return nil, order.ErrShipmentNotFound
+}
+
+func (r *memoryOrderRepository) UpdateShipments(ctx context.Context, orderID string, update func(o order.Order, shipments []order.Shipment) (*order.ShipmentUpdate, error)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var o *order.Order
+	for i := range r.orders {
+		if r.orders[i].ID == orderID {
+			o = &r.orders[i]
+		}
+	}
+	if o == nil {
+		return order.ErrOrderNotFound
+	}
+
+	u, err := update(*o, r.shipmentsOf(orderID))
+	if err != nil {
+		return err
+	}
+	if u.Created {
+		r.shipments = append(r.shipments, u.Shipment)
+	}
+	for i, sh := range r.shipments {
+		if sh.ID == u.Shipment.ID {
+			r.shipments[i] = u.Shipment
+		}
+	}
+	o.Status = u.OrderStatus
+	for _, e := range u.Events {
+		r.insert(e)
+	}
+	return nil
+}
+
+// shipmentsOf copies the shipments of the order, as the Postgres repository
+// reads them afresh.
+func (r *memoryOrderRepository) shipmentsOf(orderID string) []order.Shipment {
+	shipments := []order.Shipment{}
+	for _, sh := range r.shipments {
+		if sh.OrderID == orderID {
+			sh.Lines = append([]order.ShipmentLine{}, sh.Lines...)
+			sh.Events = append([]order.ShipmentEvent{}, sh.Events...)
+			shipments = append(shipments, sh)
+		}
+	}
+	return shipments
+}
+
+func (r *memoryOrderRepository) CreateReturn(ctx context.Context, orderID string, create func(o order.Order, shipments []order.Shipment, returns []order.Return) (*order.Return, error)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.orders {
+		if o.ID != orderID {
+			continue
+		}
+		rt, err := create(o, r.shipmentsOf(orderID), r.returnsOf(orderID))
+		if err != nil {
+			return err
+		}
+		r.returns = append(r.returns, *rt)
+		return nil
+	}
+	return order.ErrOrderNotFound
+}
+
+func (r *memoryOrderRepository) GetReturn(ctx context.Context, id string) (*order.Return, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rt := range r.returns {
+		if rt.ID == id {
+			rt.Lines = append([]order.ReturnLine{}, rt.Lines...)
+			return &rt, nil
+		}
+	}
+	return nil, order.ErrReturnNotFound
+}
+
+func (r *memoryOrderRepository) ListReturns(ctx context.Context, orderID string) ([]order.Return, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.returnsOf(orderID), nil
+}
+
+func (r *memoryOrderRepository) UpdateReturn(ctx context.Context, rt order.Return, from string, refund *order.Refund) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.returns {
+		if r.returns[i].ID != rt.ID {
+			continue
+		}
+		if r.returns[i].Status != from {
+			return order.ErrReturnStatus
+		}
+		r.returns[i].Status, r.returns[i].Note, r.returns[i].UpdatedAt = rt.Status, rt.Note, rt.UpdatedAt
+		if refund != nil {
+			r.refunds = append(r.refunds, *refund)
+		}
+		return nil
+	}
+	return order.ErrReturnNotFound
+}
+
+func (r *memoryOrderRepository) ListRefunds(ctx context.Context, orderID string) ([]order.Refund, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	refunds := []order.Refund{}
+	for _, rf := range r.refunds {
+		if rf.OrderID == orderID {
+			refunds = append(refunds, rf)
+		}
+	}
+	return refunds, nil
+}
+
+// returnsOf copies the returns of the order, like shipmentsOf.
+func (r *memoryOrderRepository) returnsOf(orderID string) []order.Return {
+	returns := []order.Return{}
+	for _, rt := range r.returns {
+		if rt.OrderID == orderID {
+			rt.Lines = append([]order.ReturnLine{}, rt.Lines...)
+			returns = append(returns, rt)
+		}
+	}
+	return returns
+}
+
+func (r *memoryOrderRepository) CreateSubscription(ctx context.Context, sub order.Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sub.Lines = append([]order.SubscriptionLine{}, sub.Lines...)
+	r.subscriptions = append(r.subscriptions, sub)
+	return nil
+}
+
+func (r *memoryOrderRepository) ListSubscriptions(ctx context.Context, accountID string) ([]order.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subs := []order.Subscription{}
+	for _, sub := range r.subscriptions {
+		if sub.AccountID == accountID {
+			sub.Lines = append([]order.SubscriptionLine{}, sub.Lines...)
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+func (r *memoryOrderRepository) UpdateSubscription(ctx context.Context, id string, update func(sub *order.Subscription) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.subscriptions {
+		if r.subscriptions[i].ID != id {
+			continue
+		}
+		sub := r.subscriptions[i]
+		sub.Lines = append([]order.SubscriptionLine{}, sub.Lines...)
+		if err := update(&sub); err != nil {
+			return err
+		}
+		r.subscriptions[i] = sub
+		return nil
+	}
+	return order.ErrSubscriptionNotFound
+}
+
+func (r *memoryOrderRepository) ClaimDueSubscription(ctx context.Context, now time.Time, until time.Time) (*order.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, sub := range r.subscriptions {
+		if sub.Status != order.SubscriptionActive || sub.DueAt.After(now) {
+			continue
+		}
+		r.subscriptions[i].DueAt = until
+		sub.DueAt = until
+		sub.Lines = append([]order.SubscriptionLine{}, sub.Lines...)
+		return &sub, nil
+	}
+	return nil, nil
+}
+
+func hasProduct(o order.Order, productID string) bool {
+	for _, p := range o.Products {
+		if p.ID == productID {
+			return true
+		}
+	}
+	return false
+}
+
+type memoryReviewRepository struct {
+	review.Repository
+
+	mu            sync.Mutex
+	reviews       map[string]review.Review
+	ratingChanges map[string]memoryRatingChange
+}
+
+// memoryRatingChange is a row of the rating_changes table.
+type memoryRatingChange struct {
+	version      int64
+	claimedUntil time.Time
+}
+
+func newMemoryReviewRepository() *memoryReviewRepository {
+	return &memoryReviewRepository{
+		reviews:       map[string]review.Review{},
+		ratingChanges: map[string]memoryRatingChange{},
+	}
+}
+
+func (r *memoryReviewRepository) Close()                         {}
+func (r *memoryReviewRepository) Ping(ctx context.Context) error { return nil }
+
+func (r *memoryReviewRepository) PutReview(ctx context.Context, rv review.Review) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.reviews {
+		if existing.ProductID == rv.ProductID && existing.AccountID == rv.AccountID {
+			return review.ErrAlreadyReviewed
+		}
+	}
+	r.reviews[rv.ID] = rv
+	return nil
+}
+
+func (r *memoryReviewRepository) ListReviewsForProduct(ctx context.Context, productID, status string, skip uint64, take uint64) ([]review.Review, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reviews := []review.Review{}
+	for _, rv := range r.reviews {
+		if rv.ProductID == productID && rv.Status == status {
+			reviews = append(reviews, rv)
+		}
+	}
+	sort.Slice(reviews, func(i, j int) bool { return reviews[i].CreatedAt.After(reviews[j].CreatedAt) })
+	return page(reviews, skip, take), nil
+}
+
+func (r *memoryReviewRepository) UpdateReviewStatus(ctx context.Context, id, status string) (*review.Review, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rv, ok := r.reviews[id]
+	if !ok {
+		return nil, review.ErrNotFound.With("id", id)
+	}
+	rv.Status = status
+	r.reviews[id] = rv
+
+	c := r.ratingChanges[rv.ProductID]
+	c.version++
+	r.ratingChanges[rv.ProductID] = c
+	return &rv, nil
+}
+
+func (r *memoryReviewRepository) GetProductRating(ctx context.Context, productID string) (*review.Rating, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rating := review.Rating{}
+	var sum uint32
+	for _, rv := range r.reviews {
+		if rv.ProductID == productID && rv.Status == review.StatusApproved {
+			sum += rv.Rating
+			rating.Count++
+		}
+	}
+	if rating.Count > 0 {
+		rating.Average = float64(sum) / float64(rating.Count)
+	}
+	return &rating, nil
+}
+
+func (r *memoryReviewRepository) ClaimRatingChanges(ctx context.Context, productID string, now time.Time, until time.Time, limit uint64) ([]review.RatingChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changes := []review.RatingChange{}
+	for id, c := range r.ratingChanges {
+		if uint64(len(changes)) == limit || c.claimedUntil.After(now) || (productID != "" && id != productID) {
+			continue
+		}
+		c.claimedUntil = until
+		r.ratingChanges[id] = c
+		changes = append(changes, review.RatingChange{ProductID: id, Version: c.version})
+	}
+	return changes, nil
+}
+
+func (r *memoryReviewRepository) CompleteRatingChange(ctx context.Context, c review.RatingChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ratingChanges[c.ProductID].version == c.Version {
+		delete(r.ratingChanges, c.ProductID)
+		return nil
+	}
+	r.release(c.ProductID)
+	return nil
+}
+
+func (r *memoryReviewRepository) ReleaseRatingChange(ctx context.Context, productID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.release(productID)
+	return nil
+}
+
+func (r *memoryReviewRepository) release(productID string) {
+	if c, ok := r.ratingChanges[productID]; ok {
+		c.claimedUntil = time.Time{}
+		r.ratingChanges[productID] = c
+	}
+}
+
+type memoryNotificationRepository struct {
+	notification.Repository
+
+	mu            sync.Mutex
+	notifications map[string]notification.Notification
+	preferences   map[string]notification.Preferences
+}
+
+func newMemoryNotificationRepository() *memoryNotificationRepository {
+	return &memoryNotificationRepository{
+		notifications: map[string]notification.Notification{},
+		preferences:   map[string]notification.Preferences{},
+	}
+}
+
+func (r *memoryNotificationRepository) Close()                         {}
+func (r *memoryNotificationRepository) Ping(ctx context.Context) error { return nil }
+
+func (r *memoryNotificationRepository) PutNotification(ctx context.Context, n notification.Notification) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.notifications {
+		if existing.EventID == n.EventID {
+			return false, nil
+		}
+	}
+	r.notifications[n.ID] = n
+	return true, nil
+}
+
+func (r *memoryNotificationRepository) UpdateNotification(ctx context.Context, n notification.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifications[n.ID] = n
+	return nil
+}
+
+func (r *memoryNotificationRepository) ClaimDueNotifications(ctx context.Context, now time.Time, until time.Time, limit int) ([]notification.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	due := []notification.Notification{}
+	for id, n := range r.notifications {
+		if n.Status == notification.StatusPending && !n.NextAttemptAt.After(now) && len(due) < limit {
+			n.NextAttemptAt = until
+			r.notifications[id] = n
+			due = append(due, n)
+		}
+	}
+	return due, nil
+}
+
+func (r *memoryNotificationRepository) GetPreferences(ctx context.Context, accountID string) (*notification.Preferences, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.preferences[accountID]
+	if !ok {
+		return nil, notification.ErrPreferencesNotFound.With("accountId", accountID)
+	}
+	return &p, nil
+}
+
+func (r *memoryNotificationRepository) PutPreferences(ctx context.Context, p notification.Preferences) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.preferences[p.AccountID] = p
+	return nil
+}
+
+// find returns the notifications of accountID rendered from template.
+func (r *memoryNotificationRepository) find(accountID, template string) []notification.Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	found := []notification.Notification{}
+	for _, n := range r.notifications {
+		if n.AccountID == accountID && n.Template == template {
+			found = append(found, n)
+		}
+	}
+	return found
+}
+
+// makeDue brings the next attempt of every pending notification forward to
+// now, as if its backoff had passed.
+func (r *memoryNotificationRepository) makeDue() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, n := range r.notifications {
+		if n.Status == notification.StatusPending {
+			n.NextAttemptAt = time.Now().UTC()
+			r.notifications[id] = n
+		}
+	}
+}
+
+// memorySender stands in for the mail server. It keeps the messages it
+// accepted, and rejects the next failures messages with a transient error,
+// or with a permanent one if permanent is set.
+type memorySender struct {
+	mu        sync.Mutex
+	sent      []notification.Message
+	failures  int
+	permanent bool
+}
+
+func (m *memorySender) Send(ctx context.Context, msg notification.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failures > 0 {
+		m.failures--
+		err := errors.New("421 service not available")
+		if m.permanent {
+			err = retry.Permanent(errors.New("550 mailbox unavailable"))
+		}
+		return err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func (m *memorySender) fail(n int, permanent bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures, m.permanent = n, permanent
+}
+
+// to returns the messages sent to address.
+func (m *memorySender) to(address string) []notification.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	msgs := []notification.Message{}
+	for _, msg := range m.sent {
+		if msg.To == address {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+func page[T any](items []T, skip, take uint64) []T {
+	if skip >= uint64(len(items)) {
+		return []T{}
+	}
+	items = items[skip:]
+	if take < uint64(len(items)) {
+		items = items[:take]
+	}
+	return items
+}
@@ -1,35 +1,68 @@
 package main
 
 import (
+	"net/http"
+
 	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/sunil8777/E-commerce-microservices/account"
 	"github.com/sunil8777/E-commerce-microservices/catalog"
 	"github.com/sunil8777/E-commerce-microservices/graphql/generated"
+	"github.com/sunil8777/E-commerce-microservices/notification"
 	"github.com/sunil8777/E-commerce-microservices/order"
+	"github.com/sunil8777/E-commerce-microservices/retry"
+	"github.com/sunil8777/E-commerce-microservices/review"
+	"google.golang.org/grpc"
 )
 
 type Server struct {
-	accountClient *account.Client
-	catalogClient *catalog.Client
-	orderClient   *order.Client
+	accountClient      *account.Client
+	catalogClient      *catalog.Client
+	orderClient        *order.Client
+	reviewClient       *review.Client
+	notificationClient *notification.Client
+	adminToken         string
+	invoiceKey         []byte
 }
 
-func NewGraphQLServer(accountUrl, catalogUrl, orderUrl string) (*Server, error) {
-	accountClient, err := account.NewClient(accountUrl)
+// NewGraphQLServer dials the five services. Requests bearing adminToken may
+// resolve the @admin fields and fetch any invoice. Invoice links are signed
+// with invoiceKey; when it is empty they are not, and only admins may
+// follow them. opts are added to the dial options of every client.
+func NewGraphQLServer(accountUrl, catalogUrl, orderUrl, reviewUrl, notificationUrl string, adminToken string, invoiceKey string, opts ...grpc.DialOption) (*Server, error) {
+	accountClient, err := account.NewClient(accountUrl, append([]grpc.DialOption{retry.NewBreaker("account").DialOption()}, opts...)...)
 	if err != nil {
 		return nil, err
 	}
 
-	catalogClient, err := catalog.NewClient(catalogUrl)
+	catalogClient, err := catalog.NewClient(catalogUrl, append([]grpc.DialOption{retry.NewBreaker("catalog").DialOption()}, opts...)...)
 	if err != nil {
 		accountClient.Close()
 		return nil, err
 	}
 
-	orderClient, err := order.NewClient(orderUrl)
+	orderClient, err := order.NewClient(orderUrl, append([]grpc.DialOption{retry.NewBreaker("order").DialOption()}, opts...)...)
+	if err != nil {
+		accountClient.Close()
+		catalogClient.Close()
+		return nil, err
+	}
+
+	reviewClient, err := review.NewClient(reviewUrl, append([]grpc.DialOption{retry.NewBreaker("review").DialOption()}, opts...)...)
+	if err != nil {
+		accountClient.Close()
+		catalogClient.Close()
+		orderClient.Close()
+		return nil, err
+	}
+
+	notificationClient, err := notification.NewClient(notificationUrl, append([]grpc.DialOption{retry.NewBreaker("notification").DialOption()}, opts...)...)
 	if err != nil {
 		accountClient.Close()
 		catalogClient.Close()
+		orderClient.Close()
+		reviewClient.Close()
 		return nil, err
 	}
 
@@ -37,9 +70,23 @@ func NewGraphQLServer(accountUrl, catalogUrl, orderUrl string) (*Server, error)
 		accountClient,
 		catalogClient,
 		orderClient,
+		reviewClient,
+		notificationClient,
+		adminToken,
+		[]byte(invoiceKey),
 	}, nil
 }
 
+// Close closes the service clients. Call it only after the HTTP server has
+// drained, since in-flight resolvers still use them.
+func (s *Server) Close() {
+	s.notificationClient.Close()
+	s.reviewClient.Close()
+	s.orderClient.Close()
+	s.catalogClient.Close()
+	s.accountClient.Close()
+}
+
 func (s *Server) Mutation() generated.MutationResolver {
 	return &mutationResolver{
 		server: s,
@@ -52,14 +99,46 @@ func (s *Server) Query() generated.QueryResolver {
 	}
 }
 
-func (s *Server) Account() *accountResolver {
+func (s *Server) Account() generated.AccountResolver {
 	return &accountResolver{
 		server: s,
 	}
 }
 
+func (s *Server) Order() generated.OrderResolver {
+	return &orderResolver{
+		server: s,
+	}
+}
+
+func (s *Server) Product() generated.ProductResolver {
+	return &productResolver{
+		server: s,
+	}
+}
+
 func (s *Server) ToExecutableSchema() graphql.ExecutableSchema {
 	return generated.NewExecutableSchema(generated.Config{
-		Resolvers: s,
+		Resolvers:  s,
+		Directives: generated.DirectiveRoot{Constraint: constraint, Admin: admin},
+	})
+}
+
+// Handler serves the schema at /graphql with the gateway's error
+// presenter, extensions and transports.
+func (s *Server) Handler() http.Handler {
+	es := s.ToExecutableSchema()
+	operations := newOperationNames(es)
+	srv := handler.New(es)
+	srv.SetErrorPresenter(presentError)
+	srv.Use(tracingExtension{operations})
+	srv.Use(metricsExtension{operations})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{
+		MaxUploadSize: maxImageSize + 1<<20,
+		MaxMemory:     maxImageSize,
 	})
+	return withRequestID(withAdmin(s.adminToken, srv))
 }
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// readinessTimeout bounds the downstream health checks behind /readyz so a
+// hung service fails the probe instead of holding it open.
+const readinessTimeout = 2 * time.Second
+
+type healthReport struct {
+	Status   string            `json:"status"`
+	Services map[string]string `json:"services,omitempty"`
+}
+
+// healthzHandler reports that the gateway process is up. It does not look at
+// downstream services, so an outage behind the gateway never gets it
+// restarted.
+func healthzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, http.StatusOK, healthReport{Status: "ok"})
+	})
+}
+
+// readyzHandler reports whether every downstream service is SERVING over the
+// gRPC health protocol, and answers 503 with the failing services otherwise.
+func (s *Server) readyzHandler() http.Handler {
+	checks := map[string]func(context.Context) error{
+		"account":      s.accountClient.HealthCheck,
+		"catalog":      s.catalogClient.HealthCheck,
+		"order":        s.orderClient.HealthCheck,
+		"review":       s.reviewClient.HealthCheck,
+		"notification": s.notificationClient.HealthCheck,
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+
+		report := healthReport{Status: "ok", Services: map[string]string{}}
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for name, check := range checks {
+			wg.Add(1)
+			go func(name string, check func(context.Context) error) {
+				defer wg.Done()
+				status := "SERVING"
+				if err := check(ctx); err != nil {
+					status = err.Error()
+				}
+				mu.Lock()
+				report.Services[name] = status
+				mu.Unlock()
+			}(name, check)
+		}
+		wg.Wait()
+
+		code := http.StatusOK
+		for _, status := range report.Services {
+			if status != "SERVING" {
+				report.Status = "unavailable"
+				code = http.StatusServiceUnavailable
+			}
+		}
+		writeHealth(w, code, report)
+	})
+}
+
+func writeHealth(w http.ResponseWriter, code int, report healthReport) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,1997 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/segmentio/ksuid"
+	"github.com/sunil8777/E-commerce-microservices/account"
+	"github.com/sunil8777/E-commerce-microservices/catalog"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+	"github.com/sunil8777/E-commerce-microservices/events"
+	"github.com/sunil8777/E-commerce-microservices/notification"
+	"github.com/sunil8777/E-commerce-microservices/order"
+	"github.com/sunil8777/E-commerce-microservices/review"
+	"github.com/sunil8777/E-commerce-microservices/validation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const bufSize = 1 << 20
+
+// paymentLimit is the largest order total the order service authorizes.
+const paymentLimit = 1000
+
+// unknownID is a well-formed KSUID that no service ever issued.
+const unknownID = "0ujsswThIGTUYm2K8FjOOfXtY1K"
+
+// adminToken is the gateway's admin token; see asAdmin.
+const adminToken = "test-admin-token"
+
+// invoiceKey signs the gateway's invoice links.
+const invoiceKey = "test-invoice-key"
+
+// invoiceConfig is the seller on invoices, whose prices include 20% tax.
+var invoiceConfig = order.InvoiceConfig{
+	SellerName:    "Test Shop",
+	SellerAddress: []string{"1 Market Street", "Springfield"},
+	SellerTaxID:   "GB123456789",
+	TaxRate:       0.2,
+}
+
+// asAdmin makes a gateway request as support staff.
+var asAdmin = client.AddHeader("Authorization", "Bearer "+adminToken)
+
+// stack is the account, catalog, order, review and notification services
+// served over in-memory gRPC connections, with the gateway's schema on top. The
+// services talk to each other through their real Clients, and account and
+// order relay their outboxes to the notification service, exactly as they
+// do when deployed; only the repositories, the mail server and the network
+// are replaced.
+type stack struct {
+	gateway *client.Client
+	// invoices is the gateway's invoice handler.
+	invoices http.Handler
+	account  *account.Client
+	catalog  *catalog.Client
+	order    *order.Client
+	// events publishes straight to the notification service, as a relay
+	// does.
+	events *events.Client
+
+	products *memoryCatalogRepository
+	// mediaDir is where the catalog stores the files of product media.
+	mediaDir      string
+	orders        *memoryOrderRepository
+	reviews       *memoryReviewRepository
+	notifications *memoryNotificationRepository
+	mail          *memorySender
+	// serveOrder starts another order server on the same repository, as a
+	// replica or a restart would.
+	serveOrder func()
+}
+
+func startStack(t *testing.T) *stack {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	listeners := map[string]*bufconn.Listener{
+		"account": bufconn.Listen(bufSize),
+		"catalog": bufconn.Listen(bufSize),
+		"order":   bufconn.Listen(bufSize),
+		"review":  bufconn.Listen(bufSize),
+
+		"notification": bufconn.Listen(bufSize),
+	}
+	dialer := grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+		lis, ok := listeners[addr]
+		if !ok {
+			return nil, fmt.Errorf("no service listening on %s", addr)
+		}
+		return lis.DialContext(ctx)
+	})
+	target := func(service string) string {
+		return "passthrough:///" + service
+	}
+
+	var wg sync.WaitGroup
+	serve := func(service string, fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				t.Errorf("%s server: %v", service, err)
+			}
+		}()
+	}
+	accounts := newMemoryAccountRepository()
+	serve("account", func() error {
+		return account.Serve(ctx, account.NewService(accounts), listeners["account"])
+	})
+	products := newMemoryCatalogRepository()
+	mediaDir := t.TempDir()
+	media, err := catalog.NewFileBlobStore(mediaDir, "/media")
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve("catalog", func() error {
+		return catalog.Serve(ctx, catalog.NewService(products, media), listeners["catalog"])
+	})
+	s := &stack{
+		products:      products,
+		mediaDir:      mediaDir,
+		orders:        newMemoryOrderRepository(),
+		reviews:       newMemoryReviewRepository(),
+		notifications: newMemoryNotificationRepository(),
+		mail:          &memorySender{},
+	}
+	s.serveOrder = func() {
+		lis := bufconn.Listen(bufSize)
+		serve("order", func() error {
+			return order.Serve(ctx, order.NewService(s.orders, order.NewPaymentLimit(paymentLimit), invoiceConfig), target("account"), target("catalog"), lis, dialer)
+		})
+	}
+	serve("order", func() error {
+		return order.Serve(ctx, order.NewService(s.orders, order.NewPaymentLimit(paymentLimit), invoiceConfig), target("account"), target("catalog"), listeners["order"], dialer)
+	})
+	serve("review", func() error {
+		return review.Serve(ctx, review.NewService(s.reviews), target("order"), target("catalog"), listeners["review"], dialer)
+	})
+	serve("notification", func() error {
+		return notification.Serve(ctx, notification.NewService(s.notifications, s.mail), target("account"), listeners["notification"], dialer)
+	})
+
+	if s.account, err = account.NewClient(target("account"), dialer); err != nil {
+		t.Fatal(err)
+	}
+	if s.catalog, err = catalog.NewClient(target("catalog"), dialer); err != nil {
+		t.Fatal(err)
+	}
+	if s.order, err = order.NewClient(target("order"), dialer); err != nil {
+		t.Fatal(err)
+	}
+	if s.events, err = events.NewClient(target("notification"), dialer); err != nil {
+		t.Fatal(err)
+	}
+	for _, outbox := range []events.Store{accounts, s.orders} {
+		relay := events.NewRelay(outbox, s.events)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			relay.Run(ctx, 10*time.Millisecond)
+		}()
+	}
+
+	gateway, err := NewGraphQLServer(target("account"), target("catalog"), target("order"), target("review"), target("notification"), adminToken, invoiceKey, dialer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.gateway = client.New(gateway.Handler())
+	s.invoices = gateway.invoiceHandler()
+
+	t.Cleanup(func() {
+		gateway.Close()
+		cancel()
+		wg.Wait()
+		s.events.Close()
+		s.order.Close()
+		s.catalog.Close()
+		s.account.Close()
+	})
+	return s
+}
+
+func (s *stack) createAccount(t *testing.T, name string) *account.Account {
+	t.Helper()
+	a, err := s.account.PostAccount(context.Background(), name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func (s *stack) createProduct(t *testing.T, name string, price float64, options []catalog.ProductOption, variants []catalog.Variant) *catalog.Product {
+	t.Helper()
+	p, err := s.catalog.PostProduct(context.Background(), name, name+" description", price, options, variants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// tee is a product with one option axis and a variant that overrides the
+// product price.
+func (s *stack) createTee(t *testing.T) (*catalog.Product, catalog.Variant) {
+	t.Helper()
+	smallPrice := 18.0
+	p := s.createProduct(t, "Tee", 20,
+		[]catalog.ProductOption{{Name: "size", Values: []string{"S", "M"}}},
+		[]catalog.Variant{
+			{SKU: "TEE-S", Options: map[string]string{"size": "S"}, Price: &smallPrice, Stock: 5},
+			{SKU: "TEE-M", Options: map[string]string{"size": "M"}, Stock: 5},
+		},
+	)
+	return p, p.Variants[0]
+}
+
+// expectGraphQLError fails the test unless err is a GraphQL error response
+// carrying reason in its extensions.
+func expectGraphQLError(t *testing.T, err error, reason string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expected %s error, got none", reason)
+	}
+	if !strings.Contains(err.Error(), `"reason":"`+reason+`"`) {
+		t.Fatalf("expected %s error, got %v", reason, err)
+	}
+}
+
+func TestCreateAccount(t *testing.T) {
+	s := startStack(t)
+
+	var created struct {
+		CreateAccount struct {
+			ID   string
+			Name string
+		}
+	}
+	err := s.gateway.Post(
+		`mutation($name: String!) { createAccount(account: {name: $name}) { id name } }`,
+		&created,
+		client.Var("name", "Ada"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.CreateAccount.ID == "" || created.CreateAccount.Name != "Ada" {
+		t.Fatalf("unexpected account %+v", created.CreateAccount)
+	}
+
+	a, err := s.account.GetAccount(context.Background(), created.CreateAccount.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Name != "Ada" {
+		t.Fatalf("account service has name %q, want Ada", a.Name)
+	}
+
+	var queried struct {
+		Accounts []struct {
+			ID   string
+			Name string
+		}
+	}
+	err = s.gateway.Post(`query($id: String) { accounts(id: $id) { id name } }`, &queried, client.Var("id", a.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queried.Accounts) != 1 || queried.Accounts[0].Name != "Ada" {
+		t.Fatalf("unexpected accounts %+v", queried.Accounts)
+	}
+
+	err = s.gateway.Post(`query($id: String) { accounts(id: $id) { id } }`, &queried, client.Var("id", unknownID))
+	expectGraphQLError(t, err, "ACCOUNT_NOT_FOUND")
+}
+
+func TestCreateProduct(t *testing.T) {
+	s := startStack(t)
+
+	var created struct {
+		CreateProduct struct {
+			ID       string
+			Price    float64
+			Variants []struct {
+				ID    string
+				Sku   string
+				Price float64
+			}
+		}
+	}
+	err := s.gateway.Post(`mutation {
+		createProduct(product: {
+			name: "Tee", description: "Cotton", price: 20,
+			options: [{name: "size", values: ["S", "M", "L"]}],
+			variants: [
+				{sku: "TEE-S", options: [{name: "size", value: "S"}], price: 18, stock: 5},
+				{sku: "TEE-M", options: [{name: "size", value: "M"}], stock: 5},
+				{sku: "TEE-L", options: [{name: "size", value: "L"}], price: 0, stock: 5}
+			]
+		}) { id price variants { id sku price } }
+	}`, &created)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := created.CreateProduct
+	if p.ID == "" || p.Price != 20 || len(p.Variants) != 3 {
+		t.Fatalf("unexpected product %+v", p)
+	}
+	if p.Variants[0].Price != 18 || p.Variants[1].Price != 20 || p.Variants[2].Price != 0 {
+		t.Fatalf("variant prices %v, %v and %v, want 18, the product price 20 and free", p.Variants[0].Price, p.Variants[1].Price, p.Variants[2].Price)
+	}
+
+	stored, err := s.catalog.GetProduct(context.Background(), p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Name != "Tee" || len(stored.Variants) != 3 || stored.Variants[0].SKU != "TEE-S" {
+		t.Fatalf("catalog service has %+v", stored)
+	}
+
+	err = s.gateway.Post(`mutation {
+		createProduct(product: {
+			name: "Tee", description: "Cotton", price: 20,
+			options: [{name: "size", values: ["S"]}],
+			variants: [{sku: "TEE-XL", options: [{name: "size", value: "XL"}], stock: 1}]
+		}) { id }
+	}`, &created)
+	expectGraphQLError(t, err, "INVALID_VARIANT")
+}
+
+func TestProductMedia(t *testing.T) {
+	s := startStack(t)
+	mug := s.createProduct(t, "Mug", 12, nil, nil)
+
+	type mediaList []struct {
+		URL      string
+		Width    int
+		Position int
+	}
+	upload := func(width int, position *int, options ...client.Option) (mediaList, error) {
+		t.Helper()
+		var img bytes.Buffer
+		if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, width, 1))); err != nil {
+			t.Fatal(err)
+		}
+		name := filepath.Join(t.TempDir(), "image.png")
+		if err := os.WriteFile(name, img.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		var res struct{ UploadProductImage struct{ Media mediaList } }
+		err = s.gateway.Post(`mutation($id: String!, $file: Upload!, $position: Int) {
+			uploadProductImage(productId: $id, file: $file, position: $position) { media { url width position } }
+		}`, &res, append(options, client.Var("id", mug.ID), client.Var("file", f), client.Var("position", position), client.WithFiles())...)
+		return res.UploadProductImage.Media, err
+	}
+
+	_, err := upload(1, nil)
+	expectGraphQLError(t, err, "ADMIN_REQUIRED")
+	if entries, _ := os.ReadDir(s.mediaDir); len(entries) != 0 {
+		t.Fatalf("stored %d media files for an upload that was rejected", len(entries))
+	}
+
+	if _, err := upload(1, nil, asAdmin); err != nil {
+		t.Fatal(err)
+	}
+	first := 0
+	media, err := upload(2, &first, asAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(media) != 2 || media[0].Width != 2 || media[1].Width != 1 || media[0].Position != 0 || media[1].Position != 1 {
+		t.Fatalf("unexpected media %+v", media)
+	}
+
+	// The catalog stores the files it hands out URLs to.
+	for _, m := range media {
+		key, ok := strings.CutPrefix(m.URL, "/media/"+mug.ID+"/")
+		if !ok || !strings.HasSuffix(key, ".png") {
+			t.Fatalf("media URL is %q", m.URL)
+		}
+		if _, err := os.Stat(filepath.Join(s.mediaDir, mug.ID, key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPriceSchedules(t *testing.T) {
+	s := startStack(t)
+	ctx := context.Background()
+	mug := s.createProduct(t, "Mug", 10, nil, nil)
+	// Far enough ahead that the test decides when schedules are due.
+	start := time.Date(2030, time.January, 1, 9, 0, 0, 0, time.UTC)
+	prices := catalog.NewService(s.products, nil)
+	applyAt := func(now time.Time) {
+		t.Helper()
+		if err := prices.ApplyDuePriceSchedules(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectPrice := func(price float64) {
+		t.Helper()
+		p, err := s.catalog.GetProduct(ctx, mug.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Price != price {
+			t.Fatalf("mug costs %v, want %v", p.Price, price)
+		}
+	}
+	schedule := func(price float64, startsAt, endsAt time.Time, options ...client.Option) (string, error) {
+		var res struct{ SchedulePriceChange struct{ ID string } }
+		err := s.gateway.Post(`mutation($schedule: PriceScheduleInput!) { schedulePriceChange(schedule: $schedule) { id } }`,
+			&res, append(options, client.Var("schedule", map[string]interface{}{
+				"productId": mug.ID,
+				"price":     price,
+				"startsAt":  startsAt.Format(time.RFC3339),
+				"endsAt":    endsAt.Format(time.RFC3339),
+				"actor":     "ada",
+			}))...)
+		return res.SchedulePriceChange.ID, err
+	}
+
+	_, err := schedule(8, start, start.AddDate(0, 0, 7))
+	expectGraphQLError(t, err, "ADMIN_REQUIRED")
+	sale, err := schedule(8, start, start.AddDate(0, 0, 7), asAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applyAt(start.Add(time.Hour))
+	expectPrice(8)
+
+	// A scheduler that stopped after pricing the mug but before recording
+	// the sale as active leaves it pending; taking it up again once the
+	// claim runs out changes nothing.
+	s.products.mu.Lock()
+	ps := s.products.schedules[sale]
+	ps.Status, ps.PreviousPrice = catalog.PriceSchedulePending, 0
+	s.products.schedules[sale] = ps
+	s.products.mu.Unlock()
+	applyAt(start.Add(2 * time.Hour))
+	expectPrice(8)
+	if n := len(s.products.changes); n != 1 {
+		t.Fatalf("%d price changes recorded, want 1", n)
+	}
+
+	// A price set by hand during the sale is kept when the sale ends.
+	var updated struct{ UpdateProductPrice struct{ Price float64 } }
+	setPrice := `mutation($id: String!) { updateProductPrice(productId: $id, price: 9, actor: "ada") { price } }`
+	err = s.gateway.Post(setPrice, &updated, client.Var("id", mug.ID))
+	expectGraphQLError(t, err, "ADMIN_REQUIRED")
+	// The price is not changed unless the change is recorded.
+	s.products.mu.Lock()
+	s.products.historyDown = true
+	s.products.mu.Unlock()
+	if err := s.gateway.Post(setPrice, &updated, client.Var("id", mug.ID), asAdmin); err == nil {
+		t.Fatal("price changed without recording it")
+	}
+	expectPrice(8)
+	s.products.mu.Lock()
+	s.products.historyDown = false
+	s.products.mu.Unlock()
+	if err := s.gateway.Post(setPrice, &updated, client.Var("id", mug.ID), asAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(s.products.changes); n != 2 || s.products.changes[1].OldPrice != 8 || s.products.changes[1].NewPrice != 9 {
+		t.Fatalf("unexpected price changes %+v", s.products.changes)
+	}
+	applyAt(start.AddDate(0, 0, 8))
+	expectPrice(9)
+
+	// A sale picked up after it ended starts and ends at once.
+	if _, err := schedule(5, start.AddDate(0, 1, 0), start.AddDate(0, 1, 1), asAdmin); err != nil {
+		t.Fatal(err)
+	}
+	applyAt(start.AddDate(0, 1, 2))
+	expectPrice(9)
+	for _, ps := range s.products.schedules {
+		if ps.Status != catalog.PriceScheduleDone {
+			t.Fatalf("schedule %s is %s, want done", ps.ID, ps.Status)
+		}
+	}
+}
+
+func TestPlaceOrder(t *testing.T) {
+	s := startStack(t)
+	a := s.createAccount(t, "Ada")
+	mug := s.createProduct(t, "Mug", 10, nil, nil)
+	tee, small := s.createTee(t)
+
+	var placed struct {
+		CreateOrder struct {
+			ID         string
+			TotalPrice float64
+			Products   []struct {
+				ID       string
+				Name     string
+				Price    float64
+				Quantity int
+				Sku      *string
+			}
+		}
+	}
+	err := s.gateway.Post(`mutation($account: String!, $mug: String!, $tee: String!, $variant: String) {
+		createOrder(order: {accountId: $account, products: [
+			{id: $mug, quantity: 2},
+			{id: $tee, quantity: 1, variantId: $variant}
+		]}) { id totalPrice products { id name price quantity sku } }
+	}`, &placed,
+		client.Var("account", a.ID),
+		client.Var("mug", mug.ID),
+		client.Var("tee", tee.ID),
+		client.Var("variant", small.ID),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := placed.CreateOrder
+	if o.ID == "" || o.TotalPrice != 2*10+18 {
+		t.Fatalf("order %s has total %v, want 38", o.ID, o.TotalPrice)
+	}
+	if len(o.Products) != 2 {
+		t.Fatalf("order has %d lines, want 2", len(o.Products))
+	}
+	if line := o.Products[1]; line.Name != "Tee" || line.Price != 18 || line.Sku == nil || *line.Sku != "TEE-S" {
+		t.Fatalf("unexpected variant line %+v", line)
+	}
+
+	err = s.gateway.Post(`mutation($account: String!, $mug: String!) {
+		createOrder(order: {accountId: $account, products: [{id: $mug, quantity: 1}]}) { id }
+	}`, &placed, client.Var("account", unknownID), client.Var("mug", mug.ID))
+	expectGraphQLError(t, err, "ACCOUNT_NOT_FOUND")
+
+	err = s.gateway.Post(`mutation($account: String!, $tee: String!, $variant: String) {
+		createOrder(order: {accountId: $account, products: [{id: $tee, quantity: 1, variantId: $variant}]}) { id }
+	}`, &placed, client.Var("account", a.ID), client.Var("tee", tee.ID), client.Var("variant", unknownID))
+	expectGraphQLError(t, err, "VARIANT_NOT_FOUND")
+}
+
+func TestOrderHistoryEnrichment(t *testing.T) {
+	s := startStack(t)
+	ctx := context.Background()
+	a := s.createAccount(t, "Ada")
+	mug := s.createProduct(t, "Mug", 10, nil, nil)
+	tee, small := s.createTee(t)
+
+	placed, err := s.order.PostOrder(ctx, a.ID, []order.OrderedProduct{
+		{ID: mug.ID, Quantity: 3},
+		{ID: tee.ID, VariantID: small.ID, Quantity: 1},
+	}, order.Address{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Orders keep the price paid even after the catalog price changes.
+	if _, err := s.catalog.UpdateProductPrice(ctx, mug.ID, 15, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	var history struct {
+		Accounts []struct {
+			Orders []struct {
+				ID         string
+				TotalPrice float64
+				CreatedAt  string
+				Products   []struct {
+					ID          string
+					Name        string
+					Description string
+					Price       float64
+					Quantity    int
+					Sku         *string
+					Options     []struct {
+						Name  string
+						Value string
+					}
+				}
+			}
+		}
+	}
+	err = s.gateway.Post(`query($id: String) {
+		accounts(id: $id) {
+			orders {
+				id totalPrice createdAt
+				products { id name description price quantity sku options { name value } }
+			}
+		}
+	}`, &history, client.Var("id", a.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(history.Accounts) != 1 || len(history.Accounts[0].Orders) != 1 {
+		t.Fatalf("unexpected history %+v", history)
+	}
+	o := history.Accounts[0].Orders[0]
+	if o.ID != placed.ID || o.TotalPrice != 3*10+18 || o.CreatedAt == "" || strings.HasPrefix(o.CreatedAt, "0001") {
+		t.Fatalf("unexpected order %+v", o)
+	}
+
+	products := map[string]int{}
+	for i, p := range o.Products {
+		products[p.ID] = i
+	}
+	m := o.Products[products[mug.ID]]
+	if m.Name != "Mug" || m.Description != "Mug description" || m.Price != 10 || m.Quantity != 3 {
+		t.Fatalf("mug line not enriched from the catalog: %+v", m)
+	}
+	v := o.Products[products[tee.ID]]
+	if v.Name != "Tee" || v.Price != 18 || v.Sku == nil || *v.Sku != "TEE-S" {
+		t.Fatalf("unexpected variant line %+v", v)
+	}
+	if len(v.Options) != 1 || v.Options[0].Name != "size" || v.Options[0].Value != "S" {
+		t.Fatalf("variant options %+v, want size=S", v.Options)
+	}
+}
+
+func TestOrderHistoryPaging(t *testing.T) {
+	s := startStack(t)
+	ctx := context.Background()
+	a := s.createAccount(t, "Ada")
+	mug := s.createProduct(t, "Mug", 10, nil, nil)
+
+	ids := []string{}
+	for quantity := uint64(1); quantity <= 3; quantity++ {
+		o, err := s.order.PostOrder(ctx, a.ID, []order.OrderedProduct{{ID: mug.ID, Quantity: quantity}}, order.Address{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, o.ID)
+	}
+
+	type history struct {
+		Accounts []struct {
+			OrdersConnection struct {
+				Edges []struct {
+					Cursor string
+					Node   struct {
+						ID         string
+						TotalPrice float64
+						Status     string
+					}
+				}
+				PageInfo struct {
+					HasNextPage bool
+					EndCursor   *string
+				}
+			}
+		}
+	}
+	query := `query($id: String, $first: Int, $after: String, $filter: OrderFilterInput, $sort: SortDirection) {
+		accounts(id: $id) {
+			ordersConnection(first: $first, after: $after, filter: $filter, sort: $sort) {
+				edges { cursor node { id totalPrice status } }
+				pageInfo { hasNextPage endCursor }
+			}
+		}
+	}`
+	page := func(first int, after *string, filter map[string]interface{}, sort string) ([]string, bool, *string) {
+		t.Helper()
+		var h history
+		err := s.gateway.Post(query, &h,
+			client.Var("id", a.ID),
+			client.Var("first", first),
+			client.Var("after", after),
+			client.Var("filter", filter),
+			client.Var("sort", sort),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(h.Accounts) != 1 {
+			t.Fatalf("unexpected history %+v", h)
+		}
+		conn := h.Accounts[0].OrdersConnection
+		got := []string{}
+		for _, e := range conn.Edges {
+			if e.Node.Status != "PLACED" {
+				t.Fatalf("order %s has status %q, want PLACED", e.Node.ID, e.Node.Status)
+			}
+			got = append(got, e.Node.ID)
+		}
+		return got, conn.PageInfo.HasNextPage, conn.PageInfo.EndCursor
+	}
+	expect := func(got []string, want ...string) {
+		t.Helper()
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("got orders %v, want %v", got, want)
+		}
+	}
+
+	got, more, end := page(2, nil, nil, "NEWEST_FIRST")
+	expect(got, ids[2], ids[1])
+	if !more || end == nil {
+		t.Fatal("expected a next page")
+	}
+	got, more, _ = page(2, end, nil, "NEWEST_FIRST")
+	expect(got, ids[0])
+	if more {
+		t.Fatal("expected the last page")
+	}
+
+	got, _, _ = page(10, nil, map[string]interface{}{"minTotal": 15, "status": "PLACED"}, "OLDEST_FIRST")
+	expect(got, ids[1], ids[2])
+	got, _, _ = page(10, nil, map[string]interface{}{"placedTo": "2000-01-01T00:00:00Z"}, "OLDEST_FIRST")
+	expect(got)
+
+	// The plain list is the first page of the same history.
+	var first struct {
+		Accounts []struct{ Orders []struct{ ID string } }
+	}
+	err := s.gateway.Post(`query($id: String) {
+		accounts(id: $id) { orders(first: 1, sort: NEWEST_FIRST) { id } }
+	}`, &first, client.Var("id", a.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Accounts) != 1 || len(first.Accounts[0].Orders) != 1 || first.Accounts[0].Orders[0].ID != ids[2] {
+		t.Fatalf("unexpected first page %+v", first)
+	}
+
+	// The client still returns every order to callers that need them all.
+	all, err := s.order.GetOrderForAccount(ctx, a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || all[0].ID != ids[0] {
+		t.Fatalf("unexpected orders %+v", all)
+	}
+
+	bad := "not-a-cursor"
+	var h history
+	err = s.gateway.Post(query, &h, client.Var("id", a.ID), client.Var("first", 2), client.Var("after", &bad), client.Var("filter", nil), client.Var("sort", nil))
+	expectGraphQLError(t, err, "INVALID_CURSOR")
+}
+
+func TestOrderSearch(t *testing.T) {
+	s := startStack(t)
+	ctx := context.Background()
+	ada := s.createAccount(t, "Ada")
+	bob := s.createAccount(t, "Bob")
+	mug := s.createProduct(t, "Mug", 10, nil, nil)
+	pen := s.createProduct(t, "Pen", 2, nil, nil)
+
+	place := func(accountID string, products ...order.OrderedProduct) string {
+		t.Helper()
+		o, err := s.order.PostOrder(ctx, accountID, products, order.Address{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return o.ID
+	}
+	adaMug := place(ada.ID, order.OrderedProduct{ID: mug.ID, Quantity: 5})
+	bobPen := place(bob.ID, order.OrderedProduct{ID: pen.ID, Quantity: 1})
+	bobBoth := place(bob.ID, order.OrderedProduct{ID: mug.ID, Quantity: 1}, order.OrderedProduct{ID: pen.ID, Quantity: 1})
+
+	type result struct {
+		Orders struct {
+			Edges []struct {
+				Node struct {
+					ID        string
+					AccountID string
+				}
+			}
+		}
+	}
+	query := `query($search: OrderSearchInput) {
+		orders(search: $search, sort: NEWEST_FIRST) { edges { node { id accountId } } }
+	}`
+	search := func(filter map[string]interface{}) []string {
+		t.Helper()
+		var r result
+		if err := s.gateway.Post(query, &r, client.Var("search", filter), asAdmin); err != nil {
+			t.Fatal(err)
+		}
+		ids := []string{}
+		for _, e := range r.Orders.Edges {
+			ids = append(ids, e.Node.ID)
+		}
+		return ids
+	}
+	expect := func(got []string, want ...string) {
+		t.Helper()
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("got orders %v, want %v", got, want)
+		}
+	}
+
+	expect(search(nil), bobBoth, bobPen, adaMug)
+	expect(search(map[string]interface{}{"productId": mug.ID}), bobBoth, adaMug)
+	expect(search(map[string]interface{}{"accountId": bob.ID, "productId": pen.ID}), bobBoth, bobPen)
+	expect(search(map[string]interface{}{"minTotal": 5, "maxTotal": 20}), bobBoth)
+	expect(search(map[string]interface{}{"status": "PLACED", "placedFrom": "2999-01-01T00:00:00Z"}))
+
+	// Only staff may search.
+	var r result
+	err := s.gateway.Post(query, &r, client.Var("search", nil))
+	expectGraphQLError(t, err, "ADMIN_REQUIRED")
+	err = s.gateway.Post(query, &r, client.Var("search", nil), client.AddHeader("Authorization", "Bearer wrong"))
+	expectGraphQLError(t, err, "ADMIN_REQUIRED")
+
+	err = s.gateway.Post(query, &r, client.Var("search", map[string]interface{}{"productId": "mug"}), asAdmin)
+	expectGraphQLError(t, err, "INVALID_REQUEST")
+}
+
+func TestInvoices(t *testing.T) {
+	s := startStack(t)
+	a := s.createAccount(t, "Ada")
+	mug := s.createProduct(t, "Mug", 12, nil, nil)
+
+	var placed struct {
+		CreateOrder struct {
+			ID              string
+			InvoiceURL      string
+			ShippingAddress struct {
+				City    string
+				Country string
+			}
+		}
+	}
+	err := s.gateway.Post(`mutation($account: String!, $mug: String!) {
+		createOrder(order: {accountId: $account, products: [{id: $mug, quantity: 2}], shippingAddress: {
+			name: "Ada Lovelace", line1: "12 St James's Square", city: "London", postalCode: "SW1Y 4JH", country: "GB"
+		}}) { id invoiceUrl shippingAddress { city country } }
+	}`, &placed, client.Var("account", a.ID), client.Var("mug", mug.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := placed.CreateOrder
+	if o.ShippingAddress.City != "London" || o.ShippingAddress.Country != "GB" {
+		t.Fatalf("order ships to %+v, want London, GB", o.ShippingAddress)
+	}
+	if !strings.HasPrefix(o.InvoiceURL, "/invoices/"+o.ID+".pdf?") {
+		t.Fatalf("invoice URL is %q", o.InvoiceURL)
+	}
+
+	err = s.gateway.Post(`mutation($account: String!, $mug: String!) {
+		createOrder(order: {accountId: $account, products: [{id: $mug, quantity: 1}], shippingAddress: {
+			name: "Ada", line1: "1 Road", city: "London", country: "uk"
+		}}) { id }
+	}`, &placed, client.Var("account", a.ID), client.Var("mug", mug.ID))
+	expectGraphQLError(t, err, "INVALID_REQUEST")
+
+	get := func(path string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if admin {
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+		}
+		rec := httptest.NewRecorder()
+		s.invoices.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The invoice was issued when the order was confirmed, before anyone
+	// asked for it.
+	issued, err := s.order.GetInvoice(context.Background(), o.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pdf := get(o.InvoiceURL, false)
+	if pdf.Code != http.StatusOK || pdf.Header().Get("Content-Type") != "application/pdf" || !strings.HasPrefix(pdf.Body.String(), "%PDF-") {
+		t.Fatalf("GET %s: %d %s", o.InvoiceURL, pdf.Code, pdf.Header().Get("Content-Type"))
+	}
+	if disposition := pdf.Header().Get("Content-Disposition"); !strings.Contains(disposition, "INV-000001.pdf") {
+		t.Fatalf("first invoice is served as %q, want INV-000001.pdf", disposition)
+	}
+
+	html := get(strings.Replace(o.InvoiceURL, ".pdf?", ".html?", 1), false).Body.String()
+	for _, want := range []string{"INV-000001", "Test Shop", "GB123456789", "Ada", "SW1Y 4JH London", "Mug", "$24.00", "$20.00", "$4.00", "20%"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("invoice HTML does not mention %q", want)
+		}
+	}
+
+	// Order lines keep the name the product had when it was ordered.
+	s.products.mu.Lock()
+	renamed := s.products.products[mug.ID]
+	renamed.Name = "Cup"
+	s.products.products[mug.ID] = renamed
+	s.products.mu.Unlock()
+	var res struct {
+		Accounts []struct {
+			Orders []struct {
+				Products []struct{ Name string }
+			}
+		}
+	}
+	err = s.gateway.Post(`query($id: String) { accounts(id: $id) { orders { products { name } } } }`, &res, client.Var("id", a.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := res.Accounts[0].Orders[0].Products[0].Name; name != "Mug" {
+		t.Fatalf("ordered product is listed as %q, want Mug", name)
+	}
+
+	// Serving the invoice does not change it, and the next order gets the
+	// next number.
+	if issued.Number != 1 || string(issued.PDF) != pdf.Body.String() {
+		t.Fatalf("served invoice differs from the issued %s", issued.Name())
+	}
+	next, err := s.order.PostOrder(context.Background(), a.ID, []order.OrderedProduct{{ID: mug.ID, Quantity: 1}}, order.Address{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inv, err := s.order.GetInvoice(context.Background(), next.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv.Name() != "INV-000002" {
+		t.Fatalf("second invoice is %s, want INV-000002", inv.Name())
+	}
+
+	// Only a signed, unexpired link to the order's own invoice, or an
+	// admin, gets the invoice.
+	expired := invoiceURL([]byte(invoiceKey), o.ID, time.Now().Add(-time.Minute))
+	for path, code := range map[string]int{
+		"/invoices/" + o.ID + ".pdf":                    http.StatusForbidden,
+		strings.Replace(o.InvoiceURL, o.ID, next.ID, 1): http.StatusForbidden,
+		expired: http.StatusForbidden,
+		invoiceURL([]byte("wrong"), o.ID, time.Now().Add(time.Hour)): http.StatusForbidden,
+	} {
+		if rec := get(path, false); rec.Code != code {
+			t.Errorf("GET %s: %d, want %d", path, rec.Code, code)
+		}
+	}
+	for path, code := range map[string]int{
+		"/invoices/" + o.ID + ".pdf":      http.StatusOK,
+		"/invoices/" + unknownID + ".pdf": http.StatusNotFound,
+		"/invoices/" + o.ID + ".txt":      http.StatusNotFound,
+		"/invoices/not-an-id.pdf":         http.StatusBadRequest,
+	} {
+		if rec := get(path, true); rec.Code != code {
+			t.Errorf("GET %s as admin: %d, want %d", path, rec.Code, code)
+		}
+	}
+}
+
+func TestShipments(t *testing.T) {
+	s := startStack(t)
+	ctx := context.Background()
+	const address = "ada@example.com"
+	a, err := s.account.PostAccount(ctx, "Ada", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mug := s.createProduct(t, "Mug", 10, nil, nil)
+	tee, small := s.createTee(t)
+	o, err := s.order.PostOrder(ctx, a.ID, []order.OrderedProduct{
+		{ID: mug.ID, Quantity: 2},
+		{ID: tee.ID, VariantID: small.ID, Quantity: 1},
+	}, order.Address{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.expectMail(t, address, 2)
+
+	type shipment struct {
+		ID          string
+		Status      string
+		TrackingURL *string
+		Lines       []struct {
+			ProductID string
+			VariantID *string
+			Quantity  int
+		}
+		Events []struct {
+			Status   string
+			Location string
+		}
+	}
+	createShipment := func(carrier, tracking string, lines []map[string]interface{}, opts ...client.Option) (shipment, error) {
+		var res struct{ CreateShipment shipment }
+		err := s.gateway.Post(`mutation($shipment: ShipmentInput!) {
+			createShipment(shipment: $shipment) { id status trackingUrl lines { productId variantId quantity } events { status location } }
+		}`, &res, append([]client.Option{client.Var("shipment", map[string]interface{}{
+			"orderId": o.ID, "carrier": carrier, "trackingNumber": tracking, "lines": lines,
+		})}, opts...)...)
+		return res.CreateShipment, err
+	}
+	addEvent := func(shipmentID, status string) (shipment, error) {
+		var res struct{ AddShipmentEvent shipment }
+		err := s.gateway.Post(`mutation($event: ShipmentEventInput!) {
+			addShipmentEvent(event: $event) { id status events { status location } }
+		}`, &res, client.Var("event", map[string]interface{}{
+			"shipmentId": shipmentID, "status": status, "location": "Depot",
+		}), asAdmin)
+		return res.AddShipmentEvent, err
+	}
+	orderStatus := func() string {
+		t.Helper()
+		var res struct {
+			Accounts []struct {
+				Orders []struct {
+					Status    string
+					Shipments []struct{ ID string }
+				}
+			}
+		}
+		err := s.gateway.Post(`query($id: String) { accounts(id: $id) { orders { status shipments { id } } } }`, &res, client.Var("id", a.ID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Accounts[0].Orders[0].Status
+	}
+	if status := orderStatus(); status != "PLACED" {
+		t.Fatalf("new order is %s, want PLACED", status)
+	}
+
+	oneMug := []map[string]interface{}{{"productId": mug.ID, "quantity": 1}}
+	_, err = createShipment("UPS", "1Z001", oneMug)
+	expectGraphQLError(t, err, "ADMIN_REQUIRED")
+
+	first, err := createShipment("UPS", "1Z001", oneMug, asAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Status != "SHIPPED" || first.TrackingURL == nil || !strings.Contains(*first.TrackingURL, "1Z001") || len(first.Events) != 1 {
+		t.Fatalf("unexpected shipment %+v", first)
+	}
+	if status := orderStatus(); status != "PARTIALLY_SHIPPED" {
+		t.Fatalf("order with a mug left to ship is %s, want PARTIALLY_SHIPPED", status)
+	}
+	update := s.expectMail(t, address, 3)
+	if !strings.Contains(update.Body, "1Z001") {
+		t.Fatalf("shipping update does not mention the tracking number: %q", update.Body)
+	}
+
+	_, err = createShipment("UPS", "1Z002", []map[string]interface{}{{"productId": mug.ID, "quantity": 2}}, asAdmin)
+	expectGraphQLError(t, err, "SHIPMENT_QUANTITY_EXCEEDED")
+	_, err = createShipment("UPS", "1Z002", []map[string]interface{}{{"productId": tee.ID, "quantity": 1}}, asAdmin)
+	expectGraphQLError(t, err, "SHIPMENT_LINE_NOT_ON_ORDER")
+
+	second, err := createShipment("Acme Freight", "AF-2", []map[string]interface{}{
+		{"productId": mug.ID, "quantity": 1},
+		{"productId": tee.ID, "variantId": small.ID, "quantity": 1},
+	}, asAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.TrackingURL != nil || len(second.Lines) != 2 {
+		t.Fatalf("unexpected shipment %+v", second)
+	}
+	if status := orderStatus(); status != "SHIPPED" {
+		t.Fatalf("fully shipped order is %s, want SHIPPED", status)
+	}
+
+	for _, status := range []string{"IN_TRANSIT", "DELIVERED"} {
+		if first, err = addEvent(first.ID, status); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if first.Status != "DELIVERED" || len(first.Events) != 3 || first.Events[2].Location != "Depot" {
+		t.Fatalf("unexpected shipment %+v", first)
+	}
+	if status := orderStatus(); status != "SHIPPED" {
+		t.Fatalf("order with a shipment in transit is %s, want SHIPPED", status)
+	}
+	if _, err := addEvent(second.ID, "DELIVERED"); err != nil {
+		t.Fatal(err)
+	}
+	if status := orderStatus(); status != "DELIVERED" {
+		t.Fatalf("order with every shipment delivered is %s, want DELIVERED", status)
+	}
+
+	_, err = addEvent(first.ID, "EXCEPTION")
+	expectGraphQLError(t, err, "SHIPMENT_DELIVERED")
+	_, err = addEvent(second.ID, "SHIPPED")
+	expectGraphQLError(t, err, "INVALID_REQUEST")
+	_, err = addEvent(unknownID, "IN_TRANSIT")
+	expectGraphQLError(t, err, "SHIPMENT_NOT_FOUND")
+}
+
+func TestReviews(t *testing.T) {
+	s := startStack(t)
+	ctx := context.Background()
+	a := s.createAccount(t, "Ada")
+	mug := s.createProduct(t, "Mug", 10, nil, nil)
+
+	var created struct{ CreateReview struct{ ID string } }
+	createReview := `mutation($review: ReviewInput!) { createReview(review: $review) { id } }`
+	reviewVar := client.Var("review", map[string]interface{}{
+		"productId": mug.ID,
+		"accountId": a.ID,
+		"rating":    4,
+		"body":      "Keeps tea hot.",
+	})
+	err := s.gateway.Post(createReview, &created, reviewVar)
+	expectGraphQLError(t, err, "PRODUCT_NOT_PURCHASED")
+	if _, err := s.order.PostOrder(ctx, a.ID, []order.OrderedProduct{{ID: mug.ID, Quantity: 1}}, order.Address{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.gateway.Post(createReview, &created, reviewVar); err != nil {
+		t.Fatal(err)
+	}
+
+	moderate := func(status string, options ...client.Option) error {
+		var res struct{ ModerateReview struct{ Status string } }
+		return s.gateway.Post(`mutation($id: String!, $status: ReviewStatus!) { moderateReview(id: $id, status: $status) { status } }`,
+			&res, append(options, client.Var("id", created.CreateReview.ID), client.Var("status", status))...)
+	}
+	expectRating := func(rating float64, count uint32) {
+		t.Helper()
+		p, err := s.catalog.GetProduct(ctx, mug.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Rating != rating || p.ReviewCount != count {
+			t.Fatalf("rating %v from %d reviews, want %v from %d", p.Rating, p.ReviewCount, rating, count)
+		}
+	}
+
+	expectGraphQLError(t, moderate("APPROVED"), "ADMIN_REQUIRED")
+	expectRating(0, 0)
+	if err := moderate("APPROVED", asAdmin); err != nil {
+		t.Fatal(err)
+	}
+	expectRating(4, 1)
+
+	// A decision the catalog cannot take up at once still stands, and the
+	// rating is sent once the catalog is back.
+	s.products.mu.Lock()
+	s.products.ratingsDown = true
+	s.products.mu.Unlock()
+	if err := moderate("REJECTED", asAdmin); err != nil {
+		t.Fatal(err)
+	}
+	expectRating(4, 1)
+
+	s.products.mu.Lock()
+	s.products.ratingsDown = false
+	s.products.mu.Unlock()
+	err = review.NewService(s.reviews).SyncRatings(ctx, func(ctx context.Context, productID string, rating review.Rating) error {
+		return s.catalog.UpdateProductRating(ctx, productID, rating.Average, rating.Count)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectRating(0, 0)
+	if n := len(s.reviews.ratingChanges); n != 0 {
+		t.Fatalf("%d rating changes left to send, want 0", n)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	s := startStack(t)
+	ctx := context.Background()
+	a := s.createAccount(t, "Ada")
+	mug := s.createProduct(t, "Mug", 10, nil, nil)
+
+	// The services reject invalid requests from any client.
+	_, err := s.account.PostAccount(ctx, strings.Repeat("a", 25), "ada")
+	expectViolations(t, err, "name", "email")
+
+	_, err = s.catalog.PostProduct(ctx, "Mug", "", -1, nil, []catalog.Variant{{SKU: "MUG"}, {SKU: "MUG"}})
+	expectViolations(t, err, "price", "variants[1]")
+
+	_, err = s.order.PostOrder(ctx, a.ID, nil, order.Address{})
+	expectViolations(t, err, "products")
+
+	_, err = s.order.PostOrder(ctx, a.ID, []order.OrderedProduct{
+		{ID: mug.ID, Quantity: 0},
+		{ID: mug.ID, Quantity: 1},
+	}, order.Address{})
+	expectViolations(t, err, "products[0].quantity", "products[1]")
+
+	// The gateway rejects the same input before calling them.
+	var created struct{ CreateAccount struct{ ID string } }
+	err = s.gateway.Post(`mutation { createAccount(account: {name: ""}) { id } }`, &created)
+	expectGraphQLError(t, err, "INVALID_REQUEST")
+	if !strings.Contains(err.Error(), `"field":"account.name"`) {
+		t.Fatalf("expected a violation of account.name, got %v", err)
+	}
+
+	var placed struct{ CreateOrder struct{ ID string } }
+	err = s.gateway.Post(`mutation($account: String!, $mug: String!) {
+		createOrder(order: {accountId: $account, products: [{id: $mug, quantity: 1}, {id: $mug, quantity: 2}]}) { id }
+	}`, &placed, client.Var("account", a.ID), client.Var("mug", mug.ID))
+	expectGraphQLError(t, err, "INVALID_REQUEST")
+	if !strings.Contains(err.Error(), `"field":"order.products"`) {
+		t.Fatalf("expected a violation of order.products, got %v", err)
+	}
+}
+
+// expectViolations fails the test unless err is validation.ErrInvalidRequest
+// listing exactly the violated fields.
+func expectViolations(t *testing.T, err error, fields ...string) {
+	t.Helper()
+	var e *errs.Error
+	if !errors.As(err, &e) || !errors.Is(e, validation.ErrInvalidRequest) {
+		t.Fatalf("expected INVALID_REQUEST error, got %v", err)
+	}
+	got := []string{}
+	for _, v := range e.Violations {
+		got = append(got, v.Field)
+	}
+	if strings.Join(got, ",") != strings.Join(fields, ",") {
+		t.Fatalf("violations of %v, want %v (%v)", got, fields, err)
+	}
+}
+
+func TestReturns(t *testing.T) {
+	s := startStack(t)
+	ctx := context.Background()
+	a := s.createAccount(t, "Ada")
+	mug := s.createProduct(t, "Mug", 10, nil, nil)
+	tee, small := s.createTee(t)
+	o, err := s.order.PostOrder(ctx, a.ID, []order.OrderedProduct{
+		{ID: mug.ID, Quantity: 2},
+		{ID: tee.ID, VariantID: small.ID, Quantity: 2},
+	}, order.Address{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.expectStock(t, tee.ID, 3)
+
+	type ret struct {
+		ID           string
+		Status       string
+		Note         string
+		RefundAmount float64
+		Lines        []struct {
+			ProductID string
+			VariantID *string
+			Quantity  int
+		}
+	}
+	requestReturn := func(lines []map[string]interface{}) (ret, error) {
+		var res struct{ RequestReturn ret }
+		err := s.gateway.Post(`mutation($request: ReturnInput!) {
+			requestReturn(request: $request) { id status note refundAmount lines { productId variantId quantity } }
+		}`, &res, client.Var("request", map[string]interface{}{
+			"orderId": o.ID, "reason": "Too small", "lines": lines,
+		}))
+		return res.RequestReturn, err
+	}
+	resolve := func(mutation, id string, opts ...client.Option) (ret, error) {
+		var res map[string]ret
+		err := s.gateway.Post(`mutation($id: String!) { `+mutation+`(id: $id) { id status note refundAmount } }`,
+			&res, append([]client.Option{client.Var("id", id)}, opts...)...)
+		return res[mutation], err
+	}
+	history := func() (returns []ret, refunds []struct {
+		ReturnID string
+		Amount   float64
+	}) {
+		t.Helper()
+		var res struct {
+			Accounts []struct {
+				Orders []struct {
+					Returns []ret
+					Refunds []struct {
+						ReturnID string
+						Amount   float64
+					}
+				}
+			}
+		}
+		err := s.gateway.Post(`query($id: String) { accounts(id: $id) { orders { returns { id status } refunds { returnId amount } } } }`, &res, client.Var("id", a.ID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Accounts[0].Orders[0].Returns, res.Accounts[0].Orders[0].Refunds
+	}
+
+	twoTees := []map[string]interface{}{{"productId": tee.ID, "variantId": small.ID, "quantity": 2}}
+	_, err = requestReturn(twoTees)
+	expectGraphQLError(t, err, "RETURN_QUANTITY_EXCEEDED")
+
+	_, err = s.order.CreateShipment(ctx, o.ID, "UPS", "1Z001", []order.ShipmentLine{
+		{ProductID: mug.ID, Quantity: 1},
+		{ProductID: tee.ID, VariantID: small.ID, Quantity: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = requestReturn([]map[string]interface{}{{"productId": mug.ID, "quantity": 2}})
+	expectGraphQLError(t, err, "RETURN_QUANTITY_EXCEEDED")
+	_, err = requestReturn([]map[string]interface{}{{"productId": tee.ID, "quantity": 1}})
+	expectGraphQLError(t, err, "RETURN_LINE_NOT_ON_ORDER")
+
+	tees, err := requestReturn(twoTees)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tees.Status != "REQUESTED" || tees.RefundAmount != 36 || len(tees.Lines) != 1 {
+		t.Fatalf("unexpected return %+v", tees)
+	}
+	_, err = requestReturn([]map[string]interface{}{{"productId": tee.ID, "variantId": small.ID, "quantity": 1}})
+	expectGraphQLError(t, err, "RETURN_QUANTITY_EXCEEDED")
+
+	_, err = resolve("receiveReturn", tees.ID, asAdmin)
+	expectGraphQLError(t, err, "RETURN_STATUS_INVALID")
+	_, err = resolve("approveReturn", tees.ID)
+	expectGraphQLError(t, err, "ADMIN_REQUIRED")
+	s.expectStock(t, tee.ID, 3)
+
+	approved, err := resolve("approveReturn", tees.ID, asAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if approved.Status != "APPROVED" {
+		t.Fatalf("approved return is %s, want APPROVED", approved.Status)
+	}
+	s.expectStock(t, tee.ID, 5)
+	_, err = resolve("approveReturn", tees.ID, asAdmin)
+	expectGraphQLError(t, err, "RETURN_STATUS_INVALID")
+	s.expectStock(t, tee.ID, 5)
+
+	received, err := resolve("receiveReturn", tees.ID, asAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received.Status != "RECEIVED" {
+		t.Fatalf("received return is %s, want RECEIVED", received.Status)
+	}
+
+	oneMug := []map[string]interface{}{{"productId": mug.ID, "quantity": 1}}
+	mugs, err := requestReturn(oneMug)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rejected struct{ RejectReturn ret }
+	err = s.gateway.Post(`mutation($id: String!) { rejectReturn(id: $id, note: "Used") { status note } }`,
+		&rejected, client.Var("id", mugs.ID), asAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rejected.RejectReturn.Status != "REJECTED" || rejected.RejectReturn.Note != "Used" {
+		t.Fatalf("unexpected rejected return %+v", rejected.RejectReturn)
+	}
+	mugs, err = requestReturn(oneMug)
+	if err != nil {
+		t.Fatalf("rejected return did not free its lines: %v", err)
+	}
+
+	// An approval that fails to restock leaves the return approving, where
+	// it can no longer be rejected, and approving it again finishes it.
+	restockDown := errs.New(errs.Unavailable, "CATALOG_DOWN", "catalog is down")
+	_, err = order.NewService(s.orders, order.NewPaymentLimit(paymentLimit), invoiceConfig).ApproveReturn(ctx, mugs.ID, "", func(ctx context.Context, rt order.Return) error {
+		return restockDown
+	})
+	if !errors.Is(err, restockDown) {
+		t.Fatalf("approving with a failing restock: %v", err)
+	}
+	_, err = resolve("rejectReturn", mugs.ID, asAdmin)
+	expectGraphQLError(t, err, "RETURN_STATUS_INVALID")
+	approved, err = resolve("approveReturn", mugs.ID, asAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if approved.Status != "APPROVED" {
+		t.Fatalf("return approved again is %s, want APPROVED", approved.Status)
+	}
+
+	returns, refunds := history()
+	if len(returns) != 3 || returns[0].Status != "RECEIVED" || returns[1].Status != "REJECTED" || returns[2].Status != "APPROVED" {
+		t.Fatalf("unexpected returns %+v", returns)
+	}
+	if len(refunds) != 2 || refunds[0].ReturnID != tees.ID || refunds[0].Amount != 36 || refunds[1].ReturnID != mugs.ID {
+		t.Fatalf("unexpected refunds %+v", refunds)
+	}
+
+	_, err = resolve("rejectReturn", unknownID, asAdmin)
+	expectGraphQLError(t, err, "RETURN_NOT_FOUND")
+}
+
+func TestSubscriptions(t *testing.T) {
+	s := startStack(t)
+	ctx := context.Background()
+	a := s.createAccount(t, "Ada")
+	mug := s.createProduct(t, "Mug", 10, nil, nil)
+	tee, small := s.createTee(t)
+	// Far enough ahead that the scheduler of the order server never finds
+	// the subscription due; the test runs its own against chosen times.
+	start := time.Date(2030, time.January, 31, 9, 0, 0, 0, time.UTC)
+	scheduler := order.NewScheduler(order.NewService(s.orders, order.NewPaymentLimit(paymentLimit), invoiceConfig), s.order.PostOrderOnce)
+	runDue := func(now time.Time) []order.Subscription {
+		t.Helper()
+		subs, err := scheduler.RunDue(ctx, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return subs
+	}
+
+	type subscription struct {
+		ID          string
+		Status      string
+		NextRunAt   string
+		Failures    int
+		LastFailure *string
+		RetryAt     *string
+		LastOrderID *string
+		Lines       []struct {
+			ProductID string
+			VariantID *string
+			Quantity  int
+		}
+	}
+	create := func(accountID string) (subscription, error) {
+		var res struct{ CreateSubscription subscription }
+		err := s.gateway.Post(`mutation($subscription: SubscriptionInput!) {
+			createSubscription(subscription: $subscription) { id status nextRunAt failures lines { productId variantId quantity } }
+		}`, &res, client.Var("subscription", map[string]interface{}{
+			"accountId": accountID,
+			"lines": []map[string]interface{}{
+				{"productId": mug.ID, "quantity": 1},
+				{"productId": tee.ID, "variantId": small.ID, "quantity": 2},
+			},
+			"intervalUnit":  "MONTH",
+			"intervalCount": 1,
+			"startsAt":      start.Format(time.RFC3339),
+		}))
+		return res.CreateSubscription, err
+	}
+	move := func(mutation, id string) (subscription, error) {
+		var res map[string]subscription
+		err := s.gateway.Post(`mutation($id: String!) { `+mutation+`(id: $id) { id status nextRunAt failures } }`, &res, client.Var("id", id))
+		return res[mutation], err
+	}
+	listed := func() subscription {
+		t.Helper()
+		var res struct {
+			Accounts []struct {
+				Subscriptions []subscription
+			}
+		}
+		err := s.gateway.Post(`query($id: String) { accounts(id: $id) {
+			subscriptions { id status nextRunAt failures lastFailure retryAt lastOrderId }
+		} }`, &res, client.Var("id", a.ID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Accounts[0].Subscriptions) != 1 {
+			t.Fatalf("expected one subscription, got %+v", res.Accounts[0].Subscriptions)
+		}
+		return res.Accounts[0].Subscriptions[0]
+	}
+
+	_, err := create(unknownID)
+	expectGraphQLError(t, err, "ACCOUNT_NOT_FOUND")
+
+	sub, err := create(a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Status != "ACTIVE" || sub.NextRunAt != start.Format(time.RFC3339) || len(sub.Lines) != 2 {
+		t.Fatalf("unexpected subscription %+v", sub)
+	}
+
+	if subs := runDue(start.Add(-time.Hour)); len(subs) != 0 {
+		t.Fatalf("ran %d subscriptions before they were due", len(subs))
+	}
+	subs := runDue(start)
+	if len(subs) != 1 || subs[0].LastOrderID == "" || subs[0].Failures != 0 {
+		t.Fatalf("unexpected run %+v", subs)
+	}
+	// January 31st runs again on the last day of February.
+	february := time.Date(2030, time.February, 28, 9, 0, 0, 0, time.UTC)
+	if !subs[0].NextRunAt.Equal(february) {
+		t.Fatalf("next run at %v, want %v", subs[0].NextRunAt, february)
+	}
+	orders, err := s.order.GetOrderForAccount(ctx, a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 1 || orders[0].ID != subs[0].LastOrderID || orders[0].TotalPrice != 46 {
+		t.Fatalf("unexpected orders %+v", orders)
+	}
+	s.expectStock(t, tee.ID, 3)
+
+	if subs := runDue(february); len(subs) != 1 || subs[0].Failures != 0 {
+		t.Fatalf("unexpected run %+v", subs)
+	}
+	s.expectStock(t, tee.ID, 1)
+
+	// With one tee left, the March order fails until it is skipped.
+	march := time.Date(2030, time.March, 31, 9, 0, 0, 0, time.UTC)
+	subs = runDue(march)
+	if len(subs) != 1 || subs[0].Failures != 1 || subs[0].LastFailure != "OUT_OF_STOCK" || !subs[0].NextRunAt.Equal(march) {
+		t.Fatalf("unexpected failed run %+v", subs)
+	}
+	retryAt := subs[0].DueAt
+	if !retryAt.Equal(march.Add(time.Hour)) {
+		t.Fatalf("retry at %v, want an hour after the failure", retryAt)
+	}
+	got := listed()
+	if got.Failures != 1 || got.LastFailure == nil || *got.LastFailure != "OUT_OF_STOCK" || got.RetryAt == nil || *got.RetryAt != retryAt.Format(time.RFC3339) {
+		t.Fatalf("unexpected failing subscription %+v", got)
+	}
+	if subs := runDue(retryAt.Add(-time.Minute)); len(subs) != 0 {
+		t.Fatalf("retried %d subscriptions early", len(subs))
+	}
+	for failures := 2; failures < 5; failures++ {
+		subs = runDue(retryAt)
+		if len(subs) != 1 || subs[0].Failures != uint32(failures) {
+			t.Fatalf("unexpected retry %+v", subs)
+		}
+		retryAt = subs[0].DueAt
+	}
+	subs = runDue(retryAt)
+	april := time.Date(2030, time.April, 30, 9, 0, 0, 0, time.UTC)
+	if len(subs) != 1 || subs[0].Failures != 0 || !subs[0].NextRunAt.Equal(april) || subs[0].LastFailure != "OUT_OF_STOCK" {
+		t.Fatalf("failing run was not skipped: %+v", subs)
+	}
+	if orders, _ := s.order.GetOrderForAccount(ctx, a.ID); len(orders) != 2 {
+		t.Fatalf("expected the two orders that were placed, got %d", len(orders))
+	}
+
+	paused, err := move("pauseSubscription", sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paused.Status != "PAUSED" {
+		t.Fatalf("paused subscription is %s", paused.Status)
+	}
+	if subs := runDue(april); len(subs) != 0 {
+		t.Fatalf("ran %d paused subscriptions", len(subs))
+	}
+	_, err = move("pauseSubscription", sub.ID)
+	expectGraphQLError(t, err, "SUBSCRIPTION_STATUS_INVALID")
+	resumed, err := move("resumeSubscription", sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Status != "ACTIVE" || resumed.NextRunAt != april.Format(time.RFC3339) {
+		t.Fatalf("unexpected resumed subscription %+v", resumed)
+	}
+
+	cancelled, err := move("cancelSubscription", sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.Status != "CANCELLED" {
+		t.Fatalf("cancelled subscription is %s", cancelled.Status)
+	}
+	if subs := runDue(april); len(subs) != 0 {
+		t.Fatalf("ran %d cancelled subscriptions", len(subs))
+	}
+	for _, mutation := range []string{"cancelSubscription", "resumeSubscription"} {
+		_, err = move(mutation, sub.ID)
+		expectGraphQLError(t, err, "SUBSCRIPTION_STATUS_INVALID")
+	}
+	_, err = move("pauseSubscription", unknownID)
+	expectGraphQLError(t, err, "SUBSCRIPTION_NOT_FOUND")
+}
+
+// TestSubscriptionPendingRun checks that a run whose order is still being
+// placed is taken up again with the same idempotency key, so that once the
+// saga completes the run records that order instead of placing another.
+func TestSubscriptionPendingRun(t *testing.T) {
+	s := startStack(t)
+	ctx := context.Background()
+	a := s.createAccount(t, "Ada")
+	mug := s.createProduct(t, "Mug", 10, nil, nil)
+	start := time.Date(2030, time.January, 31, 9, 0, 0, 0, time.UTC)
+	sub, err := s.order.CreateSubscription(ctx, a.ID, []order.SubscriptionLine{{ProductID: mug.ID, Quantity: 1}}, order.Address{}, order.Interval{Unit: order.IntervalMonth, Count: 1}, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first attempt stops with its saga still running, as when the
+	// order server stops part way through placing the order.
+	var keys []string
+	pending := true
+	place := func(ctx context.Context, idempotencyKey string, accountID string, products []order.OrderedProduct, shippingAddress order.Address) (*order.Order, error) {
+		keys = append(keys, idempotencyKey)
+		if !pending {
+			return s.order.PostOrderOnce(ctx, idempotencyKey, accountID, products, shippingAddress)
+		}
+		pending = false
+		o := order.Order{
+			ID:         order.IdempotentOrderID(accountID, idempotencyKey),
+			CreatedAt:  time.Now().UTC(),
+			AccountID:  accountID,
+			TotalPrice: 10,
+			Products:   []order.OrderedProduct{{ID: mug.ID, Name: "Mug", Price: 10, Quantity: 1}},
+		}
+		sg := order.Saga{Order: o, Step: order.StepValidateAccount, Status: order.SagaRunning, UpdatedAt: time.Now().Add(-time.Hour)}
+		if err := s.orders.CreateSaga(ctx, sg); err != nil {
+			t.Fatal(err)
+		}
+		return nil, order.ErrPlacementPending.With("id", o.ID)
+	}
+	scheduler := order.NewScheduler(order.NewService(s.orders, order.NewPaymentLimit(paymentLimit), invoiceConfig), place)
+
+	subs, err := scheduler.RunDue(ctx, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 0 {
+		t.Fatalf("recorded a run whose order is still being placed: %+v", subs)
+	}
+	orderID := order.IdempotentOrderID(a.ID, keys[0])
+
+	// Recovery completes the saga, and the run is taken up again once its
+	// lease runs out.
+	s.serveOrder()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.orders.saga(orderID).Status != order.SagaCompleted {
+		if time.Now().After(deadline) {
+			t.Fatalf("saga not resumed: %+v", s.orders.saga(orderID))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	subs, err = scheduler.RunDue(ctx, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 1 || subs[0].ID != sub.ID || subs[0].LastOrderID != orderID || subs[0].Failures != 0 {
+		t.Fatalf("unexpected run %+v", subs)
+	}
+	if len(keys) != 2 || keys[1] != keys[0] {
+		t.Fatalf("run retried with keys %q, want the same key twice", keys)
+	}
+	orders, err := s.order.GetOrderForAccount(ctx, a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 1 || orders[0].ID != orderID {
+		t.Fatalf("account has orders %+v, want only %s", orders, orderID)
+	}
+}
+
+func TestOrderSagaCompensation(t *testing.T) {
+	s := startStack(t)
+	ctx := context.Background()
+	a := s.createAccount(t, "Ada")
+	mug := s.createProduct(t, "Mug", 10, nil, nil)
+	tee, small := s.createTee(t)
+
+	_, err := s.order.PostOrder(ctx, a.ID, []order.OrderedProduct{
+		{ID: mug.ID, Quantity: 1},
+		{ID: tee.ID, VariantID: small.ID, Quantity: 2},
+	}, order.Address{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.expectStock(t, tee.ID, 3)
+
+	failures := []struct {
+		name      string
+		accountID string
+		products  []order.OrderedProduct
+		err       error
+	}{
+		{"unknown account", unknownID, []order.OrderedProduct{{ID: tee.ID, VariantID: small.ID, Quantity: 1}}, account.ErrNotFound},
+		{"out of stock", a.ID, []order.OrderedProduct{{ID: mug.ID, Quantity: 1}, {ID: tee.ID, VariantID: small.ID, Quantity: 4}}, catalog.ErrOutOfStock},
+		{"payment declined", a.ID, []order.OrderedProduct{{ID: tee.ID, VariantID: small.ID, Quantity: 1}, {ID: mug.ID, Quantity: 100}}, order.ErrPaymentDeclined},
+	}
+	for _, f := range failures {
+		if _, err := s.order.PostOrder(ctx, f.accountID, f.products, order.Address{}); !errors.Is(err, f.err) {
+			t.Fatalf("%s: got %v, want %v", f.name, err, f.err)
+		}
+		// Whatever the saga reserved before failing was given back.
+		s.expectStock(t, tee.ID, 3)
+	}
+
+	orders, err := s.order.GetOrderForAccount(ctx, a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 1 {
+		t.Fatalf("account has %d orders, want only the one that was placed", len(orders))
+	}
+}
+
+func TestPostOrderOnce(t *testing.T) {
+	s := startStack(t)
+	ctx := context.Background()
+	a := s.createAccount(t, "Ada")
+	b := s.createAccount(t, "Bob")
+	tee, small := s.createTee(t)
+	tees := []order.OrderedProduct{{ID: tee.ID, VariantID: small.ID, Quantity: 2}}
+
+	first, err := s.order.PostOrderOnce(ctx, "weekly", a.ID, tees, order.Address{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := s.order.PostOrderOnce(ctx, "weekly", a.ID, tees, order.Address{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != first.ID {
+		t.Fatalf("posting again placed order %s, want %s", again.ID, first.ID)
+	}
+	s.expectStock(t, tee.ID, 3)
+
+	// Keys are per account.
+	other, err := s.order.PostOrderOnce(ctx, "weekly", b.ID, tees, order.Address{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.ID == first.ID {
+		t.Fatalf("another account got order %s back", first.ID)
+	}
+	s.expectStock(t, tee.ID, 1)
+
+	tooMany := []order.OrderedProduct{{ID: tee.ID, VariantID: small.ID, Quantity: 2}}
+	if _, err := s.order.PostOrderOnce(ctx, "big", a.ID, tooMany, order.Address{}); !errors.Is(err, catalog.ErrOutOfStock) {
+		t.Fatalf("got %v, want %v", err, catalog.ErrOutOfStock)
+	}
+	if _, err := s.order.PostOrderOnce(ctx, "big", a.ID, tooMany, order.Address{}); !errors.Is(err, order.ErrPlacementFailed) {
+		t.Fatalf("got %v, want %v", err, order.ErrPlacementFailed)
+	}
+
+	orders, err := s.order.GetOrderForAccount(ctx, a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 1 {
+		t.Fatalf("account has %d orders, want 1", len(orders))
+	}
+}
+
+func TestOrderSagaResumption(t *testing.T) {
+	s := startStack(t)
+	ctx := context.Background()
+	a := s.createAccount(t, "Ada")
+	tee, small := s.createTee(t)
+
+	// A replica crashed with one order through reserve_stock and another
+	// half way through compensating.
+	abandoned := func(quantity uint64, step, status string) string {
+		o := order.Order{
+			ID:         ksuid.New().String(),
+			CreatedAt:  time.Now().UTC(),
+			AccountID:  a.ID,
+			TotalPrice: 18 * float64(quantity),
+			Products:   []order.OrderedProduct{{ID: tee.ID, VariantID: small.ID, SKU: small.SKU, Price: 18, Quantity: quantity}},
+		}
+		if err := s.catalog.ReserveStock(ctx, o.ID, []catalog.StockItem{{ProductID: tee.ID, VariantID: small.ID, Quantity: uint32(quantity)}}); err != nil {
+			t.Fatal(err)
+		}
+		sg := order.Saga{Order: o, Step: step, Status: status, UpdatedAt: time.Now().Add(-time.Hour)}
+		if status == order.SagaCompensating {
+			sg.Failure = "PAYMENT_DECLINED"
+		}
+		if err := s.orders.CreateSaga(ctx, sg); err != nil {
+			t.Fatal(err)
+		}
+		return o.ID
+	}
+	completing := abandoned(2, order.StepAuthorizePayment, order.SagaRunning)
+	compensating := abandoned(1, order.StepReserveStock, order.SagaCompensating)
+	s.expectStock(t, tee.ID, 2)
+
+	s.serveOrder()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for s.orders.saga(completing).Status != order.SagaCompleted || s.orders.saga(compensating).Status != order.SagaFailed {
+		if time.Now().After(deadline) {
+			t.Fatalf("sagas not resumed: %+v, %+v", s.orders.saga(completing), s.orders.saga(compensating))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	orders, err := s.order.GetOrderForAccount(ctx, a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 1 || orders[0].ID != completing {
+		t.Fatalf("account has orders %+v, want only %s", orders, completing)
+	}
+	// The completed order keeps its reservation; the compensated one
+	// gave its item back.
+	s.expectStock(t, tee.ID, 3)
+}
+
+// expectStock fails the test unless the first variant of productID has
+// stock left.
+func (s *stack) expectStock(t *testing.T, productID string, stock uint32) {
+	t.Helper()
+	p, err := s.catalog.GetProduct(context.Background(), productID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Variants[0].Stock != stock {
+		t.Fatalf("stock of %s is %d, want %d", p.Variants[0].SKU, p.Variants[0].Stock, stock)
+	}
+}
+
+// waitFor polls cond until it holds, failing the test after a few seconds.
+// Notifications travel through the outbox relay and the delivery loop,
+// which run in the background.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// expectMail waits for the n-th email to address and returns it.
+func (s *stack) expectMail(t *testing.T, address string, n int) notification.Message {
+	t.Helper()
+	waitFor(t, fmt.Sprintf("email %d to %s", n, address), func() bool {
+		return len(s.mail.to(address)) >= n
+	})
+	return s.mail.to(address)[n-1]
+}
+
+func TestNotifications(t *testing.T) {
+	s := startStack(t)
+	ctx := context.Background()
+	const address = "ada@example.com"
+
+	var created struct {
+		CreateAccount struct {
+			ID                      string
+			Email                   string
+			NotificationPreferences struct {
+				OrderConfirmations bool
+				ShippingUpdates    bool
+			}
+		}
+	}
+	err := s.gateway.Post(`mutation($email: String) {
+		createAccount(account: {name: "Ada", email: $email}) {
+			id email notificationPreferences { orderConfirmations shippingUpdates }
+		}
+	}`, &created, client.Var("email", address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := created.CreateAccount
+	if a.Email != address || !a.NotificationPreferences.OrderConfirmations || !a.NotificationPreferences.ShippingUpdates {
+		t.Fatalf("unexpected new account %+v", a)
+	}
+
+	welcome := s.expectMail(t, address, 1)
+	if welcome.Subject != "Welcome, Ada!" {
+		t.Fatalf("welcome email has subject %q", welcome.Subject)
+	}
+
+	tee, small := s.createTee(t)
+	o, err := s.order.PostOrder(ctx, a.ID, []order.OrderedProduct{{ID: tee.ID, VariantID: small.ID, Quantity: 2}}, order.Address{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	confirmation := s.expectMail(t, address, 2)
+	if !strings.Contains(confirmation.Subject, o.ID) {
+		t.Fatalf("confirmation subject %q does not name order %s", confirmation.Subject, o.ID)
+	}
+	for _, want := range []string{"2 x Tee (TEE-S)", "$18.00 each", "Total: $36.00"} {
+		if !strings.Contains(confirmation.Body, want) {
+			t.Fatalf("confirmation body lacks %q:\n%s", want, confirmation.Body)
+		}
+	}
+
+	var updated struct {
+		UpdateNotificationPreferences struct {
+			OrderConfirmations bool
+			ShippingUpdates    bool
+		}
+	}
+	err = s.gateway.Post(`mutation($account: String!) {
+		updateNotificationPreferences(accountId: $account, preferences: {orderConfirmations: false, shippingUpdates: true}) {
+			orderConfirmations shippingUpdates
+		}
+	}`, &updated, client.Var("account", a.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.UpdateNotificationPreferences.OrderConfirmations || !updated.UpdateNotificationPreferences.ShippingUpdates {
+		t.Fatalf("unexpected preferences %+v", updated.UpdateNotificationPreferences)
+	}
+
+	if _, err := s.order.PostOrder(ctx, a.ID, []order.OrderedProduct{{ID: tee.ID, VariantID: small.ID, Quantity: 1}}, order.Address{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the second confirmation to be skipped", func() bool {
+		for _, n := range s.notifications.find(a.ID, "order_confirmation") {
+			if n.Status == notification.StatusSkipped {
+				return true
+			}
+		}
+		return false
+	})
+	if sent := len(s.mail.to(address)); sent != 2 {
+		t.Fatalf("%d emails sent to %s, want 2", sent, address)
+	}
+
+	err = s.gateway.Post(`mutation($account: String!) {
+		updateNotificationPreferences(accountId: $account, preferences: {orderConfirmations: true, shippingUpdates: true}) { orderConfirmations }
+	}`, &updated, client.Var("account", unknownID))
+	expectGraphQLError(t, err, "ACCOUNT_NOT_FOUND")
+
+	// Accounts without an email address get nothing, but their events are
+	// still recorded as handled.
+	silent := s.createAccount(t, "Bob")
+	waitFor(t, "Bob's welcome to be skipped", func() bool {
+		found := s.notifications.find(silent.ID, "welcome")
+		return len(found) == 1 && found[0].Status == notification.StatusSkipped
+	})
+}
+
+func TestNotificationDelivery(t *testing.T) {
+	s := startStack(t)
+	ctx := context.Background()
+	const address = "ada@example.com"
+
+	a, err := s.account.PostAccount(ctx, "Ada", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.expectMail(t, address, 1)
+
+	shipped, err := events.New(events.OrderShipped, a.ID, events.OrderShippedPayload{
+		OrderID:        unknownID,
+		Carrier:        "UPS",
+		TrackingNumber: "1Z999",
+		ShippedAt:      time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first attempt fails and is retried after a backoff.
+	s.mail.fail(1, false)
+	if err := s.events.Publish(ctx, shipped); err != nil {
+		t.Fatal(err)
+	}
+	// A relay may deliver an event more than once.
+	if err := s.events.Publish(ctx, shipped); err != nil {
+		t.Fatal(err)
+	}
+
+	var n notification.Notification
+	waitFor(t, "the first attempt to fail", func() bool {
+		found := s.notifications.find(a.ID, "shipping_update")
+		if len(found) != 1 {
+			return false
+		}
+		n = found[0]
+		return n.Attempts == 1
+	})
+	if n.Status != notification.StatusPending || n.LastError == "" || time.Until(n.NextAttemptAt) < 20*time.Second {
+		t.Fatalf("failed notification was not rescheduled: %+v", n)
+	}
+
+	s.notifications.makeDue()
+	update := s.expectMail(t, address, 2)
+	if update.Subject != "Your order "+unknownID+" has shipped" || !strings.Contains(update.Body, "Tracking number: 1Z999") {
+		t.Fatalf("unexpected shipping update %+v", update)
+	}
+	if found := s.notifications.find(a.ID, "shipping_update"); len(found) != 1 || found[0].Status != notification.StatusSent || found[0].Attempts != 2 {
+		t.Fatalf("unexpected notifications %+v", found)
+	}
+
+	// A mail server that rejects the message for good is not retried.
+	s.mail.fail(1, true)
+	again, err := events.New(events.OrderShipped, a.ID, events.OrderShippedPayload{OrderID: unknownID, Carrier: "UPS", TrackingNumber: "1Z998"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.events.Publish(ctx, again); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the rejected notification to fail", func() bool {
+		for _, n := range s.notifications.find(a.ID, "shipping_update") {
+			if n.EventID == again.ID {
+				return n.Status == notification.StatusFailed && n.Attempts == 1
+			}
+		}
+		return false
+	})
+	if sent := len(s.mail.to(address)); sent != 2 {
+		t.Fatalf("%d emails sent to %s, want 2", sent, address)
+	}
+}
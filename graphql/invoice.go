@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sunil8777/E-commerce-microservices/errs"
+)
+
+// invoicePath is where invoices are served, as <order id>.pdf or
+// <order id>.html.
+const invoicePath = "/invoices/"
+
+// invoiceLinkTTL is how long the invoice link handed out with an order
+// stays valid.
+const invoiceLinkTTL = 24 * time.Hour
+
+var invoiceContentTypes = map[string]string{
+	"pdf":  "application/pdf",
+	"html": "text/html; charset=utf-8",
+}
+
+// invoiceURL returns the link to the invoice of the order as PDF, valid
+// until expires. Whoever the gateway handed the order to can follow it, as
+// it is signed with key; without a key it is not signed, and only admins
+// can follow it.
+func invoiceURL(key []byte, orderID string, expires time.Time) string {
+	path := invoicePath + orderID + ".pdf"
+	if len(key) == 0 {
+		return path
+	}
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	q.Set("signature", invoiceSignature(key, orderID, expires.Unix()))
+	return path + "?" + q.Encode()
+}
+
+func invoiceSignature(key []byte, orderID string, expires int64) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s.%d", orderID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validInvoiceLink reports whether q signs an unexpired link to the invoice
+// of the order. It serves either format of the invoice.
+func validInvoiceLink(key []byte, orderID string, q url.Values, now time.Time) bool {
+	if len(key) == 0 {
+		return false
+	}
+	expires, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+	if err != nil || now.Unix() > expires {
+		return false
+	}
+	want := invoiceSignature(key, orderID, expires)
+	return hmac.Equal([]byte(q.Get("signature")), []byte(want))
+}
+
+// invoiceHandler serves the invoice of an order to admins and to links
+// signed by invoiceURL.
+func (s *Server) invoiceHandler() http.Handler {
+	return withRequestID(withAdmin(s.adminToken, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		orderID, format, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, invoicePath), ".")
+		contentType, ok := invoiceContentTypes[format]
+		if !ok || orderID == "" {
+			http.NotFound(w, r)
+			return
+		}
+		if !isAdmin(r.Context()) && !validInvoiceLink(s.invoiceKey, orderID, r.URL.Query(), time.Now()) {
+			http.Error(w, "invoice link is invalid or has expired", http.StatusForbidden)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		inv, err := s.orderClient.GetInvoice(ctx, orderID)
+		if err != nil {
+			log.Println(err)
+			e := errs.From(err)
+			http.Error(w, e.Message, invoiceErrorStatus(e.Code))
+			return
+		}
+
+		body := inv.PDF
+		if format == "html" {
+			body = inv.HTML
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", `inline; filename="`+inv.Name()+"."+format+`"`)
+		w.Write(body)
+	})))
+}
+
+func invoiceErrorStatus(code errs.Code) int {
+	switch code {
+	case errs.InvalidArgument:
+		return http.StatusBadRequest
+	case errs.NotFound:
+		return http.StatusNotFound
+	case errs.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case errs.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadGateway
+	}
+}
@@ -1,34 +1,87 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 
-	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/kelseyhightower/envconfig"
+	"github.com/sunil8777/E-commerce-microservices/grpcopts"
+	"github.com/sunil8777/E-commerce-microservices/metrics"
+	"github.com/sunil8777/E-commerce-microservices/shutdown"
+	"github.com/sunil8777/E-commerce-microservices/tlsconfig"
+	"github.com/sunil8777/E-commerce-microservices/tracing"
 )
 
 type AppConfig struct {
-	AccountURL string `envconfig:"ACCOUNT_SERVICE_URL"`
-	CatalogURL string `envconfig:"CATALOG_SERVICE_URL"`
-	OrderURL   string `envconfig:"ORDER_SERVICE_URL"`
+	AccountURL      string `envconfig:"ACCOUNT_SERVICE_URL"`
+	CatalogURL      string `envconfig:"CATALOG_SERVICE_URL"`
+	OrderURL        string `envconfig:"ORDER_SERVICE_URL"`
+	ReviewURL       string `envconfig:"REVIEW_SERVICE_URL"`
+	NotificationURL string `envconfig:"NOTIFICATION_SERVICE_URL"`
+	TracingExporter string `envconfig:"TRACING_EXPORTER" default:"none"`
+	// AdminToken is the bearer token of support staff. Admin-only fields
+	// are unavailable when it is not set.
+	AdminToken string `envconfig:"ADMIN_TOKEN"`
+	// InvoiceKey signs the invoice links handed out with orders. Only admins
+	// can fetch invoices when it is not set.
+	InvoiceKey string `envconfig:"INVOICE_LINK_KEY"`
+
+	TLS tlsconfig.Config
 }
 
 func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves until SIGINT or SIGTERM, then drains in-flight requests before
+// closing the service clients and flushing traces.
+func run() error {
 	var cfg AppConfig
 	err := envconfig.Process("", &cfg)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	ctx, stop := shutdown.Context()
+	defer stop()
+
+	certs, err := grpcopts.ConfigureTLS(ctx, cfg.TLS)
+	if err != nil {
+		return err
 	}
 
-	graphqlServer, err := NewGraphQLServer(cfg.AccountURL, cfg.CatalogURL, cfg.OrderURL)
+	shutdownTracing, err := tracing.Init(ctx, "graphql", cfg.TracingExporter)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), shutdown.Timeout)
+		defer cancel()
+		shutdownTracing(flushCtx)
+	}()
+
+	graphqlServer, err := NewGraphQLServer(cfg.AccountURL, cfg.CatalogURL, cfg.OrderURL, cfg.ReviewURL, cfg.NotificationURL, cfg.AdminToken, cfg.InvoiceKey)
+	if err != nil {
+		return err
 	}
+	defer graphqlServer.Close()
 
-	http.Handle("/graphql", handler.New(graphqlServer.ToExecutableSchema()))
-	http.Handle("/playground", playground.Handler("graphql playground", "/graphql"))
+	mux := http.NewServeMux()
+	mux.Handle("/graphql", graphqlServer.Handler())
+	mux.Handle("/metrics", metrics.Handler())
+	mux.Handle("/healthz", healthzHandler())
+	mux.Handle("/readyz", graphqlServer.readyzHandler())
+	mux.Handle("/playground", playground.Handler("graphql playground", "/graphql"))
+	mux.Handle(invoicePath, graphqlServer.invoiceHandler())
 
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	log.Println("listening on port 8080")
+	return shutdown.ServeHTTP(ctx, &http.Server{
+		Addr:      ":8080",
+		Handler:   mux,
+		TLSConfig: certs.HTTPServerConfig(),
+	})
 }
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	graphqlRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "graphql_requests_total",
+		Help: "GraphQL operations handled, by operation and outcome.",
+	}, []string{"type", "operation", "outcome"})
+
+	graphqlDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "graphql_request_duration_seconds",
+		Help:    "Time taken to execute a GraphQL operation.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"type", "operation"})
+)
+
+// metricsExtension records the rate, errors and duration of every GraphQL
+// operation. Operations are labelled by operationLabel, so clients should
+// name the operations they send after the root field they call.
+type metricsExtension struct {
+	operations operationNames
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = metricsExtension{}
+
+// operationNames maps the lowercase name of every root query and mutation
+// field to its name in the schema.
+type operationNames map[string]string
+
+func newOperationNames(es graphql.ExecutableSchema) operationNames {
+	names := operationNames{}
+	schema := es.Schema()
+	for _, root := range []string{"Query", "Mutation"} {
+		def := schema.Types[root]
+		if def == nil {
+			continue
+		}
+		for _, f := range def.Fields {
+			if !strings.HasPrefix(f.Name, "__") {
+				names[strings.ToLower(f.Name)] = f.Name
+			}
+		}
+	}
+	return names
+}
+
+// operationLabel names the operation of oc for metrics and span names. The
+// operation name comes from the client, so only names matching a root
+// field, in any case, are kept; others become "other", which keeps the
+// number of label values bounded by the schema.
+func (names operationNames) operationLabel(oc *graphql.OperationContext) string {
+	if oc.OperationName == "" {
+		return "anonymous"
+	}
+	if name, ok := names[strings.ToLower(oc.OperationName)]; ok {
+		return name
+	}
+	return "other"
+}
+
+func (metricsExtension) ExtensionName() string {
+	return "Prometheus"
+}
+
+func (metricsExtension) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (m metricsExtension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+
+	start := time.Now()
+	res := next(ctx)
+
+	oc := graphql.GetOperationContext(ctx)
+	name := m.operations.operationLabel(oc)
+	kind := "operation"
+	if oc.Operation != nil {
+		kind = string(oc.Operation.Operation)
+	}
+	outcome := "ok"
+	if len(graphql.GetErrors(ctx)) > 0 {
+		outcome = "error"
+	}
+
+	graphqlRequests.WithLabelValues(kind, name, outcome).Inc()
+	graphqlDuration.WithLabelValues(kind, name).Observe(time.Since(start).Seconds())
+	return res
+}
@@ -3,45 +3,176 @@
 package model
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
 	"time"
 )
 
 type Account struct {
-	ID     string   `json:"id"`
-	Name   string   `json:"name"`
-	Orders []*Order `json:"orders"`
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	// orders is the first page of the account's order history. Use
+	// ordersConnection to page through the rest.
+	Orders                  []*Order                 `json:"orders"`
+	OrdersConnection        *OrderConnection         `json:"ordersConnection"`
+	NotificationPreferences *NotificationPreferences `json:"notificationPreferences"`
+	Subscriptions           []*OrderSubscription     `json:"subscriptions"`
+}
+
+type AccountConnection struct {
+	Edges    []*AccountEdge `json:"edges"`
+	PageInfo *PageInfo      `json:"pageInfo"`
+}
+
+type AccountEdge struct {
+	Cursor string   `json:"cursor"`
+	Node   *Account `json:"node"`
 }
 
 type AccountInput struct {
-	Name string `json:"name"`
+	Name  string  `json:"name"`
+	Email *string `json:"email,omitempty"`
+}
+
+// A postal address. country is an ISO 3166-1 alpha-2 code.
+type Address struct {
+	Name       string `json:"name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2"`
+	City       string `json:"city"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postalCode"`
+	Country    string `json:"country"`
+}
+
+type AddressInput struct {
+	Name       string  `json:"name"`
+	Line1      string  `json:"line1"`
+	Line2      *string `json:"line2,omitempty"`
+	City       string  `json:"city"`
+	Region     *string `json:"region,omitempty"`
+	PostalCode *string `json:"postalCode,omitempty"`
+	Country    string  `json:"country"`
 }
 
 type Mutation struct {
 }
 
+// NotificationPreferences choose which optional emails an account gets. The
+// welcome email is always sent.
+type NotificationPreferences struct {
+	OrderConfirmations bool `json:"orderConfirmations"`
+	ShippingUpdates    bool `json:"shippingUpdates"`
+}
+
+type NotificationPreferencesInput struct {
+	OrderConfirmations bool `json:"orderConfirmations"`
+	ShippingUpdates    bool `json:"shippingUpdates"`
+}
+
 type Order struct {
-	ID         string            `json:"id"`
-	TotalPrice float64           `json:"totalPrice"`
-	Products   []*OrderedProduct `json:"products"`
-	CreatedAt  time.Time         `json:"createdAt"`
+	ID              string            `json:"id"`
+	AccountID       string            `json:"accountId"`
+	TotalPrice      float64           `json:"totalPrice"`
+	Status          OrderStatus       `json:"status"`
+	Products        []*OrderedProduct `json:"products"`
+	ShippingAddress *Address          `json:"shippingAddress,omitempty"`
+	// Where the gateway serves the invoice of the order as PDF; the same link
+	// with the path ending in .html serves it as HTML. The link is signed and
+	// expires after a day; admins may fetch any invoice without a signature.
+	InvoiceURL string      `json:"invoiceUrl"`
+	Shipments  []*Shipment `json:"shipments"`
+	Returns    []*Return   `json:"returns"`
+	Refunds    []*Refund   `json:"refunds"`
+	CreatedAt  time.Time   `json:"createdAt"`
+}
+
+type OrderConnection struct {
+	Edges    []*OrderEdge `json:"edges"`
+	PageInfo *PageInfo    `json:"pageInfo"`
+}
+
+type OrderEdge struct {
+	Cursor string `json:"cursor"`
+	Node   *Order `json:"node"`
+}
+
+// OrderFilterInput narrows an order history. placedFrom is inclusive and
+// placedTo exclusive; unset fields do not filter.
+type OrderFilterInput struct {
+	PlacedFrom *time.Time   `json:"placedFrom,omitempty"`
+	PlacedTo   *time.Time   `json:"placedTo,omitempty"`
+	Status     *OrderStatus `json:"status,omitempty"`
+	MinTotal   *float64     `json:"minTotal,omitempty"`
 }
 
 type OrderInput struct {
-	AccountID string               `json:"accountId"`
-	Products  []*OrderProductInput `json:"products"`
+	AccountID       string               `json:"accountId"`
+	Products        []*OrderProductInput `json:"products"`
+	ShippingAddress *AddressInput        `json:"shippingAddress,omitempty"`
 }
 
 type OrderProductInput struct {
-	ID       string `json:"id"`
-	Quantity int    `json:"quantity"`
+	ID        string  `json:"id"`
+	Quantity  int     `json:"quantity"`
+	VariantID *string `json:"variantId,omitempty"`
+}
+
+// OrderSearchInput filters the orders of every account. placedFrom is
+// inclusive and placedTo exclusive; unset fields do not filter.
+type OrderSearchInput struct {
+	AccountID  *string      `json:"accountId,omitempty"`
+	ProductID  *string      `json:"productId,omitempty"`
+	Status     *OrderStatus `json:"status,omitempty"`
+	PlacedFrom *time.Time   `json:"placedFrom,omitempty"`
+	PlacedTo   *time.Time   `json:"placedTo,omitempty"`
+	MinTotal   *float64     `json:"minTotal,omitempty"`
+	MaxTotal   *float64     `json:"maxTotal,omitempty"`
+}
+
+// A basket ordered for an account every intervalCount days, weeks or months,
+// starting at startsAt. Each order is placed like createOrder, priced from the
+// catalog when it is placed.
+type OrderSubscription struct {
+	ID              string              `json:"id"`
+	AccountID       string              `json:"accountId"`
+	Status          SubscriptionStatus  `json:"status"`
+	Lines           []*SubscriptionLine `json:"lines"`
+	ShippingAddress *Address            `json:"shippingAddress,omitempty"`
+	IntervalUnit    IntervalUnit        `json:"intervalUnit"`
+	IntervalCount   int                 `json:"intervalCount"`
+	StartsAt        time.Time           `json:"startsAt"`
+	// When the next order is due. Orders that fall due while the subscription
+	// is paused are skipped.
+	NextRunAt time.Time `json:"nextRunAt"`
+	// How many times placing the next order has failed. It is retried at
+	// retryAt, with growing delays, and skipped after five failures.
+	Failures int `json:"failures"`
+	// The reason the latest order failed, until an order is placed.
+	LastFailure *string    `json:"lastFailure,omitempty"`
+	RetryAt     *time.Time `json:"retryAt,omitempty"`
+	LastOrderID *string    `json:"lastOrderId,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
 }
 
 type OrderedProduct struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	Quantity    int     `json:"quantity"`
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Price       float64          `json:"price"`
+	Quantity    int              `json:"quantity"`
+	VariantID   *string          `json:"variantId,omitempty"`
+	Sku         *string          `json:"sku,omitempty"`
+	Options     []*VariantOption `json:"options"`
+}
+
+type PageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor,omitempty"`
 }
 
 type PaginationInput struct {
@@ -49,18 +180,663 @@ type PaginationInput struct {
 	Take *int `json:"take,omitempty"`
 }
 
+type PriceChange struct {
+	ID         string    `json:"id"`
+	OldPrice   float64   `json:"oldPrice"`
+	NewPrice   float64   `json:"newPrice"`
+	Actor      string    `json:"actor"`
+	ScheduleID *string   `json:"scheduleId,omitempty"`
+	ChangedAt  time.Time `json:"changedAt"`
+}
+
+type PriceSchedule struct {
+	ID        string     `json:"id"`
+	ProductID string     `json:"productId"`
+	Price     float64    `json:"price"`
+	StartsAt  time.Time  `json:"startsAt"`
+	EndsAt    *time.Time `json:"endsAt,omitempty"`
+	Actor     string     `json:"actor"`
+	Status    string     `json:"status"`
+}
+
+type PriceScheduleInput struct {
+	ProductID string     `json:"productId"`
+	Price     float64    `json:"price"`
+	StartsAt  time.Time  `json:"startsAt"`
+	EndsAt    *time.Time `json:"endsAt,omitempty"`
+	Actor     string     `json:"actor"`
+}
+
 type Product struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	Price        float64           `json:"price"`
+	Options      []*ProductOption  `json:"options"`
+	Variants     []*ProductVariant `json:"variants"`
+	Rating       float64           `json:"rating"`
+	ReviewCount  int               `json:"reviewCount"`
+	Reviews      []*Review         `json:"reviews"`
+	Media        []*ProductMedia   `json:"media"`
+	PriceHistory []*PriceChange    `json:"priceHistory"`
+}
+
+type ProductConnection struct {
+	Edges    []*ProductEdge `json:"edges"`
+	PageInfo *PageInfo      `json:"pageInfo"`
+}
+
+type ProductEdge struct {
+	Cursor string   `json:"cursor"`
+	Node   *Product `json:"node"`
 }
 
 type ProductInput struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Price       float64                `json:"price"`
+	Options     []*ProductOptionInput  `json:"options,omitempty"`
+	Variants    []*ProductVariantInput `json:"variants,omitempty"`
+}
+
+type ProductMedia struct {
+	ID          string `json:"id"`
+	URL         string `json:"url"`
+	AltText     string `json:"altText"`
+	Position    int    `json:"position"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	ContentType string `json:"contentType"`
+}
+
+type ProductOption struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+type ProductOptionInput struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+type ProductVariant struct {
+	ID      string           `json:"id"`
+	Sku     string           `json:"sku"`
+	Options []*VariantOption `json:"options"`
+	Price   float64          `json:"price"`
+	Stock   int              `json:"stock"`
+}
+
+type ProductVariantInput struct {
+	Sku     string                `json:"sku"`
+	Options []*VariantOptionInput `json:"options"`
+	Price   *float64              `json:"price,omitempty"`
+	Stock   int                   `json:"stock"`
 }
 
 type Query struct {
 }
+
+type Refund struct {
+	ID        string    `json:"id"`
+	OrderID   string    `json:"orderId"`
+	ReturnID  string    `json:"returnId"`
+	Amount    float64   `json:"amount"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// A request to send back some quantity of some shipped lines of an order.
+// refundAmount is what the lines were paid, refunded when the return is
+// approved.
+type Return struct {
+	ID      string       `json:"id"`
+	OrderID string       `json:"orderId"`
+	Status  ReturnStatus `json:"status"`
+	Reason  string       `json:"reason"`
+	// Left by staff when they approve or reject the return.
+	Note         string        `json:"note"`
+	Lines        []*ReturnLine `json:"lines"`
+	RefundAmount float64       `json:"refundAmount"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	UpdatedAt    time.Time     `json:"updatedAt"`
+}
+
+// Only quantities that have shipped and are not already on a return that was
+// not rejected can be returned.
+type ReturnInput struct {
+	OrderID string             `json:"orderId"`
+	Reason  string             `json:"reason"`
+	Lines   []*ReturnLineInput `json:"lines"`
+}
+
+type ReturnLine struct {
+	ProductID string  `json:"productId"`
+	VariantID *string `json:"variantId,omitempty"`
+	Quantity  int     `json:"quantity"`
+}
+
+type ReturnLineInput struct {
+	ProductID string  `json:"productId"`
+	VariantID *string `json:"variantId,omitempty"`
+	Quantity  int     `json:"quantity"`
+}
+
+type Review struct {
+	ID        string       `json:"id"`
+	ProductID string       `json:"productId"`
+	AccountID string       `json:"accountId"`
+	Rating    int          `json:"rating"`
+	Body      string       `json:"body"`
+	Status    ReviewStatus `json:"status"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+type ReviewInput struct {
+	ProductID string `json:"productId"`
+	AccountID string `json:"accountId"`
+	Rating    int    `json:"rating"`
+	Body      string `json:"body"`
+}
+
+// A parcel sent with a carrier, carrying some quantity of some lines of an
+// order. events is its tracking history, oldest first.
+type Shipment struct {
+	ID             string `json:"id"`
+	OrderID        string `json:"orderId"`
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"trackingNumber"`
+	// The carrier's tracking page, for carriers the order service knows.
+	TrackingURL *string          `json:"trackingUrl,omitempty"`
+	Status      ShipmentStatus   `json:"status"`
+	Lines       []*ShipmentLine  `json:"lines"`
+	Events      []*ShipmentEvent `json:"events"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	UpdatedAt   time.Time        `json:"updatedAt"`
+}
+
+type ShipmentEvent struct {
+	Status      ShipmentStatus `json:"status"`
+	Description string         `json:"description"`
+	Location    string         `json:"location"`
+	OccurredAt  time.Time      `json:"occurredAt"`
+}
+
+// A tracking event moves a shipment to its status, which cannot be SHIPPED.
+// occurredAt defaults to the time the event is recorded.
+type ShipmentEventInput struct {
+	ShipmentID  string         `json:"shipmentId"`
+	Status      ShipmentStatus `json:"status"`
+	Description *string        `json:"description,omitempty"`
+	Location    *string        `json:"location,omitempty"`
+	OccurredAt  *time.Time     `json:"occurredAt,omitempty"`
+}
+
+type ShipmentInput struct {
+	OrderID        string               `json:"orderId"`
+	Carrier        string               `json:"carrier"`
+	TrackingNumber string               `json:"trackingNumber"`
+	Lines          []*ShipmentLineInput `json:"lines"`
+}
+
+type ShipmentLine struct {
+	ProductID string  `json:"productId"`
+	VariantID *string `json:"variantId,omitempty"`
+	Quantity  int     `json:"quantity"`
+}
+
+type ShipmentLineInput struct {
+	ProductID string  `json:"productId"`
+	VariantID *string `json:"variantId,omitempty"`
+	Quantity  int     `json:"quantity"`
+}
+
+// startsAt is when the first order is due, right away when it is omitted.
+type SubscriptionInput struct {
+	AccountID       string                   `json:"accountId"`
+	Lines           []*SubscriptionLineInput `json:"lines"`
+	ShippingAddress *AddressInput            `json:"shippingAddress,omitempty"`
+	IntervalUnit    IntervalUnit             `json:"intervalUnit"`
+	IntervalCount   int                      `json:"intervalCount"`
+	StartsAt        *time.Time               `json:"startsAt,omitempty"`
+}
+
+type SubscriptionLine struct {
+	ProductID string  `json:"productId"`
+	VariantID *string `json:"variantId,omitempty"`
+	Quantity  int     `json:"quantity"`
+}
+
+type SubscriptionLineInput struct {
+	ProductID string  `json:"productId"`
+	VariantID *string `json:"variantId,omitempty"`
+	Quantity  int     `json:"quantity"`
+}
+
+type VariantOption struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type VariantOptionInput struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type IntervalUnit string
+
+const (
+	IntervalUnitDay   IntervalUnit = "DAY"
+	IntervalUnitWeek  IntervalUnit = "WEEK"
+	IntervalUnitMonth IntervalUnit = "MONTH"
+)
+
+var AllIntervalUnit = []IntervalUnit{
+	IntervalUnitDay,
+	IntervalUnitWeek,
+	IntervalUnitMonth,
+}
+
+func (e IntervalUnit) IsValid() bool {
+	switch e {
+	case IntervalUnitDay, IntervalUnitWeek, IntervalUnitMonth:
+		return true
+	}
+	return false
+}
+
+func (e IntervalUnit) String() string {
+	return string(e)
+}
+
+func (e *IntervalUnit) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = IntervalUnit(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid IntervalUnit", str)
+	}
+	return nil
+}
+
+func (e IntervalUnit) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *IntervalUnit) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e IntervalUnit) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+// OrderStatus is PLACED until an order starts shipping, then derived from its
+// shipments: PARTIALLY_SHIPPED until every line has shipped in full, SHIPPED
+// until every shipment is delivered, then DELIVERED.
+type OrderStatus string
+
+const (
+	OrderStatusPlaced           OrderStatus = "PLACED"
+	OrderStatusPartiallyShipped OrderStatus = "PARTIALLY_SHIPPED"
+	OrderStatusShipped          OrderStatus = "SHIPPED"
+	OrderStatusDelivered        OrderStatus = "DELIVERED"
+)
+
+var AllOrderStatus = []OrderStatus{
+	OrderStatusPlaced,
+	OrderStatusPartiallyShipped,
+	OrderStatusShipped,
+	OrderStatusDelivered,
+}
+
+func (e OrderStatus) IsValid() bool {
+	switch e {
+	case OrderStatusPlaced, OrderStatusPartiallyShipped, OrderStatusShipped, OrderStatusDelivered:
+		return true
+	}
+	return false
+}
+
+func (e OrderStatus) String() string {
+	return string(e)
+}
+
+func (e *OrderStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrderStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrderStatus", str)
+	}
+	return nil
+}
+
+func (e OrderStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *OrderStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e OrderStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+// A return is REQUESTED by the customer, then APPROVED or REJECTED by staff.
+// An approved return is RECEIVED once its items arrive. A return is APPROVING
+// while its items are put back into stock; one left APPROVING by a failure is
+// approved by approving it again.
+type ReturnStatus string
+
+const (
+	ReturnStatusRequested ReturnStatus = "REQUESTED"
+	ReturnStatusApproving ReturnStatus = "APPROVING"
+	ReturnStatusApproved  ReturnStatus = "APPROVED"
+	ReturnStatusRejected  ReturnStatus = "REJECTED"
+	ReturnStatusReceived  ReturnStatus = "RECEIVED"
+)
+
+var AllReturnStatus = []ReturnStatus{
+	ReturnStatusRequested,
+	ReturnStatusApproving,
+	ReturnStatusApproved,
+	ReturnStatusRejected,
+	ReturnStatusReceived,
+}
+
+func (e ReturnStatus) IsValid() bool {
+	switch e {
+	case ReturnStatusRequested, ReturnStatusApproving, ReturnStatusApproved, ReturnStatusRejected, ReturnStatusReceived:
+		return true
+	}
+	return false
+}
+
+func (e ReturnStatus) String() string {
+	return string(e)
+}
+
+func (e *ReturnStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ReturnStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ReturnStatus", str)
+	}
+	return nil
+}
+
+func (e ReturnStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ReturnStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ReturnStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type ReviewStatus string
+
+const (
+	ReviewStatusPending  ReviewStatus = "PENDING"
+	ReviewStatusApproved ReviewStatus = "APPROVED"
+	ReviewStatusRejected ReviewStatus = "REJECTED"
+)
+
+var AllReviewStatus = []ReviewStatus{
+	ReviewStatusPending,
+	ReviewStatusApproved,
+	ReviewStatusRejected,
+}
+
+func (e ReviewStatus) IsValid() bool {
+	switch e {
+	case ReviewStatusPending, ReviewStatusApproved, ReviewStatusRejected:
+		return true
+	}
+	return false
+}
+
+func (e ReviewStatus) String() string {
+	return string(e)
+}
+
+func (e *ReviewStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ReviewStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ReviewStatus", str)
+	}
+	return nil
+}
+
+func (e ReviewStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ReviewStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ReviewStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+// A shipment is created SHIPPED and then takes the status of each tracking
+// event recorded for it, until it is DELIVERED.
+type ShipmentStatus string
+
+const (
+	ShipmentStatusShipped        ShipmentStatus = "SHIPPED"
+	ShipmentStatusInTransit      ShipmentStatus = "IN_TRANSIT"
+	ShipmentStatusOutForDelivery ShipmentStatus = "OUT_FOR_DELIVERY"
+	ShipmentStatusDelivered      ShipmentStatus = "DELIVERED"
+	ShipmentStatusException      ShipmentStatus = "EXCEPTION"
+)
+
+var AllShipmentStatus = []ShipmentStatus{
+	ShipmentStatusShipped,
+	ShipmentStatusInTransit,
+	ShipmentStatusOutForDelivery,
+	ShipmentStatusDelivered,
+	ShipmentStatusException,
+}
+
+func (e ShipmentStatus) IsValid() bool {
+	switch e {
+	case ShipmentStatusShipped, ShipmentStatusInTransit, ShipmentStatusOutForDelivery, ShipmentStatusDelivered, ShipmentStatusException:
+		return true
+	}
+	return false
+}
+
+func (e ShipmentStatus) String() string {
+	return string(e)
+}
+
+func (e *ShipmentStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ShipmentStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ShipmentStatus", str)
+	}
+	return nil
+}
+
+func (e ShipmentStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ShipmentStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ShipmentStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+// SortDirection orders an order history by placement time.
+type SortDirection string
+
+const (
+	SortDirectionOldestFirst SortDirection = "OLDEST_FIRST"
+	SortDirectionNewestFirst SortDirection = "NEWEST_FIRST"
+)
+
+var AllSortDirection = []SortDirection{
+	SortDirectionOldestFirst,
+	SortDirectionNewestFirst,
+}
+
+func (e SortDirection) IsValid() bool {
+	switch e {
+	case SortDirectionOldestFirst, SortDirectionNewestFirst:
+		return true
+	}
+	return false
+}
+
+func (e SortDirection) String() string {
+	return string(e)
+}
+
+func (e *SortDirection) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SortDirection(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SortDirection", str)
+	}
+	return nil
+}
+
+func (e SortDirection) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *SortDirection) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e SortDirection) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+// Only ACTIVE subscriptions place orders. A PAUSED subscription can be
+// resumed; a CANCELLED one cannot.
+type SubscriptionStatus string
+
+const (
+	SubscriptionStatusActive    SubscriptionStatus = "ACTIVE"
+	SubscriptionStatusPaused    SubscriptionStatus = "PAUSED"
+	SubscriptionStatusCancelled SubscriptionStatus = "CANCELLED"
+)
+
+var AllSubscriptionStatus = []SubscriptionStatus{
+	SubscriptionStatusActive,
+	SubscriptionStatusPaused,
+	SubscriptionStatusCancelled,
+}
+
+func (e SubscriptionStatus) IsValid() bool {
+	switch e {
+	case SubscriptionStatusActive, SubscriptionStatusPaused, SubscriptionStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (e SubscriptionStatus) String() string {
+	return string(e)
+}
+
+func (e *SubscriptionStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SubscriptionStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SubscriptionStatus", str)
+	}
+	return nil
+}
+
+func (e SubscriptionStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *SubscriptionStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e SubscriptionStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
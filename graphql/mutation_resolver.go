@@ -1,12 +1,23 @@
 package main
 
 import (
+	"bytes"
 	"context"
-	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
 	"log"
+	"strings"
 	"time"
 
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/sunil8777/E-commerce-microservices/catalog"
+	"github.com/sunil8777/E-commerce-microservices/errs"
 	"github.com/sunil8777/E-commerce-microservices/graphql/model"
+	"github.com/sunil8777/E-commerce-microservices/notification"
 	"github.com/sunil8777/E-commerce-microservices/order"
 )
 
@@ -15,41 +26,62 @@ type mutationResolver struct {
 }
 
 var (
-	ErrInvalidParameter = errors.New("invalid parameter")
+	ErrInvalidParameter = errs.New(errs.InvalidArgument, "INVALID_PARAMETER", "invalid parameter")
+	ErrInvalidImage     = errs.New(errs.InvalidArgument, "INVALID_IMAGE", "file is not a supported image")
 )
 
+const maxImageSize = catalog.MaxMediaSize
+
 func (r *mutationResolver) CreateAccount(ctx context.Context, in model.AccountInput) (*model.Account, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	a, err := r.server.accountClient.PostAccount(ctx, in.Name)
+	email := ""
+	if in.Email != nil {
+		email = *in.Email
+	}
+	a, err := r.server.accountClient.PostAccount(ctx, in.Name, email)
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
 
-	return &model.Account{
-		ID:   a.ID,
-		Name: a.Name,
-	}, nil
+	return accountModel(*a), nil
 }
 
 func (r *mutationResolver) CreateProduct(ctx context.Context, in model.ProductInput) (*model.Product, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	p, err := r.server.catalogClient.PostProduct(ctx, in.Name, in.Description, in.Price)
+	options := []catalog.ProductOption{}
+	for _, o := range in.Options {
+		options = append(options, catalog.ProductOption{
+			Name:   o.Name,
+			Values: o.Values,
+		})
+	}
+
+	variants := []catalog.Variant{}
+	for _, v := range in.Variants {
+		variant := catalog.Variant{
+			SKU:     v.Sku,
+			Options: map[string]string{},
+		}
+		variant.Price = v.Price
+		variant.Stock = uint32(v.Stock)
+		for _, o := range v.Options {
+			variant.Options[o.Name] = o.Value
+		}
+		variants = append(variants, variant)
+	}
+
+	p, err := r.server.catalogClient.PostProduct(ctx, in.Name, in.Description, in.Price, options, variants)
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
 
-	return &model.Product{
-		ID:          p.ID,
-		Name:        p.Name,
-		Description: p.Description,
-		Price:       p.Price,
-	}, nil
+	return productModel(*p), nil
 }
 
 func (r *mutationResolver) CreateOrder(ctx context.Context, in model.OrderInput) (*model.Order, error) {
@@ -58,23 +90,341 @@ func (r *mutationResolver) CreateOrder(ctx context.Context, in model.OrderInput)
 
 	var products []order.OrderedProduct
 	for _, p := range in.Products {
-		if p.Quantity <= 0 {
-			return nil, ErrInvalidParameter
-		}
-		products = append(products, order.OrderedProduct{
+		product := order.OrderedProduct{
 			ID:       p.ID,
 			Quantity: uint64(p.Quantity),
-		})
+		}
+		if p.VariantID != nil {
+			product.VariantID = *p.VariantID
+		}
+		products = append(products, product)
+	}
+	o, err := r.server.orderClient.PostOrder(ctx, in.AccountID, products, shippingAddress(in.ShippingAddress))
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return orderModel(*o), nil
+}
+
+func (r *mutationResolver) CreateShipment(ctx context.Context, in model.ShipmentInput) (*model.Shipment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var lines []order.ShipmentLine
+	for _, l := range in.Lines {
+		line := order.ShipmentLine{
+			ProductID: l.ProductID,
+			Quantity:  uint64(l.Quantity),
+		}
+		if l.VariantID != nil {
+			line.VariantID = *l.VariantID
+		}
+		lines = append(lines, line)
+	}
+	sh, err := r.server.orderClient.CreateShipment(ctx, in.OrderID, in.Carrier, in.TrackingNumber, lines)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return shipmentModel(*sh), nil
+}
+
+func (r *mutationResolver) AddShipmentEvent(ctx context.Context, in model.ShipmentEventInput) (*model.Shipment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	e := order.ShipmentEvent{Status: strings.ToLower(string(in.Status))}
+	if in.Description != nil {
+		e.Description = *in.Description
+	}
+	if in.Location != nil {
+		e.Location = *in.Location
+	}
+	if in.OccurredAt != nil {
+		e.OccurredAt = *in.OccurredAt
+	}
+	sh, err := r.server.orderClient.AddShipmentEvent(ctx, in.ShipmentID, e)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return shipmentModel(*sh), nil
+}
+
+func (r *mutationResolver) RequestReturn(ctx context.Context, in model.ReturnInput) (*model.Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var lines []order.ReturnLine
+	for _, l := range in.Lines {
+		line := order.ReturnLine{
+			ProductID: l.ProductID,
+			Quantity:  uint64(l.Quantity),
+		}
+		if l.VariantID != nil {
+			line.VariantID = *l.VariantID
+		}
+		lines = append(lines, line)
+	}
+	rt, err := r.server.orderClient.RequestReturn(ctx, in.OrderID, in.Reason, lines)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return returnModel(*rt), nil
+}
+
+func (r *mutationResolver) ApproveReturn(ctx context.Context, id string, note *string) (*model.Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var n string
+	if note != nil {
+		n = *note
+	}
+	rt, err := r.server.orderClient.ApproveReturn(ctx, id, n)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return returnModel(*rt), nil
+}
+
+func (r *mutationResolver) RejectReturn(ctx context.Context, id string, note *string) (*model.Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var n string
+	if note != nil {
+		n = *note
+	}
+	rt, err := r.server.orderClient.RejectReturn(ctx, id, n)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return returnModel(*rt), nil
+}
+
+func (r *mutationResolver) ReceiveReturn(ctx context.Context, id string) (*model.Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rt, err := r.server.orderClient.ReceiveReturn(ctx, id)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return returnModel(*rt), nil
+}
+
+func (r *mutationResolver) CreateSubscription(ctx context.Context, in model.SubscriptionInput) (*model.OrderSubscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var lines []order.SubscriptionLine
+	for _, l := range in.Lines {
+		line := order.SubscriptionLine{
+			ProductID: l.ProductID,
+			Quantity:  uint64(l.Quantity),
+		}
+		if l.VariantID != nil {
+			line.VariantID = *l.VariantID
+		}
+		lines = append(lines, line)
+	}
+	interval := order.Interval{Unit: strings.ToLower(string(in.IntervalUnit)), Count: uint32(in.IntervalCount)}
+	var startsAt time.Time
+	if in.StartsAt != nil {
+		startsAt = *in.StartsAt
+	}
+	sub, err := r.server.orderClient.CreateSubscription(ctx, in.AccountID, lines, shippingAddress(in.ShippingAddress), interval, startsAt)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return subscriptionModel(*sub), nil
+}
+
+func (r *mutationResolver) PauseSubscription(ctx context.Context, id string) (*model.OrderSubscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sub, err := r.server.orderClient.PauseSubscription(ctx, id)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return subscriptionModel(*sub), nil
+}
+
+func (r *mutationResolver) ResumeSubscription(ctx context.Context, id string) (*model.OrderSubscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sub, err := r.server.orderClient.ResumeSubscription(ctx, id)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return subscriptionModel(*sub), nil
+}
+
+func (r *mutationResolver) CancelSubscription(ctx context.Context, id string) (*model.OrderSubscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	sub, err := r.server.orderClient.CancelSubscription(ctx, id)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return subscriptionModel(*sub), nil
+}
+
+func (r *mutationResolver) CreateReview(ctx context.Context, in model.ReviewInput) (*model.Review, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rv, err := r.server.reviewClient.PostReview(ctx, in.ProductID, in.AccountID, uint32(in.Rating), in.Body)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return reviewModel(*rv), nil
+}
+
+func (r *mutationResolver) ModerateReview(ctx context.Context, id string, status model.ReviewStatus) (*model.Review, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rv, err := r.server.reviewClient.ModerateReview(ctx, id, strings.ToLower(status.String()))
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return reviewModel(*rv), nil
+}
+
+func (r *mutationResolver) UploadProductImage(ctx context.Context, productID string, file graphql.Upload, altText *string, position *int) (*model.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	data, err := io.ReadAll(io.LimitReader(file.File, maxImageSize+1))
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	if len(data) > maxImageSize {
+		return nil, ErrInvalidParameter
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	m := catalog.Media{
+		Width:       uint32(cfg.Width),
+		Height:      uint32(cfg.Height),
+		ContentType: "image/" + format,
+	}
+	if altText != nil {
+		m.AltText = *altText
 	}
-	o, err := r.server.orderClient.PostOrder(ctx, in.AccountID, products)
+
+	var pos *uint32
+	if position != nil {
+		p := uint32(*position)
+		pos = &p
+	}
+
+	p, err := r.server.catalogClient.AddProductMedia(ctx, productID, m, data, pos)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return productModel(*p), nil
+}
+
+func (r *mutationResolver) UpdateProductPrice(ctx context.Context, productID string, price float64, actor string) (*model.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	p, err := r.server.catalogClient.UpdateProductPrice(ctx, productID, price, actor)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return productModel(*p), nil
+}
+
+func (r *mutationResolver) SchedulePriceChange(ctx context.Context, in model.PriceScheduleInput) (*model.PriceSchedule, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	endsAt := time.Time{}
+	if in.EndsAt != nil {
+		endsAt = *in.EndsAt
+	}
+
+	ps, err := r.server.catalogClient.SchedulePriceChange(ctx, in.ProductID, in.Price, in.StartsAt, endsAt, in.Actor)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	schedule := &model.PriceSchedule{
+		ID:        ps.ID,
+		ProductID: ps.ProductID,
+		Price:     ps.Price,
+		StartsAt:  ps.StartsAt,
+		Actor:     ps.Actor,
+		Status:    ps.Status,
+	}
+	if !ps.EndsAt.IsZero() {
+		schedule.EndsAt = &ps.EndsAt
+	}
+
+	return schedule, nil
+}
+
+// UpdateNotificationPreferences checks that the account exists, since the
+// notification service takes preferences for any well-formed account ID.
+func (r *mutationResolver) UpdateNotificationPreferences(ctx context.Context, accountID string, in model.NotificationPreferencesInput) (*model.NotificationPreferences, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if _, err := r.server.accountClient.GetAccount(ctx, accountID); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	p, err := r.server.notificationClient.UpdatePreferences(ctx, notification.Preferences{
+		AccountID:          accountID,
+		OrderConfirmations: in.OrderConfirmations,
+		ShippingUpdates:    in.ShippingUpdates,
+	})
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
 
-	return &model.Order{
-		ID:         o.ID,
-		CreatedAt:  o.CreatedAt,
-		TotalPrice: o.TotalPrice,
-	}, nil
+	return preferencesModel(*p), nil
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sunil8777/E-commerce-microservices/graphql/model"
+)
+
+type orderResolver struct {
+	server *Server
+}
+
+func (r *orderResolver) InvoiceURL(ctx context.Context, obj *model.Order) (string, error) {
+	return invoiceURL(r.server.invoiceKey, obj.ID, time.Now().Add(invoiceLinkTTL)), nil
+}
+
+func (r *orderResolver) Shipments(ctx context.Context, obj *model.Order) ([]*model.Shipment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	shipmentList, err := r.server.orderClient.ListShipments(ctx, obj.ID)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	shipments := []*model.Shipment{}
+	for _, sh := range shipmentList {
+		shipments = append(shipments, shipmentModel(sh))
+	}
+	return shipments, nil
+}
+
+func (r *orderResolver) Returns(ctx context.Context, obj *model.Order) ([]*model.Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	returnList, err := r.server.orderClient.ListReturns(ctx, obj.ID)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	returns := []*model.Return{}
+	for _, rt := range returnList {
+		returns = append(returns, returnModel(rt))
+	}
+	return returns, nil
+}
+
+func (r *orderResolver) Refunds(ctx context.Context, obj *model.Order) ([]*model.Refund, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	refundList, err := r.server.orderClient.ListRefunds(ctx, obj.ID)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	refunds := []*model.Refund{}
+	for _, rf := range refundList {
+		refunds = append(refunds, &model.Refund{
+			ID:        rf.ID,
+			OrderID:   rf.OrderID,
+			ReturnID:  rf.ReturnID,
+			Amount:    rf.Amount,
+			CreatedAt: rf.CreatedAt,
+		})
+	}
+	return refunds, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sunil8777/E-commerce-microservices/graphql/model"
+	"github.com/sunil8777/E-commerce-microservices/review"
+)
+
+type productResolver struct {
+	server *Server
+}
+
+func (r *productResolver) Reviews(ctx context.Context, obj *model.Product, pagination *model.PaginationInput) ([]*model.Review, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	skip, take := uint64(0), uint64(0)
+	if pagination != nil {
+		skip, take = bounds(pagination)
+	}
+
+	reviewList, err := r.server.reviewClient.GetReviewsForProduct(ctx, obj.ID, review.StatusApproved, skip, take)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	reviews := []*model.Review{}
+	for _, rv := range reviewList {
+		reviews = append(reviews, reviewModel(rv))
+	}
+
+	return reviews, nil
+}
+
+func (r *productResolver) PriceHistory(ctx context.Context, obj *model.Product, take *int) ([]*model.PriceChange, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	takeValue := uint64(0)
+	if take != nil {
+		if *take < 0 {
+			return nil, ErrInvalidParameter
+		}
+		takeValue = uint64(*take)
+	}
+
+	changeList, err := r.server.catalogClient.GetPriceHistory(ctx, obj.ID, takeValue)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	changes := []*model.PriceChange{}
+	for _, c := range changeList {
+		change := &model.PriceChange{
+			ID:        c.ID,
+			OldPrice:  c.OldPrice,
+			NewPrice:  c.NewPrice,
+			Actor:     c.Actor,
+			ChangedAt: c.ChangedAt,
+		}
+		if c.ScheduleID != "" {
+			change.ScheduleID = &c.ScheduleID
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
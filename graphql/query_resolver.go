@@ -23,10 +23,7 @@ func (r *queryResolver) Accounts(ctx context.Context, pagination *model.Paginati
 			return nil, err
 		}
 
-		return []*model.Account{{
-			ID:   r.ID,
-			Name: r.Name,
-		}}, nil
+		return []*model.Account{accountModel(*r)}, nil
 	}
 
 	skip, take := uint64(0), uint64(0)
@@ -42,11 +39,7 @@ func (r *queryResolver) Accounts(ctx context.Context, pagination *model.Paginati
 
 	var accounts []*model.Account
 	for _, a := range accountList {
-		account := &model.Account{
-			ID:   a.ID,
-			Name: a.Name,
-		}
-		accounts = append(accounts, account)
+		accounts = append(accounts, accountModel(a))
 	}
 
 	return accounts, nil
@@ -63,12 +56,7 @@ func (r *queryResolver) Products(ctx context.Context, pagination *model.Paginati
 			return nil, err
 		}
 
-		return []*model.Product{{
-			ID:          r.ID,
-			Name:        r.Name,
-			Description: r.Description,
-			Price:       r.Price,
-		}}, nil
+		return []*model.Product{productModel(*r)}, nil
 	}
 
 	skip, take := uint64(0), uint64(0)
@@ -89,17 +77,81 @@ func (r *queryResolver) Products(ctx context.Context, pagination *model.Paginati
 
 	var products []*model.Product
 	for _, a := range productList {
-		products = append(products, &model.Product{
-			ID:          a.ID,
-			Name:        a.Name,
-			Description: a.Description,
-			Price:       a.Price,
-		})
+		products = append(products, productModel(a))
 	}
 
 	return products, nil
 }
 
+func (r *queryResolver) AccountsConnection(ctx context.Context, first *int, after *string) (*model.AccountConnection, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	take, cursor, err := connectionBounds(first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := r.server.accountClient.GetAccountsPage(ctx, cursor, take)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	conn := &model.AccountConnection{
+		Edges:    []*model.AccountEdge{},
+		PageInfo: &model.PageInfo{HasNextPage: page.HasNextPage},
+	}
+	for i, a := range page.Accounts {
+		conn.Edges = append(conn.Edges, &model.AccountEdge{
+			Cursor: page.Cursors[i],
+			Node:   accountModel(a),
+		})
+	}
+	if n := len(page.Cursors); n > 0 {
+		conn.PageInfo.EndCursor = &page.Cursors[n-1]
+	}
+
+	return conn, nil
+}
+
+func (r *queryResolver) ProductsConnection(ctx context.Context, first *int, after *string, query *string) (*model.ProductConnection, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	take, cursor, err := connectionBounds(first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	q := ""
+	if query != nil {
+		q = *query
+	}
+
+	page, err := r.server.catalogClient.GetProductsPage(ctx, q, cursor, take)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	conn := &model.ProductConnection{
+		Edges:    []*model.ProductEdge{},
+		PageInfo: &model.PageInfo{HasNextPage: page.HasNextPage},
+	}
+	for i, p := range page.Products {
+		conn.Edges = append(conn.Edges, &model.ProductEdge{
+			Cursor: page.Cursors[i],
+			Node:   productModel(p),
+		})
+	}
+	if n := len(page.Cursors); n > 0 {
+		conn.PageInfo.EndCursor = &page.Cursors[n-1]
+	}
+
+	return conn, nil
+}
+
 func bounds(p *model.PaginationInput) (uint64, uint64) {
 	skipValue := uint64(0)
 	takeValue := uint64(100)
@@ -111,3 +163,36 @@ func bounds(p *model.PaginationInput) (uint64, uint64) {
 	}
 	return skipValue, takeValue
 }
+
+func (r *queryResolver) Orders(ctx context.Context, first *int, after *string, search *model.OrderSearchInput, sort *model.SortDirection) (*model.OrderConnection, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	take, cursor, err := connectionBounds(first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := r.server.orderClient.SearchOrders(ctx, orderSearchQuery(search, sort), cursor, take)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return orderConnectionModel(page), nil
+}
+
+func connectionBounds(first *int, after *string) (uint64, string, error) {
+	takeValue := uint64(100)
+	afterValue := ""
+	if first != nil {
+		if *first <= 0 {
+			return 0, "", ErrInvalidParameter
+		}
+		takeValue = uint64(*first)
+	}
+	if after != nil {
+		afterValue = *after
+	}
+	return takeValue, afterValue, nil
+}
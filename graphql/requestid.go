@@ -0,0 +1,24 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/segmentio/ksuid"
+	"github.com/sunil8777/E-commerce-microservices/grpcopts"
+)
+
+const requestIDHeader = "X-Request-Id"
+
+// withRequestID tags each request with the caller's X-Request-Id, or a new
+// one, and attaches it to the context so that every gRPC call the resolvers
+// make carries it. Services copy it onward to the calls they make in turn.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" {
+			id = ksuid.New().String()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(grpcopts.WithRequestID(r.Context(), id)))
+	})
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/sunil8777/E-commerce-microservices/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// tracingExtension opens a span per GraphQL operation and a child span per
+// resolver call, so that a trace starts at the gateway and continues into
+// the gRPC spans of the services the resolvers call. Fields served straight
+// from a parent object are not traced; they would only add noise.
+type tracingExtension struct {
+	operations operationNames
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = tracingExtension{}
+
+func (tracingExtension) ExtensionName() string {
+	return "OpenTelemetry"
+}
+
+func (tracingExtension) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (t tracingExtension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	name := t.operations.operationLabel(oc)
+	kind := "operation"
+	if oc.Operation != nil {
+		kind = string(oc.Operation.Operation)
+	}
+
+	ctx, span := tracing.Start(
+		ctx,
+		"graphql."+kind+" "+name,
+		attribute.String("graphql.operation.type", kind),
+		attribute.String("graphql.operation.name", name),
+	)
+	defer span.End()
+
+	res := next(ctx)
+	if errList := graphql.GetErrors(ctx); len(errList) > 0 {
+		span.SetStatus(codes.Error, errList.Error())
+	}
+	return res
+}
+
+func (tracingExtension) InterceptField(ctx context.Context, next graphql.Resolver) (res interface{}, err error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+
+	ctx, span := tracing.Start(
+		ctx,
+		"graphql.resolve "+fc.Object+"."+fc.Field.Name,
+		attribute.String("graphql.field.path", fc.Path().String()),
+	)
+	defer tracing.End(span, &err)
+
+	return next(ctx)
+}
@@ -0,0 +1,249 @@
+package grpcopts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sunil8777/E-commerce-microservices/errs"
+	"github.com/sunil8777/E-commerce-microservices/tlsconfig"
+	"github.com/sunil8777/E-commerce-microservices/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// DefaultTimeout applies to every RPC that has no timeout of its own in
+	// the service config. A caller's shorter deadline still wins.
+	DefaultTimeout = 5 * time.Second
+
+	// keepaliveTime is how often an idle connection is pinged. Servers must
+	// allow pings at least this often; see ServerOptions.
+	keepaliveTime    = 30 * time.Second
+	keepaliveTimeout = 10 * time.Second
+)
+
+// PropagatedKeys are the metadata keys a service copies from an incoming
+// request onto the calls it makes to other services, so that a request ID
+// set by the gateway follows the request all the way down.
+var PropagatedKeys = []string{RequestIDKey}
+
+const RequestIDKey = "x-request-id"
+
+// Transport credentials for every server and client built by this package.
+// They stay insecure unless ConfigureTLS is called at startup.
+var (
+	serverCreds credentials.TransportCredentials
+	clientCreds = insecure.NewCredentials()
+)
+
+// ConfigureTLS switches the servers and clients built afterwards to TLS, or
+// mutual TLS, with the certificates named by cfg, reloading them as they
+// change until ctx is done. It returns nil and leaves the transport
+// insecure when cfg does not enable TLS.
+func ConfigureTLS(ctx context.Context, cfg tlsconfig.Config) (*tlsconfig.Reloader, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+
+	r, err := tlsconfig.NewReloader(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.CertFile != "" {
+		serverCreds = credentials.NewTLS(r.ServerConfig())
+	}
+	clientCreds = credentials.NewTLS(r.ClientConfig())
+	return r, nil
+}
+
+// Builder assembles the dial options shared by every service Client: the
+// service config with per-method timeouts and a retry policy for the
+// methods that are safe to repeat, round-robin balancing over the addresses
+// DNS returns for the target, keepalive, and the error, tracing and metadata
+// interceptors.
+type Builder struct {
+	service    string
+	timeout    time.Duration
+	timeouts   map[string]time.Duration
+	idempotent []string
+}
+
+// New starts a Builder for the fully qualified gRPC service name, for
+// example pb.AccountService_ServiceDesc.ServiceName.
+func New(service string) *Builder {
+	return &Builder{
+		service:  service,
+		timeout:  DefaultTimeout,
+		timeouts: map[string]time.Duration{},
+	}
+}
+
+// Idempotent marks methods that may be retried on UNAVAILABLE. Only list
+// methods for which a repeat has the same effect as a single call.
+func (b *Builder) Idempotent(methods ...string) *Builder {
+	b.idempotent = append(b.idempotent, methods...)
+	return b
+}
+
+// Timeout overrides DefaultTimeout for one method.
+func (b *Builder) Timeout(method string, d time.Duration) *Builder {
+	b.timeouts[method] = d
+	return b
+}
+
+type methodName struct {
+	Service string `json:"service"`
+	Method  string `json:"method,omitempty"`
+}
+
+type retryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+type methodConfig struct {
+	Name        []methodName `json:"name"`
+	Timeout     string       `json:"timeout"`
+	RetryPolicy *retryPolicy `json:"retryPolicy,omitempty"`
+}
+
+type serviceConfig struct {
+	LoadBalancingConfig []map[string]struct{} `json:"loadBalancingConfig"`
+	MethodConfig        []methodConfig        `json:"methodConfig"`
+}
+
+// ServiceConfig renders the gRPC service config JSON for the builder.
+func (b *Builder) ServiceConfig() string {
+	cfg := serviceConfig{
+		LoadBalancingConfig: []map[string]struct{}{{"round_robin": {}}},
+		MethodConfig: []methodConfig{{
+			Name:    []methodName{{Service: b.service}},
+			Timeout: duration(b.timeout),
+		}},
+	}
+
+	methods := map[string]bool{}
+	for m := range b.timeouts {
+		methods[m] = true
+	}
+	for _, m := range b.idempotent {
+		methods[m] = true
+	}
+	retryable := map[string]bool{}
+	for _, m := range b.idempotent {
+		retryable[m] = true
+	}
+
+	names := make([]string, 0, len(methods))
+	for m := range methods {
+		names = append(names, m)
+	}
+	sort.Strings(names)
+
+	for _, m := range names {
+		mc := methodConfig{
+			Name:    []methodName{{Service: b.service, Method: m}},
+			Timeout: duration(b.timeout),
+		}
+		if d, ok := b.timeouts[m]; ok {
+			mc.Timeout = duration(d)
+		}
+		if retryable[m] {
+			mc.RetryPolicy = &retryPolicy{
+				MaxAttempts:          4,
+				InitialBackoff:       "0.1s",
+				MaxBackoff:           "1s",
+				BackoffMultiplier:    2,
+				RetryableStatusCodes: []string{"UNAVAILABLE"},
+			}
+		}
+		cfg.MethodConfig = append(cfg.MethodConfig, mc)
+	}
+
+	res, _ := json.Marshal(cfg)
+	return string(res)
+}
+
+// DialOptions returns the shared options followed by opts.
+func (b *Builder) DialOptions(opts ...grpc.DialOption) []grpc.DialOption {
+	return append([]grpc.DialOption{
+		grpc.WithTransportCredentials(clientCreds),
+		grpc.WithDefaultServiceConfig(b.ServiceConfig()),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                keepaliveTime,
+			Timeout:             keepaliveTimeout,
+			PermitWithoutStream: true,
+		}),
+		grpc.WithChainUnaryInterceptor(
+			errs.UnaryClientInterceptor(),
+			propagateMetadata,
+		),
+		tracing.DialOption(),
+	}, opts...)
+}
+
+// ServerOptions carries the TLS credentials set by ConfigureTLS, if any, and
+// lets clients built here keep idle connections alive; without it a server
+// answers their pings with GOAWAY.
+func ServerOptions() []grpc.ServerOption {
+	opts := []grpc.ServerOption{
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             keepaliveTime / 2,
+			PermitWithoutStream: true,
+		}),
+	}
+	if serverCreds != nil {
+		opts = append(opts, grpc.Creds(serverCreds))
+	}
+	return opts
+}
+
+// Target turns a service URL from the environment into a dial target that
+// resolves every replica through DNS. Plain "host:port" and the
+// "http://host:port" form used in docker-compose are both accepted; targets
+// that already name a resolver, such as "dns:///" or "passthrough:///", are
+// left alone.
+func Target(url string) string {
+	for _, scheme := range []string{"http://", "https://"} {
+		url = strings.TrimPrefix(url, scheme)
+	}
+	if strings.Contains(url, ":///") {
+		return url
+	}
+	return "dns:///" + strings.TrimSuffix(url, "/")
+}
+
+// propagateMetadata copies PropagatedKeys from the incoming request, if any,
+// onto outgoing calls that do not set them already.
+func propagateMetadata(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if in, ok := metadata.FromIncomingContext(ctx); ok {
+		out, _ := metadata.FromOutgoingContext(ctx)
+		for _, key := range PropagatedKeys {
+			if len(out.Get(key)) == 0 {
+				if v := in.Get(key); len(v) > 0 {
+					ctx = metadata.AppendToOutgoingContext(ctx, key, v[0])
+				}
+			}
+		}
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// WithRequestID attaches a request ID to ctx for the calls made with it.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, RequestIDKey, id)
+}
+
+func duration(d time.Duration) string {
+	return fmt.Sprintf("%gs", d.Seconds())
+}
@@ -0,0 +1,87 @@
+package healthcheck
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sunil8777/E-commerce-microservices/errs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Interval is how often Register re-checks the backend.
+const Interval = 10 * time.Second
+
+// checkTimeout bounds a single backend check so that a hung database shows
+// up as NOT_SERVING instead of stalling the checker.
+const checkTimeout = 3 * time.Second
+
+var ErrNotServing = errs.New(errs.Unavailable, "NOT_SERVING", "service is not serving")
+
+// Register installs the standard grpc.health.v1 service on server and keeps
+// the status of the whole server ("") and of each named service in step
+// with check, which is run every Interval until ctx is done. Until the first
+// check passes the server reports NOT_SERVING.
+func Register(ctx context.Context, server *grpc.Server, check func(context.Context) error, services ...string) *health.Server {
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(server, hs)
+	services = append([]string{""}, services...)
+
+	setStatus := func(status healthpb.HealthCheckResponse_ServingStatus) {
+		for _, service := range services {
+			hs.SetServingStatus(service, status)
+		}
+	}
+	setStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+
+	go func() {
+		ticker := time.NewTicker(Interval)
+		defer ticker.Stop()
+
+		var last error
+		healthy := false
+		for {
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			err := check(checkCtx)
+			cancel()
+
+			switch {
+			case err == nil && !healthy:
+				if last != nil {
+					log.Println("backend is reachable again")
+				}
+				setStatus(healthpb.HealthCheckResponse_SERVING)
+				healthy = true
+			case err != nil && (healthy || last == nil):
+				log.Println("backend health check failed:", err)
+				setStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+				healthy = false
+			}
+			last = err
+
+			select {
+			case <-ctx.Done():
+				hs.Shutdown()
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return hs
+}
+
+// Check asks the server behind conn for the health of service, "" meaning
+// the server as a whole, and returns ErrNotServing unless it is SERVING.
+func Check(ctx context.Context, conn *grpc.ClientConn, service string) error {
+	res, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		return err
+	}
+	if res.Status != healthpb.HealthCheckResponse_SERVING {
+		return ErrNotServing.With("status", res.Status.String())
+	}
+	return nil
+}
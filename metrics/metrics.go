@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sunil8777/E-commerce-microservices/shutdown"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	rpcRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_handled_total",
+		Help: "RPCs completed on the server, by method and status code.",
+	}, []string{"service", "method", "code"})
+
+	rpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_handling_seconds",
+		Help:    "Time taken to handle an RPC on the server.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"service", "method"})
+
+	queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "repository_query_duration_seconds",
+		Help:    "Latency of repository calls against Postgres or Elasticsearch.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"store", "operation", "outcome"})
+)
+
+// Handler serves the default registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ListenHTTP serves /metrics on port until ctx is done. Services run it next
+// to ListenGRPC so that scraping never competes with RPC traffic on the gRPC
+// port.
+func ListenHTTP(ctx context.Context, port int) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	return shutdown.ServeHTTP(ctx, &http.Server{
+		Addr:    ":" + strconv.Itoa(port),
+		Handler: mux,
+	})
+}
+
+// UnaryServerInterceptor records the rate, errors and duration of every
+// unary RPC. It must run outside errs.UnaryServerInterceptor so that it sees
+// the final status code rather than the domain error.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		res, err := handler(ctx, req)
+
+		service, method := splitMethod(info.FullMethod)
+		rpcRequests.WithLabelValues(service, method, status.Code(err).String()).Inc()
+		rpcDuration.WithLabelValues(service, method).Observe(time.Since(start).Seconds())
+		return res, err
+	}
+}
+
+// Query times one repository call. Defer the returned function with a
+// pointer to the call's error result:
+//
+//	defer metrics.Query("postgres", "PutAccount")(&err)
+func Query(store string, operation string) func(err *error) {
+	start := time.Now()
+	return func(err *error) {
+		outcome := "ok"
+		if err != nil && *err != nil {
+			outcome = "error"
+		}
+		queryDuration.WithLabelValues(store, operation, outcome).Observe(time.Since(start).Seconds())
+	}
+}
+
+// splitMethod turns "/pb.AccountService/PostAccount" into its service and
+// method parts.
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
@@ -0,0 +1,68 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+var errUsage = errors.New("usage: migrate [up | down [steps] | to <version> | version]")
+
+// Command runs the migrate subcommand of a service binary with the
+// arguments that follow "migrate". Without arguments it migrates up.
+func Command(ctx context.Context, m *Migrator, args []string) error {
+	if len(args) == 0 {
+		args = []string{"up"}
+	}
+
+	switch args[0] {
+	case "up":
+		if len(args) != 1 {
+			return errUsage
+		}
+		if err := m.Up(ctx); err != nil {
+			return err
+		}
+	case "down":
+		steps := 1
+		if len(args) > 2 {
+			return errUsage
+		}
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("migrate: steps must be a positive number, got %q", args[1])
+			}
+			steps = n
+		}
+		if err := m.Down(ctx, steps); err != nil {
+			return err
+		}
+	case "to":
+		if len(args) != 2 {
+			return errUsage
+		}
+		target, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("migrate: version must be a number, got %q", args[1])
+		}
+		if err := m.To(ctx, target); err != nil {
+			return err
+		}
+	case "version":
+		if len(args) != 1 {
+			return errUsage
+		}
+	default:
+		return errUsage
+	}
+
+	v, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("database is at version %d of %d", v, m.Latest())
+	return nil
+}
@@ -0,0 +1,268 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+
+	_ "github.com/lib/pq"
+)
+
+// ErrSchemaMismatch is returned by Check when the database is not at the
+// version the code was built for, and by To when the database is newer than
+// the code.
+var ErrSchemaMismatch = errors.New("migrate: schema version mismatch")
+
+// lockID is the Postgres advisory lock held while migrating, so replicas
+// started together do not apply the same migration twice.
+const lockID = 7226193014
+
+// Migration is one schema version. Up moves the database from Version-1 to
+// Version and Down moves it back.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Load reads the migrations in dir of fsys. Files are named
+// NNNN_name.up.sql and NNNN_name.down.sql; versions must start at 1 and
+// have no gaps, and every version needs both files.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		m := fileName.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(m[1])
+		body, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("migrate: version %d has two names, %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	for i, mig := range migrations {
+		if mig.Version != i+1 {
+			return nil, fmt.Errorf("migrate: missing version %d", i+1)
+		}
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migrate: version %d needs both an up and a down file", mig.Version)
+		}
+	}
+	return migrations, nil
+}
+
+// MustLoad is Load for migrations embedded in the binary, where an error is
+// a build mistake.
+func MustLoad(fsys fs.FS, dir string) []Migration {
+	migrations, err := Load(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return migrations
+}
+
+// Migrator applies migrations to a database and records the applied
+// versions in the schema_migrations table. Every migration runs in its own
+// transaction together with its schema_migrations row, so a failed
+// migration leaves the database at the previous version.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	owned      bool
+}
+
+func New(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Open connects to the Postgres database at url for a Migrator of its own.
+func Open(url string, migrations []Migration) (*Migrator, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations, owned: true}, nil
+}
+
+// Close closes the database if the Migrator opened it.
+func (m *Migrator) Close() error {
+	if m.owned {
+		return m.db.Close()
+	}
+	return nil
+}
+
+// Latest is the version the code expects.
+func (m *Migrator) Latest() int {
+	return len(m.migrations)
+}
+
+// Version is the version the database is at; 0 before any migration.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	return version(ctx, m.db)
+}
+
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func version(ctx context.Context, q queryer) (int, error) {
+	var exists bool
+	if err := q.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	var v int
+	err := q.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&v)
+	return v, err
+}
+
+// Check returns ErrSchemaMismatch unless the database is at Latest.
+// Services call it at startup rather than migrating on their own.
+func (m *Migrator) Check(ctx context.Context) error {
+	v, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if v != m.Latest() {
+		return fmt.Errorf("%w: database is at version %d, code expects %d; run the migrate subcommand", ErrSchemaMismatch, v, m.Latest())
+	}
+	return nil
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.Latest())
+}
+
+// Down reverts the last steps migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	v, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if steps > v {
+		steps = v
+	}
+	return m.To(ctx, v-steps)
+}
+
+// To migrates up or down to target.
+func (m *Migrator) To(ctx context.Context, target int) error {
+	if target < 0 || target > m.Latest() {
+		return fmt.Errorf("migrate: no version %d, latest is %d", target, m.Latest())
+	}
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// Read the version under the lock; another replica may have just moved it.
+	current, err := version(ctx, conn)
+	if err != nil {
+		return err
+	}
+	// Code older than the database cannot revert migrations it does not
+	// know.
+	if current > m.Latest() {
+		return fmt.Errorf("%w: database is at version %d, newer than the latest known %d", ErrSchemaMismatch, current, m.Latest())
+	}
+
+	for current < target {
+		mig := m.migrations[current]
+		if err := apply(ctx, conn, mig.Up, "INSERT INTO schema_migrations(version, name) VALUES ($1, $2)", mig.Version, mig.Name); err != nil {
+			return fmt.Errorf("migrate: applying %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		log.Printf("applied migration %d_%s", mig.Version, mig.Name)
+		current++
+	}
+	for current > target {
+		mig := m.migrations[current-1]
+		if err := apply(ctx, conn, mig.Down, "DELETE FROM schema_migrations WHERE version = $1", mig.Version); err != nil {
+			return fmt.Errorf("migrate: reverting %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		log.Printf("reverted migration %d_%s", mig.Version, mig.Name)
+		current--
+	}
+	return nil
+}
+
+func apply(ctx context.Context, conn *sql.Conn, body, record string, args ...interface{}) (err error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	if _, err = tx.ExecContext(ctx, body); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, record, args...)
+	return err
+}
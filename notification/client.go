@@ -0,0 +1,65 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/sunil8777/E-commerce-microservices/grpcopts"
+	"github.com/sunil8777/E-commerce-microservices/healthcheck"
+	pb "github.com/sunil8777/E-commerce-microservices/notification/pb"
+	"google.golang.org/grpc"
+)
+
+type Client struct {
+	conn    *grpc.ClientConn
+	service pb.NotificationServiceClient
+}
+
+// NewClient dials url with the shared grpcopts defaults. opts are applied
+// after them, for example to guard the connection with a retry.Breaker.
+func NewClient(url string, opts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.NewClient(
+		grpcopts.Target(url),
+		grpcopts.New(pb.NotificationService_ServiceDesc.ServiceName).
+			Idempotent("GetPreferences", "UpdatePreferences").
+			DialOptions(opts...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	c := pb.NewNotificationServiceClient(conn)
+	return &Client{conn, c}, nil
+}
+
+func (c *Client) Close() {
+	c.conn.Close()
+}
+
+// HealthCheck reports whether the notification service is SERVING.
+func (c *Client) HealthCheck(ctx context.Context) error {
+	return healthcheck.Check(ctx, c.conn, "")
+}
+
+func (c *Client) GetPreferences(ctx context.Context, accountID string) (*Preferences, error) {
+	r, err := c.service.GetPreferences(ctx, &pb.GetPreferencesRequest{AccountId: accountID})
+	if err != nil {
+		return nil, err
+	}
+	return preferencesFromProto(r.Preferences), nil
+}
+
+func (c *Client) UpdatePreferences(ctx context.Context, p Preferences) (*Preferences, error) {
+	r, err := c.service.UpdatePreferences(ctx, &pb.UpdatePreferencesRequest{Preferences: preferencesToProto(p)})
+	if err != nil {
+		return nil, err
+	}
+	return preferencesFromProto(r.Preferences), nil
+}
+
+func preferencesFromProto(p *pb.Preferences) *Preferences {
+	return &Preferences{
+		AccountID:          p.AccountId,
+		OrderConfirmations: p.OrderConfirmations,
+		ShippingUpdates:    p.ShippingUpdates,
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/sunil8777/E-commerce-microservices/grpcopts"
+	"github.com/sunil8777/E-commerce-microservices/metrics"
+	"github.com/sunil8777/E-commerce-microservices/migrate"
+	"github.com/sunil8777/E-commerce-microservices/notification"
+	"github.com/sunil8777/E-commerce-microservices/retry"
+	"github.com/sunil8777/E-commerce-microservices/shutdown"
+	"github.com/sunil8777/E-commerce-microservices/tlsconfig"
+	"github.com/sunil8777/E-commerce-microservices/tracing"
+)
+
+type Config struct {
+	DatabaseURL     string `envconfig:"DATABASE_URL"`
+	AccountURL      string `envconfig:"ACCOUNT_SERVICE_URL"`
+	TracingExporter string `envconfig:"TRACING_EXPORTER" default:"none"`
+	MetricsPort     int    `envconfig:"METRICS_PORT" default:"9090"`
+
+	// Mail is sent through SMTPHost when it is set, and written to MailDir
+	// as .eml files otherwise.
+	SMTPHost     string `envconfig:"SMTP_HOST"`
+	SMTPPort     int    `envconfig:"SMTP_PORT" default:"1025"`
+	SMTPUsername string `envconfig:"SMTP_USERNAME"`
+	SMTPPassword string `envconfig:"SMTP_PASSWORD"`
+	MailFrom     string `envconfig:"MAIL_FROM" default:"shop@example.com"`
+	MailDir      string `envconfig:"MAIL_DIR" default:"./mail"`
+
+	TLS tlsconfig.Config
+}
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves until SIGINT or SIGTERM and then unwinds in reverse order of
+// startup: drain the gRPC server, close the repository, flush traces.
+func run() error {
+	var cfg Config
+	err := envconfig.Process("", &cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := shutdown.Context()
+	defer stop()
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		return migrateDatabase(ctx, cfg.DatabaseURL, os.Args[2:])
+	}
+
+	if _, err := grpcopts.ConfigureTLS(ctx, cfg.TLS); err != nil {
+		return err
+	}
+
+	shutdownTracing, err := tracing.Init(ctx, "notification", cfg.TracingExporter)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), shutdown.Timeout)
+		defer cancel()
+		shutdownTracing(flushCtx)
+	}()
+
+	var sender notification.Sender
+	if cfg.SMTPHost != "" {
+		sender = notification.NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	} else {
+		log.Printf("SMTP_HOST is not set; writing mail to %s", cfg.MailDir)
+		sender, err = notification.NewFileSender(cfg.MailDir, cfg.MailFrom)
+		if err != nil {
+			return err
+		}
+	}
+
+	var r notification.Repository
+	err = retry.Do(ctx, retry.Connect("notification database"), func(ctx context.Context) error {
+		r, err = notification.NewPostgresRepository(cfg.DatabaseURL)
+		if errors.Is(err, migrate.ErrSchemaMismatch) {
+			return retry.Permanent(err)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	go func() {
+		if err := metrics.ListenHTTP(ctx, cfg.MetricsPort); err != nil {
+			log.Println(err)
+		}
+	}()
+
+	log.Println("Listening on port 8080...")
+	s := notification.NewService(r, sender)
+	return notification.ListenGRPC(ctx, s, cfg.AccountURL, 8080)
+}
+
+// migrateDatabase runs "notification migrate [args]": it moves the database
+// schema to the version this binary expects, or to the one given in args,
+// and exits. See migrate.Command for the arguments.
+func migrateDatabase(ctx context.Context, url string, args []string) error {
+	var m *migrate.Migrator
+	err := retry.Do(ctx, retry.Connect("notification database"), func(ctx context.Context) error {
+		var err error
+		m, err = migrate.Open(url, notification.Migrations)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	return migrate.Command(ctx, m, args)
+}
@@ -0,0 +1,18 @@
+package notification
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	notificationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notifications_total",
+		Help: "Notifications that were sent, failed for good, or skipped, by template.",
+	}, []string{"template", "status"})
+
+	notificationsDuplicate = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "notifications_duplicate_events_total",
+		Help: "Events ignored because a notification for them already exists.",
+	})
+)
@@ -0,0 +1,20 @@
+-- One row per event that calls for an email, whether or not the email is
+-- sent. The unique event_id is what makes redelivered events harmless.
+CREATE TABLE IF NOT EXISTS notifications (
+  id CHAR(27) PRIMARY KEY,
+  event_id CHAR(27) NOT NULL UNIQUE,
+  account_id VARCHAR(27) NOT NULL DEFAULT '',
+  template VARCHAR(64) NOT NULL,
+  recipient VARCHAR(254) NOT NULL DEFAULT '',
+  subject TEXT NOT NULL DEFAULT '',
+  body TEXT NOT NULL DEFAULT '',
+  status VARCHAR(16) NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  last_error TEXT NOT NULL DEFAULT '',
+  next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  sent_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS notifications_pending_idx ON notifications (next_attempt_at)
+  WHERE status = 'pending';
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS notification_preferences (
+  account_id CHAR(27) PRIMARY KEY,
+  order_confirmations BOOLEAN NOT NULL,
+  shipping_updates BOOLEAN NOT NULL,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
@@ -0,0 +1,207 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/sunil8777/E-commerce-microservices/metrics"
+	"github.com/sunil8777/E-commerce-microservices/migrate"
+	"github.com/sunil8777/E-commerce-microservices/tracing"
+)
+
+type Repository interface {
+	Close()
+	Ping(ctx context.Context) error
+	// PutNotification stores n unless a notification for n.EventID exists,
+	// and reports whether it stored it.
+	PutNotification(ctx context.Context, n Notification) (bool, error)
+	UpdateNotification(ctx context.Context, n Notification) error
+	ClaimDueNotifications(ctx context.Context, now time.Time, until time.Time, limit int) ([]Notification, error)
+	GetPreferences(ctx context.Context, accountID string) (*Preferences, error)
+	PutPreferences(ctx context.Context, p Preferences) error
+}
+
+type postgresRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresRepository(url string) (Repository, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Ping()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = migrate.New(db, Migrations).Check(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &postgresRepository{db}, nil
+}
+
+func (r *postgresRepository) Close() {
+	r.db.Close()
+}
+
+func (r *postgresRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+func (r *postgresRepository) PutNotification(ctx context.Context, n Notification) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "notification.repository.PutNotification")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "notification.PutNotification")(&err)
+
+	res, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO notifications(id, event_id, account_id, template, recipient, subject, body, status, last_error, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (event_id) DO NOTHING`,
+		n.ID,
+		n.EventID,
+		n.AccountID,
+		n.Template,
+		n.Recipient,
+		n.Subject,
+		n.Body,
+		n.Status,
+		n.LastError,
+		n.NextAttemptAt,
+		n.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *postgresRepository) UpdateNotification(ctx context.Context, n Notification) (err error) {
+	ctx, span := tracing.Start(ctx, "notification.repository.UpdateNotification")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "notification.UpdateNotification")(&err)
+
+	var sentAt interface{}
+	if !n.SentAt.IsZero() {
+		sentAt = n.SentAt
+	}
+	_, err = r.db.ExecContext(
+		ctx,
+		`UPDATE notifications SET status = $2, attempts = $3, last_error = $4, next_attempt_at = $5, sent_at = $6
+		WHERE id = $1`,
+		n.ID,
+		n.Status,
+		n.Attempts,
+		n.LastError,
+		n.NextAttemptAt,
+		sentAt,
+	)
+	return err
+}
+
+// ClaimDueNotifications moves the next attempt of up to limit pending
+// notifications due at now to until and returns them. SKIP LOCKED keeps two
+// replicas from claiming the same notification, and until keeps them from
+// claiming it again while it is being sent.
+func (r *postgresRepository) ClaimDueNotifications(ctx context.Context, now time.Time, until time.Time, limit int) (_ []Notification, err error) {
+	ctx, span := tracing.Start(ctx, "notification.repository.ClaimDueNotifications")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "notification.ClaimDueNotifications")(&err)
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`UPDATE notifications SET next_attempt_at = $2
+		WHERE id IN (
+			SELECT id FROM notifications
+			WHERE status = $3 AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, event_id, account_id, template, recipient, subject, body, status, attempts, last_error, next_attempt_at, created_at`,
+		now,
+		until,
+		StatusPending,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	due := []Notification{}
+	for rows.Next() {
+		n := Notification{}
+		err = rows.Scan(
+			&n.ID,
+			&n.EventID,
+			&n.AccountID,
+			&n.Template,
+			&n.Recipient,
+			&n.Subject,
+			&n.Body,
+			&n.Status,
+			&n.Attempts,
+			&n.LastError,
+			&n.NextAttemptAt,
+			&n.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		due = append(due, n)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return due, nil
+}
+
+func (r *postgresRepository) GetPreferences(ctx context.Context, accountID string) (_ *Preferences, err error) {
+	ctx, span := tracing.Start(ctx, "notification.repository.GetPreferences")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "notification.GetPreferences")(&err)
+
+	p := Preferences{AccountID: accountID}
+	err = r.db.QueryRowContext(
+		ctx,
+		"SELECT order_confirmations, shipping_updates, updated_at FROM notification_preferences WHERE account_id = $1",
+		accountID,
+	).Scan(&p.OrderConfirmations, &p.ShippingUpdates, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrPreferencesNotFound.With("accountId", accountID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *postgresRepository) PutPreferences(ctx context.Context, p Preferences) (err error) {
+	ctx, span := tracing.Start(ctx, "notification.repository.PutPreferences")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "notification.PutPreferences")(&err)
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`INSERT INTO notification_preferences(account_id, order_confirmations, shipping_updates, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (account_id) DO UPDATE
+		SET order_confirmations = EXCLUDED.order_confirmations, shipping_updates = EXCLUDED.shipping_updates, updated_at = EXCLUDED.updated_at`,
+		p.AccountID,
+		p.OrderConfirmations,
+		p.ShippingUpdates,
+		p.UpdatedAt,
+	)
+	return err
+}
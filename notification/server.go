@@ -0,0 +1,165 @@
+package notification
+
+import (
+	"context"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/sunil8777/E-commerce-microservices/account"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+	"github.com/sunil8777/E-commerce-microservices/events"
+	eventspb "github.com/sunil8777/E-commerce-microservices/events/pb"
+	"github.com/sunil8777/E-commerce-microservices/grpcopts"
+	"github.com/sunil8777/E-commerce-microservices/healthcheck"
+	"github.com/sunil8777/E-commerce-microservices/metrics"
+	pb "github.com/sunil8777/E-commerce-microservices/notification/pb"
+	"github.com/sunil8777/E-commerce-microservices/retry"
+	"github.com/sunil8777/E-commerce-microservices/shutdown"
+	"github.com/sunil8777/E-commerce-microservices/tlsconfig"
+	"github.com/sunil8777/E-commerce-microservices/tracing"
+	"github.com/sunil8777/E-commerce-microservices/validation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// deliveryInterval is how often pending notifications are looked for.
+const deliveryInterval = time.Second
+
+// peerRules limits publishing events to the services that produce them.
+// They are enforced when the server runs with mutual TLS.
+var peerRules = map[string][]string{
+	eventspb.EventSink_Publish_FullMethodName: {"account", "order"},
+}
+
+type grpcServer struct {
+	pb.UnimplementedNotificationServiceServer
+	service Service
+}
+
+// sinkServer receives the events of the other services.
+type sinkServer struct {
+	eventspb.UnimplementedEventSinkServer
+	service       Service
+	accountClient *account.Client
+}
+
+func ListenGRPC(ctx context.Context, s Service, accountURL string, port int) error {
+	lis, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		return err
+	}
+	return Serve(ctx, s, accountURL, lis)
+}
+
+// Serve serves s on lis until ctx is done. opts are added to the dial
+// options of the account client. It also delivers pending notifications
+// until ctx is done.
+func Serve(ctx context.Context, s Service, accountURL string, lis net.Listener, opts ...grpc.DialOption) error {
+	accountClient, err := account.NewClient(accountURL, append([]grpc.DialOption{retry.NewBreaker("account").DialOption()}, opts...)...)
+	if err != nil {
+		lis.Close()
+		return err
+	}
+	defer accountClient.Close()
+
+	server := grpc.NewServer(append(
+		grpcopts.ServerOptions(),
+		grpc.ChainUnaryInterceptor(
+			metrics.UnaryServerInterceptor(),
+			errs.UnaryServerInterceptor(),
+			tlsconfig.AuthorizePeers(peerRules),
+			validation.UnaryServerInterceptor(),
+		),
+		tracing.ServerOption(),
+	)...)
+	pb.RegisterNotificationServiceServer(server, &grpcServer{service: s})
+	eventspb.RegisterEventSinkServer(server, &sinkServer{service: s, accountClient: accountClient})
+
+	delivered := make(chan struct{})
+	go func() {
+		defer close(delivered)
+		deliver(ctx, s, deliveryInterval)
+	}()
+	defer func() { <-delivered }()
+
+	healthcheck.Register(ctx, server, s.Ping, pb.NotificationService_ServiceDesc.ServiceName)
+	reflection.Register(server)
+	// The account client is closed by the deferred call above, only once
+	// the server has drained the RPCs.
+	return shutdown.ServeGRPC(ctx, server, lis)
+}
+
+// deliver sends due notifications right away and then every interval until
+// ctx is done. A pass that sends a full batch is followed by another one
+// straight away.
+func deliver(ctx context.Context, s Service, interval time.Duration) {
+	for {
+		n, err := s.DeliverDue(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Println("Error delivering notifications:", err)
+		}
+		if n == deliveryBatch {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Publish looks up the account e is about and hands both to the service.
+// An error makes the publishing relay try again later.
+func (s *sinkServer) Publish(ctx context.Context, r *eventspb.PublishRequest) (*eventspb.PublishResponse, error) {
+	e := events.FromProto(r.Event)
+
+	to := Recipient{AccountID: e.AccountID}
+	if e.AccountID != "" {
+		a, err := s.accountClient.GetAccount(ctx, e.AccountID)
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+		to.Name, to.Email = a.Name, a.Email
+	}
+
+	if err := s.service.HandleEvent(ctx, e, to); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return &eventspb.PublishResponse{}, nil
+}
+
+func (s *grpcServer) GetPreferences(ctx context.Context, r *pb.GetPreferencesRequest) (*pb.GetPreferencesResponse, error) {
+	p, err := s.service.GetPreferences(ctx, r.AccountId)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return &pb.GetPreferencesResponse{Preferences: preferencesToProto(*p)}, nil
+}
+
+func (s *grpcServer) UpdatePreferences(ctx context.Context, r *pb.UpdatePreferencesRequest) (*pb.UpdatePreferencesResponse, error) {
+	p, err := s.service.UpdatePreferences(ctx, Preferences{
+		AccountID:          r.Preferences.AccountId,
+		OrderConfirmations: r.Preferences.OrderConfirmations,
+		ShippingUpdates:    r.Preferences.ShippingUpdates,
+	})
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return &pb.UpdatePreferencesResponse{Preferences: preferencesToProto(*p)}, nil
+}
+
+func preferencesToProto(p Preferences) *pb.Preferences {
+	return &pb.Preferences{
+		AccountId:          p.AccountID,
+		OrderConfirmations: p.OrderConfirmations,
+		ShippingUpdates:    p.ShippingUpdates,
+	}
+}
@@ -0,0 +1,220 @@
+package notification
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/segmentio/ksuid"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+	"github.com/sunil8777/E-commerce-microservices/events"
+	"github.com/sunil8777/E-commerce-microservices/retry"
+)
+
+var (
+	ErrPreferencesNotFound = errs.New(errs.NotFound, "PREFERENCES_NOT_FOUND", "notification preferences not found")
+)
+
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	// StatusFailed notifications ran out of attempts or were rejected by
+	// the mail server.
+	StatusFailed = "failed"
+	// StatusSkipped notifications were not sent because of the account's
+	// preferences or because it has no email address. They are kept so
+	// that a redelivered event is still recognised as a duplicate.
+	StatusSkipped = "skipped"
+)
+
+const (
+	// deliveryBatch is how many due notifications one delivery pass sends.
+	deliveryBatch = 50
+	// deliveryLease is how long a claimed notification is left to the
+	// replica that claimed it before another may retry it.
+	deliveryLease = time.Minute
+)
+
+// deliveryPolicy spaces out the attempts at sending one notification.
+var deliveryPolicy = retry.Policy{
+	InitialInterval: 30 * time.Second,
+	MaxInterval:     time.Hour,
+	Multiplier:      2,
+	Jitter:          0.2,
+	MaxAttempts:     8,
+}
+
+type Service interface {
+	HandleEvent(ctx context.Context, e events.Event, to Recipient) error
+	DeliverDue(ctx context.Context) (int, error)
+	GetPreferences(ctx context.Context, accountID string) (*Preferences, error)
+	UpdatePreferences(ctx context.Context, p Preferences) (*Preferences, error)
+	Ping(ctx context.Context) error
+}
+
+// Recipient is the account an event is about, as the account service
+// knows it when the event arrives.
+type Recipient struct {
+	AccountID string
+	Name      string
+	Email     string
+}
+
+// Notification is one email, rendered when its event arrived and kept
+// until it is sent or given up on. EventID is unique, which is what makes
+// redelivered events harmless.
+type Notification struct {
+	ID            string
+	EventID       string
+	AccountID     string
+	Template      string
+	Recipient     string
+	Subject       string
+	Body          string
+	Status        string
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	SentAt        time.Time
+}
+
+type Preferences struct {
+	AccountID          string
+	OrderConfirmations bool
+	ShippingUpdates    bool
+	UpdatedAt          time.Time
+}
+
+// DefaultPreferences are the preferences of accounts that never set any:
+// every email is sent.
+func DefaultPreferences(accountID string) Preferences {
+	return Preferences{
+		AccountID:          accountID,
+		OrderConfirmations: true,
+		ShippingUpdates:    true,
+	}
+}
+
+type notificationService struct {
+	repository Repository
+	sender     Sender
+}
+
+func NewService(r Repository, s Sender) Service {
+	return &notificationService{r, s}
+}
+
+// HandleEvent renders the email for e, if e calls for one, and queues it
+// for delivery. Events that were handled before are ignored.
+func (s *notificationService) HandleEvent(ctx context.Context, e events.Event, to Recipient) error {
+	t, ok := templates[e.Type]
+	if !ok {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	n := Notification{
+		ID:            ksuid.New().String(),
+		EventID:       e.ID,
+		AccountID:     e.AccountID,
+		Template:      t.name,
+		Recipient:     to.Email,
+		Status:        StatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+
+	prefs, err := s.GetPreferences(ctx, e.AccountID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case t.wanted != nil && !t.wanted(*prefs):
+		n.Status, n.LastError = StatusSkipped, "disabled in preferences"
+	case to.Email == "":
+		n.Status, n.LastError = StatusSkipped, "account has no email address"
+	default:
+		n.Subject, n.Body, err = t.render(e, to)
+		if err != nil {
+			return err
+		}
+	}
+
+	created, err := s.repository.PutNotification(ctx, n)
+	if err != nil {
+		return err
+	}
+	if !created {
+		notificationsDuplicate.Inc()
+		return nil
+	}
+	if n.Status == StatusSkipped {
+		notificationsTotal.WithLabelValues(t.name, StatusSkipped).Inc()
+	}
+	return nil
+}
+
+// DeliverDue sends the pending notifications whose next attempt is due and
+// returns how many it attempted. A notification that fails to send is
+// retried according to deliveryPolicy, unless the mail server rejected it
+// for good.
+func (s *notificationService) DeliverDue(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	due, err := s.repository.ClaimDueNotifications(ctx, now, now.Add(deliveryLease), deliveryBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, n := range due {
+		err := s.sender.Send(ctx, Message{
+			ID:      n.ID,
+			To:      n.Recipient,
+			Subject: n.Subject,
+			Body:    n.Body,
+		})
+
+		n.Attempts++
+		now := time.Now().UTC()
+		switch {
+		case err == nil:
+			n.Status, n.SentAt, n.LastError = StatusSent, now, ""
+		case retry.IsPermanent(err) || n.Attempts >= deliveryPolicy.MaxAttempts:
+			n.Status, n.LastError = StatusFailed, err.Error()
+			log.Printf("Giving up on notification %s to %s: %v", n.ID, n.Recipient, err)
+		default:
+			n.LastError = err.Error()
+			n.NextAttemptAt = now.Add(deliveryPolicy.Backoff(n.Attempts))
+		}
+		if n.Status != StatusPending {
+			notificationsTotal.WithLabelValues(n.Template, n.Status).Inc()
+		}
+
+		if err := s.repository.UpdateNotification(ctx, n); err != nil {
+			return 0, err
+		}
+	}
+	return len(due), nil
+}
+
+func (s *notificationService) GetPreferences(ctx context.Context, accountID string) (*Preferences, error) {
+	p, err := s.repository.GetPreferences(ctx, accountID)
+	if errs.CodeOf(err) == errs.NotFound {
+		d := DefaultPreferences(accountID)
+		return &d, nil
+	}
+	return p, err
+}
+
+func (s *notificationService) UpdatePreferences(ctx context.Context, p Preferences) (*Preferences, error) {
+	p.UpdatedAt = time.Now().UTC()
+	if err := s.repository.PutPreferences(ctx, p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Ping fails while the notifications database in Postgres is unreachable.
+func (s *notificationService) Ping(ctx context.Context) error {
+	return s.repository.Ping(ctx)
+}
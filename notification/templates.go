@@ -0,0 +1,79 @@
+package notification
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/sunil8777/E-commerce-microservices/events"
+)
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+var templateFuncs = template.FuncMap{
+	"money": func(v float64) string { return fmt.Sprintf("$%.2f", v) },
+}
+
+// parseTemplate parses templates/<name>.tmpl, which defines a "subject" and
+// a plain-text "body" template, both executed with a templateData.
+func parseTemplate(name string) *template.Template {
+	return template.Must(template.New(name).Funcs(templateFuncs).ParseFS(templateFiles, "templates/"+name+".tmpl"))
+}
+
+// emailTemplate is the email sent for one type of event.
+type emailTemplate struct {
+	name string
+	tmpl *template.Template
+	// wanted reports whether preferences allow the email. Nil means the
+	// email is always sent.
+	wanted func(p Preferences) bool
+	// payload returns a pointer to decode the event payload into.
+	payload func() interface{}
+}
+
+// templates maps the event types that are notified to their email. Other
+// events are ignored.
+var templates = map[string]emailTemplate{
+	events.AccountCreated: {
+		name:    "welcome",
+		tmpl:    parseTemplate("welcome"),
+		payload: func() interface{} { return &events.AccountCreatedPayload{} },
+	},
+	events.OrderPlaced: {
+		name:    "order_confirmation",
+		tmpl:    parseTemplate("order_confirmation"),
+		wanted:  func(p Preferences) bool { return p.OrderConfirmations },
+		payload: func() interface{} { return &events.OrderPlacedPayload{} },
+	},
+	events.OrderShipped: {
+		name:    "shipping_update",
+		tmpl:    parseTemplate("shipping_update"),
+		wanted:  func(p Preferences) bool { return p.ShippingUpdates },
+		payload: func() interface{} { return &events.OrderShippedPayload{} },
+	},
+}
+
+type templateData struct {
+	Recipient Recipient
+	Payload   interface{}
+}
+
+// render returns the subject and body of the email for e.
+func (t emailTemplate) render(e events.Event, to Recipient) (string, string, error) {
+	payload := t.payload()
+	if err := e.Decode(payload); err != nil {
+		return "", "", fmt.Errorf("decoding %s event %s: %w", e.Type, e.ID, err)
+	}
+	data := templateData{Recipient: to, Payload: payload}
+
+	var subject, body strings.Builder
+	if err := t.tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return "", "", err
+	}
+	if err := t.tmpl.ExecuteTemplate(&body, "body", data); err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(subject.String()), strings.TrimLeft(body.String(), "\n"), nil
+}
@@ -5,9 +5,11 @@ import (
 	"log"
 	"time"
 
+	"github.com/sunil8777/E-commerce-microservices/grpcopts"
+	"github.com/sunil8777/E-commerce-microservices/healthcheck"
 	pb "github.com/sunil8777/E-commerce-microservices/order/pb"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type Client struct {
@@ -15,8 +17,16 @@ type Client struct {
 	service pb.OrderServiceClient
 }
 
-func NewClient(url string) (*Client, error) {
-	conn, err := grpc.NewClient(url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// NewClient dials url with the shared grpcopts defaults. opts are applied
+// after them, for example to guard the connection with a retry.Breaker.
+func NewClient(url string, opts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.NewClient(
+		grpcopts.Target(url),
+		grpcopts.New(pb.OrderService_ServiceDesc.ServiceName).
+			Idempotent("GetOrderForAccount", "SearchOrders", "GetInvoice", "ListShipments", "ListReturns", "ListRefunds", "ListSubscriptions").
+			Timeout("PostOrder", 10*time.Second).
+			DialOptions(opts...)...,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -29,12 +39,25 @@ func (c *Client) Close() {
 	c.conn.Close()
 }
 
-func (c *Client) PostOrder(ctx context.Context, accountID string, products []OrderedProduct) (*Order, error) {
+// HealthCheck fails unless the order service reports SERVING.
+func (c *Client) HealthCheck(ctx context.Context) error {
+	return healthcheck.Check(ctx, c.conn, "")
+}
+
+// PostOrder places an order. shippingAddress may be zero.
+func (c *Client) PostOrder(ctx context.Context, accountID string, products []OrderedProduct, shippingAddress Address) (*Order, error) {
+	return c.PostOrderOnce(ctx, "", accountID, products, shippingAddress)
+}
+
+// PostOrderOnce is PostOrder placing at most one order per account and
+// idempotencyKey: posting again returns the order posted first.
+func (c *Client) PostOrderOnce(ctx context.Context, idempotencyKey string, accountID string, products []OrderedProduct, shippingAddress Address) (*Order, error) {
 	protoProducts := []*pb.PostOrderRequest_OrderProduct{}
 	for _, p := range products{
 		protoProducts = append(protoProducts, &pb.PostOrderRequest_OrderProduct{
 			ProductId: p.ID,
 			Quantity: uint32(p.Quantity),
+			VariantId: p.VariantID,
 		})
 	}
 	r, err := c.service.PostOrder(
@@ -42,58 +65,415 @@ func (c *Client) PostOrder(ctx context.Context, accountID string, products []Ord
 		&pb.PostOrderRequest{
 			AccountId: accountID,
 			Products: protoProducts,
+			ShippingAddress: addressToProto(shippingAddress),
+			IdempotencyKey: idempotencyKey,
 		},
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	newOrder := r.Order
-	newOrderCreatedAt := time.Time{}
-	newOrderCreatedAt.UnmarshalBinary(newOrder.CreatedAt)
+	return orderFromProto(r.Order), nil
+}
 
-	return &Order{
-		ID: newOrder.Id,
-		CreatedAt: newOrderCreatedAt,
-		TotalPrice: newOrder.TotalPrice,
-		AccountID: newOrder.AccountId,
-	},nil
+// GetOrderForAccount returns every order of the account, oldest first,
+// fetching the history page by page. Use GetOrdersPage to show a history.
+func (c *Client) GetOrderForAccount(ctx context.Context, accountID string) ([]Order, error) {
+	orders := []Order{}
+	after := ""
+	for {
+		page, err := c.GetOrdersPage(ctx, accountID, OrderQuery{}, after, 0)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, page.Orders...)
+		if !page.HasNextPage || len(page.Cursors) == 0 {
+			return orders, nil
+		}
+		after = page.Cursors[len(page.Cursors)-1]
+	}
 }
 
-func (c *Client) GetOrderForAccount(ctx context.Context, accountID string ) ([]Order, error) {
-	r, err := c.service.GetOrderForAccount(ctx, &pb.GetOrderForAccountRequest{
+// GetOrdersPage returns up to take orders of the account matching q,
+// starting after the order the after cursor points at.
+func (c *Client) GetOrdersPage(ctx context.Context, accountID string, q OrderQuery, after string, take uint64) (*OrderPage, error) {
+	req := &pb.GetOrderForAccountRequest{
 		AccountId: accountID,
-	})
+		Take:      take,
+		After:     after,
+		Status:    q.Status,
+		MinTotal:  q.MinTotal,
+	}
+	if !q.PlacedFrom.IsZero() {
+		req.PlacedFrom = timestamppb.New(q.PlacedFrom)
+	}
+	if !q.PlacedTo.IsZero() {
+		req.PlacedTo = timestamppb.New(q.PlacedTo)
+	}
+	if q.NewestFirst {
+		req.Sort = pb.SortDirection_NEWEST_FIRST
+	}
+	r, err := c.service.GetOrderForAccount(ctx, req)
 	if err != nil {
 		log.Println(err)
-		return nil,err
+		return nil, err
 	}
 
-	orders := []Order{}
-	for _, orderProto := range r.Orders { 
-		newOrder := Order{
-			ID: orderProto.Id,
-			TotalPrice: orderProto.TotalPrice,
-			AccountID: orderProto.AccountId,
-		}
-		newOrderCreatedAt := time.Time{}
-		newOrderCreatedAt.UnmarshalBinary(orderProto.CreatedAt)
-
-		products := []OrderedProduct{}
-
-		for _,op := range orderProto.Products{
-			products = append(products, OrderedProduct{
-				ID: op.Id,
-				Name: op.Name,
-				Description: op.Description,
-				Price: op.Price,
-				Quantity: uint64(op.Quantity),
-			})
-		}
-		newOrder.Products = products
-		orders = append(orders, newOrder)
+	return orderPageFromProto(r.Orders, r.Cursors, r.HasNextPage), nil
+}
+
+// SearchOrders returns up to take orders of any account matching q,
+// starting after the order the after cursor points at. The order service
+// only accepts it from the gateway and the review service when it runs with
+// mutual TLS.
+func (c *Client) SearchOrders(ctx context.Context, q OrderQuery, after string, take uint64) (*OrderPage, error) {
+	req := &pb.SearchOrdersRequest{
+		AccountId: q.AccountID,
+		ProductId: q.ProductID,
+		Status:    q.Status,
+		MinTotal:  q.MinTotal,
+		MaxTotal:  q.MaxTotal,
+		Take:      take,
+		After:     after,
+	}
+	if !q.PlacedFrom.IsZero() {
+		req.PlacedFrom = timestamppb.New(q.PlacedFrom)
+	}
+	if !q.PlacedTo.IsZero() {
+		req.PlacedTo = timestamppb.New(q.PlacedTo)
+	}
+	if q.NewestFirst {
+		req.Sort = pb.SortDirection_NEWEST_FIRST
+	}
+	r, err := c.service.SearchOrders(ctx, req)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return orderPageFromProto(r.Orders, r.Cursors, r.HasNextPage), nil
+}
+
+// GetInvoice returns the invoice issued when the order was confirmed.
+func (c *Client) GetInvoice(ctx context.Context, orderID string) (*Invoice, error) {
+	r, err := c.service.GetInvoice(ctx, &pb.GetInvoiceRequest{OrderId: orderID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Invoice{
+		Number:   r.Invoice.Number,
+		OrderID:  r.Invoice.OrderId,
+		IssuedAt: r.Invoice.IssuedAt.AsTime(),
+		HTML:     r.Invoice.Html,
+		PDF:      r.Invoice.Pdf,
+	}, nil
+}
+
+// CreateShipment records that lines of the order left with carrier. The
+// order service only accepts it from the gateway when it runs with mutual
+// TLS.
+func (c *Client) CreateShipment(ctx context.Context, orderID string, carrier string, trackingNumber string, lines []ShipmentLine) (*Shipment, error) {
+	req := &pb.CreateShipmentRequest{
+		OrderId:        orderID,
+		Carrier:        carrier,
+		TrackingNumber: trackingNumber,
+	}
+	for _, l := range lines {
+		req.Lines = append(req.Lines, &pb.Shipment_Line{ProductId: l.ProductID, VariantId: l.VariantID, Quantity: l.Quantity})
+	}
+	r, err := c.service.CreateShipment(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return shipmentFromProto(r.Shipment), nil
+}
+
+// AddShipmentEvent records a tracking event of the shipment. The order
+// service only accepts it from the gateway when it runs with mutual TLS.
+func (c *Client) AddShipmentEvent(ctx context.Context, shipmentID string, e ShipmentEvent) (*Shipment, error) {
+	req := &pb.AddShipmentEventRequest{
+		ShipmentId:  shipmentID,
+		Status:      e.Status,
+		Description: e.Description,
+		Location:    e.Location,
+	}
+	if !e.OccurredAt.IsZero() {
+		req.OccurredAt = timestamppb.New(e.OccurredAt)
+	}
+	r, err := c.service.AddShipmentEvent(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return shipmentFromProto(r.Shipment), nil
+}
+
+// ListShipments returns the shipments of the order, oldest first.
+func (c *Client) ListShipments(ctx context.Context, orderID string) ([]Shipment, error) {
+	r, err := c.service.ListShipments(ctx, &pb.ListShipmentsRequest{OrderId: orderID})
+	if err != nil {
+		return nil, err
+	}
+
+	shipments := []Shipment{}
+	for _, sh := range r.Shipments {
+		shipments = append(shipments, *shipmentFromProto(sh))
+	}
+	return shipments, nil
+}
+
+// RequestReturn asks to return lines of the order for reason.
+func (c *Client) RequestReturn(ctx context.Context, orderID string, reason string, lines []ReturnLine) (*Return, error) {
+	req := &pb.RequestReturnRequest{OrderId: orderID, Reason: reason}
+	for _, l := range lines {
+		req.Lines = append(req.Lines, &pb.Return_Line{ProductId: l.ProductID, VariantId: l.VariantID, Quantity: l.Quantity})
+	}
+	r, err := c.service.RequestReturn(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return returnFromProto(r.Return), nil
+}
+
+// ApproveReturn approves the return, restocking its items and recording its
+// refund. The order service only accepts it from the gateway when it runs
+// with mutual TLS, like RejectReturn and ReceiveReturn.
+func (c *Client) ApproveReturn(ctx context.Context, id string, note string) (*Return, error) {
+	r, err := c.service.ApproveReturn(ctx, &pb.ApproveReturnRequest{ReturnId: id, Note: note})
+	if err != nil {
+		return nil, err
+	}
+
+	return returnFromProto(r.Return), nil
+}
+
+func (c *Client) RejectReturn(ctx context.Context, id string, note string) (*Return, error) {
+	r, err := c.service.RejectReturn(ctx, &pb.RejectReturnRequest{ReturnId: id, Note: note})
+	if err != nil {
+		return nil, err
+	}
+
+	return returnFromProto(r.Return), nil
+}
+
+func (c *Client) ReceiveReturn(ctx context.Context, id string) (*Return, error) {
+	r, err := c.service.ReceiveReturn(ctx, &pb.ReceiveReturnRequest{ReturnId: id})
+	if err != nil {
+		return nil, err
+	}
+
+	return returnFromProto(r.Return), nil
+}
+
+// ListReturns returns the returns of the order, oldest first.
+func (c *Client) ListReturns(ctx context.Context, orderID string) ([]Return, error) {
+	r, err := c.service.ListReturns(ctx, &pb.ListReturnsRequest{OrderId: orderID})
+	if err != nil {
+		return nil, err
+	}
+
+	returns := []Return{}
+	for _, rt := range r.Returns {
+		returns = append(returns, *returnFromProto(rt))
+	}
+	return returns, nil
+}
+
+// ListRefunds returns the refunds of the order, oldest first.
+func (c *Client) ListRefunds(ctx context.Context, orderID string) ([]Refund, error) {
+	r, err := c.service.ListRefunds(ctx, &pb.ListRefundsRequest{OrderId: orderID})
+	if err != nil {
+		return nil, err
+	}
+
+	refunds := []Refund{}
+	for _, rf := range r.Refunds {
+		refunds = append(refunds, Refund{
+			ID:        rf.Id,
+			OrderID:   rf.OrderId,
+			ReturnID:  rf.ReturnId,
+			Amount:    rf.Amount,
+			CreatedAt: rf.CreatedAt.AsTime(),
+		})
+	}
+	return refunds, nil
+}
+
+// CreateSubscription subscribes the account to lines every interval, the
+// first order being due at startsAt, or right away when it is zero.
+func (c *Client) CreateSubscription(ctx context.Context, accountID string, lines []SubscriptionLine, shippingAddress Address, interval Interval, startsAt time.Time) (*Subscription, error) {
+	req := &pb.CreateSubscriptionRequest{
+		AccountId:       accountID,
+		ShippingAddress: addressToProto(shippingAddress),
+		IntervalUnit:    interval.Unit,
+		IntervalCount:   interval.Count,
+	}
+	for _, l := range lines {
+		req.Lines = append(req.Lines, &pb.Subscription_Line{ProductId: l.ProductID, VariantId: l.VariantID, Quantity: l.Quantity})
+	}
+	if !startsAt.IsZero() {
+		req.StartsAt = timestamppb.New(startsAt)
+	}
+	r, err := c.service.CreateSubscription(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriptionFromProto(r.Subscription), nil
+}
+
+// ListSubscriptions returns the subscriptions of the account, oldest first.
+func (c *Client) ListSubscriptions(ctx context.Context, accountID string) ([]Subscription, error) {
+	r, err := c.service.ListSubscriptions(ctx, &pb.ListSubscriptionsRequest{AccountId: accountID})
+	if err != nil {
+		return nil, err
+	}
+
+	subs := []Subscription{}
+	for _, sub := range r.Subscriptions {
+		subs = append(subs, *subscriptionFromProto(sub))
 	}
-	
-	return orders, nil
+	return subs, nil
 }
 
+func (c *Client) PauseSubscription(ctx context.Context, id string) (*Subscription, error) {
+	r, err := c.service.PauseSubscription(ctx, &pb.PauseSubscriptionRequest{SubscriptionId: id})
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriptionFromProto(r.Subscription), nil
+}
+
+func (c *Client) ResumeSubscription(ctx context.Context, id string) (*Subscription, error) {
+	r, err := c.service.ResumeSubscription(ctx, &pb.ResumeSubscriptionRequest{SubscriptionId: id})
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriptionFromProto(r.Subscription), nil
+}
+
+func (c *Client) CancelSubscription(ctx context.Context, id string) (*Subscription, error) {
+	r, err := c.service.CancelSubscription(ctx, &pb.CancelSubscriptionRequest{SubscriptionId: id})
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriptionFromProto(r.Subscription), nil
+}
+
+// subscriptionFromProto sets DueAt to the retry time of a failing
+// subscription, and leaves it zero otherwise.
+func subscriptionFromProto(p *pb.Subscription) *Subscription {
+	sub := &Subscription{
+		ID:              p.Id,
+		AccountID:       p.AccountId,
+		Status:          p.Status,
+		Lines:           []SubscriptionLine{},
+		ShippingAddress: addressFromProto(p.ShippingAddress),
+		Interval:        Interval{Unit: p.IntervalUnit, Count: p.IntervalCount},
+		StartsAt:        p.StartsAt.AsTime(),
+		NextRunAt:       p.NextRunAt.AsTime(),
+		Failures:        p.Failures,
+		LastFailure:     p.LastFailure,
+		LastOrderID:     p.LastOrderId,
+		CreatedAt:       p.CreatedAt.AsTime(),
+		UpdatedAt:       p.UpdatedAt.AsTime(),
+	}
+	if p.RetryAt != nil {
+		sub.DueAt = p.RetryAt.AsTime()
+	}
+	for _, l := range p.Lines {
+		sub.Lines = append(sub.Lines, SubscriptionLine{ProductID: l.ProductId, VariantID: l.VariantId, Quantity: l.Quantity})
+	}
+	return sub
+}
+
+func returnFromProto(p *pb.Return) *Return {
+	rt := &Return{
+		ID:           p.Id,
+		OrderID:      p.OrderId,
+		Status:       p.Status,
+		Reason:       p.Reason,
+		Note:         p.Note,
+		Lines:        []ReturnLine{},
+		RefundAmount: p.RefundAmount,
+		CreatedAt:    p.CreatedAt.AsTime(),
+		UpdatedAt:    p.UpdatedAt.AsTime(),
+	}
+	for _, l := range p.Lines {
+		rt.Lines = append(rt.Lines, ReturnLine{ProductID: l.ProductId, VariantID: l.VariantId, Quantity: l.Quantity})
+	}
+	return rt
+}
+
+func shipmentFromProto(p *pb.Shipment) *Shipment {
+	sh := &Shipment{
+		ID:             p.Id,
+		OrderID:        p.OrderId,
+		Carrier:        p.Carrier,
+		TrackingNumber: p.TrackingNumber,
+		Status:         p.Status,
+		Lines:          []ShipmentLine{},
+		Events:         []ShipmentEvent{},
+		CreatedAt:      p.CreatedAt.AsTime(),
+		UpdatedAt:      p.UpdatedAt.AsTime(),
+	}
+	for _, l := range p.Lines {
+		sh.Lines = append(sh.Lines, ShipmentLine{ProductID: l.ProductId, VariantID: l.VariantId, Quantity: l.Quantity})
+	}
+	for _, e := range p.Events {
+		sh.Events = append(sh.Events, ShipmentEvent{
+			Status:      e.Status,
+			Description: e.Description,
+			Location:    e.Location,
+			OccurredAt:  e.OccurredAt.AsTime(),
+		})
+	}
+	return sh
+}
+
+func orderPageFromProto(orders []*pb.Order, cursors []string, hasNextPage bool) *OrderPage {
+	page := &OrderPage{
+		Orders:      []Order{},
+		Cursors:     cursors,
+		HasNextPage: hasNextPage,
+	}
+	for _, orderProto := range orders {
+		page.Orders = append(page.Orders, *orderFromProto(orderProto))
+	}
+	return page
+}
+
+func orderFromProto(o *pb.Order) *Order {
+	createdAt := time.Time{}
+	createdAt.UnmarshalBinary(o.CreatedAt)
+
+	products := []OrderedProduct{}
+	for _, op := range o.Products {
+		products = append(products, OrderedProduct{
+			ID:          op.Id,
+			VariantID:   op.VariantId,
+			SKU:         op.Sku,
+			Options:     op.Options,
+			Name:        op.Name,
+			Description: op.Description,
+			Price:       op.Price,
+			Quantity:    uint64(op.Quantity),
+		})
+	}
+
+	return &Order{
+		ID:         o.Id,
+		CreatedAt:  createdAt,
+		TotalPrice: o.TotalPrice,
+		AccountID:  o.AccountId,
+		Status:     o.Status,
+		Products:   products,
+		ShippingAddress: addressFromProto(o.ShippingAddress),
+	}
+}
@@ -0,0 +1,209 @@
+package order
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sunil8777/E-commerce-microservices/errs"
+)
+
+var (
+	ErrOrderNotFound   = errs.New(errs.NotFound, "ORDER_NOT_FOUND", "order not found")
+	ErrInvoiceNotFound = errs.New(errs.NotFound, "INVOICE_NOT_FOUND", "invoice not found")
+)
+
+// InvoiceConfig is what invoices say about the seller, and the rate of the
+// tax included in every price. Invoices split that tax out of the total
+// charged rather than adding it.
+type InvoiceConfig struct {
+	SellerName    string   `envconfig:"SELLER_NAME" default:"E-commerce Microservices"`
+	SellerAddress []string `envconfig:"SELLER_ADDRESS"`
+	SellerTaxID   string   `envconfig:"SELLER_TAX_ID"`
+	TaxRate       float64  `envconfig:"TAX_RATE" default:"0"`
+}
+
+// Invoice is the document issued for an order. Number is assigned from a
+// gapless sequence when the invoice is issued, and HTML and PDF are rendered
+// then and never again, so an invoice stays as it was issued.
+type Invoice struct {
+	Number   uint64
+	OrderID  string
+	IssuedAt time.Time
+	HTML     []byte
+	PDF      []byte
+}
+
+// Name is the invoice number as printed, such as "INV-000042".
+func (i Invoice) Name() string {
+	return invoiceName(i.Number)
+}
+
+func invoiceName(number uint64) string {
+	return fmt.Sprintf("INV-%06d", number)
+}
+
+// Customer is who an invoice is billed to.
+type Customer struct {
+	Name  string
+	Email string
+}
+
+// invoiceDocument is everything printed on an invoice.
+type invoiceDocument struct {
+	Number        string
+	IssuedAt      time.Time
+	OrderID       string
+	PlacedAt      time.Time
+	SellerName    string
+	SellerAddress []string
+	SellerTaxID   string
+	BillTo        Customer
+	ShipTo        []string
+	Lines         []invoiceLine
+	TaxRate       float64
+	Net           float64
+	Tax           float64
+	Total         float64
+}
+
+type invoiceLine struct {
+	Description string
+	SKU         string
+	Quantity    uint64
+	UnitPrice   float64
+	Amount      float64
+}
+
+func newInvoiceDocument(c InvoiceConfig, number uint64, issuedAt time.Time, o Order, billTo Customer) invoiceDocument {
+	d := invoiceDocument{
+		Number:        invoiceName(number),
+		IssuedAt:      issuedAt,
+		OrderID:       o.ID,
+		PlacedAt:      o.CreatedAt,
+		SellerName:    c.SellerName,
+		SellerAddress: c.SellerAddress,
+		SellerTaxID:   c.SellerTaxID,
+		BillTo:        billTo,
+		ShipTo:        o.ShippingAddress.Lines(),
+		Lines:         []invoiceLine{},
+		TaxRate:       c.TaxRate,
+		Total:         o.TotalPrice,
+	}
+	for _, p := range o.Products {
+		description := p.Name
+		if description == "" {
+			description = p.ID
+		}
+		if len(p.Options) > 0 {
+			description += " (" + formatOptions(p.Options) + ")"
+		}
+		d.Lines = append(d.Lines, invoiceLine{
+			Description: description,
+			SKU:         p.SKU,
+			Quantity:    p.Quantity,
+			UnitPrice:   p.Price,
+			Amount:      p.Price * float64(p.Quantity),
+		})
+	}
+	d.Net = roundCents(d.Total / (1 + c.TaxRate))
+	d.Tax = roundCents(d.Total - d.Net)
+	return d
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+//go:embed templates/invoice.html
+var invoiceTemplateFiles embed.FS
+
+var invoiceTemplate = template.Must(template.New("invoice.html").Funcs(template.FuncMap{
+	"money":   money,
+	"percent": percent,
+}).ParseFS(invoiceTemplateFiles, "templates/invoice.html"))
+
+func money(v float64) string {
+	return fmt.Sprintf("$%.2f", v)
+}
+
+func percent(rate float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", rate*100), "0"), ".") + "%"
+}
+
+// renderInvoice renders d as HTML and as PDF.
+func renderInvoice(d invoiceDocument) ([]byte, []byte, error) {
+	var html bytes.Buffer
+	if err := invoiceTemplate.Execute(&html, d); err != nil {
+		return nil, nil, err
+	}
+	return html.Bytes(), textPDF(invoiceText(d)), nil
+}
+
+// invoiceText lays d out as the monospaced lines of its PDF.
+func invoiceText(d invoiceDocument) []string {
+	lines := []string{
+		"INVOICE " + d.Number,
+		"",
+		d.SellerName,
+	}
+	lines = append(lines, d.SellerAddress...)
+	if d.SellerTaxID != "" {
+		lines = append(lines, "Tax ID: "+d.SellerTaxID)
+	}
+	lines = append(lines,
+		"",
+		"Issued:  "+d.IssuedAt.Format("2006-01-02"),
+		"Order:   "+d.OrderID,
+		"Placed:  "+d.PlacedAt.Format("2006-01-02"),
+		"",
+		"Bill to:",
+		"  "+d.BillTo.Name,
+	)
+	if d.BillTo.Email != "" {
+		lines = append(lines, "  "+d.BillTo.Email)
+	}
+	if len(d.ShipTo) > 0 {
+		lines = append(lines, "", "Ship to:")
+		for _, l := range d.ShipTo {
+			lines = append(lines, "  "+l)
+		}
+	}
+
+	const row = "%-40.40s %5s %12s %12s"
+	lines = append(lines, "", fmt.Sprintf(row, "Item", "Qty", "Unit price", "Amount"), strings.Repeat("-", 72))
+	for _, l := range d.Lines {
+		lines = append(lines, fmt.Sprintf(row, l.Description, fmt.Sprint(l.Quantity), money(l.UnitPrice), money(l.Amount)))
+		if l.SKU != "" {
+			lines = append(lines, "  SKU "+l.SKU)
+		}
+	}
+	const total = "%59s %12s"
+	lines = append(lines,
+		strings.Repeat("-", 72),
+		fmt.Sprintf(total, "Net", money(d.Net)),
+		fmt.Sprintf(total, "Tax ("+percent(d.TaxRate)+")", money(d.Tax)),
+		fmt.Sprintf(total, "Total", money(d.Total)),
+	)
+	return lines
+}
+
+// formatOptions formats variant options as "color: red, size: M", sorted
+// by name.
+func formatOptions(options map[string]string) string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := []string{}
+	for _, name := range names {
+		parts = append(parts, name+": "+options[name])
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,56 @@
+package order
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	ordersPlaced = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "orders_placed_total",
+		Help: "Orders successfully placed.",
+	})
+
+	orderValue = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "order_value",
+		Help:    "Total price of placed orders.",
+		Buckets: []float64{10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000},
+	})
+
+	orderLines = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "order_lines",
+		Help:    "Number of lines on placed orders.",
+		Buckets: []float64{1, 2, 3, 5, 10, 20, 50},
+	})
+
+	orderSagas = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "order_sagas_total",
+		Help: "Order placement sagas that completed, failed and were compensated, or were resumed after being abandoned.",
+	}, []string{"outcome"})
+
+	invoicesIssued = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "invoices_issued_total",
+		Help: "Invoices issued, each with a new invoice number.",
+	})
+
+	shipmentsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "shipments_created_total",
+		Help: "Shipments handed to a carrier.",
+	})
+
+	returnsMoved = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "order_returns_total",
+		Help: "Returns requested, and moved to approved, rejected or received.",
+	}, []string{"status"})
+
+	refundValue = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "refund_value",
+		Help:    "Amount of refunds recorded for approved returns.",
+		Buckets: []float64{10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000},
+	})
+
+	subscriptionRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "subscription_runs_total",
+		Help: "Scheduled subscription orders that were placed, failed and will be retried, or were skipped after failing too often.",
+	}, []string{"outcome"})
+)
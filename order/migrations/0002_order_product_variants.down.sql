@@ -0,0 +1,6 @@
+ALTER TABLE order_products DROP CONSTRAINT order_products_pkey;
+ALTER TABLE order_products ADD PRIMARY KEY (product_id, order_id);
+ALTER TABLE order_products DROP COLUMN IF EXISTS price;
+ALTER TABLE order_products DROP COLUMN IF EXISTS variant_options;
+ALTER TABLE order_products DROP COLUMN IF EXISTS sku;
+ALTER TABLE order_products DROP COLUMN IF EXISTS variant_id;
@@ -0,0 +1,8 @@
+-- Order lines name the variant they were ordered in, with its SKU, options
+-- and price at the time, so the same product can appear once per variant.
+ALTER TABLE order_products ADD COLUMN IF NOT EXISTS variant_id VARCHAR(27) NOT NULL DEFAULT '';
+ALTER TABLE order_products ADD COLUMN IF NOT EXISTS sku VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE order_products ADD COLUMN IF NOT EXISTS variant_options JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE order_products ADD COLUMN IF NOT EXISTS price DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE order_products DROP CONSTRAINT order_products_pkey;
+ALTER TABLE order_products ADD PRIMARY KEY (order_id, product_id, variant_id);
@@ -0,0 +1 @@
+ALTER TABLE orders ALTER COLUMN total_price TYPE MONEY USING total_price::NUMERIC::MONEY;
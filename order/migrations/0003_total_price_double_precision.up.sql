@@ -0,0 +1,3 @@
+-- MONEY does not scan into a float64 and depends on lc_monetary; store the
+-- total the same way as the line prices.
+ALTER TABLE orders ALTER COLUMN total_price TYPE DOUBLE PRECISION USING total_price::NUMERIC::DOUBLE PRECISION;
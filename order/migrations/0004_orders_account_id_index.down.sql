@@ -0,0 +1 @@
+DROP INDEX IF EXISTS orders_account_id_idx;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS orders_account_id_idx ON orders (account_id);
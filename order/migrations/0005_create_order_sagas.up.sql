@@ -0,0 +1,16 @@
+-- One row per order placement. order_data holds the order being placed until
+-- the saga confirms it into orders; step is the next step to run while the
+-- saga is running and the next one to undo while it is compensating.
+CREATE TABLE IF NOT EXISTS order_sagas (
+  id CHAR(27) PRIMARY KEY,
+  order_data JSONB NOT NULL,
+  step VARCHAR(32) NOT NULL DEFAULT '',
+  status VARCHAR(16) NOT NULL,
+  payment_id VARCHAR(64) NOT NULL DEFAULT '',
+  failure VARCHAR(64) NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS order_sagas_unfinished_idx ON order_sagas (updated_at)
+  WHERE status IN ('running', 'compensating');
@@ -0,0 +1,13 @@
+-- Events waiting to be relayed to the event sink, written in the transaction
+-- of the change they describe. See package events.
+CREATE TABLE IF NOT EXISTS outbox (
+  id CHAR(27) PRIMARY KEY,
+  type VARCHAR(64) NOT NULL,
+  account_id VARCHAR(27) NOT NULL DEFAULT '',
+  payload JSONB NOT NULL,
+  occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  published_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (occurred_at)
+  WHERE published_at IS NULL;
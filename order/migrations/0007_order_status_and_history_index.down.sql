@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS orders_account_id_idx ON orders (account_id);
+DROP INDEX IF EXISTS orders_account_id_created_at_idx;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS status;
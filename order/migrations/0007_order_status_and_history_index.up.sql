@@ -0,0 +1,6 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS status VARCHAR(32) NOT NULL DEFAULT 'placed';
+
+-- Order history is listed per account by placement time, newest or oldest
+-- first, with the id breaking ties between orders placed at the same time.
+CREATE INDEX IF NOT EXISTS orders_account_id_created_at_idx ON orders (account_id, created_at, id);
+DROP INDEX IF EXISTS orders_account_id_idx;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS order_products_product_id_idx;
+DROP INDEX IF EXISTS orders_total_price_idx;
+DROP INDEX IF EXISTS orders_status_created_at_idx;
+DROP INDEX IF EXISTS orders_created_at_idx;
@@ -0,0 +1,6 @@
+-- Indexes for searching the orders of every account, which sorts by
+-- placement time like the per-account history.
+CREATE INDEX IF NOT EXISTS orders_created_at_idx ON orders (created_at, id);
+CREATE INDEX IF NOT EXISTS orders_status_created_at_idx ON orders (status, created_at, id);
+CREATE INDEX IF NOT EXISTS orders_total_price_idx ON orders (total_price);
+CREATE INDEX IF NOT EXISTS order_products_product_id_idx ON order_products (product_id, order_id);
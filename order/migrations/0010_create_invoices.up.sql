@@ -0,0 +1,16 @@
+-- invoice_counter holds the last invoice number issued. Issuing an invoice
+-- increments it in the same transaction that stores the invoice, so numbers
+-- have no gaps.
+CREATE TABLE IF NOT EXISTS invoice_counter (
+  id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+  last_number BIGINT NOT NULL
+);
+INSERT INTO invoice_counter (id, last_number) VALUES (TRUE, 0) ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS invoices (
+  number BIGINT PRIMARY KEY,
+  order_id CHAR(27) NOT NULL UNIQUE REFERENCES orders (id),
+  issued_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  html BYTEA NOT NULL,
+  pdf BYTEA NOT NULL
+);
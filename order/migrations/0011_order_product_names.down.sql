@@ -0,0 +1,2 @@
+ALTER TABLE order_products DROP COLUMN IF EXISTS description;
+ALTER TABLE order_products DROP COLUMN IF EXISTS name;
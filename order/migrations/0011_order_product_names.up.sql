@@ -0,0 +1,5 @@
+-- Order lines keep the name and description the product had when the order
+-- was placed, so that invoices and order history do not follow later
+-- catalog edits. Lines of older orders have neither.
+ALTER TABLE order_products ADD COLUMN IF NOT EXISTS name TEXT NOT NULL DEFAULT '';
+ALTER TABLE order_products ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
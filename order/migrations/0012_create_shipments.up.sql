@@ -0,0 +1,32 @@
+-- An order ships in one or more shipments, each carrying some quantity of
+-- some of its lines. The status of a shipment is that of its latest event,
+-- and the status of the order is derived from its shipments.
+CREATE TABLE IF NOT EXISTS shipments (
+  id CHAR(27) PRIMARY KEY,
+  order_id CHAR(27) NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+  carrier VARCHAR(64) NOT NULL,
+  tracking_number VARCHAR(128) NOT NULL,
+  status VARCHAR(32) NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS shipments_order_id_idx ON shipments (order_id, created_at);
+
+CREATE TABLE IF NOT EXISTS shipment_lines (
+  shipment_id CHAR(27) REFERENCES shipments (id) ON DELETE CASCADE,
+  product_id CHAR(27),
+  variant_id VARCHAR(27) NOT NULL DEFAULT '',
+  quantity INT NOT NULL,
+  PRIMARY KEY (shipment_id, product_id, variant_id)
+);
+
+CREATE TABLE IF NOT EXISTS shipment_events (
+  shipment_id CHAR(27) REFERENCES shipments (id) ON DELETE CASCADE,
+  seq INT NOT NULL,
+  status VARCHAR(32) NOT NULL,
+  description VARCHAR(500) NOT NULL DEFAULT '',
+  location VARCHAR(200) NOT NULL DEFAULT '',
+  occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  PRIMARY KEY (shipment_id, seq)
+);
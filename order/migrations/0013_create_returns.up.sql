@@ -0,0 +1,33 @@
+-- A return asks to send back some quantity of some lines of an order. Staff
+-- approve or reject it, and an approved return is refunded once, by the
+-- refund recorded with its approval.
+CREATE TABLE IF NOT EXISTS returns (
+  id CHAR(27) PRIMARY KEY,
+  order_id CHAR(27) NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+  status VARCHAR(32) NOT NULL,
+  reason VARCHAR(500) NOT NULL,
+  note VARCHAR(500) NOT NULL DEFAULT '',
+  refund_amount DOUBLE PRECISION NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS returns_order_id_idx ON returns (order_id, created_at);
+
+CREATE TABLE IF NOT EXISTS return_lines (
+  return_id CHAR(27) REFERENCES returns (id) ON DELETE CASCADE,
+  product_id CHAR(27),
+  variant_id VARCHAR(27) NOT NULL DEFAULT '',
+  quantity INT NOT NULL,
+  PRIMARY KEY (return_id, product_id, variant_id)
+);
+
+CREATE TABLE IF NOT EXISTS refunds (
+  id CHAR(27) PRIMARY KEY,
+  order_id CHAR(27) NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+  return_id CHAR(27) NOT NULL UNIQUE REFERENCES returns (id),
+  amount DOUBLE PRECISION NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS refunds_order_id_idx ON refunds (order_id, created_at);
@@ -0,0 +1,33 @@
+-- A subscription reorders the same lines for an account every interval.
+-- next_run_at is when the next order is due; due_at is when the scheduler
+-- next takes the subscription up, which is later while it retries a failed
+-- run or while a scheduler holds it. attempt numbers the orders tried for
+-- next_run_at, and is part of their idempotency key.
+CREATE TABLE IF NOT EXISTS subscriptions (
+  id CHAR(27) PRIMARY KEY,
+  account_id CHAR(27) NOT NULL,
+  status VARCHAR(16) NOT NULL,
+  shipping_address JSONB NOT NULL DEFAULT '{}',
+  interval_unit VARCHAR(8) NOT NULL,
+  interval_count INT NOT NULL,
+  starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  due_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  attempt INT NOT NULL DEFAULT 0,
+  failures INT NOT NULL DEFAULT 0,
+  last_failure VARCHAR(64) NOT NULL DEFAULT '',
+  last_order_id VARCHAR(27) NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS subscriptions_account_id_idx ON subscriptions (account_id, created_at);
+CREATE INDEX IF NOT EXISTS subscriptions_due_at_idx ON subscriptions (due_at) WHERE status = 'active';
+
+CREATE TABLE IF NOT EXISTS subscription_lines (
+  subscription_id CHAR(27) REFERENCES subscriptions (id) ON DELETE CASCADE,
+  product_id CHAR(27),
+  variant_id VARCHAR(27) NOT NULL DEFAULT '',
+  quantity INT NOT NULL,
+  PRIMARY KEY (subscription_id, product_id, variant_id)
+);
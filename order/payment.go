@@ -0,0 +1,66 @@
+package order
+
+import (
+	"context"
+	"sync"
+
+	"github.com/segmentio/ksuid"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+)
+
+var (
+	ErrPaymentDeclined = errs.New(errs.FailedPrecondition, "PAYMENT_DECLINED", "payment declined")
+)
+
+// Payments authorizes the total of an order against the account placing it,
+// and voids the authorization if the order is not placed after all. Both are
+// keyed by the order ID so that retries are idempotent, and voiding an order
+// that was never authorized succeeds and prevents a late authorization.
+type Payments interface {
+	Authorize(ctx context.Context, orderID string, accountID string, amount float64) (string, error)
+	Void(ctx context.Context, orderID string) error
+}
+
+type paymentLimit struct {
+	limit float64
+
+	mu             sync.Mutex
+	authorizations map[string]string
+	voided         map[string]bool
+}
+
+// NewPaymentLimit returns Payments that authorize any order up to limit and
+// decline larger ones. It keeps authorizations in memory and stands in for a
+// payment provider until one is integrated.
+func NewPaymentLimit(limit float64) Payments {
+	return &paymentLimit{
+		limit:          limit,
+		authorizations: map[string]string{},
+		voided:         map[string]bool{},
+	}
+}
+
+func (p *paymentLimit) Authorize(ctx context.Context, orderID string, accountID string, amount float64) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if id, ok := p.authorizations[orderID]; ok {
+		return id, nil
+	}
+	if p.voided[orderID] || amount > p.limit {
+		return "", ErrPaymentDeclined.With("orderId", orderID)
+	}
+
+	id := ksuid.New().String()
+	p.authorizations[orderID] = id
+	return id, nil
+}
+
+func (p *paymentLimit) Void(ctx context.Context, orderID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.authorizations, orderID)
+	p.voided[orderID] = true
+	return nil
+}
@@ -7,8 +7,11 @@
 package __
 
 import (
+	_ "github.com/sunil8777/E-commerce-microservices/validation/pb"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -21,20 +24,165 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
-type Order struct {
+// SortDirection orders an order listing by placement time.
+type SortDirection int32
+
+const (
+	SortDirection_OLDEST_FIRST SortDirection = 0
+	SortDirection_NEWEST_FIRST SortDirection = 1
+)
+
+// Enum value maps for SortDirection.
+var (
+	SortDirection_name = map[int32]string{
+		0: "OLDEST_FIRST",
+		1: "NEWEST_FIRST",
+	}
+	SortDirection_value = map[string]int32{
+		"OLDEST_FIRST": 0,
+		"NEWEST_FIRST": 1,
+	}
+)
+
+func (x SortDirection) Enum() *SortDirection {
+	p := new(SortDirection)
+	*p = x
+	return p
+}
+
+func (x SortDirection) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SortDirection) Descriptor() protoreflect.EnumDescriptor {
+	return file_order_proto_enumTypes[0].Descriptor()
+}
+
+func (SortDirection) Type() protoreflect.EnumType {
+	return &file_order_proto_enumTypes[0]
+}
+
+func (x SortDirection) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SortDirection.Descriptor instead.
+func (SortDirection) EnumDescriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{0}
+}
+
+// Address is a postal address. country is an ISO 3166-1 alpha-2 code.
+type Address struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	CreatedAt     []byte                 `protobuf:"bytes,2,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
-	AccountId     string                 `protobuf:"bytes,3,opt,name=accountId,proto3" json:"accountId,omitempty"`
-	TotalPrice    float64                `protobuf:"fixed64,4,opt,name=totalPrice,proto3" json:"totalPrice,omitempty"`
-	Products      []*Order_OrderProduct  `protobuf:"bytes,5,rep,name=products,proto3" json:"products,omitempty"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Line1         string                 `protobuf:"bytes,2,opt,name=line1,proto3" json:"line1,omitempty"`
+	Line2         string                 `protobuf:"bytes,3,opt,name=line2,proto3" json:"line2,omitempty"`
+	City          string                 `protobuf:"bytes,4,opt,name=city,proto3" json:"city,omitempty"`
+	Region        string                 `protobuf:"bytes,5,opt,name=region,proto3" json:"region,omitempty"`
+	PostalCode    string                 `protobuf:"bytes,6,opt,name=postalCode,proto3" json:"postalCode,omitempty"`
+	Country       string                 `protobuf:"bytes,7,opt,name=country,proto3" json:"country,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
+func (x *Address) Reset() {
+	*x = Address{}
+	mi := &file_order_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Address) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Address) ProtoMessage() {}
+
+func (x *Address) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Address.ProtoReflect.Descriptor instead.
+func (*Address) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Address) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Address) GetLine1() string {
+	if x != nil {
+		return x.Line1
+	}
+	return ""
+}
+
+func (x *Address) GetLine2() string {
+	if x != nil {
+		return x.Line2
+	}
+	return ""
+}
+
+func (x *Address) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *Address) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *Address) GetPostalCode() string {
+	if x != nil {
+		return x.PostalCode
+	}
+	return ""
+}
+
+func (x *Address) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+type Order struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	CreatedAt  []byte                 `protobuf:"bytes,2,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
+	AccountId  string                 `protobuf:"bytes,3,opt,name=accountId,proto3" json:"accountId,omitempty"`
+	TotalPrice float64                `protobuf:"fixed64,4,opt,name=totalPrice,proto3" json:"totalPrice,omitempty"`
+	Products   []*Order_OrderProduct  `protobuf:"bytes,5,rep,name=products,proto3" json:"products,omitempty"`
+	// placedAt is createdAt as a Timestamp, for REST clients that cannot
+	// decode the binary time.Time in createdAt.
+	PlacedAt        *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=placedAt,proto3" json:"placedAt,omitempty"`
+	Status          string                 `protobuf:"bytes,7,opt,name=status,proto3" json:"status,omitempty"`
+	ShippingAddress *Address               `protobuf:"bytes,8,opt,name=shippingAddress,proto3" json:"shippingAddress,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
 func (x *Order) Reset() {
 	*x = Order{}
-	mi := &file_order_proto_msgTypes[0]
+	mi := &file_order_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -46,7 +194,7 @@ func (x *Order) String() string {
 func (*Order) ProtoMessage() {}
 
 func (x *Order) ProtoReflect() protoreflect.Message {
-	mi := &file_order_proto_msgTypes[0]
+	mi := &file_order_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -59,7 +207,7 @@ func (x *Order) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Order.ProtoReflect.Descriptor instead.
 func (*Order) Descriptor() ([]byte, []int) {
-	return file_order_proto_rawDescGZIP(), []int{0}
+	return file_order_proto_rawDescGZIP(), []int{1}
 }
 
 func (x *Order) GetId() string {
@@ -97,17 +245,43 @@ func (x *Order) GetProducts() []*Order_OrderProduct {
 	return nil
 }
 
+func (x *Order) GetPlacedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PlacedAt
+	}
+	return nil
+}
+
+func (x *Order) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Order) GetShippingAddress() *Address {
+	if x != nil {
+		return x.ShippingAddress
+	}
+	return nil
+}
+
 type PostOrderRequest struct {
-	state         protoimpl.MessageState           `protogen:"open.v1"`
-	AccountId     string                           `protobuf:"bytes,1,opt,name=accountId,proto3" json:"accountId,omitempty"`
-	Products      []*PostOrderRequest_OrderProduct `protobuf:"bytes,2,rep,name=products,proto3" json:"products,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state           protoimpl.MessageState           `protogen:"open.v1"`
+	AccountId       string                           `protobuf:"bytes,1,opt,name=accountId,proto3" json:"accountId,omitempty"`
+	Products        []*PostOrderRequest_OrderProduct `protobuf:"bytes,2,rep,name=products,proto3" json:"products,omitempty"`
+	ShippingAddress *Address                         `protobuf:"bytes,3,opt,name=shippingAddress,proto3" json:"shippingAddress,omitempty"`
+	// idempotencyKey, when set, places the order at most once per account:
+	// posting again with the same key returns the order placed the first
+	// time, or the reason it failed.
+	IdempotencyKey string `protobuf:"bytes,4,opt,name=idempotencyKey,proto3" json:"idempotencyKey,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *PostOrderRequest) Reset() {
 	*x = PostOrderRequest{}
-	mi := &file_order_proto_msgTypes[1]
+	mi := &file_order_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -119,7 +293,7 @@ func (x *PostOrderRequest) String() string {
 func (*PostOrderRequest) ProtoMessage() {}
 
 func (x *PostOrderRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_order_proto_msgTypes[1]
+	mi := &file_order_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -132,7 +306,7 @@ func (x *PostOrderRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PostOrderRequest.ProtoReflect.Descriptor instead.
 func (*PostOrderRequest) Descriptor() ([]byte, []int) {
-	return file_order_proto_rawDescGZIP(), []int{1}
+	return file_order_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *PostOrderRequest) GetAccountId() string {
@@ -149,6 +323,20 @@ func (x *PostOrderRequest) GetProducts() []*PostOrderRequest_OrderProduct {
 	return nil
 }
 
+func (x *PostOrderRequest) GetShippingAddress() *Address {
+	if x != nil {
+		return x.ShippingAddress
+	}
+	return nil
+}
+
+func (x *PostOrderRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
 type PostOrderResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
@@ -158,7 +346,7 @@ type PostOrderResponse struct {
 
 func (x *PostOrderResponse) Reset() {
 	*x = PostOrderResponse{}
-	mi := &file_order_proto_msgTypes[2]
+	mi := &file_order_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -170,7 +358,7 @@ func (x *PostOrderResponse) String() string {
 func (*PostOrderResponse) ProtoMessage() {}
 
 func (x *PostOrderResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_order_proto_msgTypes[2]
+	mi := &file_order_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -183,7 +371,7 @@ func (x *PostOrderResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PostOrderResponse.ProtoReflect.Descriptor instead.
 func (*PostOrderResponse) Descriptor() ([]byte, []int) {
-	return file_order_proto_rawDescGZIP(), []int{2}
+	return file_order_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *PostOrderResponse) GetOrder() *Order {
@@ -202,7 +390,7 @@ type GetOrderRequest struct {
 
 func (x *GetOrderRequest) Reset() {
 	*x = GetOrderRequest{}
-	mi := &file_order_proto_msgTypes[3]
+	mi := &file_order_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -214,7 +402,7 @@ func (x *GetOrderRequest) String() string {
 func (*GetOrderRequest) ProtoMessage() {}
 
 func (x *GetOrderRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_order_proto_msgTypes[3]
+	mi := &file_order_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -227,7 +415,7 @@ func (x *GetOrderRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetOrderRequest.ProtoReflect.Descriptor instead.
 func (*GetOrderRequest) Descriptor() ([]byte, []int) {
-	return file_order_proto_rawDescGZIP(), []int{3}
+	return file_order_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *GetOrderRequest) GetId() string {
@@ -246,7 +434,7 @@ type GetOrderResopnse struct {
 
 func (x *GetOrderResopnse) Reset() {
 	*x = GetOrderResopnse{}
-	mi := &file_order_proto_msgTypes[4]
+	mi := &file_order_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -258,7 +446,7 @@ func (x *GetOrderResopnse) String() string {
 func (*GetOrderResopnse) ProtoMessage() {}
 
 func (x *GetOrderResopnse) ProtoReflect() protoreflect.Message {
-	mi := &file_order_proto_msgTypes[4]
+	mi := &file_order_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -271,7 +459,7 @@ func (x *GetOrderResopnse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetOrderResopnse.ProtoReflect.Descriptor instead.
 func (*GetOrderResopnse) Descriptor() ([]byte, []int) {
-	return file_order_proto_rawDescGZIP(), []int{4}
+	return file_order_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *GetOrderResopnse) GetOrder() *Order {
@@ -282,15 +470,26 @@ func (x *GetOrderResopnse) GetOrder() *Order {
 }
 
 type GetOrderForAccountRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	AccountId     string                 `protobuf:"bytes,1,opt,name=accountId,proto3" json:"accountId,omitempty"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	AccountId string                 `protobuf:"bytes,1,opt,name=accountId,proto3" json:"accountId,omitempty"`
+	// take defaults to, and is capped at, 100 orders.
+	Take  uint64 `protobuf:"varint,2,opt,name=take,proto3" json:"take,omitempty"`
+	After string `protobuf:"bytes,3,opt,name=after,proto3" json:"after,omitempty"`
+	// placedFrom and placedTo bound the placement time; placedFrom is
+	// inclusive and placedTo exclusive. The filters below are ignored when
+	// unset.
+	PlacedFrom    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=placedFrom,proto3" json:"placedFrom,omitempty"`
+	PlacedTo      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=placedTo,proto3" json:"placedTo,omitempty"`
+	Status        string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	MinTotal      float64                `protobuf:"fixed64,7,opt,name=minTotal,proto3" json:"minTotal,omitempty"`
+	Sort          SortDirection          `protobuf:"varint,8,opt,name=sort,proto3,enum=pb.SortDirection" json:"sort,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetOrderForAccountRequest) Reset() {
 	*x = GetOrderForAccountRequest{}
-	mi := &file_order_proto_msgTypes[5]
+	mi := &file_order_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -302,7 +501,7 @@ func (x *GetOrderForAccountRequest) String() string {
 func (*GetOrderForAccountRequest) ProtoMessage() {}
 
 func (x *GetOrderForAccountRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_order_proto_msgTypes[5]
+	mi := &file_order_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -315,7 +514,7 @@ func (x *GetOrderForAccountRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetOrderForAccountRequest.ProtoReflect.Descriptor instead.
 func (*GetOrderForAccountRequest) Descriptor() ([]byte, []int) {
-	return file_order_proto_rawDescGZIP(), []int{5}
+	return file_order_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *GetOrderForAccountRequest) GetAccountId() string {
@@ -325,16 +524,67 @@ func (x *GetOrderForAccountRequest) GetAccountId() string {
 	return ""
 }
 
+func (x *GetOrderForAccountRequest) GetTake() uint64 {
+	if x != nil {
+		return x.Take
+	}
+	return 0
+}
+
+func (x *GetOrderForAccountRequest) GetAfter() string {
+	if x != nil {
+		return x.After
+	}
+	return ""
+}
+
+func (x *GetOrderForAccountRequest) GetPlacedFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PlacedFrom
+	}
+	return nil
+}
+
+func (x *GetOrderForAccountRequest) GetPlacedTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PlacedTo
+	}
+	return nil
+}
+
+func (x *GetOrderForAccountRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *GetOrderForAccountRequest) GetMinTotal() float64 {
+	if x != nil {
+		return x.MinTotal
+	}
+	return 0
+}
+
+func (x *GetOrderForAccountRequest) GetSort() SortDirection {
+	if x != nil {
+		return x.Sort
+	}
+	return SortDirection_OLDEST_FIRST
+}
+
 type GetOrderForAccountResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Orders        []*Order               `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	Cursors       []string               `protobuf:"bytes,2,rep,name=cursors,proto3" json:"cursors,omitempty"`
+	HasNextPage   bool                   `protobuf:"varint,3,opt,name=hasNextPage,proto3" json:"hasNextPage,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetOrderForAccountResponse) Reset() {
 	*x = GetOrderForAccountResponse{}
-	mi := &file_order_proto_msgTypes[6]
+	mi := &file_order_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -346,7 +596,7 @@ func (x *GetOrderForAccountResponse) String() string {
 func (*GetOrderForAccountResponse) ProtoMessage() {}
 
 func (x *GetOrderForAccountResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_order_proto_msgTypes[6]
+	mi := &file_order_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -359,7 +609,7 @@ func (x *GetOrderForAccountResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetOrderForAccountResponse.ProtoReflect.Descriptor instead.
 func (*GetOrderForAccountResponse) Descriptor() ([]byte, []int) {
-	return file_order_proto_rawDescGZIP(), []int{6}
+	return file_order_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *GetOrderForAccountResponse) GetOrders() []*Order {
@@ -369,32 +619,55 @@ func (x *GetOrderForAccountResponse) GetOrders() []*Order {
 	return nil
 }
 
-type Order_OrderProduct struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
-	Price         float64                `protobuf:"fixed64,4,opt,name=price,proto3" json:"price,omitempty"`
-	Quantity      uint32                 `protobuf:"varint,5,opt,name=quantity,proto3" json:"quantity,omitempty"`
+func (x *GetOrderForAccountResponse) GetCursors() []string {
+	if x != nil {
+		return x.Cursors
+	}
+	return nil
+}
+
+func (x *GetOrderForAccountResponse) GetHasNextPage() bool {
+	if x != nil {
+		return x.HasNextPage
+	}
+	return false
+}
+
+// SearchOrdersRequest filters the orders of every account, for support
+// staff. Unset filters are ignored; placedFrom is inclusive and placedTo
+// exclusive.
+type SearchOrdersRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	AccountId  string                 `protobuf:"bytes,1,opt,name=accountId,proto3" json:"accountId,omitempty"`
+	ProductId  string                 `protobuf:"bytes,2,opt,name=productId,proto3" json:"productId,omitempty"`
+	Status     string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	PlacedFrom *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=placedFrom,proto3" json:"placedFrom,omitempty"`
+	PlacedTo   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=placedTo,proto3" json:"placedTo,omitempty"`
+	MinTotal   float64                `protobuf:"fixed64,6,opt,name=minTotal,proto3" json:"minTotal,omitempty"`
+	MaxTotal   float64                `protobuf:"fixed64,7,opt,name=maxTotal,proto3" json:"maxTotal,omitempty"`
+	Sort       SortDirection          `protobuf:"varint,8,opt,name=sort,proto3,enum=pb.SortDirection" json:"sort,omitempty"`
+	// take defaults to, and is capped at, 100 orders.
+	Take          uint64 `protobuf:"varint,9,opt,name=take,proto3" json:"take,omitempty"`
+	After         string `protobuf:"bytes,10,opt,name=after,proto3" json:"after,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Order_OrderProduct) Reset() {
-	*x = Order_OrderProduct{}
-	mi := &file_order_proto_msgTypes[7]
+func (x *SearchOrdersRequest) Reset() {
+	*x = SearchOrdersRequest{}
+	mi := &file_order_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Order_OrderProduct) String() string {
+func (x *SearchOrdersRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Order_OrderProduct) ProtoMessage() {}
+func (*SearchOrdersRequest) ProtoMessage() {}
 
-func (x *Order_OrderProduct) ProtoReflect() protoreflect.Message {
-	mi := &file_order_proto_msgTypes[7]
+func (x *SearchOrdersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -405,69 +678,2265 @@ func (x *Order_OrderProduct) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Order_OrderProduct.ProtoReflect.Descriptor instead.
-func (*Order_OrderProduct) Descriptor() ([]byte, []int) {
-	return file_order_proto_rawDescGZIP(), []int{0, 0}
+// Deprecated: Use SearchOrdersRequest.ProtoReflect.Descriptor instead.
+func (*SearchOrdersRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *Order_OrderProduct) GetId() string {
+func (x *SearchOrdersRequest) GetAccountId() string {
 	if x != nil {
-		return x.Id
+		return x.AccountId
 	}
 	return ""
 }
 
-func (x *Order_OrderProduct) GetName() string {
+func (x *SearchOrdersRequest) GetProductId() string {
 	if x != nil {
-		return x.Name
+		return x.ProductId
 	}
 	return ""
 }
 
-func (x *Order_OrderProduct) GetDescription() string {
+func (x *SearchOrdersRequest) GetStatus() string {
 	if x != nil {
-		return x.Description
+		return x.Status
 	}
 	return ""
 }
 
-func (x *Order_OrderProduct) GetPrice() float64 {
+func (x *SearchOrdersRequest) GetPlacedFrom() *timestamppb.Timestamp {
 	if x != nil {
-		return x.Price
+		return x.PlacedFrom
 	}
-	return 0
+	return nil
 }
 
-func (x *Order_OrderProduct) GetQuantity() uint32 {
+func (x *SearchOrdersRequest) GetPlacedTo() *timestamppb.Timestamp {
 	if x != nil {
-		return x.Quantity
+		return x.PlacedTo
 	}
-	return 0
+	return nil
 }
 
-type PostOrderRequest_OrderProduct struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ProductId     string                 `protobuf:"bytes,1,opt,name=productId,proto3" json:"productId,omitempty"`
-	Quantity      uint32                 `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *SearchOrdersRequest) GetMinTotal() float64 {
+	if x != nil {
+		return x.MinTotal
+	}
+	return 0
 }
 
-func (x *PostOrderRequest_OrderProduct) Reset() {
-	*x = PostOrderRequest_OrderProduct{}
-	mi := &file_order_proto_msgTypes[8]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *SearchOrdersRequest) GetMaxTotal() float64 {
+	if x != nil {
+		return x.MaxTotal
+	}
+	return 0
 }
 
-func (x *PostOrderRequest_OrderProduct) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *SearchOrdersRequest) GetSort() SortDirection {
+	if x != nil {
+		return x.Sort
+	}
+	return SortDirection_OLDEST_FIRST
 }
 
-func (*PostOrderRequest_OrderProduct) ProtoMessage() {}
-
-func (x *PostOrderRequest_OrderProduct) ProtoReflect() protoreflect.Message {
-	mi := &file_order_proto_msgTypes[8]
+func (x *SearchOrdersRequest) GetTake() uint64 {
+	if x != nil {
+		return x.Take
+	}
+	return 0
+}
+
+func (x *SearchOrdersRequest) GetAfter() string {
+	if x != nil {
+		return x.After
+	}
+	return ""
+}
+
+type SearchOrdersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Orders        []*Order               `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	Cursors       []string               `protobuf:"bytes,2,rep,name=cursors,proto3" json:"cursors,omitempty"`
+	HasNextPage   bool                   `protobuf:"varint,3,opt,name=hasNextPage,proto3" json:"hasNextPage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchOrdersResponse) Reset() {
+	*x = SearchOrdersResponse{}
+	mi := &file_order_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchOrdersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchOrdersResponse) ProtoMessage() {}
+
+func (x *SearchOrdersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchOrdersResponse.ProtoReflect.Descriptor instead.
+func (*SearchOrdersResponse) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SearchOrdersResponse) GetOrders() []*Order {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+func (x *SearchOrdersResponse) GetCursors() []string {
+	if x != nil {
+		return x.Cursors
+	}
+	return nil
+}
+
+func (x *SearchOrdersResponse) GetHasNextPage() bool {
+	if x != nil {
+		return x.HasNextPage
+	}
+	return false
+}
+
+// Invoice is the document issued for an order, rendered once when it is
+// first requested and stored from then on.
+type Invoice struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// number is printed on the invoice as INV-000042.
+	Number        uint64                 `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+	OrderId       string                 `protobuf:"bytes,2,opt,name=orderId,proto3" json:"orderId,omitempty"`
+	IssuedAt      *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=issuedAt,proto3" json:"issuedAt,omitempty"`
+	Html          []byte                 `protobuf:"bytes,4,opt,name=html,proto3" json:"html,omitempty"`
+	Pdf           []byte                 `protobuf:"bytes,5,opt,name=pdf,proto3" json:"pdf,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Invoice) Reset() {
+	*x = Invoice{}
+	mi := &file_order_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Invoice) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Invoice) ProtoMessage() {}
+
+func (x *Invoice) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Invoice.ProtoReflect.Descriptor instead.
+func (*Invoice) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Invoice) GetNumber() uint64 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *Invoice) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *Invoice) GetIssuedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.IssuedAt
+	}
+	return nil
+}
+
+func (x *Invoice) GetHtml() []byte {
+	if x != nil {
+		return x.Html
+	}
+	return nil
+}
+
+func (x *Invoice) GetPdf() []byte {
+	if x != nil {
+		return x.Pdf
+	}
+	return nil
+}
+
+type GetInvoiceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=orderId,proto3" json:"orderId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetInvoiceRequest) Reset() {
+	*x = GetInvoiceRequest{}
+	mi := &file_order_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetInvoiceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetInvoiceRequest) ProtoMessage() {}
+
+func (x *GetInvoiceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetInvoiceRequest.ProtoReflect.Descriptor instead.
+func (*GetInvoiceRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetInvoiceRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+type GetInvoiceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Invoice       *Invoice               `protobuf:"bytes,1,opt,name=invoice,proto3" json:"invoice,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetInvoiceResponse) Reset() {
+	*x = GetInvoiceResponse{}
+	mi := &file_order_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetInvoiceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetInvoiceResponse) ProtoMessage() {}
+
+func (x *GetInvoiceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetInvoiceResponse.ProtoReflect.Descriptor instead.
+func (*GetInvoiceResponse) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetInvoiceResponse) GetInvoice() *Invoice {
+	if x != nil {
+		return x.Invoice
+	}
+	return nil
+}
+
+// Shipment is a parcel sent with a carrier, carrying some quantity of some
+// lines of an order. status is shipped, in_transit, out_for_delivery,
+// delivered or exception, and events is its tracking history, oldest first.
+type Shipment struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	OrderId        string                 `protobuf:"bytes,2,opt,name=orderId,proto3" json:"orderId,omitempty"`
+	Carrier        string                 `protobuf:"bytes,3,opt,name=carrier,proto3" json:"carrier,omitempty"`
+	TrackingNumber string                 `protobuf:"bytes,4,opt,name=trackingNumber,proto3" json:"trackingNumber,omitempty"`
+	// trackingUrl is empty for carriers without a known tracking page.
+	TrackingUrl   string                 `protobuf:"bytes,5,opt,name=trackingUrl,proto3" json:"trackingUrl,omitempty"`
+	Status        string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	Lines         []*Shipment_Line       `protobuf:"bytes,7,rep,name=lines,proto3" json:"lines,omitempty"`
+	Events        []*Shipment_Event      `protobuf:"bytes,8,rep,name=events,proto3" json:"events,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=updatedAt,proto3" json:"updatedAt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Shipment) Reset() {
+	*x = Shipment{}
+	mi := &file_order_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Shipment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Shipment) ProtoMessage() {}
+
+func (x *Shipment) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Shipment.ProtoReflect.Descriptor instead.
+func (*Shipment) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Shipment) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Shipment) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *Shipment) GetCarrier() string {
+	if x != nil {
+		return x.Carrier
+	}
+	return ""
+}
+
+func (x *Shipment) GetTrackingNumber() string {
+	if x != nil {
+		return x.TrackingNumber
+	}
+	return ""
+}
+
+func (x *Shipment) GetTrackingUrl() string {
+	if x != nil {
+		return x.TrackingUrl
+	}
+	return ""
+}
+
+func (x *Shipment) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Shipment) GetLines() []*Shipment_Line {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+func (x *Shipment) GetEvents() []*Shipment_Event {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *Shipment) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Shipment) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type CreateShipmentRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	OrderId        string                 `protobuf:"bytes,1,opt,name=orderId,proto3" json:"orderId,omitempty"`
+	Carrier        string                 `protobuf:"bytes,2,opt,name=carrier,proto3" json:"carrier,omitempty"`
+	TrackingNumber string                 `protobuf:"bytes,3,opt,name=trackingNumber,proto3" json:"trackingNumber,omitempty"`
+	Lines          []*Shipment_Line       `protobuf:"bytes,4,rep,name=lines,proto3" json:"lines,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateShipmentRequest) Reset() {
+	*x = CreateShipmentRequest{}
+	mi := &file_order_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateShipmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateShipmentRequest) ProtoMessage() {}
+
+func (x *CreateShipmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateShipmentRequest.ProtoReflect.Descriptor instead.
+func (*CreateShipmentRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CreateShipmentRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *CreateShipmentRequest) GetCarrier() string {
+	if x != nil {
+		return x.Carrier
+	}
+	return ""
+}
+
+func (x *CreateShipmentRequest) GetTrackingNumber() string {
+	if x != nil {
+		return x.TrackingNumber
+	}
+	return ""
+}
+
+func (x *CreateShipmentRequest) GetLines() []*Shipment_Line {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+type CreateShipmentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Shipment      *Shipment              `protobuf:"bytes,1,opt,name=shipment,proto3" json:"shipment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateShipmentResponse) Reset() {
+	*x = CreateShipmentResponse{}
+	mi := &file_order_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateShipmentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateShipmentResponse) ProtoMessage() {}
+
+func (x *CreateShipmentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateShipmentResponse.ProtoReflect.Descriptor instead.
+func (*CreateShipmentResponse) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CreateShipmentResponse) GetShipment() *Shipment {
+	if x != nil {
+		return x.Shipment
+	}
+	return nil
+}
+
+type AddShipmentEventRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	ShipmentId string                 `protobuf:"bytes,1,opt,name=shipmentId,proto3" json:"shipmentId,omitempty"`
+	// status is what the event moves the shipment to; a shipment is only
+	// ever created shipped.
+	Status      string `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Description string `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Location    string `protobuf:"bytes,4,opt,name=location,proto3" json:"location,omitempty"`
+	// occurredAt defaults to the time the event is recorded.
+	OccurredAt    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=occurredAt,proto3" json:"occurredAt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddShipmentEventRequest) Reset() {
+	*x = AddShipmentEventRequest{}
+	mi := &file_order_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddShipmentEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddShipmentEventRequest) ProtoMessage() {}
+
+func (x *AddShipmentEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddShipmentEventRequest.ProtoReflect.Descriptor instead.
+func (*AddShipmentEventRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *AddShipmentEventRequest) GetShipmentId() string {
+	if x != nil {
+		return x.ShipmentId
+	}
+	return ""
+}
+
+func (x *AddShipmentEventRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *AddShipmentEventRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *AddShipmentEventRequest) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *AddShipmentEventRequest) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+type AddShipmentEventResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Shipment      *Shipment              `protobuf:"bytes,1,opt,name=shipment,proto3" json:"shipment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddShipmentEventResponse) Reset() {
+	*x = AddShipmentEventResponse{}
+	mi := &file_order_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddShipmentEventResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddShipmentEventResponse) ProtoMessage() {}
+
+func (x *AddShipmentEventResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddShipmentEventResponse.ProtoReflect.Descriptor instead.
+func (*AddShipmentEventResponse) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *AddShipmentEventResponse) GetShipment() *Shipment {
+	if x != nil {
+		return x.Shipment
+	}
+	return nil
+}
+
+type ListShipmentsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=orderId,proto3" json:"orderId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListShipmentsRequest) Reset() {
+	*x = ListShipmentsRequest{}
+	mi := &file_order_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListShipmentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListShipmentsRequest) ProtoMessage() {}
+
+func (x *ListShipmentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListShipmentsRequest.ProtoReflect.Descriptor instead.
+func (*ListShipmentsRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ListShipmentsRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+type ListShipmentsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Shipments     []*Shipment            `protobuf:"bytes,1,rep,name=shipments,proto3" json:"shipments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListShipmentsResponse) Reset() {
+	*x = ListShipmentsResponse{}
+	mi := &file_order_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListShipmentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListShipmentsResponse) ProtoMessage() {}
+
+func (x *ListShipmentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListShipmentsResponse.ProtoReflect.Descriptor instead.
+func (*ListShipmentsResponse) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ListShipmentsResponse) GetShipments() []*Shipment {
+	if x != nil {
+		return x.Shipments
+	}
+	return nil
+}
+
+// Return is a customer's request to send back some quantity of some lines
+// of an order. status is requested, approved, rejected or received, and
+// refundAmount is refunded when the return is approved.
+type Return struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Id      string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	OrderId string                 `protobuf:"bytes,2,opt,name=orderId,proto3" json:"orderId,omitempty"`
+	Status  string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Reason  string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	// note is left by staff when they approve or reject the return.
+	Note          string                 `protobuf:"bytes,5,opt,name=note,proto3" json:"note,omitempty"`
+	Lines         []*Return_Line         `protobuf:"bytes,6,rep,name=lines,proto3" json:"lines,omitempty"`
+	RefundAmount  float64                `protobuf:"fixed64,7,opt,name=refundAmount,proto3" json:"refundAmount,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updatedAt,proto3" json:"updatedAt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Return) Reset() {
+	*x = Return{}
+	mi := &file_order_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Return) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Return) ProtoMessage() {}
+
+func (x *Return) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Return.ProtoReflect.Descriptor instead.
+func (*Return) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *Return) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Return) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *Return) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Return) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Return) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+func (x *Return) GetLines() []*Return_Line {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+func (x *Return) GetRefundAmount() float64 {
+	if x != nil {
+		return x.RefundAmount
+	}
+	return 0
+}
+
+func (x *Return) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Return) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type Refund struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	OrderId       string                 `protobuf:"bytes,2,opt,name=orderId,proto3" json:"orderId,omitempty"`
+	ReturnId      string                 `protobuf:"bytes,3,opt,name=returnId,proto3" json:"returnId,omitempty"`
+	Amount        float64                `protobuf:"fixed64,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Refund) Reset() {
+	*x = Refund{}
+	mi := &file_order_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Refund) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Refund) ProtoMessage() {}
+
+func (x *Refund) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Refund.ProtoReflect.Descriptor instead.
+func (*Refund) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *Refund) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Refund) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *Refund) GetReturnId() string {
+	if x != nil {
+		return x.ReturnId
+	}
+	return ""
+}
+
+func (x *Refund) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Refund) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type RequestReturnRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=orderId,proto3" json:"orderId,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	Lines         []*Return_Line         `protobuf:"bytes,3,rep,name=lines,proto3" json:"lines,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestReturnRequest) Reset() {
+	*x = RequestReturnRequest{}
+	mi := &file_order_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestReturnRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestReturnRequest) ProtoMessage() {}
+
+func (x *RequestReturnRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestReturnRequest.ProtoReflect.Descriptor instead.
+func (*RequestReturnRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *RequestReturnRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *RequestReturnRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *RequestReturnRequest) GetLines() []*Return_Line {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+type RequestReturnResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Return        *Return                `protobuf:"bytes,1,opt,name=return,proto3" json:"return,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestReturnResponse) Reset() {
+	*x = RequestReturnResponse{}
+	mi := &file_order_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestReturnResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestReturnResponse) ProtoMessage() {}
+
+func (x *RequestReturnResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestReturnResponse.ProtoReflect.Descriptor instead.
+func (*RequestReturnResponse) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *RequestReturnResponse) GetReturn() *Return {
+	if x != nil {
+		return x.Return
+	}
+	return nil
+}
+
+type ApproveReturnRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReturnId      string                 `protobuf:"bytes,1,opt,name=returnId,proto3" json:"returnId,omitempty"`
+	Note          string                 `protobuf:"bytes,2,opt,name=note,proto3" json:"note,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApproveReturnRequest) Reset() {
+	*x = ApproveReturnRequest{}
+	mi := &file_order_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApproveReturnRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApproveReturnRequest) ProtoMessage() {}
+
+func (x *ApproveReturnRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApproveReturnRequest.ProtoReflect.Descriptor instead.
+func (*ApproveReturnRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ApproveReturnRequest) GetReturnId() string {
+	if x != nil {
+		return x.ReturnId
+	}
+	return ""
+}
+
+func (x *ApproveReturnRequest) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+type ApproveReturnResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Return        *Return                `protobuf:"bytes,1,opt,name=return,proto3" json:"return,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApproveReturnResponse) Reset() {
+	*x = ApproveReturnResponse{}
+	mi := &file_order_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApproveReturnResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApproveReturnResponse) ProtoMessage() {}
+
+func (x *ApproveReturnResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApproveReturnResponse.ProtoReflect.Descriptor instead.
+func (*ApproveReturnResponse) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ApproveReturnResponse) GetReturn() *Return {
+	if x != nil {
+		return x.Return
+	}
+	return nil
+}
+
+type RejectReturnRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReturnId      string                 `protobuf:"bytes,1,opt,name=returnId,proto3" json:"returnId,omitempty"`
+	Note          string                 `protobuf:"bytes,2,opt,name=note,proto3" json:"note,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RejectReturnRequest) Reset() {
+	*x = RejectReturnRequest{}
+	mi := &file_order_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RejectReturnRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RejectReturnRequest) ProtoMessage() {}
+
+func (x *RejectReturnRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RejectReturnRequest.ProtoReflect.Descriptor instead.
+func (*RejectReturnRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *RejectReturnRequest) GetReturnId() string {
+	if x != nil {
+		return x.ReturnId
+	}
+	return ""
+}
+
+func (x *RejectReturnRequest) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+type RejectReturnResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Return        *Return                `protobuf:"bytes,1,opt,name=return,proto3" json:"return,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RejectReturnResponse) Reset() {
+	*x = RejectReturnResponse{}
+	mi := &file_order_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RejectReturnResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RejectReturnResponse) ProtoMessage() {}
+
+func (x *RejectReturnResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RejectReturnResponse.ProtoReflect.Descriptor instead.
+func (*RejectReturnResponse) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *RejectReturnResponse) GetReturn() *Return {
+	if x != nil {
+		return x.Return
+	}
+	return nil
+}
+
+type ReceiveReturnRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReturnId      string                 `protobuf:"bytes,1,opt,name=returnId,proto3" json:"returnId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReceiveReturnRequest) Reset() {
+	*x = ReceiveReturnRequest{}
+	mi := &file_order_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReceiveReturnRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReceiveReturnRequest) ProtoMessage() {}
+
+func (x *ReceiveReturnRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReceiveReturnRequest.ProtoReflect.Descriptor instead.
+func (*ReceiveReturnRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ReceiveReturnRequest) GetReturnId() string {
+	if x != nil {
+		return x.ReturnId
+	}
+	return ""
+}
+
+type ReceiveReturnResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Return        *Return                `protobuf:"bytes,1,opt,name=return,proto3" json:"return,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReceiveReturnResponse) Reset() {
+	*x = ReceiveReturnResponse{}
+	mi := &file_order_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReceiveReturnResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReceiveReturnResponse) ProtoMessage() {}
+
+func (x *ReceiveReturnResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReceiveReturnResponse.ProtoReflect.Descriptor instead.
+func (*ReceiveReturnResponse) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ReceiveReturnResponse) GetReturn() *Return {
+	if x != nil {
+		return x.Return
+	}
+	return nil
+}
+
+type ListReturnsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=orderId,proto3" json:"orderId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListReturnsRequest) Reset() {
+	*x = ListReturnsRequest{}
+	mi := &file_order_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListReturnsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReturnsRequest) ProtoMessage() {}
+
+func (x *ListReturnsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReturnsRequest.ProtoReflect.Descriptor instead.
+func (*ListReturnsRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ListReturnsRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+type ListReturnsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Returns       []*Return              `protobuf:"bytes,1,rep,name=returns,proto3" json:"returns,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListReturnsResponse) Reset() {
+	*x = ListReturnsResponse{}
+	mi := &file_order_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListReturnsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReturnsResponse) ProtoMessage() {}
+
+func (x *ListReturnsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReturnsResponse.ProtoReflect.Descriptor instead.
+func (*ListReturnsResponse) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ListReturnsResponse) GetReturns() []*Return {
+	if x != nil {
+		return x.Returns
+	}
+	return nil
+}
+
+type ListRefundsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=orderId,proto3" json:"orderId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRefundsRequest) Reset() {
+	*x = ListRefundsRequest{}
+	mi := &file_order_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRefundsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRefundsRequest) ProtoMessage() {}
+
+func (x *ListRefundsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRefundsRequest.ProtoReflect.Descriptor instead.
+func (*ListRefundsRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ListRefundsRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+type ListRefundsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Refunds       []*Refund              `protobuf:"bytes,1,rep,name=refunds,proto3" json:"refunds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRefundsResponse) Reset() {
+	*x = ListRefundsResponse{}
+	mi := &file_order_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRefundsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRefundsResponse) ProtoMessage() {}
+
+func (x *ListRefundsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRefundsResponse.ProtoReflect.Descriptor instead.
+func (*ListRefundsResponse) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ListRefundsResponse) GetRefunds() []*Refund {
+	if x != nil {
+		return x.Refunds
+	}
+	return nil
+}
+
+// Subscription reorders the same lines for an account every intervalCount
+// days, weeks or months (intervalUnit), starting at startsAt. status is
+// active, paused or cancelled. nextRunAt is when the next order is due.
+// failures counts the failed attempts at that order, which is retried at
+// retryAt, and lastFailure is the reason of the latest.
+type Subscription struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	AccountId       string                 `protobuf:"bytes,2,opt,name=accountId,proto3" json:"accountId,omitempty"`
+	Status          string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Lines           []*Subscription_Line   `protobuf:"bytes,4,rep,name=lines,proto3" json:"lines,omitempty"`
+	ShippingAddress *Address               `protobuf:"bytes,5,opt,name=shippingAddress,proto3" json:"shippingAddress,omitempty"`
+	IntervalUnit    string                 `protobuf:"bytes,6,opt,name=intervalUnit,proto3" json:"intervalUnit,omitempty"`
+	IntervalCount   uint32                 `protobuf:"varint,7,opt,name=intervalCount,proto3" json:"intervalCount,omitempty"`
+	StartsAt        *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=startsAt,proto3" json:"startsAt,omitempty"`
+	NextRunAt       *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=nextRunAt,proto3" json:"nextRunAt,omitempty"`
+	Failures        uint32                 `protobuf:"varint,10,opt,name=failures,proto3" json:"failures,omitempty"`
+	LastFailure     string                 `protobuf:"bytes,11,opt,name=lastFailure,proto3" json:"lastFailure,omitempty"`
+	RetryAt         *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=retryAt,proto3" json:"retryAt,omitempty"`
+	LastOrderId     string                 `protobuf:"bytes,13,opt,name=lastOrderId,proto3" json:"lastOrderId,omitempty"`
+	CreatedAt       *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
+	UpdatedAt       *timestamppb.Timestamp `protobuf:"bytes,15,opt,name=updatedAt,proto3" json:"updatedAt,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Subscription) Reset() {
+	*x = Subscription{}
+	mi := &file_order_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Subscription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Subscription) ProtoMessage() {}
+
+func (x *Subscription) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Subscription.ProtoReflect.Descriptor instead.
+func (*Subscription) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *Subscription) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Subscription) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *Subscription) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Subscription) GetLines() []*Subscription_Line {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+func (x *Subscription) GetShippingAddress() *Address {
+	if x != nil {
+		return x.ShippingAddress
+	}
+	return nil
+}
+
+func (x *Subscription) GetIntervalUnit() string {
+	if x != nil {
+		return x.IntervalUnit
+	}
+	return ""
+}
+
+func (x *Subscription) GetIntervalCount() uint32 {
+	if x != nil {
+		return x.IntervalCount
+	}
+	return 0
+}
+
+func (x *Subscription) GetStartsAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartsAt
+	}
+	return nil
+}
+
+func (x *Subscription) GetNextRunAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NextRunAt
+	}
+	return nil
+}
+
+func (x *Subscription) GetFailures() uint32 {
+	if x != nil {
+		return x.Failures
+	}
+	return 0
+}
+
+func (x *Subscription) GetLastFailure() string {
+	if x != nil {
+		return x.LastFailure
+	}
+	return ""
+}
+
+func (x *Subscription) GetRetryAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RetryAt
+	}
+	return nil
+}
+
+func (x *Subscription) GetLastOrderId() string {
+	if x != nil {
+		return x.LastOrderId
+	}
+	return ""
+}
+
+func (x *Subscription) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Subscription) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type CreateSubscriptionRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	AccountId       string                 `protobuf:"bytes,1,opt,name=accountId,proto3" json:"accountId,omitempty"`
+	Lines           []*Subscription_Line   `protobuf:"bytes,2,rep,name=lines,proto3" json:"lines,omitempty"`
+	ShippingAddress *Address               `protobuf:"bytes,3,opt,name=shippingAddress,proto3" json:"shippingAddress,omitempty"`
+	IntervalUnit    string                 `protobuf:"bytes,4,opt,name=intervalUnit,proto3" json:"intervalUnit,omitempty"`
+	IntervalCount   uint32                 `protobuf:"varint,5,opt,name=intervalCount,proto3" json:"intervalCount,omitempty"`
+	// startsAt is when the first order is due, right away when unset.
+	StartsAt      *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=startsAt,proto3" json:"startsAt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSubscriptionRequest) Reset() {
+	*x = CreateSubscriptionRequest{}
+	mi := &file_order_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSubscriptionRequest) ProtoMessage() {}
+
+func (x *CreateSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*CreateSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *CreateSubscriptionRequest) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *CreateSubscriptionRequest) GetLines() []*Subscription_Line {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+func (x *CreateSubscriptionRequest) GetShippingAddress() *Address {
+	if x != nil {
+		return x.ShippingAddress
+	}
+	return nil
+}
+
+func (x *CreateSubscriptionRequest) GetIntervalUnit() string {
+	if x != nil {
+		return x.IntervalUnit
+	}
+	return ""
+}
+
+func (x *CreateSubscriptionRequest) GetIntervalCount() uint32 {
+	if x != nil {
+		return x.IntervalCount
+	}
+	return 0
+}
+
+func (x *CreateSubscriptionRequest) GetStartsAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartsAt
+	}
+	return nil
+}
+
+type CreateSubscriptionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subscription  *Subscription          `protobuf:"bytes,1,opt,name=subscription,proto3" json:"subscription,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSubscriptionResponse) Reset() {
+	*x = CreateSubscriptionResponse{}
+	mi := &file_order_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSubscriptionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSubscriptionResponse) ProtoMessage() {}
+
+func (x *CreateSubscriptionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSubscriptionResponse.ProtoReflect.Descriptor instead.
+func (*CreateSubscriptionResponse) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *CreateSubscriptionResponse) GetSubscription() *Subscription {
+	if x != nil {
+		return x.Subscription
+	}
+	return nil
+}
+
+type ListSubscriptionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountId     string                 `protobuf:"bytes,1,opt,name=accountId,proto3" json:"accountId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSubscriptionsRequest) Reset() {
+	*x = ListSubscriptionsRequest{}
+	mi := &file_order_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSubscriptionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSubscriptionsRequest) ProtoMessage() {}
+
+func (x *ListSubscriptionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSubscriptionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSubscriptionsRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ListSubscriptionsRequest) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+type ListSubscriptionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subscriptions []*Subscription        `protobuf:"bytes,1,rep,name=subscriptions,proto3" json:"subscriptions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSubscriptionsResponse) Reset() {
+	*x = ListSubscriptionsResponse{}
+	mi := &file_order_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSubscriptionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSubscriptionsResponse) ProtoMessage() {}
+
+func (x *ListSubscriptionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSubscriptionsResponse.ProtoReflect.Descriptor instead.
+func (*ListSubscriptionsResponse) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *ListSubscriptionsResponse) GetSubscriptions() []*Subscription {
+	if x != nil {
+		return x.Subscriptions
+	}
+	return nil
+}
+
+type PauseSubscriptionRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	SubscriptionId string                 `protobuf:"bytes,1,opt,name=subscriptionId,proto3" json:"subscriptionId,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *PauseSubscriptionRequest) Reset() {
+	*x = PauseSubscriptionRequest{}
+	mi := &file_order_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PauseSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PauseSubscriptionRequest) ProtoMessage() {}
+
+func (x *PauseSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PauseSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*PauseSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *PauseSubscriptionRequest) GetSubscriptionId() string {
+	if x != nil {
+		return x.SubscriptionId
+	}
+	return ""
+}
+
+type PauseSubscriptionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subscription  *Subscription          `protobuf:"bytes,1,opt,name=subscription,proto3" json:"subscription,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PauseSubscriptionResponse) Reset() {
+	*x = PauseSubscriptionResponse{}
+	mi := &file_order_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PauseSubscriptionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PauseSubscriptionResponse) ProtoMessage() {}
+
+func (x *PauseSubscriptionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PauseSubscriptionResponse.ProtoReflect.Descriptor instead.
+func (*PauseSubscriptionResponse) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *PauseSubscriptionResponse) GetSubscription() *Subscription {
+	if x != nil {
+		return x.Subscription
+	}
+	return nil
+}
+
+type ResumeSubscriptionRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	SubscriptionId string                 `protobuf:"bytes,1,opt,name=subscriptionId,proto3" json:"subscriptionId,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ResumeSubscriptionRequest) Reset() {
+	*x = ResumeSubscriptionRequest{}
+	mi := &file_order_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeSubscriptionRequest) ProtoMessage() {}
+
+func (x *ResumeSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*ResumeSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *ResumeSubscriptionRequest) GetSubscriptionId() string {
+	if x != nil {
+		return x.SubscriptionId
+	}
+	return ""
+}
+
+type ResumeSubscriptionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subscription  *Subscription          `protobuf:"bytes,1,opt,name=subscription,proto3" json:"subscription,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumeSubscriptionResponse) Reset() {
+	*x = ResumeSubscriptionResponse{}
+	mi := &file_order_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeSubscriptionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeSubscriptionResponse) ProtoMessage() {}
+
+func (x *ResumeSubscriptionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeSubscriptionResponse.ProtoReflect.Descriptor instead.
+func (*ResumeSubscriptionResponse) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *ResumeSubscriptionResponse) GetSubscription() *Subscription {
+	if x != nil {
+		return x.Subscription
+	}
+	return nil
+}
+
+type CancelSubscriptionRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	SubscriptionId string                 `protobuf:"bytes,1,opt,name=subscriptionId,proto3" json:"subscriptionId,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CancelSubscriptionRequest) Reset() {
+	*x = CancelSubscriptionRequest{}
+	mi := &file_order_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelSubscriptionRequest) ProtoMessage() {}
+
+func (x *CancelSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*CancelSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *CancelSubscriptionRequest) GetSubscriptionId() string {
+	if x != nil {
+		return x.SubscriptionId
+	}
+	return ""
+}
+
+type CancelSubscriptionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subscription  *Subscription          `protobuf:"bytes,1,opt,name=subscription,proto3" json:"subscription,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelSubscriptionResponse) Reset() {
+	*x = CancelSubscriptionResponse{}
+	mi := &file_order_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelSubscriptionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelSubscriptionResponse) ProtoMessage() {}
+
+func (x *CancelSubscriptionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelSubscriptionResponse.ProtoReflect.Descriptor instead.
+func (*CancelSubscriptionResponse) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *CancelSubscriptionResponse) GetSubscription() *Subscription {
+	if x != nil {
+		return x.Subscription
+	}
+	return nil
+}
+
+type Order_OrderProduct struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Price         float64                `protobuf:"fixed64,4,opt,name=price,proto3" json:"price,omitempty"`
+	Quantity      uint32                 `protobuf:"varint,5,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	VariantId     string                 `protobuf:"bytes,6,opt,name=variantId,proto3" json:"variantId,omitempty"`
+	Sku           string                 `protobuf:"bytes,7,opt,name=sku,proto3" json:"sku,omitempty"`
+	Options       map[string]string      `protobuf:"bytes,8,rep,name=options,proto3" json:"options,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Order_OrderProduct) Reset() {
+	*x = Order_OrderProduct{}
+	mi := &file_order_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Order_OrderProduct) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Order_OrderProduct) ProtoMessage() {}
+
+func (x *Order_OrderProduct) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Order_OrderProduct.ProtoReflect.Descriptor instead.
+func (*Order_OrderProduct) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{1, 0}
+}
+
+func (x *Order_OrderProduct) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Order_OrderProduct) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Order_OrderProduct) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Order_OrderProduct) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Order_OrderProduct) GetQuantity() uint32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *Order_OrderProduct) GetVariantId() string {
+	if x != nil {
+		return x.VariantId
+	}
+	return ""
+}
+
+func (x *Order_OrderProduct) GetSku() string {
+	if x != nil {
+		return x.Sku
+	}
+	return ""
+}
+
+func (x *Order_OrderProduct) GetOptions() map[string]string {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type PostOrderRequest_OrderProduct struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     string                 `protobuf:"bytes,1,opt,name=productId,proto3" json:"productId,omitempty"`
+	Quantity      uint32                 `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	VariantId     string                 `protobuf:"bytes,3,opt,name=variantId,proto3" json:"variantId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PostOrderRequest_OrderProduct) Reset() {
+	*x = PostOrderRequest_OrderProduct{}
+	mi := &file_order_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PostOrderRequest_OrderProduct) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PostOrderRequest_OrderProduct) ProtoMessage() {}
+
+func (x *PostOrderRequest_OrderProduct) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[47]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -480,7 +2949,7 @@ func (x *PostOrderRequest_OrderProduct) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PostOrderRequest_OrderProduct.ProtoReflect.Descriptor instead.
 func (*PostOrderRequest_OrderProduct) Descriptor() ([]byte, []int) {
-	return file_order_proto_rawDescGZIP(), []int{1, 0}
+	return file_order_proto_rawDescGZIP(), []int{2, 0}
 }
 
 func (x *PostOrderRequest_OrderProduct) GetProductId() string {
@@ -497,11 +2966,277 @@ func (x *PostOrderRequest_OrderProduct) GetQuantity() uint32 {
 	return 0
 }
 
+func (x *PostOrderRequest_OrderProduct) GetVariantId() string {
+	if x != nil {
+		return x.VariantId
+	}
+	return ""
+}
+
+type Shipment_Line struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     string                 `protobuf:"bytes,1,opt,name=productId,proto3" json:"productId,omitempty"`
+	VariantId     string                 `protobuf:"bytes,2,opt,name=variantId,proto3" json:"variantId,omitempty"`
+	Quantity      uint64                 `protobuf:"varint,3,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Shipment_Line) Reset() {
+	*x = Shipment_Line{}
+	mi := &file_order_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Shipment_Line) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Shipment_Line) ProtoMessage() {}
+
+func (x *Shipment_Line) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Shipment_Line.ProtoReflect.Descriptor instead.
+func (*Shipment_Line) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{13, 0}
+}
+
+func (x *Shipment_Line) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *Shipment_Line) GetVariantId() string {
+	if x != nil {
+		return x.VariantId
+	}
+	return ""
+}
+
+func (x *Shipment_Line) GetQuantity() uint64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+type Shipment_Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Location      string                 `protobuf:"bytes,3,opt,name=location,proto3" json:"location,omitempty"`
+	OccurredAt    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=occurredAt,proto3" json:"occurredAt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Shipment_Event) Reset() {
+	*x = Shipment_Event{}
+	mi := &file_order_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Shipment_Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Shipment_Event) ProtoMessage() {}
+
+func (x *Shipment_Event) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Shipment_Event.ProtoReflect.Descriptor instead.
+func (*Shipment_Event) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{13, 1}
+}
+
+func (x *Shipment_Event) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Shipment_Event) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Shipment_Event) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *Shipment_Event) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+type Return_Line struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     string                 `protobuf:"bytes,1,opt,name=productId,proto3" json:"productId,omitempty"`
+	VariantId     string                 `protobuf:"bytes,2,opt,name=variantId,proto3" json:"variantId,omitempty"`
+	Quantity      uint64                 `protobuf:"varint,3,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Return_Line) Reset() {
+	*x = Return_Line{}
+	mi := &file_order_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Return_Line) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Return_Line) ProtoMessage() {}
+
+func (x *Return_Line) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Return_Line.ProtoReflect.Descriptor instead.
+func (*Return_Line) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{20, 0}
+}
+
+func (x *Return_Line) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *Return_Line) GetVariantId() string {
+	if x != nil {
+		return x.VariantId
+	}
+	return ""
+}
+
+func (x *Return_Line) GetQuantity() uint64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+type Subscription_Line struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     string                 `protobuf:"bytes,1,opt,name=productId,proto3" json:"productId,omitempty"`
+	VariantId     string                 `protobuf:"bytes,2,opt,name=variantId,proto3" json:"variantId,omitempty"`
+	Quantity      uint64                 `protobuf:"varint,3,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Subscription_Line) Reset() {
+	*x = Subscription_Line{}
+	mi := &file_order_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Subscription_Line) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Subscription_Line) ProtoMessage() {}
+
+func (x *Subscription_Line) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Subscription_Line.ProtoReflect.Descriptor instead.
+func (*Subscription_Line) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{34, 0}
+}
+
+func (x *Subscription_Line) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *Subscription_Line) GetVariantId() string {
+	if x != nil {
+		return x.VariantId
+	}
+	return ""
+}
+
+func (x *Subscription_Line) GetQuantity() uint64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
 var File_order_proto protoreflect.FileDescriptor
 
 const file_order_proto_rawDesc = "" +
 	"\n" +
-	"\vorder.proto\x12\x02pb\"\xb0\x02\n" +
+	"\vorder.proto\x12\x02pb\x1a\x1cgoogle/api/annotations.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bvalidation/validation.proto\"\xfb\x01\n" +
+	"\aAddress\x12\x1c\n" +
+	"\x04name\x18\x01 \x01(\tB\b\xc2\xf3\x18\x04\b\x01\x10dR\x04name\x12\x1f\n" +
+	"\x05line1\x18\x02 \x01(\tB\t\xc2\xf3\x18\x05\b\x01\x10\xc8\x01R\x05line1\x12\x1d\n" +
+	"\x05line2\x18\x03 \x01(\tB\a\xc2\xf3\x18\x03\x10\xc8\x01R\x05line2\x12\x1c\n" +
+	"\x04city\x18\x04 \x01(\tB\b\xc2\xf3\x18\x04\b\x01\x10dR\x04city\x12\x1e\n" +
+	"\x06region\x18\x05 \x01(\tB\x06\xc2\xf3\x18\x02\x10dR\x06region\x12&\n" +
+	"\n" +
+	"postalCode\x18\x06 \x01(\tB\x06\xc2\xf3\x18\x02\x10\x14R\n" +
+	"postalCode\x12,\n" +
+	"\acountry\x18\a \x01(\tB\x12\xc2\xf3\x18\x0e\b\x01\x1a\n" +
+	"^[A-Z]{2}$R\acountry\"\xe2\x04\n" +
 	"\x05Order\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1c\n" +
 	"\tcreatedAt\x18\x02 \x01(\fR\tcreatedAt\x12\x1c\n" +
@@ -509,32 +3244,250 @@ const file_order_proto_rawDesc = "" +
 	"\n" +
 	"totalPrice\x18\x04 \x01(\x01R\n" +
 	"totalPrice\x122\n" +
-	"\bproducts\x18\x05 \x03(\v2\x16.pb.Order.OrderProductR\bproducts\x1a\x86\x01\n" +
+	"\bproducts\x18\x05 \x03(\v2\x16.pb.Order.OrderProductR\bproducts\x126\n" +
+	"\bplacedAt\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\bplacedAt\x12\x16\n" +
+	"\x06status\x18\a \x01(\tR\x06status\x125\n" +
+	"\x0fshippingAddress\x18\b \x01(\v2\v.pb.AddressR\x0fshippingAddress\x1a\xb1\x02\n" +
 	"\fOrderProduct\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
 	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x14\n" +
 	"\x05price\x18\x04 \x01(\x01R\x05price\x12\x1a\n" +
-	"\bquantity\x18\x05 \x01(\rR\bquantity\"\xb9\x01\n" +
-	"\x10PostOrderRequest\x12\x1c\n" +
-	"\taccountId\x18\x01 \x01(\tR\taccountId\x12=\n" +
-	"\bproducts\x18\x02 \x03(\v2!.pb.PostOrderRequest.OrderProductR\bproducts\x1aH\n" +
-	"\fOrderProduct\x12\x1c\n" +
-	"\tproductId\x18\x01 \x01(\tR\tproductId\x12\x1a\n" +
-	"\bquantity\x18\x02 \x01(\rR\bquantity\"4\n" +
+	"\bquantity\x18\x05 \x01(\rR\bquantity\x12\x1c\n" +
+	"\tvariantId\x18\x06 \x01(\tR\tvariantId\x12\x10\n" +
+	"\x03sku\x18\a \x01(\tR\x03sku\x12=\n" +
+	"\aoptions\x18\b \x03(\v2#.pb.Order.OrderProduct.OptionsEntryR\aoptions\x1a:\n" +
+	"\fOptionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xc7\x03\n" +
+	"\x10PostOrderRequest\x127\n" +
+	"\taccountId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\taccountId\x12]\n" +
+	"\bproducts\x18\x02 \x03(\v2!.pb.PostOrderRequest.OrderProductB\x1e\xc2\xf3\x18\x1a8\x01@dR\tproductIdR\tvariantIdR\bproducts\x125\n" +
+	"\x0fshippingAddress\x18\x03 \x01(\v2\v.pb.AddressR\x0fshippingAddress\x12/\n" +
+	"\x0eidempotencyKey\x18\x04 \x01(\tB\a\xc2\xf3\x18\x03\x10\x80\x01R\x0eidempotencyKey\x1a\xb2\x01\n" +
+	"\fOrderProduct\x127\n" +
+	"\tproductId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\tproductId\x122\n" +
+	"\bquantity\x18\x02 \x01(\rB\x16\xc2\xf3\x18\x12)\x00\x00\x00\x00\x00\x00\xf0?1\x00\x00\x00\x00\x00@\x8f@R\bquantity\x125\n" +
+	"\tvariantId\x18\x03 \x01(\tB\x17\xc2\xf3\x18\x13\x1a\x11^[0-9A-Za-z]{27}$R\tvariantId\"4\n" +
 	"\x11PostOrderResponse\x12\x1f\n" +
-	"\x05order\x18\x01 \x01(\v2\t.pb.OrderR\x05order\"!\n" +
-	"\x0fGetOrderRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"3\n" +
+	"\x05order\x18\x01 \x01(\v2\t.pb.OrderR\x05order\"<\n" +
+	"\x0fGetOrderRequest\x12)\n" +
+	"\x02id\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\x02id\"3\n" +
 	"\x10GetOrderResopnse\x12\x1f\n" +
-	"\x05order\x18\x01 \x01(\v2\t.pb.OrderR\x05order\"9\n" +
-	"\x19GetOrderForAccountRequest\x12\x1c\n" +
-	"\taccountId\x18\x01 \x01(\tR\taccountId\"?\n" +
+	"\x05order\x18\x01 \x01(\v2\t.pb.OrderR\x05order\"\xe4\x02\n" +
+	"\x19GetOrderForAccountRequest\x127\n" +
+	"\taccountId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\taccountId\x12\x12\n" +
+	"\x04take\x18\x02 \x01(\x04R\x04take\x12\x14\n" +
+	"\x05after\x18\x03 \x01(\tR\x05after\x12:\n" +
+	"\n" +
+	"placedFrom\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"placedFrom\x126\n" +
+	"\bplacedTo\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\bplacedTo\x12\x1e\n" +
+	"\x06status\x18\x06 \x01(\tB\x06\xc2\xf3\x18\x02\x10 R\x06status\x12)\n" +
+	"\bminTotal\x18\a \x01(\x01B\r\xc2\xf3\x18\t)\x00\x00\x00\x00\x00\x00\x00\x00R\bminTotal\x12%\n" +
+	"\x04sort\x18\b \x01(\x0e2\x11.pb.SortDirectionR\x04sort\"{\n" +
 	"\x1aGetOrderForAccountResponse\x12!\n" +
-	"\x06orders\x18\x01 \x03(\v2\t.pb.OrderR\x06orders2\x9d\x01\n" +
-	"\fOrderService\x128\n" +
-	"\tPostOrder\x12\x14.pb.PostOrderRequest\x1a\x15.pb.PostOrderResponse\x12S\n" +
-	"\x12GetOrderForAccount\x12\x1d.pb.GetOrderForAccountRequest\x1a\x1e.pb.GetOrderForAccountResponseB\x03Z\x01.b\x06proto3"
+	"\x06orders\x18\x01 \x03(\v2\t.pb.OrderR\x06orders\x12\x18\n" +
+	"\acursors\x18\x02 \x03(\tR\acursors\x12 \n" +
+	"\vhasNextPage\x18\x03 \x01(\bR\vhasNextPage\"\xbe\x03\n" +
+	"\x13SearchOrdersRequest\x125\n" +
+	"\taccountId\x18\x01 \x01(\tB\x17\xc2\xf3\x18\x13\x1a\x11^[0-9A-Za-z]{27}$R\taccountId\x125\n" +
+	"\tproductId\x18\x02 \x01(\tB\x17\xc2\xf3\x18\x13\x1a\x11^[0-9A-Za-z]{27}$R\tproductId\x12\x1e\n" +
+	"\x06status\x18\x03 \x01(\tB\x06\xc2\xf3\x18\x02\x10 R\x06status\x12:\n" +
+	"\n" +
+	"placedFrom\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"placedFrom\x126\n" +
+	"\bplacedTo\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\bplacedTo\x12)\n" +
+	"\bminTotal\x18\x06 \x01(\x01B\r\xc2\xf3\x18\t)\x00\x00\x00\x00\x00\x00\x00\x00R\bminTotal\x12)\n" +
+	"\bmaxTotal\x18\a \x01(\x01B\r\xc2\xf3\x18\t)\x00\x00\x00\x00\x00\x00\x00\x00R\bmaxTotal\x12%\n" +
+	"\x04sort\x18\b \x01(\x0e2\x11.pb.SortDirectionR\x04sort\x12\x12\n" +
+	"\x04take\x18\t \x01(\x04R\x04take\x12\x14\n" +
+	"\x05after\x18\n" +
+	" \x01(\tR\x05after\"u\n" +
+	"\x14SearchOrdersResponse\x12!\n" +
+	"\x06orders\x18\x01 \x03(\v2\t.pb.OrderR\x06orders\x12\x18\n" +
+	"\acursors\x18\x02 \x03(\tR\acursors\x12 \n" +
+	"\vhasNextPage\x18\x03 \x01(\bR\vhasNextPage\"\x99\x01\n" +
+	"\aInvoice\x12\x16\n" +
+	"\x06number\x18\x01 \x01(\x04R\x06number\x12\x18\n" +
+	"\aorderId\x18\x02 \x01(\tR\aorderId\x126\n" +
+	"\bissuedAt\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\bissuedAt\x12\x12\n" +
+	"\x04html\x18\x04 \x01(\fR\x04html\x12\x10\n" +
+	"\x03pdf\x18\x05 \x01(\fR\x03pdf\"H\n" +
+	"\x11GetInvoiceRequest\x123\n" +
+	"\aorderId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\aorderId\";\n" +
+	"\x12GetInvoiceResponse\x12%\n" +
+	"\ainvoice\x18\x01 \x01(\v2\v.pb.InvoiceR\ainvoice\"\xc2\x05\n" +
+	"\bShipment\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\aorderId\x18\x02 \x01(\tR\aorderId\x12\x18\n" +
+	"\acarrier\x18\x03 \x01(\tR\acarrier\x12&\n" +
+	"\x0etrackingNumber\x18\x04 \x01(\tR\x0etrackingNumber\x12 \n" +
+	"\vtrackingUrl\x18\x05 \x01(\tR\vtrackingUrl\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\tR\x06status\x12'\n" +
+	"\x05lines\x18\a \x03(\v2\x11.pb.Shipment.LineR\x05lines\x12*\n" +
+	"\x06events\x18\b \x03(\v2\x12.pb.Shipment.EventR\x06events\x128\n" +
+	"\tcreatedAt\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x128\n" +
+	"\tupdatedAt\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x1a\xaa\x01\n" +
+	"\x04Line\x127\n" +
+	"\tproductId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\tproductId\x125\n" +
+	"\tvariantId\x18\x02 \x01(\tB\x17\xc2\xf3\x18\x13\x1a\x11^[0-9A-Za-z]{27}$R\tvariantId\x122\n" +
+	"\bquantity\x18\x03 \x01(\x04B\x16\xc2\xf3\x18\x12)\x00\x00\x00\x00\x00\x00\xf0?1\x00\x00\x00\x00\x00@\x8f@R\bquantity\x1a\x99\x01\n" +
+	"\x05Event\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x1a\n" +
+	"\blocation\x18\x03 \x01(\tR\blocation\x12:\n" +
+	"\n" +
+	"occurredAt\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\"\xec\x01\n" +
+	"\x15CreateShipmentRequest\x123\n" +
+	"\aorderId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\aorderId\x12\"\n" +
+	"\acarrier\x18\x02 \x01(\tB\b\xc2\xf3\x18\x04\b\x01\x10@R\acarrier\x121\n" +
+	"\x0etrackingNumber\x18\x03 \x01(\tB\t\xc2\xf3\x18\x05\b\x01\x10\x80\x01R\x0etrackingNumber\x12G\n" +
+	"\x05lines\x18\x04 \x03(\v2\x11.pb.Shipment.LineB\x1e\xc2\xf3\x18\x1a8\x01@dR\tproductIdR\tvariantIdR\x05lines\"B\n" +
+	"\x16CreateShipmentResponse\x12(\n" +
+	"\bshipment\x18\x01 \x01(\v2\f.pb.ShipmentR\bshipment\"\xb5\x02\n" +
+	"\x17AddShipmentEventRequest\x129\n" +
+	"\n" +
+	"shipmentId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\n" +
+	"shipmentId\x12S\n" +
+	"\x06status\x18\x02 \x01(\tB;\xc2\xf3\x187\b\x01\x1a3^(in_transit|out_for_delivery|delivered|exception)$R\x06status\x12)\n" +
+	"\vdescription\x18\x03 \x01(\tB\a\xc2\xf3\x18\x03\x10\xf4\x03R\vdescription\x12#\n" +
+	"\blocation\x18\x04 \x01(\tB\a\xc2\xf3\x18\x03\x10\xc8\x01R\blocation\x12:\n" +
+	"\n" +
+	"occurredAt\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\"D\n" +
+	"\x18AddShipmentEventResponse\x12(\n" +
+	"\bshipment\x18\x01 \x01(\v2\f.pb.ShipmentR\bshipment\"K\n" +
+	"\x14ListShipmentsRequest\x123\n" +
+	"\aorderId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\aorderId\"C\n" +
+	"\x15ListShipmentsResponse\x12*\n" +
+	"\tshipments\x18\x01 \x03(\v2\f.pb.ShipmentR\tshipments\"\xe2\x03\n" +
+	"\x06Return\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\aorderId\x18\x02 \x01(\tR\aorderId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x12\x12\n" +
+	"\x04note\x18\x05 \x01(\tR\x04note\x12%\n" +
+	"\x05lines\x18\x06 \x03(\v2\x0f.pb.Return.LineR\x05lines\x12\"\n" +
+	"\frefundAmount\x18\a \x01(\x01R\frefundAmount\x128\n" +
+	"\tcreatedAt\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x128\n" +
+	"\tupdatedAt\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x1a\xaa\x01\n" +
+	"\x04Line\x127\n" +
+	"\tproductId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\tproductId\x125\n" +
+	"\tvariantId\x18\x02 \x01(\tB\x17\xc2\xf3\x18\x13\x1a\x11^[0-9A-Za-z]{27}$R\tvariantId\x122\n" +
+	"\bquantity\x18\x03 \x01(\x04B\x16\xc2\xf3\x18\x12)\x00\x00\x00\x00\x00\x00\xf0?1\x00\x00\x00\x00\x00@\x8f@R\bquantity\"\xa0\x01\n" +
+	"\x06Refund\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\aorderId\x18\x02 \x01(\tR\aorderId\x12\x1a\n" +
+	"\breturnId\x18\x03 \x01(\tR\breturnId\x12\x16\n" +
+	"\x06amount\x18\x04 \x01(\x01R\x06amount\x128\n" +
+	"\tcreatedAt\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\xb5\x01\n" +
+	"\x14RequestReturnRequest\x123\n" +
+	"\aorderId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\aorderId\x12!\n" +
+	"\x06reason\x18\x02 \x01(\tB\t\xc2\xf3\x18\x05\b\x01\x10\xf4\x03R\x06reason\x12E\n" +
+	"\x05lines\x18\x03 \x03(\v2\x0f.pb.Return.LineB\x1e\xc2\xf3\x18\x1a8\x01@dR\tproductIdR\tvariantIdR\x05lines\";\n" +
+	"\x15RequestReturnResponse\x12\"\n" +
+	"\x06return\x18\x01 \x01(\v2\n" +
+	".pb.ReturnR\x06return\"j\n" +
+	"\x14ApproveReturnRequest\x125\n" +
+	"\breturnId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\breturnId\x12\x1b\n" +
+	"\x04note\x18\x02 \x01(\tB\a\xc2\xf3\x18\x03\x10\xf4\x03R\x04note\";\n" +
+	"\x15ApproveReturnResponse\x12\"\n" +
+	"\x06return\x18\x01 \x01(\v2\n" +
+	".pb.ReturnR\x06return\"i\n" +
+	"\x13RejectReturnRequest\x125\n" +
+	"\breturnId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\breturnId\x12\x1b\n" +
+	"\x04note\x18\x02 \x01(\tB\a\xc2\xf3\x18\x03\x10\xf4\x03R\x04note\":\n" +
+	"\x14RejectReturnResponse\x12\"\n" +
+	"\x06return\x18\x01 \x01(\v2\n" +
+	".pb.ReturnR\x06return\"M\n" +
+	"\x14ReceiveReturnRequest\x125\n" +
+	"\breturnId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\breturnId\";\n" +
+	"\x15ReceiveReturnResponse\x12\"\n" +
+	"\x06return\x18\x01 \x01(\v2\n" +
+	".pb.ReturnR\x06return\"I\n" +
+	"\x12ListReturnsRequest\x123\n" +
+	"\aorderId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\aorderId\";\n" +
+	"\x13ListReturnsResponse\x12$\n" +
+	"\areturns\x18\x01 \x03(\v2\n" +
+	".pb.ReturnR\areturns\"I\n" +
+	"\x12ListRefundsRequest\x123\n" +
+	"\aorderId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\aorderId\";\n" +
+	"\x13ListRefundsResponse\x12$\n" +
+	"\arefunds\x18\x01 \x03(\v2\n" +
+	".pb.RefundR\arefunds\"\xab\x06\n" +
+	"\fSubscription\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1c\n" +
+	"\taccountId\x18\x02 \x01(\tR\taccountId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12+\n" +
+	"\x05lines\x18\x04 \x03(\v2\x15.pb.Subscription.LineR\x05lines\x125\n" +
+	"\x0fshippingAddress\x18\x05 \x01(\v2\v.pb.AddressR\x0fshippingAddress\x12\"\n" +
+	"\fintervalUnit\x18\x06 \x01(\tR\fintervalUnit\x12$\n" +
+	"\rintervalCount\x18\a \x01(\rR\rintervalCount\x126\n" +
+	"\bstartsAt\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\bstartsAt\x128\n" +
+	"\tnextRunAt\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tnextRunAt\x12\x1a\n" +
+	"\bfailures\x18\n" +
+	" \x01(\rR\bfailures\x12 \n" +
+	"\vlastFailure\x18\v \x01(\tR\vlastFailure\x124\n" +
+	"\aretryAt\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\aretryAt\x12 \n" +
+	"\vlastOrderId\x18\r \x01(\tR\vlastOrderId\x128\n" +
+	"\tcreatedAt\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x128\n" +
+	"\tupdatedAt\x18\x0f \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x1a\xaa\x01\n" +
+	"\x04Line\x127\n" +
+	"\tproductId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\tproductId\x125\n" +
+	"\tvariantId\x18\x02 \x01(\tB\x17\xc2\xf3\x18\x13\x1a\x11^[0-9A-Za-z]{27}$R\tvariantId\x122\n" +
+	"\bquantity\x18\x03 \x01(\x04B\x16\xc2\xf3\x18\x12)\x00\x00\x00\x00\x00\x00\xf0?1\x00\x00\x00\x00\x00@\x8f@R\bquantity\"\x8c\x03\n" +
+	"\x19CreateSubscriptionRequest\x127\n" +
+	"\taccountId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\taccountId\x12K\n" +
+	"\x05lines\x18\x02 \x03(\v2\x15.pb.Subscription.LineB\x1e\xc2\xf3\x18\x1a8\x01@dR\tproductIdR\tvariantIdR\x05lines\x125\n" +
+	"\x0fshippingAddress\x18\x03 \x01(\v2\v.pb.AddressR\x0fshippingAddress\x12<\n" +
+	"\fintervalUnit\x18\x04 \x01(\tB\x18\xc2\xf3\x18\x14\x1a\x12^(day|week|month)$R\fintervalUnit\x12<\n" +
+	"\rintervalCount\x18\x05 \x01(\rB\x16\xc2\xf3\x18\x12)\x00\x00\x00\x00\x00\x00\xf0?1\x00\x00\x00\x00\x00\xd0v@R\rintervalCount\x126\n" +
+	"\bstartsAt\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\bstartsAt\"R\n" +
+	"\x1aCreateSubscriptionResponse\x124\n" +
+	"\fsubscription\x18\x01 \x01(\v2\x10.pb.SubscriptionR\fsubscription\"S\n" +
+	"\x18ListSubscriptionsRequest\x127\n" +
+	"\taccountId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\taccountId\"S\n" +
+	"\x19ListSubscriptionsResponse\x126\n" +
+	"\rsubscriptions\x18\x01 \x03(\v2\x10.pb.SubscriptionR\rsubscriptions\"]\n" +
+	"\x18PauseSubscriptionRequest\x12A\n" +
+	"\x0esubscriptionId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\x0esubscriptionId\"Q\n" +
+	"\x19PauseSubscriptionResponse\x124\n" +
+	"\fsubscription\x18\x01 \x01(\v2\x10.pb.SubscriptionR\fsubscription\"^\n" +
+	"\x19ResumeSubscriptionRequest\x12A\n" +
+	"\x0esubscriptionId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\x0esubscriptionId\"R\n" +
+	"\x1aResumeSubscriptionResponse\x124\n" +
+	"\fsubscription\x18\x01 \x01(\v2\x10.pb.SubscriptionR\fsubscription\"^\n" +
+	"\x19CancelSubscriptionRequest\x12A\n" +
+	"\x0esubscriptionId\x18\x01 \x01(\tB\x19\xc2\xf3\x18\x15\b\x01\x1a\x11^[0-9A-Za-z]{27}$R\x0esubscriptionId\"R\n" +
+	"\x1aCancelSubscriptionResponse\x124\n" +
+	"\fsubscription\x18\x01 \x01(\v2\x10.pb.SubscriptionR\fsubscription*3\n" +
+	"\rSortDirection\x12\x10\n" +
+	"\fOLDEST_FIRST\x10\x00\x12\x10\n" +
+	"\fNEWEST_FIRST\x10\x012\xc1\f\n" +
+	"\fOrderService\x12O\n" +
+	"\tPostOrder\x12\x14.pb.PostOrderRequest\x1a\x15.pb.PostOrderResponse\"\x15\x82\xd3\xe4\x93\x02\x0f:\x01*\"\n" +
+	"/v1/orders\x12|\n" +
+	"\x12GetOrderForAccount\x12\x1d.pb.GetOrderForAccountRequest\x1a\x1e.pb.GetOrderForAccountResponse\"'\x82\xd3\xe4\x93\x02!\x12\x1f/v1/accounts/{accountId}/orders\x12A\n" +
+	"\fSearchOrders\x12\x17.pb.SearchOrdersRequest\x1a\x18.pb.SearchOrdersResponse\x12;\n" +
+	"\n" +
+	"GetInvoice\x12\x15.pb.GetInvoiceRequest\x1a\x16.pb.GetInvoiceResponse\x12G\n" +
+	"\x0eCreateShipment\x12\x19.pb.CreateShipmentRequest\x1a\x1a.pb.CreateShipmentResponse\x12M\n" +
+	"\x10AddShipmentEvent\x12\x1b.pb.AddShipmentEventRequest\x1a\x1c.pb.AddShipmentEventResponse\x12l\n" +
+	"\rListShipments\x12\x18.pb.ListShipmentsRequest\x1a\x19.pb.ListShipmentsResponse\"&\x82\xd3\xe4\x93\x02 \x12\x1e/v1/orders/{orderId}/shipments\x12m\n" +
+	"\rRequestReturn\x12\x18.pb.RequestReturnRequest\x1a\x19.pb.RequestReturnResponse\"'\x82\xd3\xe4\x93\x02!:\x01*\"\x1c/v1/orders/{orderId}/returns\x12D\n" +
+	"\rApproveReturn\x12\x18.pb.ApproveReturnRequest\x1a\x19.pb.ApproveReturnResponse\x12A\n" +
+	"\fRejectReturn\x12\x17.pb.RejectReturnRequest\x1a\x18.pb.RejectReturnResponse\x12D\n" +
+	"\rReceiveReturn\x12\x18.pb.ReceiveReturnRequest\x1a\x19.pb.ReceiveReturnResponse\x12d\n" +
+	"\vListReturns\x12\x16.pb.ListReturnsRequest\x1a\x17.pb.ListReturnsResponse\"$\x82\xd3\xe4\x93\x02\x1e\x12\x1c/v1/orders/{orderId}/returns\x12d\n" +
+	"\vListRefunds\x12\x16.pb.ListRefundsRequest\x1a\x17.pb.ListRefundsResponse\"$\x82\xd3\xe4\x93\x02\x1e\x12\x1c/v1/orders/{orderId}/refunds\x12S\n" +
+	"\x12CreateSubscription\x12\x1d.pb.CreateSubscriptionRequest\x1a\x1e.pb.CreateSubscriptionResponse\x12\x80\x01\n" +
+	"\x11ListSubscriptions\x12\x1c.pb.ListSubscriptionsRequest\x1a\x1d.pb.ListSubscriptionsResponse\".\x82\xd3\xe4\x93\x02(\x12&/v1/accounts/{accountId}/subscriptions\x12P\n" +
+	"\x11PauseSubscription\x12\x1c.pb.PauseSubscriptionRequest\x1a\x1d.pb.PauseSubscriptionResponse\x12S\n" +
+	"\x12ResumeSubscription\x12\x1d.pb.ResumeSubscriptionRequest\x1a\x1e.pb.ResumeSubscriptionResponse\x12S\n" +
+	"\x12CancelSubscription\x12\x1d.pb.CancelSubscriptionRequest\x1a\x1e.pb.CancelSubscriptionResponseB\x03Z\x01.b\x06proto3"
 
 var (
 	file_order_proto_rawDescOnce sync.Once
@@ -548,33 +3501,160 @@ func file_order_proto_rawDescGZIP() []byte {
 	return file_order_proto_rawDescData
 }
 
-var file_order_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_order_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_order_proto_msgTypes = make([]protoimpl.MessageInfo, 52)
 var file_order_proto_goTypes = []any{
-	(*Order)(nil),                         // 0: pb.Order
-	(*PostOrderRequest)(nil),              // 1: pb.PostOrderRequest
-	(*PostOrderResponse)(nil),             // 2: pb.PostOrderResponse
-	(*GetOrderRequest)(nil),               // 3: pb.GetOrderRequest
-	(*GetOrderResopnse)(nil),              // 4: pb.GetOrderResopnse
-	(*GetOrderForAccountRequest)(nil),     // 5: pb.GetOrderForAccountRequest
-	(*GetOrderForAccountResponse)(nil),    // 6: pb.GetOrderForAccountResponse
-	(*Order_OrderProduct)(nil),            // 7: pb.Order.OrderProduct
-	(*PostOrderRequest_OrderProduct)(nil), // 8: pb.PostOrderRequest.OrderProduct
+	(SortDirection)(0),                    // 0: pb.SortDirection
+	(*Address)(nil),                       // 1: pb.Address
+	(*Order)(nil),                         // 2: pb.Order
+	(*PostOrderRequest)(nil),              // 3: pb.PostOrderRequest
+	(*PostOrderResponse)(nil),             // 4: pb.PostOrderResponse
+	(*GetOrderRequest)(nil),               // 5: pb.GetOrderRequest
+	(*GetOrderResopnse)(nil),              // 6: pb.GetOrderResopnse
+	(*GetOrderForAccountRequest)(nil),     // 7: pb.GetOrderForAccountRequest
+	(*GetOrderForAccountResponse)(nil),    // 8: pb.GetOrderForAccountResponse
+	(*SearchOrdersRequest)(nil),           // 9: pb.SearchOrdersRequest
+	(*SearchOrdersResponse)(nil),          // 10: pb.SearchOrdersResponse
+	(*Invoice)(nil),                       // 11: pb.Invoice
+	(*GetInvoiceRequest)(nil),             // 12: pb.GetInvoiceRequest
+	(*GetInvoiceResponse)(nil),            // 13: pb.GetInvoiceResponse
+	(*Shipment)(nil),                      // 14: pb.Shipment
+	(*CreateShipmentRequest)(nil),         // 15: pb.CreateShipmentRequest
+	(*CreateShipmentResponse)(nil),        // 16: pb.CreateShipmentResponse
+	(*AddShipmentEventRequest)(nil),       // 17: pb.AddShipmentEventRequest
+	(*AddShipmentEventResponse)(nil),      // 18: pb.AddShipmentEventResponse
+	(*ListShipmentsRequest)(nil),          // 19: pb.ListShipmentsRequest
+	(*ListShipmentsResponse)(nil),         // 20: pb.ListShipmentsResponse
+	(*Return)(nil),                        // 21: pb.Return
+	(*Refund)(nil),                        // 22: pb.Refund
+	(*RequestReturnRequest)(nil),          // 23: pb.RequestReturnRequest
+	(*RequestReturnResponse)(nil),         // 24: pb.RequestReturnResponse
+	(*ApproveReturnRequest)(nil),          // 25: pb.ApproveReturnRequest
+	(*ApproveReturnResponse)(nil),         // 26: pb.ApproveReturnResponse
+	(*RejectReturnRequest)(nil),           // 27: pb.RejectReturnRequest
+	(*RejectReturnResponse)(nil),          // 28: pb.RejectReturnResponse
+	(*ReceiveReturnRequest)(nil),          // 29: pb.ReceiveReturnRequest
+	(*ReceiveReturnResponse)(nil),         // 30: pb.ReceiveReturnResponse
+	(*ListReturnsRequest)(nil),            // 31: pb.ListReturnsRequest
+	(*ListReturnsResponse)(nil),           // 32: pb.ListReturnsResponse
+	(*ListRefundsRequest)(nil),            // 33: pb.ListRefundsRequest
+	(*ListRefundsResponse)(nil),           // 34: pb.ListRefundsResponse
+	(*Subscription)(nil),                  // 35: pb.Subscription
+	(*CreateSubscriptionRequest)(nil),     // 36: pb.CreateSubscriptionRequest
+	(*CreateSubscriptionResponse)(nil),    // 37: pb.CreateSubscriptionResponse
+	(*ListSubscriptionsRequest)(nil),      // 38: pb.ListSubscriptionsRequest
+	(*ListSubscriptionsResponse)(nil),     // 39: pb.ListSubscriptionsResponse
+	(*PauseSubscriptionRequest)(nil),      // 40: pb.PauseSubscriptionRequest
+	(*PauseSubscriptionResponse)(nil),     // 41: pb.PauseSubscriptionResponse
+	(*ResumeSubscriptionRequest)(nil),     // 42: pb.ResumeSubscriptionRequest
+	(*ResumeSubscriptionResponse)(nil),    // 43: pb.ResumeSubscriptionResponse
+	(*CancelSubscriptionRequest)(nil),     // 44: pb.CancelSubscriptionRequest
+	(*CancelSubscriptionResponse)(nil),    // 45: pb.CancelSubscriptionResponse
+	(*Order_OrderProduct)(nil),            // 46: pb.Order.OrderProduct
+	nil,                                   // 47: pb.Order.OrderProduct.OptionsEntry
+	(*PostOrderRequest_OrderProduct)(nil), // 48: pb.PostOrderRequest.OrderProduct
+	(*Shipment_Line)(nil),                 // 49: pb.Shipment.Line
+	(*Shipment_Event)(nil),                // 50: pb.Shipment.Event
+	(*Return_Line)(nil),                   // 51: pb.Return.Line
+	(*Subscription_Line)(nil),             // 52: pb.Subscription.Line
+	(*timestamppb.Timestamp)(nil),         // 53: google.protobuf.Timestamp
 }
 var file_order_proto_depIdxs = []int32{
-	7, // 0: pb.Order.products:type_name -> pb.Order.OrderProduct
-	8, // 1: pb.PostOrderRequest.products:type_name -> pb.PostOrderRequest.OrderProduct
-	0, // 2: pb.PostOrderResponse.order:type_name -> pb.Order
-	0, // 3: pb.GetOrderResopnse.order:type_name -> pb.Order
-	0, // 4: pb.GetOrderForAccountResponse.orders:type_name -> pb.Order
-	1, // 5: pb.OrderService.PostOrder:input_type -> pb.PostOrderRequest
-	5, // 6: pb.OrderService.GetOrderForAccount:input_type -> pb.GetOrderForAccountRequest
-	2, // 7: pb.OrderService.PostOrder:output_type -> pb.PostOrderResponse
-	6, // 8: pb.OrderService.GetOrderForAccount:output_type -> pb.GetOrderForAccountResponse
-	7, // [7:9] is the sub-list for method output_type
-	5, // [5:7] is the sub-list for method input_type
-	5, // [5:5] is the sub-list for extension type_name
-	5, // [5:5] is the sub-list for extension extendee
-	0, // [0:5] is the sub-list for field type_name
+	46, // 0: pb.Order.products:type_name -> pb.Order.OrderProduct
+	53, // 1: pb.Order.placedAt:type_name -> google.protobuf.Timestamp
+	1,  // 2: pb.Order.shippingAddress:type_name -> pb.Address
+	48, // 3: pb.PostOrderRequest.products:type_name -> pb.PostOrderRequest.OrderProduct
+	1,  // 4: pb.PostOrderRequest.shippingAddress:type_name -> pb.Address
+	2,  // 5: pb.PostOrderResponse.order:type_name -> pb.Order
+	2,  // 6: pb.GetOrderResopnse.order:type_name -> pb.Order
+	53, // 7: pb.GetOrderForAccountRequest.placedFrom:type_name -> google.protobuf.Timestamp
+	53, // 8: pb.GetOrderForAccountRequest.placedTo:type_name -> google.protobuf.Timestamp
+	0,  // 9: pb.GetOrderForAccountRequest.sort:type_name -> pb.SortDirection
+	2,  // 10: pb.GetOrderForAccountResponse.orders:type_name -> pb.Order
+	53, // 11: pb.SearchOrdersRequest.placedFrom:type_name -> google.protobuf.Timestamp
+	53, // 12: pb.SearchOrdersRequest.placedTo:type_name -> google.protobuf.Timestamp
+	0,  // 13: pb.SearchOrdersRequest.sort:type_name -> pb.SortDirection
+	2,  // 14: pb.SearchOrdersResponse.orders:type_name -> pb.Order
+	53, // 15: pb.Invoice.issuedAt:type_name -> google.protobuf.Timestamp
+	11, // 16: pb.GetInvoiceResponse.invoice:type_name -> pb.Invoice
+	49, // 17: pb.Shipment.lines:type_name -> pb.Shipment.Line
+	50, // 18: pb.Shipment.events:type_name -> pb.Shipment.Event
+	53, // 19: pb.Shipment.createdAt:type_name -> google.protobuf.Timestamp
+	53, // 20: pb.Shipment.updatedAt:type_name -> google.protobuf.Timestamp
+	49, // 21: pb.CreateShipmentRequest.lines:type_name -> pb.Shipment.Line
+	14, // 22: pb.CreateShipmentResponse.shipment:type_name -> pb.Shipment
+	53, // 23: pb.AddShipmentEventRequest.occurredAt:type_name -> google.protobuf.Timestamp
+	14, // 24: pb.AddShipmentEventResponse.shipment:type_name -> pb.Shipment
+	14, // 25: pb.ListShipmentsResponse.shipments:type_name -> pb.Shipment
+	51, // 26: pb.Return.lines:type_name -> pb.Return.Line
+	53, // 27: pb.Return.createdAt:type_name -> google.protobuf.Timestamp
+	53, // 28: pb.Return.updatedAt:type_name -> google.protobuf.Timestamp
+	53, // 29: pb.Refund.createdAt:type_name -> google.protobuf.Timestamp
+	51, // 30: pb.RequestReturnRequest.lines:type_name -> pb.Return.Line
+	21, // 31: pb.RequestReturnResponse.return:type_name -> pb.Return
+	21, // 32: pb.ApproveReturnResponse.return:type_name -> pb.Return
+	21, // 33: pb.RejectReturnResponse.return:type_name -> pb.Return
+	21, // 34: pb.ReceiveReturnResponse.return:type_name -> pb.Return
+	21, // 35: pb.ListReturnsResponse.returns:type_name -> pb.Return
+	22, // 36: pb.ListRefundsResponse.refunds:type_name -> pb.Refund
+	52, // 37: pb.Subscription.lines:type_name -> pb.Subscription.Line
+	1,  // 38: pb.Subscription.shippingAddress:type_name -> pb.Address
+	53, // 39: pb.Subscription.startsAt:type_name -> google.protobuf.Timestamp
+	53, // 40: pb.Subscription.nextRunAt:type_name -> google.protobuf.Timestamp
+	53, // 41: pb.Subscription.retryAt:type_name -> google.protobuf.Timestamp
+	53, // 42: pb.Subscription.createdAt:type_name -> google.protobuf.Timestamp
+	53, // 43: pb.Subscription.updatedAt:type_name -> google.protobuf.Timestamp
+	52, // 44: pb.CreateSubscriptionRequest.lines:type_name -> pb.Subscription.Line
+	1,  // 45: pb.CreateSubscriptionRequest.shippingAddress:type_name -> pb.Address
+	53, // 46: pb.CreateSubscriptionRequest.startsAt:type_name -> google.protobuf.Timestamp
+	35, // 47: pb.CreateSubscriptionResponse.subscription:type_name -> pb.Subscription
+	35, // 48: pb.ListSubscriptionsResponse.subscriptions:type_name -> pb.Subscription
+	35, // 49: pb.PauseSubscriptionResponse.subscription:type_name -> pb.Subscription
+	35, // 50: pb.ResumeSubscriptionResponse.subscription:type_name -> pb.Subscription
+	35, // 51: pb.CancelSubscriptionResponse.subscription:type_name -> pb.Subscription
+	47, // 52: pb.Order.OrderProduct.options:type_name -> pb.Order.OrderProduct.OptionsEntry
+	53, // 53: pb.Shipment.Event.occurredAt:type_name -> google.protobuf.Timestamp
+	3,  // 54: pb.OrderService.PostOrder:input_type -> pb.PostOrderRequest
+	7,  // 55: pb.OrderService.GetOrderForAccount:input_type -> pb.GetOrderForAccountRequest
+	9,  // 56: pb.OrderService.SearchOrders:input_type -> pb.SearchOrdersRequest
+	12, // 57: pb.OrderService.GetInvoice:input_type -> pb.GetInvoiceRequest
+	15, // 58: pb.OrderService.CreateShipment:input_type -> pb.CreateShipmentRequest
+	17, // 59: pb.OrderService.AddShipmentEvent:input_type -> pb.AddShipmentEventRequest
+	19, // 60: pb.OrderService.ListShipments:input_type -> pb.ListShipmentsRequest
+	23, // 61: pb.OrderService.RequestReturn:input_type -> pb.RequestReturnRequest
+	25, // 62: pb.OrderService.ApproveReturn:input_type -> pb.ApproveReturnRequest
+	27, // 63: pb.OrderService.RejectReturn:input_type -> pb.RejectReturnRequest
+	29, // 64: pb.OrderService.ReceiveReturn:input_type -> pb.ReceiveReturnRequest
+	31, // 65: pb.OrderService.ListReturns:input_type -> pb.ListReturnsRequest
+	33, // 66: pb.OrderService.ListRefunds:input_type -> pb.ListRefundsRequest
+	36, // 67: pb.OrderService.CreateSubscription:input_type -> pb.CreateSubscriptionRequest
+	38, // 68: pb.OrderService.ListSubscriptions:input_type -> pb.ListSubscriptionsRequest
+	40, // 69: pb.OrderService.PauseSubscription:input_type -> pb.PauseSubscriptionRequest
+	42, // 70: pb.OrderService.ResumeSubscription:input_type -> pb.ResumeSubscriptionRequest
+	44, // 71: pb.OrderService.CancelSubscription:input_type -> pb.CancelSubscriptionRequest
+	4,  // 72: pb.OrderService.PostOrder:output_type -> pb.PostOrderResponse
+	8,  // 73: pb.OrderService.GetOrderForAccount:output_type -> pb.GetOrderForAccountResponse
+	10, // 74: pb.OrderService.SearchOrders:output_type -> pb.SearchOrdersResponse
+	13, // 75: pb.OrderService.GetInvoice:output_type -> pb.GetInvoiceResponse
+	16, // 76: pb.OrderService.CreateShipment:output_type -> pb.CreateShipmentResponse
+	18, // 77: pb.OrderService.AddShipmentEvent:output_type -> pb.AddShipmentEventResponse
+	20, // 78: pb.OrderService.ListShipments:output_type -> pb.ListShipmentsResponse
+	24, // 79: pb.OrderService.RequestReturn:output_type -> pb.RequestReturnResponse
+	26, // 80: pb.OrderService.ApproveReturn:output_type -> pb.ApproveReturnResponse
+	28, // 81: pb.OrderService.RejectReturn:output_type -> pb.RejectReturnResponse
+	30, // 82: pb.OrderService.ReceiveReturn:output_type -> pb.ReceiveReturnResponse
+	32, // 83: pb.OrderService.ListReturns:output_type -> pb.ListReturnsResponse
+	34, // 84: pb.OrderService.ListRefunds:output_type -> pb.ListRefundsResponse
+	37, // 85: pb.OrderService.CreateSubscription:output_type -> pb.CreateSubscriptionResponse
+	39, // 86: pb.OrderService.ListSubscriptions:output_type -> pb.ListSubscriptionsResponse
+	41, // 87: pb.OrderService.PauseSubscription:output_type -> pb.PauseSubscriptionResponse
+	43, // 88: pb.OrderService.ResumeSubscription:output_type -> pb.ResumeSubscriptionResponse
+	45, // 89: pb.OrderService.CancelSubscription:output_type -> pb.CancelSubscriptionResponse
+	72, // [72:90] is the sub-list for method output_type
+	54, // [54:72] is the sub-list for method input_type
+	54, // [54:54] is the sub-list for extension type_name
+	54, // [54:54] is the sub-list for extension extendee
+	0,  // [0:54] is the sub-list for field type_name
 }
 
 func init() { file_order_proto_init() }
@@ -587,13 +3667,14 @@ func file_order_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_order_proto_rawDesc), len(file_order_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   9,
+			NumEnums:      1,
+			NumMessages:   52,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_order_proto_goTypes,
 		DependencyIndexes: file_order_proto_depIdxs,
+		EnumInfos:         file_order_proto_enumTypes,
 		MessageInfos:      file_order_proto_msgTypes,
 	}.Build()
 	File_order_proto = out.File
@@ -3,14 +3,68 @@ package order
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/lib/pq"
+	"github.com/sunil8777/E-commerce-microservices/events"
+	"github.com/sunil8777/E-commerce-microservices/metrics"
+	"github.com/sunil8777/E-commerce-microservices/migrate"
+	"github.com/sunil8777/E-commerce-microservices/tracing"
 )
 
+// uniqueViolation is the Postgres error code for a UNIQUE constraint failure.
+const uniqueViolation = "23505"
+
 type Repository interface {
 	Close()
-	PutOrder(ctx context.Context, o Order) error
-	GetOrdersForAccount(ctx context.Context, accountID string) ([]Order, error)
+	Ping(ctx context.Context) error
+	// CreateSaga fails with ErrOrderExists if a saga for the order was
+	// created before.
+	CreateSaga(ctx context.Context, sg Saga) error
+	GetSaga(ctx context.Context, orderID string) (*Saga, error)
+	UpdateSaga(ctx context.Context, sg Saga) error
+	// CompleteSaga stores the order of sg, records sg as completed, inserts
+	// e into the outbox and issues the order's invoice, rendered with
+	// render, in one transaction. It returns the invoice, or nil when sg
+	// was completed before.
+	CompleteSaga(ctx context.Context, sg Saga, e events.Event, render func(number uint64) (html []byte, pdf []byte, err error)) (*Invoice, error)
+	ClaimStaleSagas(ctx context.Context, before time.Time, now time.Time) ([]Saga, error)
+	// SearchOrders returns up to limit orders that match q, starting after
+	// the order the after cursor points at, each with the cursor pointing
+	// just past it.
+	SearchOrders(ctx context.Context, q OrderQuery, after string, limit uint64) ([]Order, []string, error)
+	GetOrder(ctx context.Context, id string) (*Order, error)
+	GetInvoice(ctx context.Context, orderID string) (*Invoice, error)
+	ListShipments(ctx context.Context, orderID string) ([]Shipment, error)
+	GetShipment(ctx context.Context, id string) (*Shipment, error)
+	// UpdateShipments passes the order and its shipments to update and
+	// stores the ShipmentUpdate it returns, in one transaction that keeps
+	// other updates of the order's shipments waiting.
+	UpdateShipments(ctx context.Context, orderID string, update func(o Order, shipments []Shipment) (*ShipmentUpdate, error)) error
+	// CreateReturn passes the order, its shipments and its returns to
+	// create and stores the return it returns, in one transaction that
+	// keeps other returns and shipments of the order waiting.
+	CreateReturn(ctx context.Context, orderID string, create func(o Order, shipments []Shipment, returns []Return) (*Return, error)) error
+	GetReturn(ctx context.Context, id string) (*Return, error)
+	ListReturns(ctx context.Context, orderID string) ([]Return, error)
+	// UpdateReturn stores the status, note and update time of rt if it is
+	// still in status from, with refund when it is not nil, and fails with
+	// ErrReturnStatus otherwise.
+	UpdateReturn(ctx context.Context, rt Return, from string, refund *Refund) error
+	ListRefunds(ctx context.Context, orderID string) ([]Refund, error)
+	CreateSubscription(ctx context.Context, sub Subscription) error
+	ListSubscriptions(ctx context.Context, accountID string) ([]Subscription, error)
+	// UpdateSubscription passes the subscription to update and stores what
+	// update leaves in it, in one transaction that keeps other updates of
+	// the subscription waiting. Its lines are not stored again.
+	UpdateSubscription(ctx context.Context, id string, update func(sub *Subscription) error) error
+	// ClaimDueSubscription moves the due time of one active subscription
+	// due at now to until and returns it, or nil when none is due.
+	ClaimDueSubscription(ctx context.Context, now time.Time, until time.Time) (*Subscription, error)
 }
 
 type postgresRepository struct {
@@ -28,6 +82,11 @@ func NewPostgresRepository(url string) (Repository, error) {
 		return nil, err
 	}
 
+	if err = migrate.New(db, Migrations).Check(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	return &postgresRepository{db}, nil
 }
 
@@ -35,11 +94,92 @@ func (r *postgresRepository) Close() {
 	r.db.Close()
 }
 
-func (r *postgresRepository) PutOrder(ctx context.Context, o Order) error {
-	tx, err := r.db.BeginTx(ctx, nil)
+func (r *postgresRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+func (r *postgresRepository) CreateSaga(ctx context.Context, sg Saga) (err error) {
+	ctx, span := tracing.Start(ctx, "order.repository.CreateSaga")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "order.CreateSaga")(&err)
+
+	data, err := json.Marshal(sg.Order)
 	if err != nil {
 		return err
 	}
+	_, err = r.db.ExecContext(
+		ctx,
+		`INSERT INTO order_sagas(id, order_data, step, status, payment_id, failure, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		sg.Order.ID,
+		string(data),
+		sg.Step,
+		sg.Status,
+		sg.PaymentID,
+		sg.Failure,
+		sg.Order.CreatedAt,
+		sg.UpdatedAt,
+	)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return ErrOrderExists.With("id", sg.Order.ID)
+	}
+	return err
+}
+
+func (r *postgresRepository) GetSaga(ctx context.Context, orderID string) (_ *Saga, err error) {
+	ctx, span := tracing.Start(ctx, "order.repository.GetSaga")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "order.GetSaga")(&err)
+
+	var sg Saga
+	var data []byte
+	err = r.db.QueryRowContext(
+		ctx,
+		"SELECT order_data, step, status, payment_id, failure, updated_at FROM order_sagas WHERE id = $1",
+		orderID,
+	).Scan(&data, &sg.Step, &sg.Status, &sg.PaymentID, &sg.Failure, &sg.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOrderNotFound.With("id", orderID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &sg.Order); err != nil {
+		return nil, err
+	}
+	return &sg, nil
+}
+
+func (r *postgresRepository) UpdateSaga(ctx context.Context, sg Saga) (err error) {
+	ctx, span := tracing.Start(ctx, "order.repository.UpdateSaga")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "order.UpdateSaga")(&err)
+
+	_, err = r.db.ExecContext(
+		ctx,
+		"UPDATE order_sagas SET step = $2, status = $3, payment_id = $4, failure = $5, updated_at = $6 WHERE id = $1",
+		sg.Order.ID,
+		sg.Step,
+		sg.Status,
+		sg.PaymentID,
+		sg.Failure,
+		sg.UpdatedAt,
+	)
+	return err
+}
+
+// CompleteSaga stores, announces and invoices an order exactly once, even
+// when the saga is resumed after the transaction committed. The invoice is
+// issued at the time sg was completed.
+func (r *postgresRepository) CompleteSaga(ctx context.Context, sg Saga, e events.Event, render func(number uint64) ([]byte, []byte, error)) (_ *Invoice, err error) {
+	ctx, span := tracing.Start(ctx, "order.repository.CompleteSaga")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "order.CompleteSaga")(&err)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
 	defer func() {
 		if err != nil {
 			tx.Rollback()
@@ -48,20 +188,128 @@ func (r *postgresRepository) PutOrder(ctx context.Context, o Order) error {
 		err = tx.Commit()
 	}()
 
+	var status string
+	err = tx.QueryRowContext(ctx, "SELECT status FROM order_sagas WHERE id = $1 FOR UPDATE", sg.Order.ID).Scan(&status)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case SagaCompleted:
+		return nil, nil
+	case SagaRunning:
+	default:
+		return nil, ErrPlacementFailed.With("status", status)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE order_sagas SET step = $2, status = $3, payment_id = $4, updated_at = $5 WHERE id = $1",
+		sg.Order.ID,
+		sg.Step,
+		sg.Status,
+		sg.PaymentID,
+		sg.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = putOrder(ctx, tx, sg.Order); err != nil {
+		return nil, err
+	}
+	if err = events.Insert(ctx, tx, e); err != nil {
+		return nil, err
+	}
+	return createInvoice(ctx, tx, sg.Order.ID, sg.UpdatedAt, render)
+}
+
+// ClaimStaleSagas moves the updated_at of unfinished sagas last updated
+// before before to now and returns them. SKIP LOCKED keeps two replicas
+// from claiming the same saga.
+func (r *postgresRepository) ClaimStaleSagas(ctx context.Context, before time.Time, now time.Time) (_ []Saga, err error) {
+	ctx, span := tracing.Start(ctx, "order.repository.ClaimStaleSagas")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "order.ClaimStaleSagas")(&err)
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`UPDATE order_sagas SET updated_at = $2
+		WHERE id IN (
+			SELECT id FROM order_sagas
+			WHERE status IN ($3, $4) AND updated_at < $1
+			ORDER BY updated_at
+			LIMIT 100
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING order_data, step, status, payment_id, failure, updated_at`,
+		before,
+		now,
+		SagaRunning,
+		SagaCompensating,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sagas := []Saga{}
+	for rows.Next() {
+		var sg Saga
+		var data []byte
+		if err = rows.Scan(&data, &sg.Step, &sg.Status, &sg.PaymentID, &sg.Failure, &sg.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(data, &sg.Order); err != nil {
+			return nil, err
+		}
+		sagas = append(sagas, sg)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return sagas, nil
+}
+
+func putOrder(ctx context.Context, tx *sql.Tx, o Order) error {
+	address, err := json.Marshal(o.ShippingAddress)
+	if err != nil {
+		return err
+	}
 	_, err = tx.ExecContext(
 		ctx,
-		"INSERT INTO order(id, created_at, account_id, total_price) VALUES ($1, $2, $3, $4)",
+		"INSERT INTO orders(id, created_at, account_id, total_price, status, shipping_address) VALUES ($1, $2, $3, $4, $5, $6)",
 		o.ID,
 		o.CreatedAt,
 		o.AccountID,
 		o.TotalPrice,
+		o.Status,
+		string(address),
 	)
 	if err != nil {
 		return err
 	}
-	stmt, _ := tx.PrepareContext(ctx, pq.CopyIn("order_products", "order_id", "product_id", "quantity"))
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(
+		"order_products",
+		"order_id",
+		"product_id",
+		"variant_id",
+		"sku",
+		"variant_options",
+		"price",
+		"quantity",
+		"name",
+		"description",
+	))
+	if err != nil {
+		return err
+	}
 	for _, p := range o.Products {
-		_, err = stmt.ExecContext(ctx, o.ID, p.ID, p.Quantity)
+		var options []byte
+		options, err = json.Marshal(p.Options)
+		if err != nil {
+			return err
+		}
+		_, err = stmt.ExecContext(ctx, o.ID, p.ID, p.VariantID, p.SKU, string(options), p.Price, p.Quantity, p.Name, p.Description)
 		if err != nil {
 			return err
 		}
@@ -70,70 +318,959 @@ func (r *postgresRepository) PutOrder(ctx context.Context, o Order) error {
 	if err != nil {
 		return err
 	}
-	stmt.Close()
-	return err
+	return stmt.Close()
 }
 
-func (r *postgresRepository) GetOrdersForAccount(ctx context.Context, accountID string) ([]Order, error) {
+// SearchOrders pages over the orders table alone, so that the limit counts
+// orders rather than order lines, and joins the lines of the page
+// afterwards. Orders are sorted by placement time, with the id breaking
+// ties, which the (account_id, created_at, id) index serves for an account
+// and the indexes of migration 0007 serve across accounts.
+func (r *postgresRepository) SearchOrders(ctx context.Context, q OrderQuery, after string, limit uint64) (_ []Order, _ []string, err error) {
+	ctx, span := tracing.Start(ctx, "order.repository.SearchOrders")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "order.SearchOrders")(&err)
+
+	afterCreatedAt, afterID, err := DecodeOrderCursor(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conds := []string{}
+	args := []interface{}{}
+	where := func(cond string, values ...interface{}) {
+		n := []interface{}{}
+		for _, v := range values {
+			args = append(args, v)
+			n = append(n, len(args))
+		}
+		conds = append(conds, fmt.Sprintf(cond, n...))
+	}
+	if q.AccountID != "" {
+		where("account_id = $%d", q.AccountID)
+	}
+	if q.ProductID != "" {
+		where("EXISTS (SELECT 1 FROM order_products p WHERE p.order_id = orders.id AND p.product_id = $%d)", q.ProductID)
+	}
+	if !q.PlacedFrom.IsZero() {
+		where("created_at >= $%d", q.PlacedFrom)
+	}
+	if !q.PlacedTo.IsZero() {
+		where("created_at < $%d", q.PlacedTo)
+	}
+	if q.Status != "" {
+		where("status = $%d", q.Status)
+	}
+	if q.MinTotal > 0 {
+		where("total_price >= $%d", q.MinTotal)
+	}
+	if q.MaxTotal > 0 {
+		where("total_price <= $%d", q.MaxTotal)
+	}
+	direction, next := "ASC", ">"
+	if q.NewestFirst {
+		direction, next = "DESC", "<"
+	}
+	if afterID != "" {
+		where("(created_at, id) "+next+" ($%d, $%d)", afterCreatedAt, afterID)
+	}
+	whereClause := ""
+	if len(conds) > 0 {
+		whereClause = "WHERE " + strings.Join(conds, " AND ")
+	}
+	args = append(args, limit)
+
 	rows, err := r.db.QueryContext(
 		ctx,
-		`SELECT o.id, o.created_at, o.account_id, o.total_price, op.product_id, op.quantity FROM orders
-		o JOIN order_products op ON(o.id = op.order_id)
-		WHERE o.account_id = $1,
-		ORDER BY o.id`,
-		accountID,
+		fmt.Sprintf(`WITH page AS (
+			SELECT id, created_at, account_id, total_price, status, shipping_address FROM orders
+			%s
+			ORDER BY created_at %s, id %s
+			LIMIT $%d
+		)
+		SELECT o.id, o.created_at, o.account_id, o.total_price, o.status, o.shipping_address,
+		op.product_id, op.variant_id, op.sku, op.variant_options, op.price, op.quantity, op.name, op.description
+		FROM page o JOIN order_products op ON (o.id = op.order_id)
+		ORDER BY o.created_at %s, o.id %s`,
+			whereClause, direction, direction, len(args), direction, direction,
+		),
+		args...,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	orders, err := scanOrders(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cursors := []string{}
+	for _, o := range orders {
+		cursors = append(cursors, EncodeOrderCursor(o))
+	}
+	return orders, cursors, nil
+}
+
+func (r *postgresRepository) GetOrder(ctx context.Context, id string) (_ *Order, err error) {
+	ctx, span := tracing.Start(ctx, "order.repository.GetOrder")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "order.GetOrder")(&err)
+
+	return getOrder(ctx, r.db, id)
+}
+
+func getOrder(ctx context.Context, db querier, id string) (*Order, error) {
+	rows, err := db.QueryContext(
+		ctx,
+		`SELECT o.id, o.created_at, o.account_id, o.total_price, o.status, o.shipping_address,
+		op.product_id, op.variant_id, op.sku, op.variant_options, op.price, op.quantity, op.name, op.description
+		FROM orders o JOIN order_products op ON (o.id = op.order_id)
+		WHERE o.id = $1`,
+		id,
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	orders, err := scanOrders(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return nil, ErrOrderNotFound.With("id", id)
+	}
+	return &orders[0], nil
+}
+
+// scanOrders reads rows of order columns joined with their lines, sorted so
+// that the lines of an order are adjacent.
+func scanOrders(rows *sql.Rows) ([]Order, error) {
 	orders := []Order{}
-	lastOrder := &Order{}
-	orderedProduct := &OrderedProduct{}
-	products := []OrderedProduct{}
 
 	for rows.Next() {
-		order := &Order{}
+		order := Order{}
+		product := OrderedProduct{}
+		var address, options []byte
 
-		if err = rows.Scan(
+		if err := rows.Scan(
 			&order.ID,
 			&order.CreatedAt,
 			&order.AccountID,
 			&order.TotalPrice,
-			&orderedProduct.Quantity,
+			&order.Status,
+			&address,
+			&product.ID,
+			&product.VariantID,
+			&product.SKU,
+			&options,
+			&product.Price,
+			&product.Quantity,
+			&product.Name,
+			&product.Description,
 		); err != nil {
 			return nil, err
 		}
-		if lastOrder.ID != "" && lastOrder.ID != order.ID {
-			newOrder := Order{
-				ID:         lastOrder.ID,
-				AccountID:  lastOrder.AccountID,
-				CreatedAt:  lastOrder.CreatedAt,
-				TotalPrice: lastOrder.TotalPrice,
-				Products:   lastOrder.Products,
+		if err := json.Unmarshal(address, &order.ShippingAddress); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(options, &product.Options); err != nil {
+			return nil, err
+		}
+
+		if n := len(orders); n == 0 || orders[n-1].ID != order.ID {
+			orders = append(orders, order)
+		}
+		last := &orders[len(orders)-1]
+		last.Products = append(last.Products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (r *postgresRepository) GetInvoice(ctx context.Context, orderID string) (_ *Invoice, err error) {
+	ctx, span := tracing.Start(ctx, "order.repository.GetInvoice")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "order.GetInvoice")(&err)
+
+	return getInvoice(ctx, r.db, orderID)
+}
+
+// createInvoice takes the next invoice number, renders the invoice of the
+// order with render and stores it. Issuers are serialized on the
+// invoice_counter row, so numbers are taken in order and, as they are taken
+// in the transaction that stores the invoice, without gaps.
+func createInvoice(ctx context.Context, tx *sql.Tx, orderID string, issuedAt time.Time, render func(number uint64) ([]byte, []byte, error)) (*Invoice, error) {
+	inv := &Invoice{OrderID: orderID, IssuedAt: issuedAt}
+	err := tx.QueryRowContext(ctx, "UPDATE invoice_counter SET last_number = last_number + 1 RETURNING last_number").Scan(&inv.Number)
+	if err != nil {
+		return nil, err
+	}
+	if inv.HTML, inv.PDF, err = render(inv.Number); err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO invoices(number, order_id, issued_at, html, pdf) VALUES ($1, $2, $3, $4, $5)",
+		inv.Number,
+		inv.OrderID,
+		inv.IssuedAt,
+		inv.HTML,
+		inv.PDF,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// querier is a *sql.DB or a *sql.Tx.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func getInvoice(ctx context.Context, db querier, orderID string) (*Invoice, error) {
+	inv := Invoice{OrderID: orderID}
+	err := db.QueryRowContext(
+		ctx,
+		"SELECT number, issued_at, html, pdf FROM invoices WHERE order_id = $1",
+		orderID,
+	).Scan(&inv.Number, &inv.IssuedAt, &inv.HTML, &inv.PDF)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvoiceNotFound.With("orderId", orderID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func (r *postgresRepository) ListShipments(ctx context.Context, orderID string) (_ []Shipment, err error) {
+	ctx, span := tracing.Start(ctx, "order.repository.ListShipments")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "order.ListShipments")(&err)
+
+	return listShipments(ctx, r.db, orderID)
+}
+
+func (r *postgresRepository) GetShipment(ctx context.Context, id string) (_ *Shipment, err error) {
+	ctx, span := tracing.Start(ctx, "order.repository.GetShipment")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "order.GetShipment")(&err)
+
+	var orderID string
+	err = r.db.QueryRowContext(ctx, "SELECT order_id FROM shipments WHERE id = $1", id).Scan(&orderID)
+	if err == sql.ErrNoRows {
+		return nil, ErrShipmentNotFound.With("id", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	shipments, err := listShipments(ctx, r.db, orderID)
+	if err != nil {
+		return nil, err
+	}
+	for _, sh := range shipments {
+		if sh.ID == id {
+			return &sh, nil
+		}
+	}
+	return nil, ErrShipmentNotFound.With("id", id)
+}
+
+// UpdateShipments locks the order row, so that two updates cannot both ship
+// what is left of a line, and stores the update with the derived order
+// status and the outbox events.
+func (r *postgresRepository) UpdateShipments(ctx context.Context, orderID string, update func(o Order, shipments []Shipment) (*ShipmentUpdate, error)) (err error) {
+	ctx, span := tracing.Start(ctx, "order.repository.UpdateShipments")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "order.UpdateShipments")(&err)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	o, err := lockOrder(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+	shipments, err := listShipments(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	u, err := update(*o, shipments)
+	if err != nil {
+		return err
+	}
+	sh := u.Shipment
+	if u.Created {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO shipments(id, order_id, carrier, tracking_number, status, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			sh.ID,
+			sh.OrderID,
+			sh.Carrier,
+			sh.TrackingNumber,
+			sh.Status,
+			sh.CreatedAt,
+			sh.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		for _, l := range sh.Lines {
+			_, err = tx.ExecContext(
+				ctx,
+				"INSERT INTO shipment_lines(shipment_id, product_id, variant_id, quantity) VALUES ($1, $2, $3, $4)",
+				sh.ID,
+				l.ProductID,
+				l.VariantID,
+				l.Quantity,
+			)
+			if err != nil {
+				return err
 			}
-			orders = append(orders, newOrder)
-			products = []OrderedProduct{}
 		}
-		products = append(products, OrderedProduct{
-			ID:       orderedProduct.ID,
-			Quantity: orderedProduct.Quantity,
-		})
-		*lastOrder = *order
+	} else {
+		_, err = tx.ExecContext(ctx, "UPDATE shipments SET status = $2, updated_at = $3 WHERE id = $1", sh.ID, sh.Status, sh.UpdatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	e := sh.Events[len(sh.Events)-1]
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO shipment_events(shipment_id, seq, status, description, location, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		sh.ID,
+		len(sh.Events),
+		e.Status,
+		e.Description,
+		e.Location,
+		e.OccurredAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if u.OrderStatus != o.Status {
+		_, err = tx.ExecContext(ctx, "UPDATE orders SET status = $2 WHERE id = $1", orderID, u.OrderStatus)
+		if err != nil {
+			return err
+		}
+	}
+	for _, e := range u.Events {
+		if err = events.Insert(ctx, tx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockOrder reads the order in tx and locks its row until tx ends.
+func lockOrder(ctx context.Context, tx *sql.Tx, orderID string) (*Order, error) {
+	var locked string
+	err := tx.QueryRowContext(ctx, "SELECT id FROM orders WHERE id = $1 FOR UPDATE", orderID).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return nil, ErrOrderNotFound.With("id", orderID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return getOrder(ctx, tx, orderID)
+}
+
+// listShipments reads the shipments of an order with their lines and
+// events, in three queries.
+func listShipments(ctx context.Context, db querier, orderID string) ([]Shipment, error) {
+	rows, err := db.QueryContext(
+		ctx,
+		`SELECT id, order_id, carrier, tracking_number, status, created_at, updated_at
+		FROM shipments WHERE order_id = $1 ORDER BY created_at, id`,
+		orderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shipments := []Shipment{}
+	byID := map[string]int{}
+	for rows.Next() {
+		sh := Shipment{Lines: []ShipmentLine{}, Events: []ShipmentEvent{}}
+		if err := rows.Scan(&sh.ID, &sh.OrderID, &sh.Carrier, &sh.TrackingNumber, &sh.Status, &sh.CreatedAt, &sh.UpdatedAt); err != nil {
+			return nil, err
+		}
+		byID[sh.ID] = len(shipments)
+		shipments = append(shipments, sh)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(shipments) == 0 {
+		return shipments, nil
+	}
+
+	lines, err := db.QueryContext(
+		ctx,
+		`SELECT l.shipment_id, l.product_id, l.variant_id, l.quantity
+		FROM shipment_lines l JOIN shipments s ON (s.id = l.shipment_id)
+		WHERE s.order_id = $1 ORDER BY l.product_id, l.variant_id`,
+		orderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+	for lines.Next() {
+		var shipmentID string
+		var l ShipmentLine
+		if err := lines.Scan(&shipmentID, &l.ProductID, &l.VariantID, &l.Quantity); err != nil {
+			return nil, err
+		}
+		sh := &shipments[byID[shipmentID]]
+		sh.Lines = append(sh.Lines, l)
+	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+
+	history, err := db.QueryContext(
+		ctx,
+		`SELECT e.shipment_id, e.status, e.description, e.location, e.occurred_at
+		FROM shipment_events e JOIN shipments s ON (s.id = e.shipment_id)
+		WHERE s.order_id = $1 ORDER BY e.shipment_id, e.seq`,
+		orderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer history.Close()
+	for history.Next() {
+		var shipmentID string
+		var e ShipmentEvent
+		if err := history.Scan(&shipmentID, &e.Status, &e.Description, &e.Location, &e.OccurredAt); err != nil {
+			return nil, err
+		}
+		sh := &shipments[byID[shipmentID]]
+		sh.Events = append(sh.Events, e)
+	}
+	if err := history.Err(); err != nil {
+		return nil, err
+	}
+	return shipments, nil
+}
+
+// CreateReturn locks the order row, like UpdateShipments, so that two
+// returns cannot both take what is left to return of a line.
+func (r *postgresRepository) CreateReturn(ctx context.Context, orderID string, create func(o Order, shipments []Shipment, returns []Return) (*Return, error)) (err error) {
+	ctx, span := tracing.Start(ctx, "order.repository.CreateReturn")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "order.CreateReturn")(&err)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	o, err := lockOrder(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+	shipments, err := listShipments(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+	returns, err := listReturns(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	rt, err := create(*o, shipments, returns)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO returns(id, order_id, status, reason, note, refund_amount, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		rt.ID,
+		rt.OrderID,
+		rt.Status,
+		rt.Reason,
+		rt.Note,
+		rt.RefundAmount,
+		rt.CreatedAt,
+		rt.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	for _, l := range rt.Lines {
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO return_lines(return_id, product_id, variant_id, quantity) VALUES ($1, $2, $3, $4)",
+			rt.ID,
+			l.ProductID,
+			l.VariantID,
+			l.Quantity,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *postgresRepository) GetReturn(ctx context.Context, id string) (_ *Return, err error) {
+	ctx, span := tracing.Start(ctx, "order.repository.GetReturn")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "order.GetReturn")(&err)
+
+	var orderID string
+	err = r.db.QueryRowContext(ctx, "SELECT order_id FROM returns WHERE id = $1", id).Scan(&orderID)
+	if err == sql.ErrNoRows {
+		return nil, ErrReturnNotFound.With("id", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	returns, err := listReturns(ctx, r.db, orderID)
+	if err != nil {
+		return nil, err
+	}
+	for _, rt := range returns {
+		if rt.ID == id {
+			return &rt, nil
+		}
 	}
-	if lastOrder.ID != "" {
-		newOrder := Order{
-			ID:         lastOrder.ID,
-			AccountID:  lastOrder.AccountID,
-			CreatedAt:  lastOrder.CreatedAt,
-			TotalPrice: lastOrder.TotalPrice,
-			Products:   lastOrder.Products,
+	return nil, ErrReturnNotFound.With("id", id)
+}
+
+func (r *postgresRepository) ListReturns(ctx context.Context, orderID string) (_ []Return, err error) {
+	ctx, span := tracing.Start(ctx, "order.repository.ListReturns")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "order.ListReturns")(&err)
+
+	return listReturns(ctx, r.db, orderID)
+}
+
+// UpdateReturn moves the return only from status from, so that of two
+// staff acting on the same return at once, one fails instead of both
+// succeeding.
+func (r *postgresRepository) UpdateReturn(ctx context.Context, rt Return, from string, refund *Refund) (err error) {
+	ctx, span := tracing.Start(ctx, "order.repository.UpdateReturn")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "order.UpdateReturn")(&err)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
 		}
-		orders = append(orders, newOrder)
+		err = tx.Commit()
+	}()
+
+	res, err := tx.ExecContext(
+		ctx,
+		"UPDATE returns SET status = $3, note = $4, updated_at = $5 WHERE id = $1 AND status = $2",
+		rt.ID,
+		from,
+		rt.Status,
+		rt.Note,
+		rt.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrReturnStatus.With("id", rt.ID)
+	}
+
+	if refund != nil {
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO refunds(id, order_id, return_id, amount, created_at) VALUES ($1, $2, $3, $4, $5)",
+			refund.ID,
+			refund.OrderID,
+			refund.ReturnID,
+			refund.Amount,
+			refund.CreatedAt,
+		)
+	}
+	return err
+}
+
+func (r *postgresRepository) ListRefunds(ctx context.Context, orderID string) (_ []Refund, err error) {
+	ctx, span := tracing.Start(ctx, "order.repository.ListRefunds")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "order.ListRefunds")(&err)
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT id, order_id, return_id, amount, created_at FROM refunds WHERE order_id = $1 ORDER BY created_at, id",
+		orderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds := []Refund{}
+	for rows.Next() {
+		var rf Refund
+		if err = rows.Scan(&rf.ID, &rf.OrderID, &rf.ReturnID, &rf.Amount, &rf.CreatedAt); err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, rf)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
-	return orders, nil
+	return refunds, nil
+}
+
+// listReturns reads the returns of an order with their lines, in two
+// queries.
+func listReturns(ctx context.Context, db querier, orderID string) ([]Return, error) {
+	rows, err := db.QueryContext(
+		ctx,
+		`SELECT id, order_id, status, reason, note, refund_amount, created_at, updated_at
+		FROM returns WHERE order_id = $1 ORDER BY created_at, id`,
+		orderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	returns := []Return{}
+	byID := map[string]int{}
+	for rows.Next() {
+		rt := Return{Lines: []ReturnLine{}}
+		if err := rows.Scan(&rt.ID, &rt.OrderID, &rt.Status, &rt.Reason, &rt.Note, &rt.RefundAmount, &rt.CreatedAt, &rt.UpdatedAt); err != nil {
+			return nil, err
+		}
+		byID[rt.ID] = len(returns)
+		returns = append(returns, rt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(returns) == 0 {
+		return returns, nil
+	}
+
+	lines, err := db.QueryContext(
+		ctx,
+		`SELECT l.return_id, l.product_id, l.variant_id, l.quantity
+		FROM return_lines l JOIN returns r ON (r.id = l.return_id)
+		WHERE r.order_id = $1 ORDER BY l.product_id, l.variant_id`,
+		orderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+	for lines.Next() {
+		var returnID string
+		var l ReturnLine
+		if err := lines.Scan(&returnID, &l.ProductID, &l.VariantID, &l.Quantity); err != nil {
+			return nil, err
+		}
+		rt := &returns[byID[returnID]]
+		rt.Lines = append(rt.Lines, l)
+	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+	return returns, nil
+}
+
+func (r *postgresRepository) CreateSubscription(ctx context.Context, sub Subscription) (err error) {
+	ctx, span := tracing.Start(ctx, "order.repository.CreateSubscription")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "order.CreateSubscription")(&err)
+
+	address, err := json.Marshal(sub.ShippingAddress)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO subscriptions(id, account_id, status, shipping_address, interval_unit, interval_count,
+			starts_at, next_run_at, due_at, attempt, failures, last_failure, last_order_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+		sub.ID,
+		sub.AccountID,
+		sub.Status,
+		string(address),
+		sub.Interval.Unit,
+		sub.Interval.Count,
+		sub.StartsAt,
+		sub.NextRunAt,
+		sub.DueAt,
+		sub.Attempt,
+		sub.Failures,
+		sub.LastFailure,
+		sub.LastOrderID,
+		sub.CreatedAt,
+		sub.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	for _, l := range sub.Lines {
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO subscription_lines(subscription_id, product_id, variant_id, quantity) VALUES ($1, $2, $3, $4)",
+			sub.ID,
+			l.ProductID,
+			l.VariantID,
+			l.Quantity,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *postgresRepository) ListSubscriptions(ctx context.Context, accountID string) (_ []Subscription, err error) {
+	ctx, span := tracing.Start(ctx, "order.repository.ListSubscriptions")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "order.ListSubscriptions")(&err)
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+subscriptionColumns+" FROM subscriptions WHERE account_id = $1 ORDER BY created_at, id",
+		accountID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	subs, err := scanSubscriptions(rows)
+	if err != nil {
+		return nil, err
+	}
+	return subs, addSubscriptionLines(ctx, r.db, subs)
+}
+
+// UpdateSubscription locks the subscription row, so that a scheduler
+// recording a run and a customer pausing the subscription at the same time
+// do not overwrite one another.
+func (r *postgresRepository) UpdateSubscription(ctx context.Context, id string, update func(sub *Subscription) error) (err error) {
+	ctx, span := tracing.Start(ctx, "order.repository.UpdateSubscription")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "order.UpdateSubscription")(&err)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+subscriptionColumns+" FROM subscriptions WHERE id = $1 FOR UPDATE", id)
+	if err != nil {
+		return err
+	}
+	subs, err := scanSubscriptions(rows)
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return ErrSubscriptionNotFound.With("id", id)
+	}
+	if err = addSubscriptionLines(ctx, tx, subs); err != nil {
+		return err
+	}
+
+	sub := &subs[0]
+	if err = update(sub); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE subscriptions SET status = $2, next_run_at = $3, due_at = $4, attempt = $5, failures = $6,
+			last_failure = $7, last_order_id = $8, updated_at = $9
+		WHERE id = $1`,
+		sub.ID,
+		sub.Status,
+		sub.NextRunAt,
+		sub.DueAt,
+		sub.Attempt,
+		sub.Failures,
+		sub.LastFailure,
+		sub.LastOrderID,
+		sub.UpdatedAt,
+	)
+	return err
+}
+
+// ClaimDueSubscription takes one subscription at a time, so that its lease
+// only has to cover placing one order, and SKIP LOCKED keeps two replicas
+// from claiming the same one.
+func (r *postgresRepository) ClaimDueSubscription(ctx context.Context, now time.Time, until time.Time) (_ *Subscription, err error) {
+	ctx, span := tracing.Start(ctx, "order.repository.ClaimDueSubscription")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "order.ClaimDueSubscription")(&err)
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`UPDATE subscriptions SET due_at = $2
+		WHERE id IN (
+			SELECT id FROM subscriptions
+			WHERE status = $3 AND due_at <= $1
+			ORDER BY due_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+subscriptionColumns,
+		now,
+		until,
+		SubscriptionActive,
+	)
+	if err != nil {
+		return nil, err
+	}
+	subs, err := scanSubscriptions(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(subs) == 0 {
+		return nil, nil
+	}
+	if err = addSubscriptionLines(ctx, r.db, subs); err != nil {
+		return nil, err
+	}
+	return &subs[0], nil
+}
+
+const subscriptionColumns = `id, account_id, status, shipping_address, interval_unit, interval_count,
+	starts_at, next_run_at, due_at, attempt, failures, last_failure, last_order_id, created_at, updated_at`
+
+// scanSubscriptions reads rows of subscriptionColumns, without lines, and
+// closes rows.
+func scanSubscriptions(rows *sql.Rows) ([]Subscription, error) {
+	defer rows.Close()
+
+	subs := []Subscription{}
+	for rows.Next() {
+		sub := Subscription{Lines: []SubscriptionLine{}}
+		var address []byte
+		err := rows.Scan(
+			&sub.ID,
+			&sub.AccountID,
+			&sub.Status,
+			&address,
+			&sub.Interval.Unit,
+			&sub.Interval.Count,
+			&sub.StartsAt,
+			&sub.NextRunAt,
+			&sub.DueAt,
+			&sub.Attempt,
+			&sub.Failures,
+			&sub.LastFailure,
+			&sub.LastOrderID,
+			&sub.CreatedAt,
+			&sub.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(address, &sub.ShippingAddress); err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// addSubscriptionLines reads the lines of subs into them, in one query.
+func addSubscriptionLines(ctx context.Context, db querier, subs []Subscription) error {
+	if len(subs) == 0 {
+		return nil
+	}
+	ids := []string{}
+	byID := map[string]int{}
+	for i, sub := range subs {
+		ids = append(ids, sub.ID)
+		byID[sub.ID] = i
+	}
+
+	rows, err := db.QueryContext(
+		ctx,
+		`SELECT subscription_id, product_id, variant_id, quantity FROM subscription_lines
+		WHERE subscription_id = ANY($1) ORDER BY product_id, variant_id`,
+		pq.Array(ids),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var subscriptionID string
+		var l SubscriptionLine
+		if err := rows.Scan(&subscriptionID, &l.ProductID, &l.VariantID, &l.Quantity); err != nil {
+			return err
+		}
+		sub := &subs[byID[subscriptionID]]
+		sub.Lines = append(sub.Lines, l)
+	}
+	return rows.Err()
 }
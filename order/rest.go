@@ -0,0 +1,26 @@
+package order
+
+import (
+	"context"
+	"crypto/tls"
+	_ "embed"
+	"strconv"
+
+	pb "github.com/sunil8777/E-commerce-microservices/order/pb"
+	"github.com/sunil8777/E-commerce-microservices/rest"
+)
+
+//go:embed order.swagger.json
+var openAPI []byte
+
+// ListenREST serves the REST/JSON mapping of OrderService declared in
+// order.proto on port, and its OpenAPI document at /openapi.json. Requests
+// go to the gRPC server on grpcPort of this host.
+func ListenREST(ctx context.Context, grpcPort int, port int, tlsConfig *tls.Config) error {
+	gw := rest.Gateway{
+		Service:  pb.OrderService_ServiceDesc.ServiceName,
+		Register: pb.RegisterOrderServiceHandler,
+		OpenAPI:  openAPI,
+	}
+	return gw.ListenHTTP(ctx, "localhost:"+strconv.Itoa(grpcPort), port, tlsConfig)
+}
@@ -0,0 +1,235 @@
+package order
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/ksuid"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+)
+
+var (
+	ErrReturnNotFound         = errs.New(errs.NotFound, "RETURN_NOT_FOUND", "return not found")
+	ErrReturnLineNotOnOrder   = errs.New(errs.InvalidArgument, "RETURN_LINE_NOT_ON_ORDER", "return line is not on the order")
+	ErrReturnQuantityExceeded = errs.New(errs.FailedPrecondition, "RETURN_QUANTITY_EXCEEDED", "return quantity exceeds what shipped and is not already being returned")
+	ErrReturnStatus           = errs.New(errs.FailedPrecondition, "RETURN_STATUS_INVALID", "return is not in a status that allows this")
+)
+
+// Statuses of a return. A return is requested, then approved or rejected
+// by staff, and an approved return is received once its items arrive. It
+// is approving while its items are put back into stock, after which it
+// cannot be rejected any more.
+const (
+	ReturnRequested = "requested"
+	ReturnApproving = "approving"
+	ReturnApproved  = "approved"
+	ReturnRejected  = "rejected"
+	ReturnReceived  = "received"
+)
+
+// Return is a customer's request to send back some quantity of some lines
+// of an order. RefundAmount is what the lines were paid, and is refunded
+// when the return is approved. Note is left by staff when they approve or
+// reject it.
+type Return struct {
+	ID           string
+	OrderID      string
+	Status       string
+	Reason       string
+	Note         string
+	Lines        []ReturnLine
+	RefundAmount float64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type ReturnLine struct {
+	ProductID string
+	VariantID string
+	Quantity  uint64
+}
+
+// Refund records money owed back on an order for an approved return. It is
+// only a record until a payment provider is integrated.
+type Refund struct {
+	ID        string
+	OrderID   string
+	ReturnID  string
+	Amount    float64
+	CreatedAt time.Time
+}
+
+// returnable returns the quantity of each line of o that has shipped and is
+// not on a return still open or done. Lines that have not shipped are
+// present with zero.
+func returnable(o Order, shipments []Shipment, returns []Return) map[lineKey]uint64 {
+	left := map[lineKey]uint64{}
+	for _, p := range o.Products {
+		left[lineKey{p.ID, p.VariantID}] = 0
+	}
+	for _, sh := range shipments {
+		for _, l := range sh.Lines {
+			left[lineKey{l.ProductID, l.VariantID}] += l.Quantity
+		}
+	}
+	for _, rt := range returns {
+		if rt.Status == ReturnRejected {
+			continue
+		}
+		for _, l := range rt.Lines {
+			key := lineKey{l.ProductID, l.VariantID}
+			if left[key] >= l.Quantity {
+				left[key] -= l.Quantity
+			} else {
+				left[key] = 0
+			}
+		}
+	}
+	return left
+}
+
+// RequestReturn asks to return lines of the order for reason. Only what has
+// shipped can be returned, and only once. The refund it would bring is
+// priced from what the order paid for each line.
+func (s *orderService) RequestReturn(ctx context.Context, orderID string, reason string, lines []ReturnLine) (*Return, error) {
+	now := time.Now().UTC()
+	rt := Return{
+		ID:        ksuid.New().String(),
+		OrderID:   orderID,
+		Status:    ReturnRequested,
+		Reason:    reason,
+		Lines:     lines,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err := s.repository.CreateReturn(ctx, orderID, func(o Order, shipments []Shipment, returns []Return) (*Return, error) {
+		prices := map[lineKey]float64{}
+		for _, p := range o.Products {
+			prices[lineKey{p.ID, p.VariantID}] = p.Price
+		}
+		left := returnable(o, shipments, returns)
+		amount := 0.0
+		for _, l := range lines {
+			key := lineKey{l.ProductID, l.VariantID}
+			remaining, ok := left[key]
+			if !ok {
+				return nil, ErrReturnLineNotOnOrder.With("productId", l.ProductID).With("variantId", l.VariantID)
+			}
+			if l.Quantity > remaining {
+				return nil, ErrReturnQuantityExceeded.With("productId", l.ProductID).With("remaining", strconv.FormatUint(remaining, 10))
+			}
+			amount += prices[key] * float64(l.Quantity)
+		}
+		rt.RefundAmount = roundCents(amount)
+		return &rt, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	returnsMoved.WithLabelValues(ReturnRequested).Inc()
+	return &rt, nil
+}
+
+// ApproveReturn approves a requested return: it is moved to approving, so
+// that it can no longer be rejected, then restock puts its items back into
+// stock, then the approval is stored with the refund of the return. A
+// return left approving by a failure is approved by approving it again,
+// which calls restock again, so restock must be safe to repeat.
+func (s *orderService) ApproveReturn(ctx context.Context, id string, note string, restock func(ctx context.Context, rt Return) error) (*Return, error) {
+	rt, err := s.repository.GetReturn(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	switch rt.Status {
+	case ReturnRequested:
+		rt.Status, rt.Note, rt.UpdatedAt = ReturnApproving, note, time.Now().UTC()
+		if err := s.repository.UpdateReturn(ctx, *rt, ReturnRequested, nil); err != nil {
+			return nil, err
+		}
+	case ReturnApproving:
+		if note != "" {
+			rt.Note = note
+		}
+	default:
+		return nil, ErrReturnStatus.With("id", id).With("status", rt.Status)
+	}
+	if err := restock(ctx, *rt); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	rt.Status, rt.UpdatedAt = ReturnApproved, now
+	refund := &Refund{
+		ID:        ksuid.New().String(),
+		OrderID:   rt.OrderID,
+		ReturnID:  rt.ID,
+		Amount:    rt.RefundAmount,
+		CreatedAt: now,
+	}
+	if err := s.repository.UpdateReturn(ctx, *rt, ReturnApproving, refund); err != nil {
+		return nil, err
+	}
+
+	returnsMoved.WithLabelValues(ReturnApproved).Inc()
+	refundValue.Observe(refund.Amount)
+	return rt, nil
+}
+
+// RejectReturn rejects a requested return, which frees its lines to be
+// returned again.
+func (s *orderService) RejectReturn(ctx context.Context, id string, note string) (*Return, error) {
+	rt, err := s.returnIn(ctx, id, ReturnRequested)
+	if err != nil {
+		return nil, err
+	}
+
+	rt.Status, rt.Note, rt.UpdatedAt = ReturnRejected, note, time.Now().UTC()
+	if err := s.repository.UpdateReturn(ctx, *rt, ReturnRequested, nil); err != nil {
+		return nil, err
+	}
+
+	returnsMoved.WithLabelValues(ReturnRejected).Inc()
+	return rt, nil
+}
+
+// ReceiveReturn records that the items of an approved return arrived.
+func (s *orderService) ReceiveReturn(ctx context.Context, id string) (*Return, error) {
+	rt, err := s.returnIn(ctx, id, ReturnApproved)
+	if err != nil {
+		return nil, err
+	}
+
+	rt.Status, rt.UpdatedAt = ReturnReceived, time.Now().UTC()
+	if err := s.repository.UpdateReturn(ctx, *rt, ReturnApproved, nil); err != nil {
+		return nil, err
+	}
+
+	returnsMoved.WithLabelValues(ReturnReceived).Inc()
+	return rt, nil
+}
+
+// returnIn returns the return, failing with ErrReturnStatus unless it is in
+// status.
+func (s *orderService) returnIn(ctx context.Context, id string, status string) (*Return, error) {
+	rt, err := s.repository.GetReturn(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rt.Status != status {
+		return nil, ErrReturnStatus.With("id", id).With("status", rt.Status)
+	}
+	return rt, nil
+}
+
+// ListReturns returns the returns of the order, oldest first.
+func (s *orderService) ListReturns(ctx context.Context, orderID string) ([]Return, error) {
+	return s.repository.ListReturns(ctx, orderID)
+}
+
+// ListRefunds returns the refunds of the order, oldest first.
+func (s *orderService) ListRefunds(ctx context.Context, orderID string) ([]Refund, error) {
+	return s.repository.ListRefunds(ctx, orderID)
+}
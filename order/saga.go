@@ -0,0 +1,223 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sunil8777/E-commerce-microservices/account"
+	"github.com/sunil8777/E-commerce-microservices/catalog"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+)
+
+var (
+	ErrPlacementFailed  = errs.New(errs.FailedPrecondition, "ORDER_PLACEMENT_FAILED", "order placement failed")
+	ErrPlacementPending = errs.New(errs.Unavailable, "ORDER_PLACEMENT_PENDING", "order is still being placed")
+	ErrOrderExists      = errs.New(errs.AlreadyExists, "ORDER_EXISTS", "order was already started")
+)
+
+// The steps of placing an order, in the order they run.
+const (
+	StepValidateAccount  = "validate_account"
+	StepReserveStock     = "reserve_stock"
+	StepAuthorizePayment = "authorize_payment"
+	StepConfirmOrder     = "confirm_order"
+)
+
+const (
+	SagaRunning      = "running"
+	SagaCompensating = "compensating"
+	SagaCompleted    = "completed"
+	SagaFailed       = "failed"
+)
+
+const (
+	// sagaTimeout bounds one attempt at driving a saga, independently of
+	// the deadline of the request that started it.
+	sagaTimeout = 30 * time.Second
+	// sagaStaleAfter is how long an unfinished saga may go without moving
+	// before it is taken to be abandoned and resumed. It is well above
+	// sagaTimeout so that sagas still being driven are left alone.
+	sagaStaleAfter = 2 * time.Minute
+	// sagaRecoveryInterval is how often abandoned sagas are looked for.
+	sagaRecoveryInterval = 30 * time.Second
+)
+
+// Saga is the durable state of placing one order. Step is the next step to
+// run while the saga is running, and the next step to undo while it is
+// compensating. Failure is the reason of the error that made it compensate.
+type Saga struct {
+	Order     Order
+	Step      string
+	Status    string
+	PaymentID string
+	Failure   string
+	UpdatedAt time.Time
+}
+
+type sagaStep struct {
+	name string
+	run  func(ctx context.Context, sg *Saga) error
+	// compensate undoes run. It must succeed whether or not run did, since
+	// a step that timed out may still have taken effect.
+	compensate func(ctx context.Context, sg *Saga) error
+}
+
+// placement drives order placement sagas through their steps, against the
+// account and catalog services and the payments of the order service.
+type placement struct {
+	service       Service
+	accountClient *account.Client
+	catalogClient *catalog.Client
+	steps         []sagaStep
+}
+
+func newPlacement(s Service, accountClient *account.Client, catalogClient *catalog.Client) *placement {
+	p := &placement{
+		service:       s,
+		accountClient: accountClient,
+		catalogClient: catalogClient,
+	}
+	p.steps = []sagaStep{
+		{name: StepValidateAccount, run: p.validateAccount},
+		{name: StepReserveStock, run: p.reserveStock, compensate: p.releaseStock},
+		{name: StepAuthorizePayment, run: s.AuthorizePayment, compensate: s.VoidPayment},
+		{name: StepConfirmOrder, run: p.confirmOrder},
+	}
+	return p
+}
+
+// run drives sg from where it stands to completion. When a step fails, run
+// compensates that step and every one before it, latest first, and returns
+// the error of the step. The saga is saved before every step and before any
+// compensation, so if saving fails or a compensation fails run stops and
+// returns that error, and recoverSagas picks the saga up again later.
+func (p *placement) run(ctx context.Context, sg *Saga) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sagaTimeout)
+	defer cancel()
+
+	var failure error
+	for sg.Status == SagaRunning {
+		i := p.index(sg.Step)
+		if i < 0 {
+			return fmt.Errorf("order %s is at unknown step %q", sg.Order.ID, sg.Step)
+		}
+		if err := p.steps[i].run(ctx, sg); err != nil {
+			failure = err
+			sg.Status, sg.Failure = SagaCompensating, errs.From(err).Reason
+			if err := p.service.SaveSaga(ctx, sg); err != nil {
+				return err
+			}
+			break
+		}
+		if sg.Status == SagaCompleted {
+			orderSagas.WithLabelValues(SagaCompleted).Inc()
+			return nil
+		}
+
+		sg.Step = p.steps[i+1].name
+		if err := p.service.SaveSaga(ctx, sg); err != nil {
+			return err
+		}
+	}
+
+	if sg.Status != SagaCompensating {
+		return nil
+	}
+	last := p.index(sg.Step)
+	if last < 0 {
+		return fmt.Errorf("order %s is at unknown step %q", sg.Order.ID, sg.Step)
+	}
+	for i := last; i >= 0; i-- {
+		if c := p.steps[i].compensate; c != nil {
+			if err := c(ctx, sg); err != nil {
+				return err
+			}
+		}
+
+		if i > 0 {
+			sg.Step = p.steps[i-1].name
+		} else {
+			sg.Step, sg.Status = "", SagaFailed
+		}
+		if err := p.service.SaveSaga(ctx, sg); err != nil {
+			return err
+		}
+	}
+	orderSagas.WithLabelValues(SagaFailed).Inc()
+
+	if failure == nil {
+		// Resumed: the error that failed the saga is only known by reason.
+		failure = ErrPlacementFailed.With("reason", sg.Failure)
+	}
+	return failure
+}
+
+// recoverSagas resumes, right away and then every interval until ctx is
+// done, the sagas that have not moved for staleAfter, such as those left
+// behind by a replica that crashed part way through.
+func (p *placement) recoverSagas(ctx context.Context, interval, staleAfter time.Duration) {
+	for {
+		sagas, err := p.service.ClaimStaleSagas(ctx, time.Now().Add(-staleAfter))
+		if err != nil && ctx.Err() == nil {
+			log.Println("Error claiming stale order sagas:", err)
+		}
+		for i := range sagas {
+			sg := &sagas[i]
+			log.Printf("Resuming order %s at %s (%s)", sg.Order.ID, sg.Step, sg.Status)
+			orderSagas.WithLabelValues("resumed").Inc()
+			if err := p.run(ctx, sg); err != nil {
+				log.Printf("Order %s: %v", sg.Order.ID, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// index returns the position of the step named name, or -1.
+func (p *placement) index(name string) int {
+	for i, s := range p.steps {
+		if s.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p *placement) validateAccount(ctx context.Context, sg *Saga) error {
+	_, err := p.accountClient.GetAccount(ctx, sg.Order.AccountID)
+	return err
+}
+
+func (p *placement) reserveStock(ctx context.Context, sg *Saga) error {
+	items := []catalog.StockItem{}
+	for _, op := range sg.Order.Products {
+		items = append(items, catalog.StockItem{
+			ProductID: op.ID,
+			VariantID: op.VariantID,
+			Quantity:  uint32(op.Quantity),
+		})
+	}
+	return p.catalogClient.ReserveStock(ctx, sg.Order.ID, items)
+}
+
+func (p *placement) releaseStock(ctx context.Context, sg *Saga) error {
+	return p.catalogClient.ReleaseStock(ctx, sg.Order.ID)
+}
+
+// confirmOrder bills the invoice of the order to the account's name and
+// email as they are when the order is confirmed.
+func (p *placement) confirmOrder(ctx context.Context, sg *Saga) error {
+	a, err := p.accountClient.GetAccount(ctx, sg.Order.AccountID)
+	if err != nil {
+		return err
+	}
+	_, err = p.service.ConfirmOrder(ctx, sg, Customer{Name: a.Name, Email: a.Email})
+	return err
+}
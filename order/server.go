@@ -6,127 +6,543 @@ import (
 	"log"
 	"net"
 	"strconv"
+	"time"
 
 	"github.com/sunil8777/E-commerce-microservices/account"
 	"github.com/sunil8777/E-commerce-microservices/catalog"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+	"github.com/sunil8777/E-commerce-microservices/grpcopts"
+	"github.com/sunil8777/E-commerce-microservices/healthcheck"
+	"github.com/sunil8777/E-commerce-microservices/metrics"
 	pb "github.com/sunil8777/E-commerce-microservices/order/pb"
+	"github.com/sunil8777/E-commerce-microservices/retry"
+	"github.com/sunil8777/E-commerce-microservices/shutdown"
+	"github.com/sunil8777/E-commerce-microservices/tlsconfig"
+	"github.com/sunil8777/E-commerce-microservices/tracing"
+	"github.com/sunil8777/E-commerce-microservices/validation"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// peerRules limits searching every account's orders, recording shipments
+// and resolving returns to the gateway, which only lets admins do any of
+// them. The review service also searches orders, to check that a reviewer
+// bought the product. They are enforced when the server runs with mutual
+// TLS.
+var peerRules = map[string][]string{
+	pb.OrderService_SearchOrders_FullMethodName:     {"graphql", "review"},
+	pb.OrderService_CreateShipment_FullMethodName:   {"graphql"},
+	pb.OrderService_AddShipmentEvent_FullMethodName: {"graphql"},
+	pb.OrderService_ApproveReturn_FullMethodName:    {"graphql"},
+	pb.OrderService_RejectReturn_FullMethodName:     {"graphql"},
+	pb.OrderService_ReceiveReturn_FullMethodName:    {"graphql"},
+}
+
 type grpcServer struct {
 	pb.UnimplementedOrderServiceServer
 	service       Service
 	accountClient *account.Client
 	catalogClient *catalog.Client
+	placement     *placement
 }
 
-func ListenGRPC(s Service, accountURL string, catalogURL string, port int) error {
-	accountClient, err := account.NewClient(accountURL)
+func ListenGRPC(ctx context.Context, s Service, accountURL string, catalogURL string, port int) error {
+	lis, err := net.Listen("tcp", ":"+strconv.Itoa(port))
 	if err != nil {
 		return err
 	}
+	return Serve(ctx, s, accountURL, catalogURL, lis)
+}
 
-	catalogClient, err := catalog.NewClient(catalogURL)
+// Serve serves s on lis until ctx is done. opts are added to the dial
+// options of the account and catalog clients. It also resumes order
+// placements left unfinished, for example by a crash, and places the orders
+// of subscriptions as they fall due, until ctx is done.
+func Serve(ctx context.Context, s Service, accountURL string, catalogURL string, lis net.Listener, opts ...grpc.DialOption) error {
+	accountClient, err := account.NewClient(accountURL, append([]grpc.DialOption{retry.NewBreaker("account").DialOption()}, opts...)...)
 	if err != nil {
+		lis.Close()
 		return err
 	}
+	defer accountClient.Close()
 
-	lis, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	catalogClient, err := catalog.NewClient(catalogURL, append([]grpc.DialOption{retry.NewBreaker("catalog").DialOption()}, opts...)...)
 	if err != nil {
-		accountClient.Close()
-		catalogClient.Close()
+		lis.Close()
 		return err
 	}
+	defer catalogClient.Close()
 
-	server := grpc.NewServer()
-	pb.RegisterOrderServiceServer(server, &grpcServer{
+	server := grpc.NewServer(append(
+		grpcopts.ServerOptions(),
+		grpc.ChainUnaryInterceptor(
+			metrics.UnaryServerInterceptor(),
+			errs.UnaryServerInterceptor(),
+			tlsconfig.AuthorizePeers(peerRules),
+			validation.UnaryServerInterceptor(),
+		),
+		tracing.ServerOption(),
+	)...)
+	placement := newPlacement(s, accountClient, catalogClient)
+	orders := &grpcServer{
 		service:       s,
 		accountClient: accountClient,
 		catalogClient: catalogClient,
-	})
+		placement:     placement,
+	}
+	pb.RegisterOrderServiceServer(server, orders)
+
+	recovered := make(chan struct{})
+	go func() {
+		defer close(recovered)
+		placement.recoverSagas(ctx, sagaRecoveryInterval, sagaStaleAfter)
+	}()
+	defer func() { <-recovered }()
 
+	scheduled := make(chan struct{})
+	go func() {
+		defer close(scheduled)
+		NewScheduler(s, orders.placeOrder).Run(ctx, subscriptionPollInterval)
+	}()
+	defer func() { <-scheduled }()
+
+	healthcheck.Register(ctx, server, s.Ping, pb.OrderService_ServiceDesc.ServiceName)
 	reflection.Register(server)
-	return server.Serve(lis)
+	// The clients are closed by the deferred calls above, only once the
+	// server has drained the RPCs and recovery and the scheduler have
+	// stopped using them.
+	return shutdown.ServeGRPC(ctx, server, lis)
 }
 
+// PostOrder prices the order from the catalog and places it through the
+// placement saga: validate the account, reserve stock, authorize payment
+// and confirm the order, undoing the steps already taken if one fails.
 func (s *grpcServer) PostOrder(ctx context.Context, r *pb.PostOrderRequest) (*pb.PostOrderResponse, error) {
-	_, err := s.accountClient.GetAccount(ctx, r.AccountId)
+	lines := []OrderedProduct{}
+	for _, p := range r.Products {
+		lines = append(lines, OrderedProduct{ID: p.ProductId, VariantID: p.VariantId, Quantity: uint64(p.Quantity)})
+	}
+	order, err := s.placeOrder(ctx, r.IdempotencyKey, r.AccountId, lines, addressFromProto(r.ShippingAddress))
 	if err != nil {
-		log.Println("Error getting account:", err)
-		return nil, errors.New("account not found")
+		return nil, err
 	}
 
+	orderProto := &pb.Order{
+		Id:              order.ID,
+		AccountId:       order.AccountID,
+		TotalPrice:      order.TotalPrice,
+		Status:          order.Status,
+		Products:        []*pb.Order_OrderProduct{},
+		ShippingAddress: addressToProto(order.ShippingAddress),
+	}
+	orderProto.CreatedAt, _ = order.CreatedAt.MarshalBinary()
+	orderProto.PlacedAt = timestamppb.New(order.CreatedAt)
+	for _, p := range order.Products {
+		orderProto.Products = append(orderProto.Products, orderedProductToProto(p))
+	}
+
+	return &pb.PostOrderResponse{
+		Order: orderProto,
+	}, nil
+}
+
+// placeOrder is PostOrder for lines that name only a product, variant and
+// quantity, and is how subscriptions place their orders too. An order with
+// an idempotencyKey is placed once: placing it again returns that order, or
+// why it failed, and ErrPlacementPending while its saga has not finished.
+func (s *grpcServer) placeOrder(ctx context.Context, idempotencyKey string, accountID string, lines []OrderedProduct, shippingAddress Address) (*Order, error) {
 	productIDs := []string{}
-	for _, p := range r.Products {
-		productIDs = append(productIDs, p.ProductId)
+	for _, l := range lines {
+		productIDs = append(productIDs, l.ID)
 	}
 
-	orderedProducts, err := s.catalogClient.GetProducts(ctx, productIDs, "", 0, 0)
+	catalogProducts, err := s.catalogClient.GetProducts(ctx, productIDs, "", 0, 0)
 	if err != nil {
-		log.Println("Error getting account:", err)
-		return nil, errors.New("products not found")
+		log.Println("Error getting products:", err)
+		return nil, err
+	}
+
+	productsByID := map[string]catalog.Product{}
+	for _, p := range catalogProducts {
+		productsByID[p.ID] = p
 	}
 
 	products := []OrderedProduct{}
-	for _, p := range orderedProducts {
+	for _, l := range lines {
+		p, ok := productsByID[l.ID]
+		if !ok {
+			return nil, catalog.ErrNotFound.With("id", l.ID)
+		}
+
 		product := OrderedProduct{
 			ID:          p.ID,
-			Quantity:    0,
+			Quantity:    l.Quantity,
 			Price:       p.Price,
 			Name:        p.Name,
 			Description: p.Description,
 		}
 
-		for _, rp := range r.Products {
-			if rp.ProductId == p.ID {
-				product.Quantity = uint64(rp.Quantity)
-				break
+		if l.VariantID != "" || len(p.Variants) != 0 {
+			v, ok := p.Variant(l.VariantID)
+			if !ok {
+				return nil, ErrVariantNotFound.With("productId", p.ID)
 			}
+			product.VariantID = v.ID
+			product.SKU = v.SKU
+			product.Options = v.Options
+			product.Price = p.VariantPrice(*v)
 		}
-		if product.Quantity != 0 {
-			products = append(products, product)
+
+		products = append(products, product)
+	}
+
+	var orderID string
+	if idempotencyKey != "" {
+		orderID = IdempotentOrderID(accountID, idempotencyKey)
+	}
+	sg, err := s.service.StartOrder(ctx, orderID, accountID, products, shippingAddress)
+	if errors.Is(err, ErrOrderExists) {
+		return s.placedOrder(ctx, orderID)
+	}
+	if err != nil {
+		log.Println("Error starting order:", err)
+		return nil, err
+	}
+	if err := s.placement.run(ctx, sg); err != nil {
+		log.Printf("Error placing order %s: %v", sg.Order.ID, err)
+		return nil, err
+	}
+	return &sg.Order, nil
+}
+
+// placedOrder returns the order placed under orderID by an earlier
+// placeOrder with the same idempotency key.
+func (s *grpcServer) placedOrder(ctx context.Context, orderID string) (*Order, error) {
+	sg, err := s.service.GetSaga(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	switch sg.Status {
+	case SagaCompleted:
+		return &sg.Order, nil
+	case SagaFailed:
+		return nil, ErrPlacementFailed.With("id", orderID).With("reason", sg.Failure)
+	}
+	return nil, ErrPlacementPending.With("id", orderID)
+}
+
+func (s *grpcServer) GetOrderForAccount(ctx context.Context, r *pb.GetOrderForAccountRequest) (*pb.GetOrderForAccountResponse, error) {
+	q := OrderQuery{
+		Status:      r.Status,
+		MinTotal:    r.MinTotal,
+		NewestFirst: r.Sort == pb.SortDirection_NEWEST_FIRST,
+	}
+	if r.PlacedFrom != nil {
+		q.PlacedFrom = r.PlacedFrom.AsTime()
+	}
+	if r.PlacedTo != nil {
+		q.PlacedTo = r.PlacedTo.AsTime()
+	}
+	page, err := s.service.GetOrdersForAccount(ctx, r.AccountId, q, r.After, r.Take)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	orders, err := s.enrichedOrders(ctx, page.Orders)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetOrderForAccountResponse{
+		Orders:      orders,
+		Cursors:     page.Cursors,
+		HasNextPage: page.HasNextPage,
+	}, nil
+}
+
+// SearchOrders finds orders across accounts for support staff. The gateway
+// only exposes it to admins, and peerRules only to the gateway.
+func (s *grpcServer) SearchOrders(ctx context.Context, r *pb.SearchOrdersRequest) (*pb.SearchOrdersResponse, error) {
+	q := OrderQuery{
+		AccountID:   r.AccountId,
+		ProductID:   r.ProductId,
+		Status:      r.Status,
+		MinTotal:    r.MinTotal,
+		MaxTotal:    r.MaxTotal,
+		NewestFirst: r.Sort == pb.SortDirection_NEWEST_FIRST,
+	}
+	if r.PlacedFrom != nil {
+		q.PlacedFrom = r.PlacedFrom.AsTime()
+	}
+	if r.PlacedTo != nil {
+		q.PlacedTo = r.PlacedTo.AsTime()
+	}
+	page, err := s.service.SearchOrders(ctx, q, r.After, r.Take)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	orders, err := s.enrichedOrders(ctx, page.Orders)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.SearchOrdersResponse{
+		Orders:      orders,
+		Cursors:     page.Cursors,
+		HasNextPage: page.HasNextPage,
+	}, nil
+}
+
+// GetInvoice returns the invoice issued when the order was confirmed.
+func (s *grpcServer) GetInvoice(ctx context.Context, r *pb.GetInvoiceRequest) (*pb.GetInvoiceResponse, error) {
+	inv, err := s.service.GetInvoice(ctx, r.OrderId)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return &pb.GetInvoiceResponse{Invoice: &pb.Invoice{
+		Number:   inv.Number,
+		OrderId:  inv.OrderID,
+		IssuedAt: timestamppb.New(inv.IssuedAt),
+		Html:     inv.HTML,
+		Pdf:      inv.PDF,
+	}}, nil
+}
+
+// CreateShipment ships lines of an order. The gateway only exposes it to
+// admins, and peerRules only to the gateway.
+func (s *grpcServer) CreateShipment(ctx context.Context, r *pb.CreateShipmentRequest) (*pb.CreateShipmentResponse, error) {
+	lines := []ShipmentLine{}
+	for _, l := range r.Lines {
+		lines = append(lines, ShipmentLine{ProductID: l.ProductId, VariantID: l.VariantId, Quantity: l.Quantity})
+	}
+	sh, err := s.service.CreateShipment(ctx, r.OrderId, r.Carrier, r.TrackingNumber, lines)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return &pb.CreateShipmentResponse{Shipment: shipmentToProto(*sh)}, nil
+}
+
+// AddShipmentEvent records a tracking event of a shipment. The gateway only
+// exposes it to admins, and peerRules only to the gateway.
+func (s *grpcServer) AddShipmentEvent(ctx context.Context, r *pb.AddShipmentEventRequest) (*pb.AddShipmentEventResponse, error) {
+	e := ShipmentEvent{Status: r.Status, Description: r.Description, Location: r.Location}
+	if r.OccurredAt != nil {
+		e.OccurredAt = r.OccurredAt.AsTime()
+	}
+	sh, err := s.service.AddShipmentEvent(ctx, r.ShipmentId, e)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return &pb.AddShipmentEventResponse{Shipment: shipmentToProto(*sh)}, nil
+}
+
+func (s *grpcServer) ListShipments(ctx context.Context, r *pb.ListShipmentsRequest) (*pb.ListShipmentsResponse, error) {
+	shipments, err := s.service.ListShipments(ctx, r.OrderId)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	res := &pb.ListShipmentsResponse{Shipments: []*pb.Shipment{}}
+	for _, sh := range shipments {
+		res.Shipments = append(res.Shipments, shipmentToProto(sh))
+	}
+	return res, nil
+}
+
+func (s *grpcServer) RequestReturn(ctx context.Context, r *pb.RequestReturnRequest) (*pb.RequestReturnResponse, error) {
+	lines := []ReturnLine{}
+	for _, l := range r.Lines {
+		lines = append(lines, ReturnLine{ProductID: l.ProductId, VariantID: l.VariantId, Quantity: l.Quantity})
+	}
+	rt, err := s.service.RequestReturn(ctx, r.OrderId, r.Reason, lines)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return &pb.RequestReturnResponse{Return: returnToProto(*rt)}, nil
+}
+
+// ApproveReturn puts the items of the return back into stock in the
+// catalog, under the return's ID so that approving again after a failure
+// does not restock them twice, and records its refund.
+func (s *grpcServer) ApproveReturn(ctx context.Context, r *pb.ApproveReturnRequest) (*pb.ApproveReturnResponse, error) {
+	rt, err := s.service.ApproveReturn(ctx, r.ReturnId, r.Note, func(ctx context.Context, rt Return) error {
+		items := []catalog.StockItem{}
+		for _, l := range rt.Lines {
+			items = append(items, catalog.StockItem{
+				ProductID: l.ProductID,
+				VariantID: l.VariantID,
+				Quantity:  uint32(l.Quantity),
+			})
 		}
+		return s.catalogClient.ReturnStock(ctx, rt.ID, items)
+	})
+	if err != nil {
+		log.Println(err)
+		return nil, err
 	}
+	return &pb.ApproveReturnResponse{Return: returnToProto(*rt)}, nil
+}
 
-	order, err := s.service.PostOrder(ctx, r.AccountId, products)
+func (s *grpcServer) RejectReturn(ctx context.Context, r *pb.RejectReturnRequest) (*pb.RejectReturnResponse, error) {
+	rt, err := s.service.RejectReturn(ctx, r.ReturnId, r.Note)
 	if err != nil {
-		log.Println("Error posting order:", err)
-		return nil, errors.New("could not post order")
+		log.Println(err)
+		return nil, err
 	}
+	return &pb.RejectReturnResponse{Return: returnToProto(*rt)}, nil
+}
 
-	orderProto := &pb.Order{
-		Id:         order.ID,
-		AccountId:  order.AccountID,
-		TotalPrice: order.TotalPrice,
-		Products:   []*pb.Order_OrderProduct{},
+func (s *grpcServer) ReceiveReturn(ctx context.Context, r *pb.ReceiveReturnRequest) (*pb.ReceiveReturnResponse, error) {
+	rt, err := s.service.ReceiveReturn(ctx, r.ReturnId)
+	if err != nil {
+		log.Println(err)
+		return nil, err
 	}
-	orderProto.CreatedAt, _ = order.CreatedAt.MarshalBinary()
-	for _, p := range order.Products {
-		orderProto.Products = append(orderProto.Products, &pb.Order_OrderProduct{
-			Id:          p.ID,
-			Name:        p.Name,
-			Description: p.Description,
-			Price:       p.Price,
+	return &pb.ReceiveReturnResponse{Return: returnToProto(*rt)}, nil
+}
+
+func (s *grpcServer) ListReturns(ctx context.Context, r *pb.ListReturnsRequest) (*pb.ListReturnsResponse, error) {
+	returns, err := s.service.ListReturns(ctx, r.OrderId)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	res := &pb.ListReturnsResponse{Returns: []*pb.Return{}}
+	for _, rt := range returns {
+		res.Returns = append(res.Returns, returnToProto(rt))
+	}
+	return res, nil
+}
+
+func (s *grpcServer) ListRefunds(ctx context.Context, r *pb.ListRefundsRequest) (*pb.ListRefundsResponse, error) {
+	refunds, err := s.service.ListRefunds(ctx, r.OrderId)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	res := &pb.ListRefundsResponse{Refunds: []*pb.Refund{}}
+	for _, rf := range refunds {
+		res.Refunds = append(res.Refunds, &pb.Refund{
+			Id:        rf.ID,
+			OrderId:   rf.OrderID,
+			ReturnId:  rf.ReturnID,
+			Amount:    rf.Amount,
+			CreatedAt: timestamppb.New(rf.CreatedAt),
 		})
 	}
+	return res, nil
+}
 
-	return &pb.PostOrderResponse{
-		Order: orderProto,
-	}, nil
+// CreateSubscription checks that the account exists, so that a
+// subscription is not left failing every run for want of it.
+func (s *grpcServer) CreateSubscription(ctx context.Context, r *pb.CreateSubscriptionRequest) (*pb.CreateSubscriptionResponse, error) {
+	if _, err := s.accountClient.GetAccount(ctx, r.AccountId); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	lines := []SubscriptionLine{}
+	for _, l := range r.Lines {
+		lines = append(lines, SubscriptionLine{ProductID: l.ProductId, VariantID: l.VariantId, Quantity: l.Quantity})
+	}
+	var startsAt time.Time
+	if r.StartsAt != nil {
+		startsAt = r.StartsAt.AsTime()
+	}
+	interval := Interval{Unit: r.IntervalUnit, Count: r.IntervalCount}
+	sub, err := s.service.CreateSubscription(ctx, r.AccountId, lines, addressFromProto(r.ShippingAddress), interval, startsAt)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return &pb.CreateSubscriptionResponse{Subscription: subscriptionToProto(*sub)}, nil
 }
 
-func (s *grpcServer) GetOrderForAccount(ctx context.Context, r *pb.GetOrderForAccountRequest) (*pb.GetOrderForAccountResponse, error) {
-	accountOrders, err := s.service.GetOrdersForAccount(ctx, r.AccountId)
+func (s *grpcServer) ListSubscriptions(ctx context.Context, r *pb.ListSubscriptionsRequest) (*pb.ListSubscriptionsResponse, error) {
+	subs, err := s.service.ListSubscriptions(ctx, r.AccountId)
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
+	res := &pb.ListSubscriptionsResponse{Subscriptions: []*pb.Subscription{}}
+	for _, sub := range subs {
+		res.Subscriptions = append(res.Subscriptions, subscriptionToProto(sub))
+	}
+	return res, nil
+}
 
-	productIDMap := map[string]bool{}
+func (s *grpcServer) PauseSubscription(ctx context.Context, r *pb.PauseSubscriptionRequest) (*pb.PauseSubscriptionResponse, error) {
+	sub, err := s.service.PauseSubscription(ctx, r.SubscriptionId)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return &pb.PauseSubscriptionResponse{Subscription: subscriptionToProto(*sub)}, nil
+}
+
+func (s *grpcServer) ResumeSubscription(ctx context.Context, r *pb.ResumeSubscriptionRequest) (*pb.ResumeSubscriptionResponse, error) {
+	sub, err := s.service.ResumeSubscription(ctx, r.SubscriptionId)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return &pb.ResumeSubscriptionResponse{Subscription: subscriptionToProto(*sub)}, nil
+}
+
+func (s *grpcServer) CancelSubscription(ctx context.Context, r *pb.CancelSubscriptionRequest) (*pb.CancelSubscriptionResponse, error) {
+	sub, err := s.service.CancelSubscription(ctx, r.SubscriptionId)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return &pb.CancelSubscriptionResponse{Subscription: subscriptionToProto(*sub)}, nil
+}
+
+// enrichedOrders converts orders to protos, filling in the product names
+// and descriptions their lines do not store from the catalog.
+func (s *grpcServer) enrichedOrders(ctx context.Context, accountOrders []Order) ([]*pb.Order, error) {
+	if err := s.enrichProducts(ctx, accountOrders); err != nil {
+		return nil, err
+	}
+
+	orders := []*pb.Order{}
 	for _, o := range accountOrders {
+		op := &pb.Order{
+			AccountId:       o.AccountID,
+			Id:              o.ID,
+			TotalPrice:      o.TotalPrice,
+			Status:          o.Status,
+			Products:        []*pb.Order_OrderProduct{},
+			ShippingAddress: addressToProto(o.ShippingAddress),
+		}
+		op.CreatedAt, _ = o.CreatedAt.MarshalBinary()
+		op.PlacedAt = timestamppb.New(o.CreatedAt)
+
+		for _, product := range o.Products {
+			op.Products = append(op.Products, orderedProductToProto(product))
+		}
+		orders = append(orders, op)
+	}
+	return orders, nil
+}
+
+// enrichProducts fills in the names and descriptions of the lines of
+// orders placed before order lines stored them, from the catalog. Lines
+// that store a name keep the one the product had when it was ordered.
+func (s *grpcServer) enrichProducts(ctx context.Context, orders []Order) error {
+	productIDMap := map[string]bool{}
+	for _, o := range orders {
 		for _, p := range o.Products {
-			productIDMap[p.ID] = true
+			if p.Name == "" {
+				productIDMap[p.ID] = true
+			}
 		}
 	}
 
@@ -134,40 +550,147 @@ func (s *grpcServer) GetOrderForAccount(ctx context.Context, r *pb.GetOrderForAc
 	for id := range productIDMap {
 		productIDs = append(productIDs, id)
 	}
+	// Without ids the catalog would list products rather than none.
+	if len(productIDs) == 0 {
+		return nil
+	}
 	products, err := s.catalogClient.GetProducts(ctx, productIDs, "", 0, 0)
 	if err != nil {
 		log.Println("Error getting account products: ", err)
-		return nil, err
+		return err
 	}
-	orders := []*pb.Order{}
-	for _, o := range accountOrders {
-		op := &pb.Order{
-			AccountId:  o.AccountID,
-			Id:         o.ID,
-			TotalPrice: o.TotalPrice,
-			Products:   []*pb.Order_OrderProduct{},
-		}
-		op.CreatedAt, _ = o.CreatedAt.MarshalBinary()
 
-		for _, product := range o.Products {
+	for _, o := range orders {
+		for i := range o.Products {
+			product := &o.Products[i]
+			if product.Name != "" {
+				continue
+			}
 			for _, p := range products {
 				if p.ID == product.ID {
 					product.Name = p.Name
 					product.Description = p.Description
-					product.Price = p.Price
+					if product.Price == 0 {
+						product.Price = p.Price
+					}
 					break
 				}
 			}
-			op.Products = append(op.Products, &pb.Order_OrderProduct{
-				Id:          product.ID,
-				Name:        product.Name,
-				Description: product.Description,
-				Price:       product.Price,
-				Quantity:    uint32(product.Quantity),
-			})
 		}
-		orders = append(orders, op)
 	}
+	return nil
+}
+
+func orderedProductToProto(p OrderedProduct) *pb.Order_OrderProduct {
+	return &pb.Order_OrderProduct{
+		Id:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       p.Price,
+		Quantity:    uint32(p.Quantity),
+		VariantId:   p.VariantID,
+		Sku:         p.SKU,
+		Options:     p.Options,
+	}
+}
+
+func addressToProto(a Address) *pb.Address {
+	if a.IsZero() {
+		return nil
+	}
+	return &pb.Address{
+		Name:       a.Name,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+	}
+}
+
+func shipmentToProto(sh Shipment) *pb.Shipment {
+	p := &pb.Shipment{
+		Id:             sh.ID,
+		OrderId:        sh.OrderID,
+		Carrier:        sh.Carrier,
+		TrackingNumber: sh.TrackingNumber,
+		TrackingUrl:    sh.TrackingURL(),
+		Status:         sh.Status,
+		Lines:          []*pb.Shipment_Line{},
+		Events:         []*pb.Shipment_Event{},
+		CreatedAt:      timestamppb.New(sh.CreatedAt),
+		UpdatedAt:      timestamppb.New(sh.UpdatedAt),
+	}
+	for _, l := range sh.Lines {
+		p.Lines = append(p.Lines, &pb.Shipment_Line{ProductId: l.ProductID, VariantId: l.VariantID, Quantity: l.Quantity})
+	}
+	for _, e := range sh.Events {
+		p.Events = append(p.Events, &pb.Shipment_Event{
+			Status:      e.Status,
+			Description: e.Description,
+			Location:    e.Location,
+			OccurredAt:  timestamppb.New(e.OccurredAt),
+		})
+	}
+	return p
+}
+
+func returnToProto(rt Return) *pb.Return {
+	p := &pb.Return{
+		Id:           rt.ID,
+		OrderId:      rt.OrderID,
+		Status:       rt.Status,
+		Reason:       rt.Reason,
+		Note:         rt.Note,
+		Lines:        []*pb.Return_Line{},
+		RefundAmount: rt.RefundAmount,
+		CreatedAt:    timestamppb.New(rt.CreatedAt),
+		UpdatedAt:    timestamppb.New(rt.UpdatedAt),
+	}
+	for _, l := range rt.Lines {
+		p.Lines = append(p.Lines, &pb.Return_Line{ProductId: l.ProductID, VariantId: l.VariantID, Quantity: l.Quantity})
+	}
+	return p
+}
 
-	return &pb.GetOrderForAccountResponse{Orders: orders}, nil
+func subscriptionToProto(sub Subscription) *pb.Subscription {
+	p := &pb.Subscription{
+		Id:              sub.ID,
+		AccountId:       sub.AccountID,
+		Status:          sub.Status,
+		Lines:           []*pb.Subscription_Line{},
+		ShippingAddress: addressToProto(sub.ShippingAddress),
+		IntervalUnit:    sub.Interval.Unit,
+		IntervalCount:   sub.Interval.Count,
+		StartsAt:        timestamppb.New(sub.StartsAt),
+		NextRunAt:       timestamppb.New(sub.NextRunAt),
+		Failures:        sub.Failures,
+		LastFailure:     sub.LastFailure,
+		LastOrderId:     sub.LastOrderID,
+		CreatedAt:       timestamppb.New(sub.CreatedAt),
+		UpdatedAt:       timestamppb.New(sub.UpdatedAt),
+	}
+	if sub.Failures > 0 {
+		p.RetryAt = timestamppb.New(sub.DueAt)
+	}
+	for _, l := range sub.Lines {
+		p.Lines = append(p.Lines, &pb.Subscription_Line{ProductId: l.ProductID, VariantId: l.VariantID, Quantity: l.Quantity})
+	}
+	return p
+}
+
+func addressFromProto(a *pb.Address) Address {
+	if a == nil {
+		return Address{}
+	}
+	return Address{
+		Name:       a.Name,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+	}
 }
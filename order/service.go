@@ -2,26 +2,122 @@ package order
 
 import (
 	"context"
+	"crypto/sha256"
+	"strings"
 	"time"
 
 	"github.com/segmentio/ksuid"
+	"github.com/sunil8777/E-commerce-microservices/cursor"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+	"github.com/sunil8777/E-commerce-microservices/events"
+)
+
+var (
+	ErrVariantNotFound = errs.New(errs.InvalidArgument, "VARIANT_NOT_FOUND", "variant not found")
 )
 
 type Service interface {
-	PostOrder(ctx context.Context, accountID string, products []OrderedProduct) (*Order, error)
-	GetOrdersForAccount(ctx context.Context, accountID string) ([]Order, error)
+	StartOrder(ctx context.Context, orderID string, accountID string, products []OrderedProduct, shippingAddress Address) (*Saga, error)
+	GetSaga(ctx context.Context, orderID string) (*Saga, error)
+	SaveSaga(ctx context.Context, sg *Saga) error
+	ClaimStaleSagas(ctx context.Context, before time.Time) ([]Saga, error)
+	AuthorizePayment(ctx context.Context, sg *Saga) error
+	VoidPayment(ctx context.Context, sg *Saga) error
+	ConfirmOrder(ctx context.Context, sg *Saga, billTo Customer) (*Order, error)
+	GetOrdersForAccount(ctx context.Context, accountID string, q OrderQuery, after string, take uint64) (*OrderPage, error)
+	SearchOrders(ctx context.Context, q OrderQuery, after string, take uint64) (*OrderPage, error)
+	GetOrder(ctx context.Context, id string) (*Order, error)
+	GetInvoice(ctx context.Context, orderID string) (*Invoice, error)
+	CreateShipment(ctx context.Context, orderID string, carrier string, trackingNumber string, lines []ShipmentLine) (*Shipment, error)
+	AddShipmentEvent(ctx context.Context, shipmentID string, e ShipmentEvent) (*Shipment, error)
+	ListShipments(ctx context.Context, orderID string) ([]Shipment, error)
+	RequestReturn(ctx context.Context, orderID string, reason string, lines []ReturnLine) (*Return, error)
+	ApproveReturn(ctx context.Context, id string, note string, restock func(ctx context.Context, rt Return) error) (*Return, error)
+	RejectReturn(ctx context.Context, id string, note string) (*Return, error)
+	ReceiveReturn(ctx context.Context, id string) (*Return, error)
+	ListReturns(ctx context.Context, orderID string) ([]Return, error)
+	ListRefunds(ctx context.Context, orderID string) ([]Refund, error)
+	CreateSubscription(ctx context.Context, accountID string, lines []SubscriptionLine, shippingAddress Address, interval Interval, startsAt time.Time) (*Subscription, error)
+	ListSubscriptions(ctx context.Context, accountID string) ([]Subscription, error)
+	PauseSubscription(ctx context.Context, id string) (*Subscription, error)
+	ResumeSubscription(ctx context.Context, id string) (*Subscription, error)
+	CancelSubscription(ctx context.Context, id string) (*Subscription, error)
+	ClaimDueSubscription(ctx context.Context, now time.Time) (*Subscription, error)
+	RecordSubscriptionRun(ctx context.Context, id string, now time.Time, orderID string, runErr error) (*Subscription, error)
+	Ping(ctx context.Context) error
 }
 
+// OrderStatusPlaced is the status of an order once its placement saga has
+// confirmed it, until it starts shipping.
+const OrderStatusPlaced = "placed"
+
 type Order struct {
 	ID         string
 	CreatedAt  time.Time
 	TotalPrice float64
 	AccountID  string
+	Status     string
 	Products   []OrderedProduct
+	// ShippingAddress is zero for orders placed without one.
+	ShippingAddress Address
+}
+
+// Address is a postal address. Country is an ISO 3166-1 alpha-2 code.
+type Address struct {
+	Name       string
+	Line1      string
+	Line2      string
+	City       string
+	Region     string
+	PostalCode string
+	Country    string
 }
 
+func (a Address) IsZero() bool {
+	return a == Address{}
+}
+
+// Lines formats a as the lines of a mailing label, skipping empty parts.
+func (a Address) Lines() []string {
+	lines := []string{}
+	for _, l := range []string{a.Name, a.Line1, a.Line2, strings.TrimSpace(a.PostalCode + " " + a.City), a.Region, a.Country} {
+		if l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+// OrderQuery filters and sorts orders by placement time. Zero fields do not
+// filter. PlacedFrom is inclusive and PlacedTo exclusive. ProductID matches
+// orders with at least one line of the product.
+type OrderQuery struct {
+	AccountID   string
+	ProductID   string
+	PlacedFrom  time.Time
+	PlacedTo    time.Time
+	Status      string
+	MinTotal    float64
+	MaxTotal    float64
+	NewestFirst bool
+}
+
+// OrderPage is one page of an order listing. Cursors[i] is the
+// opaque cursor pointing just past Orders[i].
+type OrderPage struct {
+	Orders      []Order
+	Cursors     []string
+	HasNextPage bool
+}
+
+// OrderedProduct is one order line. VariantID, SKU and Options snapshot the
+// variant that was bought, and Price is its unit price at the time of the
+// order, so later catalog edits do not rewrite order history.
 type OrderedProduct struct {
 	ID          string
+	VariantID   string
+	SKU         string
+	Options     map[string]string
 	Name        string
 	Description string
 	Price       float64
@@ -30,31 +126,199 @@ type OrderedProduct struct {
 
 type orderService struct {
 	repository Repository
+	payments   Payments
+	invoices   InvoiceConfig
+}
+
+func NewService(r Repository, p Payments, ic InvoiceConfig) Service {
+	return &orderService{r, p, ic}
 }
 
-func NewService(r Repository) Service {
-	return &orderService{r}
+// IdempotentOrderID returns the ID of the order the account places with
+// idempotencyKey, the same every time and never that of another account.
+func IdempotentOrderID(accountID string, idempotencyKey string) string {
+	sum := sha256.Sum256([]byte(accountID + "\x00" + idempotencyKey))
+	id, _ := ksuid.FromBytes(sum[:len(ksuid.Nil)])
+	return id.String()
 }
 
-func (s *orderService) PostOrder(ctx context.Context, accountID string, products []OrderedProduct) (*Order, error) {
+// StartOrder prices a new order and records the saga that will place it.
+// The order is only stored, and visible to the account, once the saga
+// confirms it. It takes a new ID unless orderID is set, and fails with
+// ErrOrderExists if an order with orderID was started before.
+func (s *orderService) StartOrder(ctx context.Context, orderID string, accountID string, products []OrderedProduct, shippingAddress Address) (*Saga, error) {
+	if orderID == "" {
+		orderID = ksuid.New().String()
+	}
+	now := time.Now().UTC()
 	o := Order{
-		ID:        ksuid.New().String(),
-		CreatedAt: time.Now().UTC(),
-		AccountID: accountID,
-		Products:  products,
+		ID:              orderID,
+		CreatedAt:       now,
+		AccountID:       accountID,
+		Products:        products,
+		ShippingAddress: shippingAddress,
 	}
 	o.TotalPrice = 0.0
 	for _, p := range products {
 		o.TotalPrice += p.Price * (float64(p.Quantity))
 	}
-	err := s.repository.PutOrder(ctx, o)
+
+	sg := &Saga{
+		Order:     o,
+		Step:      StepValidateAccount,
+		Status:    SagaRunning,
+		UpdatedAt: now,
+	}
+	if err := s.repository.CreateSaga(ctx, *sg); err != nil {
+		return nil, err
+	}
+	return sg, nil
+}
+
+// GetSaga returns the saga placing the order, however far it got.
+func (s *orderService) GetSaga(ctx context.Context, orderID string) (*Saga, error) {
+	return s.repository.GetSaga(ctx, orderID)
+}
+
+// SaveSaga records the progress of sg.
+func (s *orderService) SaveSaga(ctx context.Context, sg *Saga) error {
+	sg.UpdatedAt = time.Now().UTC()
+	return s.repository.UpdateSaga(ctx, *sg)
+}
+
+// ClaimStaleSagas returns the unfinished sagas that have not moved since
+// before, and marks them as moving so that no other replica claims them too.
+func (s *orderService) ClaimStaleSagas(ctx context.Context, before time.Time) ([]Saga, error) {
+	return s.repository.ClaimStaleSagas(ctx, before, time.Now().UTC())
+}
+
+func (s *orderService) AuthorizePayment(ctx context.Context, sg *Saga) error {
+	id, err := s.payments.Authorize(ctx, sg.Order.ID, sg.Order.AccountID, sg.Order.TotalPrice)
+	if err != nil {
+		return err
+	}
+	sg.PaymentID = id
+	return nil
+}
+
+func (s *orderService) VoidPayment(ctx context.Context, sg *Saga) error {
+	return s.payments.Void(ctx, sg.Order.ID)
+}
+
+// ConfirmOrder stores the order of sg, completes sg, records an
+// events.OrderPlaced event and issues the order's invoice, billed to billTo,
+// in one transaction.
+func (s *orderService) ConfirmOrder(ctx context.Context, sg *Saga, billTo Customer) (*Order, error) {
+	done := *sg
+	done.Step, done.Status, done.UpdatedAt = "", SagaCompleted, time.Now().UTC()
+	done.Order.Status = OrderStatusPlaced
+	e, err := events.New(events.OrderPlaced, sg.Order.AccountID, orderPlacedPayload(sg.Order))
 	if err != nil {
 		return nil, err
 	}
+	inv, err := s.repository.CompleteSaga(ctx, done, e, func(number uint64) ([]byte, []byte, error) {
+		return renderInvoice(newInvoiceDocument(s.invoices, number, done.UpdatedAt, done.Order, billTo))
+	})
+	if err != nil {
+		return nil, err
+	}
+	*sg = done
+	if inv != nil {
+		invoicesIssued.Inc()
+	}
 
-	return &o, nil
+	ordersPlaced.Inc()
+	orderValue.Observe(sg.Order.TotalPrice)
+	orderLines.Observe(float64(len(sg.Order.Products)))
+	return &sg.Order, nil
+}
+
+func orderPlacedPayload(o Order) events.OrderPlacedPayload {
+	payload := events.OrderPlacedPayload{
+		OrderID:    o.ID,
+		TotalPrice: o.TotalPrice,
+		Lines:      []events.OrderLine{},
+		PlacedAt:   o.CreatedAt,
+	}
+	for _, p := range o.Products {
+		payload.Lines = append(payload.Lines, events.OrderLine{
+			ProductID: p.ID,
+			Name:      p.Name,
+			SKU:       p.SKU,
+			Options:   p.Options,
+			Price:     p.Price,
+			Quantity:  p.Quantity,
+		})
+	}
+	return payload
+}
+
+// GetOrdersForAccount returns up to take orders of the account matching q,
+// starting after the order the after cursor points at.
+func (s *orderService) GetOrdersForAccount(ctx context.Context, accountID string, q OrderQuery, after string, take uint64) (*OrderPage, error) {
+	q.AccountID = accountID
+	return s.SearchOrders(ctx, q, after, take)
+}
+
+// SearchOrders returns up to take orders of any account matching q,
+// starting after the order the after cursor points at.
+func (s *orderService) SearchOrders(ctx context.Context, q OrderQuery, after string, take uint64) (*OrderPage, error) {
+	if take == 0 || take > 100 {
+		take = 100
+	}
+
+	orders, cursors, err := s.repository.SearchOrders(ctx, q, after, take+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &OrderPage{Orders: orders, Cursors: cursors}
+	if uint64(len(orders)) > take {
+		page.Orders, page.Cursors = orders[:take], cursors[:take]
+		page.HasNextPage = true
+	}
+	return page, nil
+}
+
+func (s *orderService) GetOrder(ctx context.Context, id string) (*Order, error) {
+	return s.repository.GetOrder(ctx, id)
+}
+
+// GetInvoice returns the invoice issued when the order was confirmed, or
+// ErrInvoiceNotFound for an order that has none.
+func (s *orderService) GetInvoice(ctx context.Context, orderID string) (*Invoice, error) {
+	return s.repository.GetInvoice(ctx, orderID)
+}
+
+// EncodeOrderCursor returns the cursor pointing just past o in an order
+// history: its placement time and id.
+func EncodeOrderCursor(o Order) string {
+	return cursor.Encode(o.CreatedAt.UTC().Format(time.RFC3339Nano), o.ID)
+}
+
+// DecodeOrderCursor unpacks a cursor made by EncodeOrderCursor. An empty
+// cursor decodes to a zero time and an empty id.
+func DecodeOrderCursor(c string) (time.Time, string, error) {
+	values, err := cursor.Decode(c)
+	if err != nil || values == nil {
+		return time.Time{}, "", err
+	}
+	if len(values) != 2 {
+		return time.Time{}, "", cursor.ErrInvalidCursor
+	}
+	createdAt, ok := values[0].(string)
+	id, ok2 := values[1].(string)
+	if !ok || !ok2 || id == "" {
+		return time.Time{}, "", cursor.ErrInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return time.Time{}, "", cursor.ErrInvalidCursor
+	}
+	return t, id, nil
 }
 
-func (s *orderService) GetOrdersForAccount(ctx context.Context, accountID string) ([]Order, error) {
-	return s.repository.GetOrdersForAccount(ctx, accountID)
+// Ping fails while the orders database in Postgres is unreachable.
+func (s *orderService) Ping(ctx context.Context) error {
+	return s.repository.Ping(ctx)
 }
@@ -0,0 +1,241 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/ksuid"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+	"github.com/sunil8777/E-commerce-microservices/events"
+)
+
+var (
+	ErrShipmentNotFound         = errs.New(errs.NotFound, "SHIPMENT_NOT_FOUND", "shipment not found")
+	ErrShipmentLineNotOnOrder   = errs.New(errs.InvalidArgument, "SHIPMENT_LINE_NOT_ON_ORDER", "shipment line is not on the order")
+	ErrShipmentQuantityExceeded = errs.New(errs.FailedPrecondition, "SHIPMENT_QUANTITY_EXCEEDED", "shipment quantity exceeds what is left to ship")
+	ErrShipmentDelivered        = errs.New(errs.FailedPrecondition, "SHIPMENT_DELIVERED", "shipment was already delivered")
+)
+
+// Statuses of an order once it starts shipping, derived from its
+// shipments by fulfilmentStatus.
+const (
+	OrderStatusPartiallyShipped = "partially_shipped"
+	OrderStatusShipped          = "shipped"
+	OrderStatusDelivered        = "delivered"
+)
+
+// Statuses of a shipment. A shipment is created ShipmentShipped and then
+// takes the status of each event recorded for it, until it is delivered.
+const (
+	ShipmentShipped        = "shipped"
+	ShipmentInTransit      = "in_transit"
+	ShipmentOutForDelivery = "out_for_delivery"
+	ShipmentDelivered      = "delivered"
+	ShipmentException      = "exception"
+)
+
+// Shipment is a parcel sent with a carrier, carrying some quantity of some
+// lines of an order. Events are its tracking history, oldest first.
+type Shipment struct {
+	ID             string
+	OrderID        string
+	Carrier        string
+	TrackingNumber string
+	Status         string
+	Lines          []ShipmentLine
+	Events         []ShipmentEvent
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// ShipmentLine is a quantity of the order line of a product, or of one of
+// its variants.
+type ShipmentLine struct {
+	ProductID string
+	VariantID string
+	Quantity  uint64
+}
+
+type ShipmentEvent struct {
+	Status      string
+	Description string
+	Location    string
+	OccurredAt  time.Time
+}
+
+// ShipmentUpdate is what Repository.UpdateShipments stores: a shipment,
+// new when Created, whose last event is the one the update added, the order
+// status derived from every shipment of the order, and events for the
+// outbox.
+type ShipmentUpdate struct {
+	Shipment    Shipment
+	Created     bool
+	OrderStatus string
+	Events      []events.Event
+}
+
+// trackingURLs are the tracking pages of well-known carriers, by lowercase
+// carrier name.
+var trackingURLs = map[string]string{
+	"ups":   "https://www.ups.com/track?tracknum=%s",
+	"usps":  "https://tools.usps.com/go/TrackConfirmAction?tLabels=%s",
+	"fedex": "https://www.fedex.com/fedextrack/?trknbr=%s",
+	"dhl":   "https://www.dhl.com/global-en/home/tracking.html?tracking-id=%s",
+}
+
+// TrackingURL is the carrier's tracking page for the shipment, or "" for
+// carriers it does not know.
+func (s Shipment) TrackingURL() string {
+	format, ok := trackingURLs[strings.ToLower(s.Carrier)]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(format, url.QueryEscape(s.TrackingNumber))
+}
+
+type lineKey struct {
+	productID string
+	variantID string
+}
+
+// fulfilmentStatus derives the status of o from its shipments: placed until
+// something ships, partially shipped until every line has shipped in full,
+// then shipped until every shipment is delivered.
+func fulfilmentStatus(o Order, shipments []Shipment) string {
+	if len(shipments) == 0 {
+		return OrderStatusPlaced
+	}
+	for _, left := range unshipped(o, shipments) {
+		if left > 0 {
+			return OrderStatusPartiallyShipped
+		}
+	}
+	for _, sh := range shipments {
+		if sh.Status != ShipmentDelivered {
+			return OrderStatusShipped
+		}
+	}
+	return OrderStatusDelivered
+}
+
+// unshipped returns the quantity of each line of o that shipments do not
+// carry.
+func unshipped(o Order, shipments []Shipment) map[lineKey]uint64 {
+	left := map[lineKey]uint64{}
+	for _, p := range o.Products {
+		left[lineKey{p.ID, p.VariantID}] += p.Quantity
+	}
+	for _, sh := range shipments {
+		for _, l := range sh.Lines {
+			key := lineKey{l.ProductID, l.VariantID}
+			if left[key] >= l.Quantity {
+				left[key] -= l.Quantity
+			} else {
+				left[key] = 0
+			}
+		}
+	}
+	return left
+}
+
+// CreateShipment records that lines of the order left with carrier under
+// trackingNumber. Each line may ship at most the quantity that earlier
+// shipments left. An events.OrderShipped event is recorded with it.
+func (s *orderService) CreateShipment(ctx context.Context, orderID string, carrier string, trackingNumber string, lines []ShipmentLine) (*Shipment, error) {
+	now := time.Now().UTC()
+	sh := Shipment{
+		ID:             ksuid.New().String(),
+		OrderID:        orderID,
+		Carrier:        carrier,
+		TrackingNumber: trackingNumber,
+		Status:         ShipmentShipped,
+		Lines:          lines,
+		Events: []ShipmentEvent{{
+			Status:      ShipmentShipped,
+			Description: "Handed to " + carrier,
+			OccurredAt:  now,
+		}},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err := s.repository.UpdateShipments(ctx, orderID, func(o Order, shipments []Shipment) (*ShipmentUpdate, error) {
+		left := unshipped(o, shipments)
+		for _, l := range lines {
+			remaining, ok := left[lineKey{l.ProductID, l.VariantID}]
+			if !ok {
+				return nil, ErrShipmentLineNotOnOrder.With("productId", l.ProductID).With("variantId", l.VariantID)
+			}
+			if l.Quantity > remaining {
+				return nil, ErrShipmentQuantityExceeded.With("productId", l.ProductID).With("remaining", strconv.FormatUint(remaining, 10))
+			}
+		}
+
+		e, err := events.New(events.OrderShipped, o.AccountID, events.OrderShippedPayload{
+			OrderID:        o.ID,
+			Carrier:        sh.Carrier,
+			TrackingNumber: sh.TrackingNumber,
+			TrackingURL:    sh.TrackingURL(),
+			ShippedAt:      now,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &ShipmentUpdate{
+			Shipment:    sh,
+			Created:     true,
+			OrderStatus: fulfilmentStatus(o, append(shipments, sh)),
+			Events:      []events.Event{e},
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	shipmentsCreated.Inc()
+	return &sh, nil
+}
+
+// AddShipmentEvent appends e to the tracking history of the shipment and
+// moves the shipment to its status, unless the shipment was delivered. A
+// zero OccurredAt is taken to be now.
+func (s *orderService) AddShipmentEvent(ctx context.Context, shipmentID string, e ShipmentEvent) (*Shipment, error) {
+	current, err := s.repository.GetShipment(ctx, shipmentID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = now
+	}
+
+	var updated Shipment
+	err = s.repository.UpdateShipments(ctx, current.OrderID, func(o Order, shipments []Shipment) (*ShipmentUpdate, error) {
+		for i, sh := range shipments {
+			if sh.ID != shipmentID {
+				continue
+			}
+			if sh.Status == ShipmentDelivered {
+				return nil, ErrShipmentDelivered.With("id", shipmentID)
+			}
+			sh.Status, sh.UpdatedAt = e.Status, now
+			sh.Events = append(sh.Events, e)
+			shipments[i], updated = sh, sh
+			return &ShipmentUpdate{Shipment: sh, OrderStatus: fulfilmentStatus(o, shipments)}, nil
+		}
+		return nil, ErrShipmentNotFound.With("id", shipmentID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// ListShipments returns the shipments of the order, oldest first.
+func (s *orderService) ListShipments(ctx context.Context, orderID string) ([]Shipment, error) {
+	return s.repository.ListShipments(ctx, orderID)
+}
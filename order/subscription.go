@@ -0,0 +1,376 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/segmentio/ksuid"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+)
+
+var (
+	ErrSubscriptionNotFound = errs.New(errs.NotFound, "SUBSCRIPTION_NOT_FOUND", "subscription not found")
+	ErrSubscriptionStatus   = errs.New(errs.FailedPrecondition, "SUBSCRIPTION_STATUS_INVALID", "subscription is not in a status that allows this")
+)
+
+// Statuses of a subscription. Only active subscriptions place orders; a
+// paused one can be resumed, a cancelled one cannot.
+const (
+	SubscriptionActive    = "active"
+	SubscriptionPaused    = "paused"
+	SubscriptionCancelled = "cancelled"
+)
+
+// Units of a subscription interval.
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+const (
+	// subscriptionPollInterval is how often the scheduler looks for
+	// subscriptions that are due.
+	subscriptionPollInterval = time.Minute
+	// subscriptionLease is how long a scheduler holds the subscription it
+	// claims before another replica may take it up again. It is well above
+	// sagaTimeout so that the order being placed finishes first.
+	subscriptionLease = 2 * time.Minute
+	// subscriptionRetryBackoff is how long after its first failure a run is
+	// retried. It doubles with each further failure, up to
+	// subscriptionMaxRetryBackoff.
+	subscriptionRetryBackoff    = time.Hour
+	subscriptionMaxRetryBackoff = 24 * time.Hour
+	// subscriptionMaxFailures is how many times a run is tried before it is
+	// skipped and the subscription waits for its next run.
+	subscriptionMaxFailures = 5
+)
+
+// Subscription reorders the same lines for an account every interval,
+// starting at StartsAt. NextRunAt is when the next order is due. DueAt is
+// when the scheduler next takes the subscription up: NextRunAt, unless the
+// run failed Failures times and waits to be retried. Attempt numbers the
+// orders tried for runs and only moves on once the order of the current
+// one has failed. LastFailure is the reason the latest run failed, until a
+// run places an order.
+type Subscription struct {
+	ID              string
+	AccountID       string
+	Status          string
+	Lines           []SubscriptionLine
+	ShippingAddress Address
+	Interval        Interval
+	StartsAt        time.Time
+	NextRunAt       time.Time
+	DueAt           time.Time
+	Attempt         uint32
+	Failures        uint32
+	LastFailure     string
+	LastOrderID     string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// SubscriptionLine is a quantity of a product, or of one of its variants,
+// ordered on every run.
+type SubscriptionLine struct {
+	ProductID string
+	VariantID string
+	Quantity  uint64
+}
+
+// Interval is Count days, weeks or months, by Unit.
+type Interval struct {
+	Unit  string
+	Count uint32
+}
+
+// run returns the nth run of the subscription, the first being StartsAt.
+func (sub Subscription) run(n int) time.Time {
+	k := n * int(sub.Interval.Count)
+	switch sub.Interval.Unit {
+	case IntervalDay:
+		return sub.StartsAt.AddDate(0, 0, k)
+	case IntervalWeek:
+		return sub.StartsAt.AddDate(0, 0, 7*k)
+	default:
+		return addMonths(sub.StartsAt, k)
+	}
+}
+
+// runAfter returns the first run of the subscription after t. Runs missed
+// while the subscription was paused or failing are not made up.
+func (sub Subscription) runAfter(t time.Time) time.Time {
+	for n := 0; ; n++ {
+		if run := sub.run(n); run.After(t) {
+			return run
+		}
+	}
+}
+
+// runKey is the idempotency key of the order the subscription places next.
+// It names the run and the attempt at it, and stays the same until that
+// order has failed: a scheduler that takes the subscription up again while
+// the order is still being placed, or after stopping before recording it,
+// gets that order instead of placing another.
+func (sub Subscription) runKey() string {
+	return fmt.Sprintf("subscription/%s/%d/%d", sub.ID, sub.NextRunAt.UnixNano(), sub.Attempt)
+}
+
+// addMonths adds months to t, keeping its day unless the month is shorter,
+// so that a subscription starting on the 31st runs on the last day of
+// shorter months.
+func addMonths(t time.Time, months int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); d > last {
+		d = last
+	}
+	return first.AddDate(0, 0, d-1)
+}
+
+// retryBackoff returns how long to wait before retrying a run that failed
+// failures times.
+func retryBackoff(failures uint32) time.Duration {
+	backoff := subscriptionRetryBackoff
+	for i := uint32(1); i < failures && backoff < subscriptionMaxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > subscriptionMaxRetryBackoff {
+		backoff = subscriptionMaxRetryBackoff
+	}
+	return backoff
+}
+
+// CreateSubscription subscribes the account to lines every interval. The
+// first order is due at startsAt, or right away when it is zero.
+func (s *orderService) CreateSubscription(ctx context.Context, accountID string, lines []SubscriptionLine, shippingAddress Address, interval Interval, startsAt time.Time) (*Subscription, error) {
+	now := time.Now().UTC()
+	if startsAt.IsZero() {
+		startsAt = now
+	}
+	sub := Subscription{
+		ID:              ksuid.New().String(),
+		AccountID:       accountID,
+		Status:          SubscriptionActive,
+		Lines:           lines,
+		ShippingAddress: shippingAddress,
+		Interval:        interval,
+		StartsAt:        startsAt.UTC(),
+		NextRunAt:       startsAt.UTC(),
+		DueAt:           startsAt.UTC(),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := s.repository.CreateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// ListSubscriptions returns the subscriptions of the account, oldest first.
+func (s *orderService) ListSubscriptions(ctx context.Context, accountID string) ([]Subscription, error) {
+	return s.repository.ListSubscriptions(ctx, accountID)
+}
+
+// PauseSubscription stops an active subscription from placing orders.
+func (s *orderService) PauseSubscription(ctx context.Context, id string) (*Subscription, error) {
+	return s.moveSubscription(ctx, id, func(sub *Subscription, now time.Time) error {
+		if sub.Status != SubscriptionActive {
+			return ErrSubscriptionStatus.With("id", id).With("status", sub.Status)
+		}
+		sub.Status = SubscriptionPaused
+		return nil
+	})
+}
+
+// ResumeSubscription makes a paused subscription active again. Runs that
+// fell due while it was paused are skipped, and a run it was retrying
+// starts over.
+func (s *orderService) ResumeSubscription(ctx context.Context, id string) (*Subscription, error) {
+	return s.moveSubscription(ctx, id, func(sub *Subscription, now time.Time) error {
+		if sub.Status != SubscriptionPaused {
+			return ErrSubscriptionStatus.With("id", id).With("status", sub.Status)
+		}
+		if !sub.NextRunAt.After(now) {
+			sub.NextRunAt = sub.runAfter(now)
+		}
+		sub.Status, sub.DueAt, sub.Failures = SubscriptionActive, sub.NextRunAt, 0
+		return nil
+	})
+}
+
+// CancelSubscription ends an active or paused subscription for good.
+func (s *orderService) CancelSubscription(ctx context.Context, id string) (*Subscription, error) {
+	return s.moveSubscription(ctx, id, func(sub *Subscription, now time.Time) error {
+		if sub.Status == SubscriptionCancelled {
+			return ErrSubscriptionStatus.With("id", id).With("status", sub.Status)
+		}
+		sub.Status = SubscriptionCancelled
+		return nil
+	})
+}
+
+// moveSubscription applies move to the subscription under the lock of
+// Repository.UpdateSubscription and returns it as stored.
+func (s *orderService) moveSubscription(ctx context.Context, id string, move func(sub *Subscription, now time.Time) error) (*Subscription, error) {
+	var updated Subscription
+	err := s.repository.UpdateSubscription(ctx, id, func(sub *Subscription) error {
+		now := time.Now().UTC()
+		if err := move(sub, now); err != nil {
+			return err
+		}
+		sub.UpdatedAt = now
+		updated = *sub
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// ClaimDueSubscription returns an active subscription due at now, held for
+// subscriptionLease so that other schedulers leave it alone, or nil when
+// none is due.
+func (s *orderService) ClaimDueSubscription(ctx context.Context, now time.Time) (*Subscription, error) {
+	return s.repository.ClaimDueSubscription(ctx, now, now.Add(subscriptionLease))
+}
+
+// RecordSubscriptionRun records the outcome of the run of the subscription
+// at now: the order it placed, or runErr, which must be final. A placed
+// order schedules the next run. A failed run is retried with backoff as a
+// new attempt, and skipped once it has failed subscriptionMaxFailures
+// times.
+func (s *orderService) RecordSubscriptionRun(ctx context.Context, id string, now time.Time, orderID string, runErr error) (*Subscription, error) {
+	var outcome string
+	var updated Subscription
+	err := s.repository.UpdateSubscription(ctx, id, func(sub *Subscription) error {
+		switch {
+		case runErr == nil:
+			outcome = "placed"
+			sub.LastOrderID, sub.LastFailure, sub.Failures = orderID, "", 0
+			sub.NextRunAt = sub.runAfter(now)
+			sub.DueAt = sub.NextRunAt
+		case sub.Failures+1 >= subscriptionMaxFailures:
+			outcome = "skipped"
+			sub.LastFailure, sub.Failures = errs.From(runErr).Reason, 0
+			sub.NextRunAt = sub.runAfter(now)
+			sub.DueAt = sub.NextRunAt
+		default:
+			outcome = "failed"
+			sub.LastFailure, sub.Failures = errs.From(runErr).Reason, sub.Failures+1
+			sub.DueAt = now.Add(retryBackoff(sub.Failures))
+		}
+		if runErr != nil {
+			sub.Attempt++
+		}
+		sub.UpdatedAt = now
+		updated = *sub
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptionRuns.WithLabelValues(outcome).Inc()
+	return &updated, nil
+}
+
+// PlaceOrderFunc places an order the way PostOrder does: pricing the lines
+// from the catalog, then running the placement saga. It places at most one
+// order per account and idempotencyKey.
+type PlaceOrderFunc func(ctx context.Context, idempotencyKey string, accountID string, products []OrderedProduct, shippingAddress Address) (*Order, error)
+
+// Scheduler places the orders of subscriptions as they fall due.
+type Scheduler struct {
+	service Service
+	place   PlaceOrderFunc
+}
+
+func NewScheduler(s Service, place PlaceOrderFunc) *Scheduler {
+	return &Scheduler{service: s, place: place}
+}
+
+// Run runs the subscriptions that are due right away and then every
+// interval, until ctx is done.
+func (sc *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	for {
+		if _, err := sc.RunDue(ctx, time.Now().UTC()); err != nil && ctx.Err() == nil {
+			log.Println("Error running due subscriptions:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// RunDue claims the subscriptions due at now one at a time, places an order
+// for each and records the outcome. It returns the subscriptions as
+// recorded, and fails only when it cannot claim them. A failed order is
+// recorded for retry. An order that may still be placed, and a run that
+// cannot be recorded, are logged and left to be taken up again with the
+// same key once the lease runs out.
+func (sc *Scheduler) RunDue(ctx context.Context, now time.Time) ([]Subscription, error) {
+	recorded := []Subscription{}
+	for {
+		sub, err := sc.service.ClaimDueSubscription(ctx, now)
+		if err != nil {
+			return recorded, err
+		}
+		if sub == nil {
+			return recorded, nil
+		}
+
+		products := []OrderedProduct{}
+		for _, l := range sub.Lines {
+			products = append(products, OrderedProduct{ID: l.ProductID, VariantID: l.VariantID, Quantity: l.Quantity})
+		}
+
+		var orderID string
+		o, runErr := sc.place(ctx, sub.runKey(), sub.AccountID, products, sub.ShippingAddress)
+		if runErr != nil {
+			log.Printf("Subscription %s: %v", sub.ID, runErr)
+			if o, runErr = sc.settled(ctx, *sub, runErr); o == nil && runErr == nil {
+				continue
+			}
+		}
+		if runErr == nil {
+			orderID = o.ID
+		}
+
+		r, err := sc.service.RecordSubscriptionRun(ctx, sub.ID, now, orderID, runErr)
+		if err != nil {
+			log.Printf("Error recording run of subscription %s: %v", sub.ID, err)
+			continue
+		}
+		recorded = append(recorded, *r)
+	}
+}
+
+// settled tells what became of the order of sub that placing failed to
+// return with runErr. It returns the order if its saga completed after
+// all, runErr if the order failed or was never started, and neither while
+// the saga may still go on: it is then running or compensating, or cannot
+// be looked up.
+func (sc *Scheduler) settled(ctx context.Context, sub Subscription, runErr error) (*Order, error) {
+	sg, err := sc.service.GetSaga(ctx, IdempotentOrderID(sub.AccountID, sub.runKey()))
+	switch {
+	case errors.Is(err, ErrOrderNotFound):
+		return nil, runErr
+	case err != nil:
+		log.Printf("Error looking up order of subscription %s: %v", sub.ID, err)
+		return nil, nil
+	case sg.Status == SagaCompleted:
+		return &sg.Order, nil
+	case sg.Status == SagaFailed:
+		return nil, runErr
+	}
+	return nil, nil
+}
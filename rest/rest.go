@@ -0,0 +1,111 @@
+package rest
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/textproto"
+	"strconv"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/segmentio/ksuid"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+	"github.com/sunil8777/E-commerce-microservices/grpcopts"
+	"github.com/sunil8777/E-commerce-microservices/shutdown"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const requestIDHeader = "X-Request-Id"
+
+// Gateway describes the REST/JSON mapping of one gRPC service, as generated
+// by protoc-gen-grpc-gateway and protoc-gen-openapiv2 from the
+// google.api.http annotations in its proto.
+type Gateway struct {
+	// Service is the fully qualified gRPC service name.
+	Service string
+	// Register is the generated Register<Service>Handler function.
+	Register func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error
+	// OpenAPI is the generated OpenAPI document, served at /openapi.json.
+	OpenAPI []byte
+	// Handlers are served next to the mapping, each at the path pattern it
+	// is keyed by, such as the files the service hands out URLs to.
+	Handlers map[string]http.Handler
+}
+
+// ListenHTTP serves g on port until ctx is done. Requests are transcoded to
+// gRPC and sent to the service's own gRPC server at grpcAddr, so they pass
+// through the same interceptors as every other call. It serves HTTPS when
+// tlsConfig is not nil.
+func (g Gateway) ListenHTTP(ctx context.Context, grpcAddr string, port int, tlsConfig *tls.Config) error {
+	conn, err := grpc.NewClient(grpcopts.Target(grpcAddr), grpcopts.New(g.Service).DialOptions()...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	gw := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+		runtime.WithIncomingHeaderMatcher(headerMatcher),
+		runtime.WithErrorHandler(errorHandler),
+	)
+	if err := g.Register(ctx, gw, conn); err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(g.OpenAPI)
+	})
+	for pattern, h := range g.Handlers {
+		mux.Handle(pattern, h)
+	}
+	mux.Handle("/", withRequestID(gw))
+
+	return shutdown.ServeHTTP(ctx, &http.Server{
+		Addr:      ":" + strconv.Itoa(port),
+		Handler:   mux,
+		TLSConfig: tlsConfig,
+	})
+}
+
+// headerMatcher forwards X-Request-Id as the request ID metadata on top of
+// the headers grpc-gateway forwards by default.
+func headerMatcher(key string) (string, bool) {
+	if textproto.CanonicalMIMEHeaderKey(key) == requestIDHeader {
+		return grpcopts.RequestIDKey, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// errorHandler writes errors as a google.rpc.Status with the HTTP status
+// matching its code. The client interceptors have already turned the
+// service's status into an *errs.Error, so it is converted back first to
+// keep the reason and metadata in the ErrorInfo detail. Errors raised by
+// the gateway itself, such as a malformed body, are left as they are.
+func errorHandler(ctx context.Context, mux *runtime.ServeMux, m runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	var e *errs.Error
+	if errors.As(err, &e) {
+		err = errs.ToStatus(e)
+	}
+	runtime.DefaultHTTPErrorHandler(ctx, mux, m, w, r, err)
+}
+
+// withRequestID gives requests without an X-Request-Id a new one and echoes
+// it in the response, as the GraphQL gateway does.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" {
+			id = ksuid.New().String()
+			r.Header.Set(requestIDHeader, id)
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,119 @@
+package retry
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/sunil8777/E-commerce-microservices/errs"
+	"google.golang.org/grpc"
+)
+
+var ErrCircuitOpen = errs.New(errs.Unavailable, "CIRCUIT_OPEN", "downstream service is unavailable")
+
+type breakerState int
+
+const (
+	closed breakerState = iota
+	open
+	halfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case open:
+		return "open"
+	case halfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// Breaker is a circuit breaker guarding calls to one downstream service.
+// After Threshold consecutive transient failures it opens and fails calls
+// immediately with ErrCircuitOpen for Cooldown, then lets a single trial
+// call through: success closes it again, failure re-opens it. Only Transient
+// errors count as failures, so a NotFound from a healthy service never trips
+// it.
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	name     string
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+func NewBreaker(name string) *Breaker {
+	return &Breaker{
+		Threshold: 5,
+		Cooldown:  10 * time.Second,
+		name:      name,
+	}
+}
+
+// Do runs fn unless the breaker is open and records its outcome.
+func (b *Breaker) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+	err := fn(ctx)
+	b.record(err)
+	return err
+}
+
+// DialOption guards every unary RPC made over a connection with b.
+func (b *Breaker) DialOption() grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return b.Do(ctx, func(ctx context.Context) error {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		})
+	})
+}
+
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case open:
+		if time.Since(b.openedAt) < b.Cooldown {
+			return ErrCircuitOpen.With("service", b.name)
+		}
+		b.setState(halfOpen)
+		return nil
+	case halfOpen:
+		// A trial call is already in flight.
+		return ErrCircuitOpen.With("service", b.name)
+	}
+	return nil
+}
+
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil || !Transient(err) {
+		b.failures = 0
+		if b.state != closed {
+			b.setState(closed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == halfOpen || b.failures >= b.Threshold {
+		b.openedAt = time.Now()
+		b.setState(open)
+	}
+}
+
+func (b *Breaker) setState(s breakerState) {
+	if b.state != s {
+		log.Printf("circuit breaker %s: %s -> %s", b.name, b.state, s)
+	}
+	b.state = s
+}
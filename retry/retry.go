@@ -0,0 +1,155 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/sunil8777/E-commerce-microservices/errs"
+)
+
+// Policy describes how Do spaces out and gives up on attempts. The wait
+// before attempt n+1 is InitialInterval*Multiplier^(n-1), capped at
+// MaxInterval and randomised by ±Jitter of itself so that replicas that
+// failed together do not retry in lockstep.
+type Policy struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+	Jitter          float64
+
+	// MaxAttempts and MaxElapsedTime stop retrying once reached; zero means
+	// no limit, leaving only ctx to end the loop.
+	MaxAttempts    int
+	MaxElapsedTime time.Duration
+
+	// Retryable classifies errors. Nil retries everything except errors
+	// wrapped with Permanent and context cancellation.
+	Retryable func(error) bool
+
+	// OnRetry, if set, is called before each wait.
+	OnRetry func(attempt int, err error, wait time.Duration)
+}
+
+var DefaultPolicy = Policy{
+	InitialInterval: 500 * time.Millisecond,
+	MaxInterval:     30 * time.Second,
+	Multiplier:      2,
+	Jitter:          0.5,
+}
+
+// Connect is the policy binaries use to reach their backends at startup: it
+// never gives up on its own, logs which backend it is waiting for, and stops
+// as soon as ctx is cancelled.
+func Connect(backend string) Policy {
+	p := DefaultPolicy
+	p.MaxInterval = 10 * time.Second
+	p.OnRetry = func(attempt int, err error, wait time.Duration) {
+		log.Printf("retrying to connect with %s in %s (attempt %d): %v", backend, wait.Round(time.Millisecond), attempt, err)
+	}
+	return p
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying. Do returns the wrapped error.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err}
+}
+
+// IsPermanent reports whether err, or an error it wraps, was marked with
+// Permanent.
+func IsPermanent(err error) bool {
+	var perm *permanentError
+	return errors.As(err, &perm)
+}
+
+// Transient reports whether err is a failure that may go away on its own:
+// an unavailable or overloaded service, or a deadline that ran out. It
+// understands both *errs.Error values and raw gRPC statuses.
+func Transient(err error) bool {
+	switch errs.CodeOf(errs.FromStatus(err)) {
+	case errs.Unavailable, errs.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// Do calls fn until it succeeds, returns a non-retryable error, the policy
+// gives up, or ctx is done. It returns the last error from fn, or ctx.Err()
+// if ctx ended the loop.
+func Do(ctx context.Context, p Policy, fn func(ctx context.Context) error) error {
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			return perm.err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if p.Retryable != nil && !p.Retryable(err) {
+			return err
+		}
+		if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+			return err
+		}
+
+		wait := p.Backoff(attempt)
+		if p.MaxElapsedTime > 0 && time.Since(start)+wait > p.MaxElapsedTime {
+			return err
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, wait)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Backoff returns how long to wait after attempt number attempt, counting
+// from 1, has failed. It is the wait Do uses, for callers that schedule
+// their attempts themselves, such as from a persisted queue.
+func (p Policy) Backoff(attempt int) time.Duration {
+	interval := p.InitialInterval
+	for i := 1; i < attempt; i++ {
+		if p.Multiplier > 1 {
+			interval = time.Duration(float64(interval) * p.Multiplier)
+		}
+		if p.MaxInterval > 0 && interval > p.MaxInterval {
+			interval = p.MaxInterval
+			break
+		}
+	}
+	return jitter(interval, p.Jitter)
+}
+
+func jitter(d time.Duration, factor float64) time.Duration {
+	if factor <= 0 {
+		return d
+	}
+	delta := factor * float64(d)
+	return time.Duration(float64(d) - delta + rand.Float64()*2*delta)
+}
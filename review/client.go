@@ -0,0 +1,112 @@
+package review
+
+import (
+	"context"
+	"time"
+
+	"github.com/sunil8777/E-commerce-microservices/grpcopts"
+	"github.com/sunil8777/E-commerce-microservices/healthcheck"
+	pb "github.com/sunil8777/E-commerce-microservices/review/pb"
+	"google.golang.org/grpc"
+)
+
+type Client struct {
+	conn    *grpc.ClientConn
+	service pb.ReviewServiceClient
+}
+
+// NewClient dials url with the shared grpcopts defaults. opts are applied
+// after them, for example to guard the connection with a retry.Breaker.
+func NewClient(url string, opts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.NewClient(
+		grpcopts.Target(url),
+		grpcopts.New(pb.ReviewService_ServiceDesc.ServiceName).
+			Idempotent("GetReviewsForProduct", "ModerateReview").
+			DialOptions(opts...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	c := pb.NewReviewServiceClient(conn)
+	return &Client{conn, c}, nil
+}
+
+func (c *Client) Close() {
+	c.conn.Close()
+}
+
+// HealthCheck checks that the review service answers SERVING.
+func (c *Client) HealthCheck(ctx context.Context) error {
+	return healthcheck.Check(ctx, c.conn, "")
+}
+
+func (c *Client) PostReview(ctx context.Context, productID, accountID string, rating uint32, body string) (*Review, error) {
+	r, err := c.service.PostReview(
+		ctx,
+		&pb.PostReviewRequest{
+			ProductId: productID,
+			AccountId: accountID,
+			Rating:    rating,
+			Body:      body,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	review := reviewFromProto(r.Review)
+	return &review, nil
+}
+
+func (c *Client) GetReviewsForProduct(ctx context.Context, productID, status string, skip uint64, take uint64) ([]Review, error) {
+	r, err := c.service.GetReviewsForProduct(
+		ctx,
+		&pb.GetReviewsForProductRequest{
+			ProductId: productID,
+			Status:    status,
+			Skip:      skip,
+			Take:      take,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews := []Review{}
+	for _, rv := range r.Reviews {
+		reviews = append(reviews, reviewFromProto(rv))
+	}
+	return reviews, nil
+}
+
+func (c *Client) ModerateReview(ctx context.Context, id, status string) (*Review, error) {
+	r, err := c.service.ModerateReview(
+		ctx,
+		&pb.ModerateReviewRequest{
+			Id:     id,
+			Status: status,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	review := reviewFromProto(r.Review)
+	return &review, nil
+}
+
+func reviewFromProto(r *pb.Review) Review {
+	createdAt := time.Time{}
+	createdAt.UnmarshalBinary(r.CreatedAt)
+
+	return Review{
+		ID:        r.Id,
+		ProductID: r.ProductId,
+		AccountID: r.AccountId,
+		Rating:    r.Rating,
+		Body:      r.Body,
+		Status:    r.Status,
+		CreatedAt: createdAt,
+	}
+}
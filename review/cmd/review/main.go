@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/sunil8777/E-commerce-microservices/grpcopts"
+	"github.com/sunil8777/E-commerce-microservices/metrics"
+	"github.com/sunil8777/E-commerce-microservices/migrate"
+	"github.com/sunil8777/E-commerce-microservices/retry"
+	"github.com/sunil8777/E-commerce-microservices/review"
+	"github.com/sunil8777/E-commerce-microservices/shutdown"
+	"github.com/sunil8777/E-commerce-microservices/tlsconfig"
+	"github.com/sunil8777/E-commerce-microservices/tracing"
+)
+
+type Config struct {
+	DatabaseURL     string `envconfig:"DATABASE_URL"`
+	OrderURL        string `envconfig:"ORDER_SERVICE_URL"`
+	CatalogURL      string `envconfig:"CATALOG_SERVICE_URL"`
+	TracingExporter string `envconfig:"TRACING_EXPORTER" default:"none"`
+	MetricsPort     int    `envconfig:"METRICS_PORT" default:"9090"`
+
+	TLS tlsconfig.Config
+}
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves until SIGINT or SIGTERM and then unwinds in reverse order of
+// startup: drain the gRPC server, close the repository, flush traces.
+func run() error {
+	var cfg Config
+	err := envconfig.Process("", &cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := shutdown.Context()
+	defer stop()
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		return migrateDatabase(ctx, cfg.DatabaseURL, os.Args[2:])
+	}
+
+	if _, err := grpcopts.ConfigureTLS(ctx, cfg.TLS); err != nil {
+		return err
+	}
+
+	shutdownTracing, err := tracing.Init(ctx, "review", cfg.TracingExporter)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), shutdown.Timeout)
+		defer cancel()
+		shutdownTracing(flushCtx)
+	}()
+
+	var r review.Repository
+	err = retry.Do(ctx, retry.Connect("review database"), func(ctx context.Context) error {
+		r, err = review.NewPostgresRepository(cfg.DatabaseURL)
+		if errors.Is(err, migrate.ErrSchemaMismatch) {
+			return retry.Permanent(err)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	go func() {
+		if err := metrics.ListenHTTP(ctx, cfg.MetricsPort); err != nil {
+			log.Println(err)
+		}
+	}()
+
+	log.Println("Listening on port 8080...")
+	s := review.NewService(r)
+	return review.ListenGRPC(ctx, s, cfg.OrderURL, cfg.CatalogURL, 8080)
+}
+
+// migrateDatabase runs "review migrate [args]": it moves the database schema to
+// the version this binary expects, or to the one given in args, and exits.
+// See migrate.Command for the arguments.
+func migrateDatabase(ctx context.Context, url string, args []string) error {
+	var m *migrate.Migrator
+	err := retry.Do(ctx, retry.Connect("review database"), func(ctx context.Context) error {
+		var err error
+		m, err = migrate.Open(url, review.Migrations)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	return migrate.Command(ctx, m, args)
+}
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS reviews (
+  id CHAR(27) PRIMARY KEY,
+  product_id CHAR(27) NOT NULL,
+  account_id CHAR(27) NOT NULL,
+  rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+  body TEXT NOT NULL,
+  status VARCHAR(16) NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  UNIQUE (product_id, account_id)
+);
+
+CREATE INDEX IF NOT EXISTS reviews_product_status_idx ON reviews (product_id, status);
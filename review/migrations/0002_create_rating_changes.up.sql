@@ -0,0 +1,10 @@
+-- A product is listed here from the moment a moderation decision changes
+-- its rating until the catalog has been sent a rating that includes the
+-- decision. version counts the decisions, so a rating sent while another
+-- one is made does not clear the row, and claimed_until keeps replicas from
+-- sending the same product at once.
+CREATE TABLE IF NOT EXISTS rating_changes (
+  product_id CHAR(27) PRIMARY KEY,
+  version BIGINT NOT NULL,
+  claimed_until TIMESTAMP WITH TIME ZONE NOT NULL
+);
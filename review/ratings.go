@@ -0,0 +1,29 @@
+package review
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// ratingSyncInterval is how often ratings that could not be sent to the
+// catalog when their reviews were moderated are sent again.
+const ratingSyncInterval = 30 * time.Second
+
+// runRatingSync sends changed ratings every interval until ctx is cancelled.
+func runRatingSync(ctx context.Context, s Service, send RatingSender, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.SyncRatings(ctx, send); err != nil {
+			log.Println("Error syncing product ratings:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
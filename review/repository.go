@@ -0,0 +1,281 @@
+package review
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sunil8777/E-commerce-microservices/metrics"
+	"github.com/sunil8777/E-commerce-microservices/migrate"
+	"github.com/sunil8777/E-commerce-microservices/tracing"
+)
+
+// uniqueViolation is the Postgres error code for a UNIQUE constraint failure.
+const uniqueViolation = "23505"
+
+type Repository interface {
+	Close()
+	Ping(ctx context.Context) error
+	PutReview(ctx context.Context, r Review) error
+	ListReviewsForProduct(ctx context.Context, productID, status string, skip uint64, take uint64) ([]Review, error)
+	// UpdateReviewStatus also records, in the same transaction, a change of
+	// the rating of the review's product, to be sent to the catalog.
+	UpdateReviewStatus(ctx context.Context, id, status string) (*Review, error)
+	GetProductRating(ctx context.Context, productID string) (*Rating, error)
+	// ClaimRatingChanges claims up to limit rating changes that are not
+	// claimed at now until until, only the change of productID if it is not
+	// empty.
+	ClaimRatingChanges(ctx context.Context, productID string, now time.Time, until time.Time, limit uint64) ([]RatingChange, error)
+	// CompleteRatingChange removes c once its rating has been sent, unless
+	// the rating changed again meanwhile, in which case c is released.
+	CompleteRatingChange(ctx context.Context, c RatingChange) error
+	// ReleaseRatingChange drops the claim on the rating change of productID,
+	// so that it can be claimed again straight away.
+	ReleaseRatingChange(ctx context.Context, productID string) error
+}
+
+type postgresRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresRepository(url string) (Repository, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Ping()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = migrate.New(db, Migrations).Check(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &postgresRepository{db}, nil
+}
+
+func (r *postgresRepository) Close() {
+	r.db.Close()
+}
+
+func (r *postgresRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+func (r *postgresRepository) PutReview(ctx context.Context, rv Review) (err error) {
+	ctx, span := tracing.Start(ctx, "review.repository.PutReview")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "review.PutReview")(&err)
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`INSERT INTO reviews(id, product_id, account_id, rating, body, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		rv.ID,
+		rv.ProductID,
+		rv.AccountID,
+		rv.Rating,
+		rv.Body,
+		rv.Status,
+		rv.CreatedAt,
+	)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return ErrAlreadyReviewed
+	}
+	return err
+}
+
+func (r *postgresRepository) ListReviewsForProduct(ctx context.Context, productID, status string, skip uint64, take uint64) (_ []Review, err error) {
+	ctx, span := tracing.Start(ctx, "review.repository.ListReviewsForProduct")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "review.ListReviewsForProduct")(&err)
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, product_id, account_id, rating, body, status, created_at FROM reviews
+		WHERE product_id = $1 AND status = $2
+		ORDER BY id DESC OFFSET $3 LIMIT $4`,
+		productID,
+		status,
+		skip,
+		take,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []Review{}
+	for rows.Next() {
+		rv := Review{}
+		if err = rows.Scan(
+			&rv.ID,
+			&rv.ProductID,
+			&rv.AccountID,
+			&rv.Rating,
+			&rv.Body,
+			&rv.Status,
+			&rv.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, rv)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+func (r *postgresRepository) UpdateReviewStatus(ctx context.Context, id, status string) (_ *Review, err error) {
+	ctx, span := tracing.Start(ctx, "review.repository.UpdateReviewStatus")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "review.UpdateReviewStatus")(&err)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	row := tx.QueryRowContext(
+		ctx,
+		`UPDATE reviews SET status = $2 WHERE id = $1
+		RETURNING id, product_id, account_id, rating, body, status, created_at`,
+		id,
+		status,
+	)
+
+	rv := Review{}
+	if err = row.Scan(
+		&rv.ID,
+		&rv.ProductID,
+		&rv.AccountID,
+		&rv.Rating,
+		&rv.Body,
+		&rv.Status,
+		&rv.CreatedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound.With("id", id)
+		}
+		return nil, err
+	}
+
+	// A claim in progress is left alone: its rating may predate this
+	// decision, but the new version keeps it from completing the change.
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO rating_changes (product_id, version, claimed_until) VALUES ($1, 1, '-infinity')
+		ON CONFLICT (product_id) DO UPDATE SET version = rating_changes.version + 1`,
+		rv.ProductID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+func (r *postgresRepository) GetProductRating(ctx context.Context, productID string) (_ *Rating, err error) {
+	ctx, span := tracing.Start(ctx, "review.repository.GetProductRating")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "review.GetProductRating")(&err)
+
+	row := r.db.QueryRowContext(
+		ctx,
+		`SELECT COALESCE(AVG(rating), 0), COUNT(*) FROM reviews
+		WHERE product_id = $1 AND status = 'approved'`,
+		productID,
+	)
+
+	rating := Rating{}
+	if err := row.Scan(&rating.Average, &rating.Count); err != nil {
+		return nil, err
+	}
+	return &rating, nil
+}
+
+// ClaimRatingChanges uses SKIP LOCKED so that two replicas never claim the
+// same change.
+func (r *postgresRepository) ClaimRatingChanges(ctx context.Context, productID string, now time.Time, until time.Time, limit uint64) (_ []RatingChange, err error) {
+	ctx, span := tracing.Start(ctx, "review.repository.ClaimRatingChanges")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "review.ClaimRatingChanges")(&err)
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`UPDATE rating_changes SET claimed_until = $2
+		WHERE product_id IN (
+			SELECT product_id FROM rating_changes
+			WHERE claimed_until <= $1 AND ($3::text = '' OR product_id = $3::text)
+			ORDER BY product_id
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING product_id, version`,
+		now,
+		until,
+		productID,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []RatingChange{}
+	for rows.Next() {
+		c := RatingChange{}
+		if err = rows.Scan(&c.ProductID, &c.Version); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (r *postgresRepository) CompleteRatingChange(ctx context.Context, c RatingChange) (err error) {
+	ctx, span := tracing.Start(ctx, "review.repository.CompleteRatingChange")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "review.CompleteRatingChange")(&err)
+
+	res, err := r.db.ExecContext(
+		ctx,
+		"DELETE FROM rating_changes WHERE product_id = $1 AND version = $2",
+		c.ProductID,
+		c.Version,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	return r.ReleaseRatingChange(ctx, c.ProductID)
+}
+
+func (r *postgresRepository) ReleaseRatingChange(ctx context.Context, productID string) (err error) {
+	ctx, span := tracing.Start(ctx, "review.repository.ReleaseRatingChange")
+	defer tracing.End(span, &err)
+	defer metrics.Query("postgres", "review.ReleaseRatingChange")(&err)
+
+	_, err = r.db.ExecContext(
+		ctx,
+		"UPDATE rating_changes SET claimed_until = '-infinity' WHERE product_id = $1",
+		productID,
+	)
+	return err
+}
@@ -0,0 +1,161 @@
+package review
+
+import (
+	"context"
+	"log"
+	"net"
+	"strconv"
+
+	"github.com/sunil8777/E-commerce-microservices/catalog"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+	"github.com/sunil8777/E-commerce-microservices/grpcopts"
+	"github.com/sunil8777/E-commerce-microservices/healthcheck"
+	"github.com/sunil8777/E-commerce-microservices/metrics"
+	"github.com/sunil8777/E-commerce-microservices/order"
+	"github.com/sunil8777/E-commerce-microservices/retry"
+	pb "github.com/sunil8777/E-commerce-microservices/review/pb"
+	"github.com/sunil8777/E-commerce-microservices/shutdown"
+	"github.com/sunil8777/E-commerce-microservices/tlsconfig"
+	"github.com/sunil8777/E-commerce-microservices/tracing"
+	"github.com/sunil8777/E-commerce-microservices/validation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// peerRules limits moderating reviews to the gateway, which only lets admins
+// do it. They are enforced when the server runs with mutual TLS.
+var peerRules = map[string][]string{
+	pb.ReviewService_ModerateReview_FullMethodName: {"graphql"},
+}
+
+type grpcServer struct {
+	pb.UnimplementedReviewServiceServer
+	service       Service
+	orderClient   *order.Client
+	catalogClient *catalog.Client
+}
+
+func ListenGRPC(ctx context.Context, s Service, orderURL string, catalogURL string, port int) error {
+	lis, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		return err
+	}
+	return Serve(ctx, s, orderURL, catalogURL, lis)
+}
+
+// Serve serves s on lis until ctx is done. opts are added to the dial
+// options of the order and catalog clients.
+func Serve(ctx context.Context, s Service, orderURL string, catalogURL string, lis net.Listener, opts ...grpc.DialOption) error {
+	orderClient, err := order.NewClient(orderURL, append([]grpc.DialOption{retry.NewBreaker("order").DialOption()}, opts...)...)
+	if err != nil {
+		lis.Close()
+		return err
+	}
+	defer orderClient.Close()
+
+	catalogClient, err := catalog.NewClient(catalogURL, append([]grpc.DialOption{retry.NewBreaker("catalog").DialOption()}, opts...)...)
+	if err != nil {
+		lis.Close()
+		return err
+	}
+	defer catalogClient.Close()
+
+	server := grpc.NewServer(append(
+		grpcopts.ServerOptions(),
+		grpc.ChainUnaryInterceptor(
+			metrics.UnaryServerInterceptor(),
+			errs.UnaryServerInterceptor(),
+			tlsconfig.AuthorizePeers(peerRules),
+			validation.UnaryServerInterceptor(),
+		),
+		tracing.ServerOption(),
+	)...)
+	reviews := &grpcServer{
+		service:       s,
+		orderClient:   orderClient,
+		catalogClient: catalogClient,
+	}
+	pb.RegisterReviewServiceServer(server, reviews)
+
+	synced := make(chan struct{})
+	go func() {
+		defer close(synced)
+		runRatingSync(ctx, s, reviews.sendRating, ratingSyncInterval)
+	}()
+	defer func() { <-synced }()
+
+	healthcheck.Register(ctx, server, s.Ping, pb.ReviewService_ServiceDesc.ServiceName)
+	reflection.Register(server)
+	// The clients are closed by the deferred calls above, only once the
+	// server has drained the RPCs and the rating sync has stopped using
+	// them.
+	return shutdown.ServeGRPC(ctx, server, lis)
+}
+
+func (s *grpcServer) PostReview(ctx context.Context, r *pb.PostReviewRequest) (*pb.PostReviewResponse, error) {
+	orders, err := s.orderClient.SearchOrders(ctx, order.OrderQuery{AccountID: r.AccountId, ProductID: r.ProductId}, "", 1)
+	if err != nil {
+		log.Println("Error searching account orders:", err)
+		return nil, err
+	}
+	if len(orders.Orders) == 0 {
+		return nil, ErrNotPurchased
+	}
+
+	review, err := s.service.PostReview(ctx, r.ProductId, r.AccountId, r.Rating, r.Body)
+	if err != nil {
+		log.Println("Error posting review:", err)
+		return nil, err
+	}
+
+	return &pb.PostReviewResponse{Review: reviewToProto(*review)}, nil
+}
+
+func (s *grpcServer) GetReviewsForProduct(ctx context.Context, r *pb.GetReviewsForProductRequest) (*pb.GetReviewsForProductResponse, error) {
+	res, err := s.service.GetReviewsForProduct(ctx, r.ProductId, r.Status, r.Skip, r.Take)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	reviews := []*pb.Review{}
+	for _, rv := range res {
+		reviews = append(reviews, reviewToProto(rv))
+	}
+
+	return &pb.GetReviewsForProductResponse{Reviews: reviews}, nil
+}
+
+func (s *grpcServer) ModerateReview(ctx context.Context, r *pb.ModerateReviewRequest) (*pb.ModerateReviewResponse, error) {
+	review, err := s.service.ModerateReview(ctx, r.Id, r.Status)
+	if err != nil {
+		log.Println("Error moderating review:", err)
+		return nil, err
+	}
+
+	// Only approved reviews count, so any moderation decision can move the
+	// product's aggregate rating. The change is recorded with the decision,
+	// so if the catalog cannot be told now, the rating sync tells it later.
+	if err := s.service.SyncProductRating(ctx, review.ProductID, s.sendRating); err != nil {
+		log.Println("Error updating product rating:", err)
+	}
+
+	return &pb.ModerateReviewResponse{Review: reviewToProto(*review)}, nil
+}
+
+func (s *grpcServer) sendRating(ctx context.Context, productID string, rating Rating) error {
+	return s.catalogClient.UpdateProductRating(ctx, productID, rating.Average, rating.Count)
+}
+
+func reviewToProto(r Review) *pb.Review {
+	review := &pb.Review{
+		Id:        r.ID,
+		ProductId: r.ProductID,
+		AccountId: r.AccountID,
+		Rating:    r.Rating,
+		Body:      r.Body,
+		Status:    r.Status,
+	}
+	review.CreatedAt, _ = r.CreatedAt.MarshalBinary()
+	return review
+}
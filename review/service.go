@@ -0,0 +1,186 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/segmentio/ksuid"
+	"github.com/sunil8777/E-commerce-microservices/errs"
+)
+
+var (
+	ErrInvalidRating   = errs.New(errs.InvalidArgument, "INVALID_RATING", "rating must be between 1 and 5")
+	ErrInvalidStatus   = errs.New(errs.InvalidArgument, "INVALID_REVIEW_STATUS", "invalid review status")
+	ErrNotPurchased    = errs.New(errs.FailedPrecondition, "PRODUCT_NOT_PURCHASED", "account has not ordered this product")
+	ErrNotFound        = errs.New(errs.NotFound, "REVIEW_NOT_FOUND", "review not found")
+	ErrAlreadyReviewed = errs.New(errs.AlreadyExists, "ALREADY_REVIEWED", "account has already reviewed this product")
+)
+
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+type Service interface {
+	PostReview(ctx context.Context, productID, accountID string, rating uint32, body string) (*Review, error)
+	GetReviewsForProduct(ctx context.Context, productID, status string, skip uint64, take uint64) ([]Review, error)
+	ModerateReview(ctx context.Context, id, status string) (*Review, error)
+	GetProductRating(ctx context.Context, productID string) (*Rating, error)
+	SyncProductRating(ctx context.Context, productID string, send RatingSender) error
+	SyncRatings(ctx context.Context, send RatingSender) error
+	Ping(ctx context.Context) error
+}
+
+// Review is a customer's star rating and comment on a product. New reviews
+// start out pending and only count towards the product rating once approved.
+type Review struct {
+	ID        string
+	ProductID string
+	AccountID string
+	Rating    uint32
+	Body      string
+	Status    string
+	CreatedAt time.Time
+}
+
+// Rating aggregates the approved reviews of a product.
+type Rating struct {
+	Average float64
+	Count   uint32
+}
+
+// RatingChange is a product whose rating has changed since it was last sent
+// to the catalog. Version counts the moderation decisions that changed it.
+type RatingChange struct {
+	ProductID string
+	Version   int64
+}
+
+// RatingSender sends the rating of a product to the catalog.
+type RatingSender func(ctx context.Context, productID string, rating Rating) error
+
+const (
+	// ratingChangeLease is how long a rating change is claimed for sending
+	// before another replica may take it up.
+	ratingChangeLease = time.Minute
+	// ratingSyncBatch is how many rating changes SyncRatings claims at once.
+	ratingSyncBatch = 100
+)
+
+type reviewService struct {
+	repository Repository
+}
+
+func NewService(r Repository) Service {
+	return &reviewService{r}
+}
+
+func (s *reviewService) PostReview(ctx context.Context, productID, accountID string, rating uint32, body string) (*Review, error) {
+	if rating < 1 || rating > 5 {
+		return nil, ErrInvalidRating
+	}
+
+	r := Review{
+		ID:        ksuid.New().String(),
+		ProductID: productID,
+		AccountID: accountID,
+		Rating:    rating,
+		Body:      body,
+		Status:    StatusPending,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.repository.PutReview(ctx, r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *reviewService) GetReviewsForProduct(ctx context.Context, productID, status string, skip uint64, take uint64) ([]Review, error) {
+	if status == "" {
+		status = StatusApproved
+	}
+	if !validStatus(status) {
+		return nil, ErrInvalidStatus
+	}
+	if take > 100 || (skip == 0 && take == 0) {
+		take = 100
+	}
+
+	return s.repository.ListReviewsForProduct(ctx, productID, status, skip, take)
+}
+
+func (s *reviewService) ModerateReview(ctx context.Context, id, status string) (*Review, error) {
+	if !validStatus(status) {
+		return nil, ErrInvalidStatus
+	}
+
+	return s.repository.UpdateReviewStatus(ctx, id, status)
+}
+
+func (s *reviewService) GetProductRating(ctx context.Context, productID string) (*Rating, error) {
+	return s.repository.GetProductRating(ctx, productID)
+}
+
+// SyncProductRating sends the rating of productID if a moderation decision
+// changed it and no other sync is sending it. If sending fails, the change
+// stays recorded for SyncRatings to try again.
+func (s *reviewService) SyncProductRating(ctx context.Context, productID string, send RatingSender) error {
+	now := time.Now().UTC()
+	changes, err := s.repository.ClaimRatingChanges(ctx, productID, now, now.Add(ratingChangeLease), 1)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return s.syncRating(ctx, changes[0], send)
+}
+
+// SyncRatings sends the rating of every product that a moderation decision
+// changed since it was last sent.
+func (s *reviewService) SyncRatings(ctx context.Context, send RatingSender) error {
+	now := time.Now().UTC()
+	changes, err := s.repository.ClaimRatingChanges(ctx, "", now, now.Add(ratingChangeLease), ratingSyncBatch)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, c := range changes {
+		if err := s.syncRating(ctx, c, send); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// syncRating reads the rating only after c was claimed, so a decision made
+// after the read bumps the version and keeps c from being completed.
+func (s *reviewService) syncRating(ctx context.Context, c RatingChange, send RatingSender) error {
+	rating, err := s.repository.GetProductRating(ctx, c.ProductID)
+	if err == nil {
+		err = send(ctx, c.ProductID, *rating)
+	}
+	if err != nil {
+		if releaseErr := s.repository.ReleaseRatingChange(ctx, c.ProductID); releaseErr != nil {
+			return errors.Join(err, releaseErr)
+		}
+		return err
+	}
+	return s.repository.CompleteRatingChange(ctx, c)
+}
+
+func validStatus(status string) bool {
+	switch status {
+	case StatusPending, StatusApproved, StatusRejected:
+		return true
+	}
+	return false
+}
+
+// Ping fails while the reviews database in Postgres is unreachable.
+func (s *reviewService) Ping(ctx context.Context) error {
+	return s.repository.Ping(ctx)
+}
@@ -0,0 +1,87 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Timeout is how long in-flight requests get to finish once shutdown starts.
+// Anything still running after that is cut off.
+const Timeout = 15 * time.Second
+
+// Context returns a context that is cancelled on SIGINT or SIGTERM. Binaries
+// build everything from it so that a signal unwinds them in order.
+func Context() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// ServeGRPC serves lis until ctx is done, then stops accepting RPCs and
+// waits up to Timeout for the ones in flight before closing every
+// connection. It returns nil after a clean shutdown.
+func ServeGRPC(ctx context.Context, server *grpc.Server, lis net.Listener) error {
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+
+		log.Println("shutting down gRPC server...")
+		done := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(Timeout):
+			log.Println("shutdown timed out, closing remaining connections")
+			server.Stop()
+		}
+	}()
+
+	if err := server.Serve(lis); err != nil {
+		return err
+	}
+	<-stopped
+	return nil
+}
+
+// ServeHTTP runs srv until ctx is done, then drains it within Timeout. srv
+// serves HTTPS when it has a TLSConfig.
+func ServeHTTP(ctx context.Context, srv *http.Server) error {
+	errc := make(chan error, 1)
+	go func() {
+		if srv.TLSConfig != nil {
+			errc <- srv.ListenAndServeTLS("", "")
+			return
+		}
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("shutting down HTTP server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package tlsconfig
+
+import (
+	"context"
+
+	"github.com/sunil8777/E-commerce-microservices/errs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+var ErrPeerNotAllowed = errs.New(errs.PermissionDenied, "PEER_NOT_ALLOWED", "caller is not allowed to call this method")
+
+// PeerNames returns the identities in the caller's verified client
+// certificate: its common name followed by its DNS names. ok is false when
+// the connection did not present a verified certificate.
+func PeerNames(ctx context.Context) (names []string, ok bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+
+	leaf := info.State.VerifiedChains[0][0]
+	names = append([]string{leaf.Subject.CommonName}, leaf.DNSNames...)
+	return names, true
+}
+
+// AuthorizePeers restricts the methods in rules, keyed by full method name,
+// to callers whose client certificate names one of the listed services.
+// Methods without a rule are open to every caller. The rules can only be
+// checked on connections with a verified client certificate, that is when
+// the server runs with TLS_CLIENT_AUTH; otherwise they are not enforced.
+func AuthorizePeers(rules map[string][]string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		allowed, ok := rules[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		names, ok := PeerNames(ctx)
+		if !ok {
+			return handler(ctx, req)
+		}
+		for _, name := range names {
+			for _, a := range allowed {
+				if name == a {
+					return handler(ctx, req)
+				}
+			}
+		}
+		return nil, ErrPeerNotAllowed.With("peer", names[0]).With("method", info.FullMethod)
+	}
+}
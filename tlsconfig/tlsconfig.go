@@ -0,0 +1,236 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Config is read from the environment by every binary as a field named TLS
+// of the binary's own envconfig struct, which gives the TLS_ prefix of the
+// variables. TLS is off unless CertFile or CAFile is set.
+//
+// CertFile and KeyFile are the binary's own certificate, presented both when
+// it serves and, for mutual TLS, when it calls other services. CAFile is the
+// bundle that peers' certificates are verified against; without it the
+// system roots are used. ClientAuth makes gRPC servers require a client
+// certificate signed by CAFile.
+type Config struct {
+	CertFile       string        `envconfig:"CERT_FILE"`
+	KeyFile        string        `envconfig:"KEY_FILE"`
+	CAFile         string        `envconfig:"CA_FILE"`
+	ClientAuth     bool          `envconfig:"CLIENT_AUTH"`
+	ReloadInterval time.Duration `envconfig:"RELOAD_INTERVAL" default:"30s"`
+}
+
+func (c Config) Enabled() bool {
+	return c.CertFile != "" || c.CAFile != ""
+}
+
+// Reloader holds the certificate and CA bundle named by a Config and swaps
+// in new ones when the files change on disk, so rotated certificates are
+// picked up by new connections without a restart. Connections that are
+// already established keep the certificates they were made with.
+type Reloader struct {
+	cfg Config
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	roots    *x509.CertPool
+	modTimes map[string]time.Time
+}
+
+// NewReloader loads the files named by cfg and re-checks them every
+// cfg.ReloadInterval until ctx is done.
+func NewReloader(ctx context.Context, cfg Config) (*Reloader, error) {
+	if cfg.CertFile == "" != (cfg.KeyFile == "") {
+		return nil, errors.New("tls: TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if cfg.ClientAuth && (cfg.CertFile == "" || cfg.CAFile == "") {
+		return nil, errors.New("tls: TLS_CLIENT_AUTH needs TLS_CERT_FILE, TLS_KEY_FILE and TLS_CA_FILE")
+	}
+
+	r := &Reloader{cfg: cfg}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	if cfg.ReloadInterval > 0 {
+		go r.watch(ctx)
+	}
+	return r, nil
+}
+
+func (r *Reloader) files() []string {
+	files := []string{}
+	for _, f := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.CAFile} {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+func (r *Reloader) load() error {
+	modTimes := map[string]time.Time{}
+	for _, f := range r.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			return fmt.Errorf("tls: %w", err)
+		}
+		modTimes[f] = info.ModTime()
+	}
+
+	var cert *tls.Certificate
+	if r.cfg.CertFile != "" {
+		c, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+		if err != nil {
+			return fmt.Errorf("tls: loading key pair: %w", err)
+		}
+		cert = &c
+	}
+
+	var roots *x509.CertPool
+	if r.cfg.CAFile != "" {
+		pem, err := os.ReadFile(r.cfg.CAFile)
+		if err != nil {
+			return fmt.Errorf("tls: %w", err)
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("tls: no certificates found in %s", r.cfg.CAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert, r.roots, r.modTimes = cert, roots, modTimes
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Reloader) changed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for f, modTime := range r.modTimes {
+		info, err := os.Stat(f)
+		if err != nil || !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reloader) watch(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !r.changed() {
+			continue
+		}
+		// A half-written file fails to load; the old certificates stay in
+		// use and the next tick tries again.
+		if err := r.load(); err != nil {
+			log.Println("keeping current certificates:", err)
+			continue
+		}
+		log.Println("reloaded TLS certificates")
+	}
+}
+
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, r.roots
+}
+
+// ServerConfig is the TLS config for gRPC servers. With ClientAuth set it
+// requires a client certificate that verifies against the CA bundle.
+func (r *Reloader) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, roots := r.current()
+			if cert == nil {
+				return nil, errors.New("tls: no server certificate configured")
+			}
+
+			c := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert},
+				NextProtos:   []string{"h2"},
+			}
+			if r.cfg.ClientAuth {
+				c.ClientAuth = tls.RequireAndVerifyClientCert
+				c.ClientCAs = roots
+			}
+			return c, nil
+		},
+	}
+}
+
+// HTTPServerConfig is the TLS config for HTTPS listeners such as the
+// gateway's. Browsers do not present certificates, so it never asks for
+// one. It is nil, meaning plain HTTP, when r is nil or has no certificate.
+func (r *Reloader) HTTPServerConfig() *tls.Config {
+	if r == nil || r.cfg.CertFile == "" {
+		return nil
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, _ := r.current()
+			if cert == nil {
+				return nil, errors.New("tls: no server certificate configured")
+			}
+			return cert, nil
+		},
+	}
+}
+
+// ClientConfig is the TLS config for calls to other services. It presents
+// the binary's certificate when asked for one and verifies the server
+// against the current CA bundle. Verification is done by hand because
+// tls.Config.RootCAs cannot be swapped once a connection is configured.
+func (r *Reloader) ClientConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := r.current()
+			if cert == nil {
+				return &tls.Certificate{}, nil
+			}
+			return cert, nil
+		},
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("tls: server sent no certificate")
+			}
+			_, roots := r.current()
+			opts := x509.VerifyOptions{
+				Roots:         roots,
+				DNSName:       cs.ServerName,
+				Intermediates: x509.NewCertPool(),
+			}
+			for _, c := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(c)
+			}
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		},
+	}
+}
@@ -0,0 +1,271 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        v3.21.12
+// source: validation/validation.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Rules constrain the value of a request field. They are checked by the
+// validation interceptor before the request reaches the service.
+type Rules struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// min_len and max_len bound the length of a string in characters.
+	MinLen *uint32 `protobuf:"varint,1,opt,name=min_len,json=minLen,proto3,oneof" json:"min_len,omitempty"`
+	MaxLen *uint32 `protobuf:"varint,2,opt,name=max_len,json=maxLen,proto3,oneof" json:"max_len,omitempty"`
+	// pattern is a regular expression a non-empty string must match. Use
+	// min_len to make the string required.
+	Pattern string `protobuf:"bytes,3,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	// gt, gte and lte bound a number.
+	Gt  *float64 `protobuf:"fixed64,4,opt,name=gt,proto3,oneof" json:"gt,omitempty"`
+	Gte *float64 `protobuf:"fixed64,5,opt,name=gte,proto3,oneof" json:"gte,omitempty"`
+	Lte *float64 `protobuf:"fixed64,6,opt,name=lte,proto3,oneof" json:"lte,omitempty"`
+	// min_items and max_items bound the length of a repeated field.
+	MinItems *uint32 `protobuf:"varint,7,opt,name=min_items,json=minItems,proto3,oneof" json:"min_items,omitempty"`
+	MaxItems *uint32 `protobuf:"varint,8,opt,name=max_items,json=maxItems,proto3,oneof" json:"max_items,omitempty"`
+	// unique requires the elements of a repeated scalar field to differ.
+	Unique bool `protobuf:"varint,9,opt,name=unique,proto3" json:"unique,omitempty"`
+	// unique_by names the fields of a repeated message field whose values,
+	// taken together, must differ between elements.
+	UniqueBy []string `protobuf:"bytes,10,rep,name=unique_by,json=uniqueBy,proto3" json:"unique_by,omitempty"`
+	// items applies to every element of a repeated field.
+	Items *Rules `protobuf:"bytes,11,opt,name=items,proto3" json:"items,omitempty"`
+	// required rejects an unset message field.
+	Required      bool `protobuf:"varint,12,opt,name=required,proto3" json:"required,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Rules) Reset() {
+	*x = Rules{}
+	mi := &file_validation_validation_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rules) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rules) ProtoMessage() {}
+
+func (x *Rules) ProtoReflect() protoreflect.Message {
+	mi := &file_validation_validation_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rules.ProtoReflect.Descriptor instead.
+func (*Rules) Descriptor() ([]byte, []int) {
+	return file_validation_validation_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Rules) GetMinLen() uint32 {
+	if x != nil && x.MinLen != nil {
+		return *x.MinLen
+	}
+	return 0
+}
+
+func (x *Rules) GetMaxLen() uint32 {
+	if x != nil && x.MaxLen != nil {
+		return *x.MaxLen
+	}
+	return 0
+}
+
+func (x *Rules) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *Rules) GetGt() float64 {
+	if x != nil && x.Gt != nil {
+		return *x.Gt
+	}
+	return 0
+}
+
+func (x *Rules) GetGte() float64 {
+	if x != nil && x.Gte != nil {
+		return *x.Gte
+	}
+	return 0
+}
+
+func (x *Rules) GetLte() float64 {
+	if x != nil && x.Lte != nil {
+		return *x.Lte
+	}
+	return 0
+}
+
+func (x *Rules) GetMinItems() uint32 {
+	if x != nil && x.MinItems != nil {
+		return *x.MinItems
+	}
+	return 0
+}
+
+func (x *Rules) GetMaxItems() uint32 {
+	if x != nil && x.MaxItems != nil {
+		return *x.MaxItems
+	}
+	return 0
+}
+
+func (x *Rules) GetUnique() bool {
+	if x != nil {
+		return x.Unique
+	}
+	return false
+}
+
+func (x *Rules) GetUniqueBy() []string {
+	if x != nil {
+		return x.UniqueBy
+	}
+	return nil
+}
+
+func (x *Rules) GetItems() *Rules {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *Rules) GetRequired() bool {
+	if x != nil {
+		return x.Required
+	}
+	return false
+}
+
+var file_validation_validation_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*Rules)(nil),
+		Field:         51000,
+		Name:          "validation.rules",
+		Tag:           "bytes,51000,opt,name=rules",
+		Filename:      "validation/validation.proto",
+	},
+}
+
+// Extension fields to descriptorpb.FieldOptions.
+var (
+	// optional validation.Rules rules = 51000;
+	E_Rules = &file_validation_validation_proto_extTypes[0]
+)
+
+var File_validation_validation_proto protoreflect.FileDescriptor
+
+const file_validation_validation_proto_rawDesc = "" +
+	"\n" +
+	"\x1bvalidation/validation.proto\x12\n" +
+	"validation\x1a google/protobuf/descriptor.proto\"\xa9\x03\n" +
+	"\x05Rules\x12\x1c\n" +
+	"\amin_len\x18\x01 \x01(\rH\x00R\x06minLen\x88\x01\x01\x12\x1c\n" +
+	"\amax_len\x18\x02 \x01(\rH\x01R\x06maxLen\x88\x01\x01\x12\x18\n" +
+	"\apattern\x18\x03 \x01(\tR\apattern\x12\x13\n" +
+	"\x02gt\x18\x04 \x01(\x01H\x02R\x02gt\x88\x01\x01\x12\x15\n" +
+	"\x03gte\x18\x05 \x01(\x01H\x03R\x03gte\x88\x01\x01\x12\x15\n" +
+	"\x03lte\x18\x06 \x01(\x01H\x04R\x03lte\x88\x01\x01\x12 \n" +
+	"\tmin_items\x18\a \x01(\rH\x05R\bminItems\x88\x01\x01\x12 \n" +
+	"\tmax_items\x18\b \x01(\rH\x06R\bmaxItems\x88\x01\x01\x12\x16\n" +
+	"\x06unique\x18\t \x01(\bR\x06unique\x12\x1b\n" +
+	"\tunique_by\x18\n" +
+	" \x03(\tR\buniqueBy\x12'\n" +
+	"\x05items\x18\v \x01(\v2\x11.validation.RulesR\x05items\x12\x1a\n" +
+	"\brequired\x18\f \x01(\bR\brequiredB\n" +
+	"\n" +
+	"\b_min_lenB\n" +
+	"\n" +
+	"\b_max_lenB\x05\n" +
+	"\x03_gtB\x06\n" +
+	"\x04_gteB\x06\n" +
+	"\x04_lteB\f\n" +
+	"\n" +
+	"_min_itemsB\f\n" +
+	"\n" +
+	"_max_items:H\n" +
+	"\x05rules\x12\x1d.google.protobuf.FieldOptions\x18\xb8\x8e\x03 \x01(\v2\x11.validation.RulesR\x05rulesB@Z>github.com/sunil8777/E-commerce-microservices/validation/pb;pbb\x06proto3"
+
+var (
+	file_validation_validation_proto_rawDescOnce sync.Once
+	file_validation_validation_proto_rawDescData []byte
+)
+
+func file_validation_validation_proto_rawDescGZIP() []byte {
+	file_validation_validation_proto_rawDescOnce.Do(func() {
+		file_validation_validation_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_validation_validation_proto_rawDesc), len(file_validation_validation_proto_rawDesc)))
+	})
+	return file_validation_validation_proto_rawDescData
+}
+
+var file_validation_validation_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_validation_validation_proto_goTypes = []any{
+	(*Rules)(nil),                     // 0: validation.Rules
+	(*descriptorpb.FieldOptions)(nil), // 1: google.protobuf.FieldOptions
+}
+var file_validation_validation_proto_depIdxs = []int32{
+	0, // 0: validation.Rules.items:type_name -> validation.Rules
+	1, // 1: validation.rules:extendee -> google.protobuf.FieldOptions
+	0, // 2: validation.rules:type_name -> validation.Rules
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	2, // [2:3] is the sub-list for extension type_name
+	1, // [1:2] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_validation_validation_proto_init() }
+func file_validation_validation_proto_init() {
+	if File_validation_validation_proto != nil {
+		return
+	}
+	file_validation_validation_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_validation_validation_proto_rawDesc), len(file_validation_validation_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 1,
+			NumServices:   0,
+		},
+		GoTypes:           file_validation_validation_proto_goTypes,
+		DependencyIndexes: file_validation_validation_proto_depIdxs,
+		MessageInfos:      file_validation_validation_proto_msgTypes,
+		ExtensionInfos:    file_validation_validation_proto_extTypes,
+	}.Build()
+	File_validation_validation_proto = out.File
+	file_validation_validation_proto_goTypes = nil
+	file_validation_validation_proto_depIdxs = nil
+}
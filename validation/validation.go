@@ -0,0 +1,218 @@
+// Package validation enforces the (validation.rules) field options declared
+// in the service protos. A violation of any rule rejects the request with an
+// INVALID_ARGUMENT error listing every invalid field.
+package validation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/sunil8777/E-commerce-microservices/errs"
+	"github.com/sunil8777/E-commerce-microservices/validation/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var ErrInvalidRequest = errs.New(errs.InvalidArgument, "INVALID_REQUEST", "invalid request")
+
+// patterns caches compiled rule patterns by source.
+var patterns sync.Map
+
+// Validate checks msg against the rules on its fields, including the fields
+// of nested messages. It returns ErrInvalidRequest with one FieldViolation
+// per broken rule, or nil if msg is valid.
+func Validate(msg proto.Message) error {
+	violations := checkMessage(msg.ProtoReflect(), "")
+	if len(violations) == 0 {
+		return nil
+	}
+
+	e := ErrInvalidRequest.WithViolations(violations...)
+	e.Message = Message(violations)
+	return e
+}
+
+// Message summarises violations as an error message.
+func Message(violations []errs.FieldViolation) string {
+	parts := make([]string, len(violations))
+	for i, v := range violations {
+		parts[i] = v.Field + " " + v.Description
+	}
+	return "invalid request: " + strings.Join(parts, "; ")
+}
+
+// UnaryServerInterceptor rejects requests that fail Validate before they
+// reach the handler.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if m, ok := req.(proto.Message); ok {
+			if err := Validate(m); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+func rulesOf(fd protoreflect.FieldDescriptor) *pb.Rules {
+	r, _ := proto.GetExtension(fd.Options(), pb.E_Rules).(*pb.Rules)
+	return r
+}
+
+func checkMessage(m protoreflect.Message, prefix string) []errs.FieldViolation {
+	var violations []errs.FieldViolation
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		path := fd.JSONName()
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		r := rulesOf(fd)
+
+		switch {
+		case fd.IsMap():
+			// Maps carry free-form options and are not constrained.
+		case fd.IsList():
+			violations = append(violations, checkList(fd, m.Get(fd).List(), r, path)...)
+		case fd.Message() != nil:
+			if !m.Has(fd) {
+				if r.GetRequired() {
+					violations = append(violations, errs.FieldViolation{Field: path, Description: "is required"})
+				}
+				continue
+			}
+			violations = append(violations, checkMessage(m.Get(fd).Message(), path)...)
+		default:
+			violations = append(violations, checkValue(fd, m.Get(fd), r, path)...)
+		}
+	}
+	return violations
+}
+
+func checkList(fd protoreflect.FieldDescriptor, list protoreflect.List, r *pb.Rules, path string) []errs.FieldViolation {
+	if r == nil {
+		r = &pb.Rules{}
+	}
+
+	var violations []errs.FieldViolation
+	if r.MinItems != nil && uint32(list.Len()) < r.GetMinItems() {
+		violations = append(violations, errs.FieldViolation{Field: path, Description: fmt.Sprintf("must have at least %d items", r.GetMinItems())})
+	}
+	if r.MaxItems != nil && uint32(list.Len()) > r.GetMaxItems() {
+		violations = append(violations, errs.FieldViolation{Field: path, Description: fmt.Sprintf("must have at most %d items", r.GetMaxItems())})
+	}
+
+	seen := map[string]int{}
+	for i := 0; i < list.Len(); i++ {
+		item := list.Get(i)
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+
+		if key, ok := uniqueKey(fd, item, r); ok {
+			if first, dup := seen[key]; dup {
+				violations = append(violations, errs.FieldViolation{Field: itemPath, Description: fmt.Sprintf("duplicates %s[%d]", path, first)})
+			} else {
+				seen[key] = i
+			}
+		}
+
+		if fd.Message() != nil {
+			violations = append(violations, checkMessage(item.Message(), itemPath)...)
+		} else {
+			violations = append(violations, checkValue(fd, item, r.GetItems(), itemPath)...)
+		}
+	}
+	return violations
+}
+
+// uniqueKey identifies item among the elements of a list whose rules ask for
+// unique or unique_by. It reports false when the list has neither.
+func uniqueKey(fd protoreflect.FieldDescriptor, item protoreflect.Value, r *pb.Rules) (string, bool) {
+	if fd.Message() == nil {
+		return fmt.Sprint(item.Interface()), r.GetUnique()
+	}
+	if len(r.GetUniqueBy()) == 0 {
+		return "", false
+	}
+
+	m := item.Message()
+	parts := make([]string, len(r.GetUniqueBy()))
+	for i, name := range r.GetUniqueBy() {
+		if f := m.Descriptor().Fields().ByName(protoreflect.Name(name)); f != nil {
+			parts[i] = fmt.Sprintf("%q", fmt.Sprint(m.Get(f).Interface()))
+		}
+	}
+	return strings.Join(parts, ","), true
+}
+
+func checkValue(fd protoreflect.FieldDescriptor, v protoreflect.Value, r *pb.Rules, path string) []errs.FieldViolation {
+	if r == nil {
+		return nil
+	}
+
+	var violations []errs.FieldViolation
+	add := func(format string, args ...interface{}) {
+		violations = append(violations, errs.FieldViolation{Field: path, Description: fmt.Sprintf(format, args...)})
+	}
+
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		s := v.String()
+		n := uint32(utf8.RuneCountInString(s))
+		if r.MinLen != nil && n < r.GetMinLen() {
+			if r.GetMinLen() == 1 {
+				add("must not be empty")
+			} else {
+				add("must be at least %d characters", r.GetMinLen())
+			}
+		}
+		if r.MaxLen != nil && n > r.GetMaxLen() {
+			add("must be at most %d characters", r.GetMaxLen())
+		}
+		if r.GetPattern() != "" && s != "" && !pattern(r.GetPattern()).MatchString(s) {
+			add("must match %s", r.GetPattern())
+		}
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		violations = append(violations, checkNumber(float64(v.Int()), r, path)...)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		violations = append(violations, checkNumber(float64(v.Uint()), r, path)...)
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		violations = append(violations, checkNumber(v.Float(), r, path)...)
+	}
+	return violations
+}
+
+// checkNumber is written so that NaN fails every bound.
+func checkNumber(x float64, r *pb.Rules, path string) []errs.FieldViolation {
+	var violations []errs.FieldViolation
+	add := func(format string, args ...interface{}) {
+		violations = append(violations, errs.FieldViolation{Field: path, Description: fmt.Sprintf(format, args...)})
+	}
+
+	if r.Gt != nil && !(x > r.GetGt()) {
+		add("must be greater than %v", r.GetGt())
+	}
+	if r.Gte != nil && !(x >= r.GetGte()) {
+		add("must be at least %v", r.GetGte())
+	}
+	if r.Lte != nil && !(x <= r.GetLte()) {
+		add("must be at most %v", r.GetLte())
+	}
+	return violations
+}
+
+func pattern(expr string) *regexp.Regexp {
+	if re, ok := patterns.Load(expr); ok {
+		return re.(*regexp.Regexp)
+	}
+	re := regexp.MustCompile(expr)
+	patterns.Store(expr, re)
+	return re
+}